@@ -9,6 +9,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 )
 
 func (o *Overseer) GetAudioText(base64 string) (string, error) {
@@ -18,14 +19,20 @@ func (o *Overseer) GetAudioText(base64 string) (string, error) {
 		return "", fmt.Errorf("failed to decode base64 audio: %w", err)
 	}
 
-	tmpFile, err := os.CreateTemp(o.savePath, "audio_*.mp3")
+	return o.TranscribeAudio(audioData, "audio.mp3")
+}
+
+// TranscribeAudio converts speech read from r to text with Whisper.
+// filename only tells the API the audio format, so its extension must match the content.
+func (o *Overseer) TranscribeAudio(r io.Reader, filename string) (string, error) {
+	tmpFile, err := os.CreateTemp(o.savePath, "audio_*"+filepath.Ext(filename))
 	if err != nil {
 		return "", fmt.Errorf("failed to create temp file: %w", err)
 	}
 	defer os.Remove(tmpFile.Name())
 	defer tmpFile.Close()
 
-	_, err = io.Copy(tmpFile, audioData)
+	_, err = io.Copy(tmpFile, r)
 	if err != nil {
 		return "", fmt.Errorf("failed to copy audio to file: %w", err)
 	}
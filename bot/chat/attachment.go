@@ -0,0 +1,79 @@
+package chat
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"DarkCS/entity"
+)
+
+// AttachmentType classifies an incoming file.
+type AttachmentType string
+
+const (
+	AttachmentImage    AttachmentType = "image"
+	AttachmentVideo    AttachmentType = "video"
+	AttachmentAudio    AttachmentType = "audio"
+	AttachmentVoice    AttachmentType = "voice"
+	AttachmentDocument AttachmentType = "document"
+)
+
+// AttachmentTypeFromMIME guesses the attachment type from a MIME type.
+// Audio is reported as AttachmentAudio; platforms that know a file is a voice
+// note set AttachmentVoice themselves.
+func AttachmentTypeFromMIME(mimeType string) AttachmentType {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return AttachmentImage
+	case strings.HasPrefix(mimeType, "video/"):
+		return AttachmentVideo
+	case strings.HasPrefix(mimeType, "audio/"):
+		return AttachmentAudio
+	}
+	return AttachmentDocument
+}
+
+// Attachment is a file sent by the user. The content is not held in memory:
+// call Open to read it, typically from GridFS where the bot stored it.
+type Attachment struct {
+	Type     AttachmentType
+	MIMEType string
+	Filename string
+	Size     int64
+	Duration time.Duration // length of audio and video; zero if the platform does not report it
+	FileID   string        // GridFS object ID (hex); empty if the file was not stored
+	Open     func() (io.ReadCloser, error)
+}
+
+// IsVoice reports whether the attachment is speech that can be transcribed.
+func (a Attachment) IsVoice() bool {
+	return a.Type == AttachmentVoice || a.Type == AttachmentAudio
+}
+
+// NewStoredAttachment describes a file already uploaded through the message
+// listener; Open downloads it back from storage on demand.
+func NewStoredAttachment(listener MessageListener, typ AttachmentType, att entity.Attachment) Attachment {
+	return Attachment{
+		Type:     typ,
+		MIMEType: att.MIMEType,
+		Filename: att.Filename,
+		Size:     att.Size,
+		FileID:   att.FileID.Hex(),
+		Open: func() (io.ReadCloser, error) {
+			_, _, reader, err := listener.DownloadFile(att.FileID)
+			return reader, err
+		},
+	}
+}
+
+// HandleAttachments processes files sent by the user from any platform.
+// caption is the text sent together with the files, if any. The current step
+// receives both in a single UserInput; global intents are not matched.
+func (e *ChatEngine) HandleAttachments(ctx context.Context, m Messenger, platform, userID, chatID, caption string, attachments []Attachment) error {
+	return e.mailboxes.do(ctx, platform, userID, func() error {
+		ctx, m := localize(ctx, m)
+		return e.dispatchInput(ctx, m, platform, userID, chatID, UserInput{Text: caption, Attachments: attachments}, true)
+	})
+}
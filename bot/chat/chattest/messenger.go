@@ -0,0 +1,171 @@
+// Package chattest runs chat workflows in-process for tests and offline tools.
+// It provides a recording Messenger, an in-memory ChatStateStorage, stubbed
+// services for the onboarding and mainmenu workflows, and a fluent script API.
+package chattest
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"DarkCS/bot/chat"
+)
+
+// Kind identifies which Messenger method produced a recorded message.
+type Kind string
+
+const (
+	KindText           Kind = "text"
+	KindFile           Kind = "file"
+	KindVideo          Kind = "video"
+	KindMenu           Kind = "menu"
+	KindInline         Kind = "inline"
+	KindInlineGrid     Kind = "inline_grid"
+	KindEditInlineGrid Kind = "edit_inline_grid"
+	KindContactRequest Kind = "contact_request"
+)
+
+// Message is a single outgoing bot message captured by Messenger.
+type Message struct {
+	Kind      Kind
+	ChatID    string
+	MessageID string // Edited message, for KindEditInlineGrid
+	Text      string
+	Menu      [][]chat.MenuButton
+	Buttons   [][]chat.InlineButton // Inline options are stored as one button per row
+	Button    string                // Contact request button text
+	Filename  string
+	Locale    string // Locale the engine asked the messenger to write its own texts in
+}
+
+// Labels returns the texts of the message's menu or inline buttons in display order.
+func (msg Message) Labels() []string {
+	var labels []string
+	for _, row := range msg.Menu {
+		for _, btn := range row {
+			labels = append(labels, btn.Text)
+		}
+	}
+	for _, row := range msg.Buttons {
+		for _, btn := range row {
+			labels = append(labels, btn.Text)
+		}
+	}
+	return labels
+}
+
+// NewAttachment returns an in-memory attachment whose Open yields content.
+func NewAttachment(typ chat.AttachmentType, filename, mimeType string, content []byte) chat.Attachment {
+	return chat.Attachment{
+		Type:     typ,
+		MIMEType: mimeType,
+		Filename: filename,
+		Size:     int64(len(content)),
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(content)), nil
+		},
+	}
+}
+
+// Messenger is a chat.Messenger that records every outgoing message instead of sending it.
+// Typing and upload indicators are ignored. It is a chat.LocalizedMessenger: each
+// message records the locale in effect when it was sent.
+type Messenger struct {
+	mu       sync.Mutex
+	messages []Message
+	parent   *Messenger // Messenger that keeps the record, for copies made by WithLocale
+	locale   func() string
+}
+
+// NewMessenger creates an empty recording messenger.
+func NewMessenger() *Messenger {
+	return &Messenger{}
+}
+
+// WithLocale returns a messenger that records into m and marks each message with the locale reported by locale.
+func (m *Messenger) WithLocale(locale func() string) chat.Messenger {
+	return &Messenger{parent: m.recorder(), locale: locale}
+}
+
+// recorder returns the messenger that keeps the record.
+func (m *Messenger) recorder() *Messenger {
+	if m.parent != nil {
+		return m.parent
+	}
+	return m
+}
+
+// Messages returns all messages recorded so far.
+func (m *Messenger) Messages() []Message {
+	m = m.recorder()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+// Take returns the messages recorded so far and clears the record.
+func (m *Messenger) Take() []Message {
+	m = m.recorder()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	msgs := m.messages
+	m.messages = nil
+	return msgs
+}
+
+func (m *Messenger) record(msg Message) {
+	if m.locale != nil {
+		msg.Locale = m.locale()
+	}
+	r := m.recorder()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+}
+
+func (m *Messenger) SendText(chatID, text string) error {
+	m.record(Message{Kind: KindText, ChatID: chatID, Text: text})
+	return nil
+}
+
+func (m *Messenger) SendFile(chatID string, file chat.FileMessage) error {
+	m.record(Message{Kind: KindFile, ChatID: chatID, Text: file.Caption, Filename: file.Filename})
+	return nil
+}
+
+func (m *Messenger) SendVideo(chatID string, r io.Reader, cachedFileID, publicURL, filename string, protected bool) (string, error) {
+	m.record(Message{Kind: KindVideo, ChatID: chatID, Filename: filename})
+	return "", nil
+}
+
+func (m *Messenger) SendMenu(chatID, text string, rows [][]chat.MenuButton) error {
+	m.record(Message{Kind: KindMenu, ChatID: chatID, Text: text, Menu: rows})
+	return nil
+}
+
+func (m *Messenger) SendInlineOptions(chatID, text string, buttons []chat.InlineButton) error {
+	rows := make([][]chat.InlineButton, 0, len(buttons))
+	for _, btn := range buttons {
+		rows = append(rows, []chat.InlineButton{btn})
+	}
+	m.record(Message{Kind: KindInline, ChatID: chatID, Text: text, Buttons: rows})
+	return nil
+}
+
+func (m *Messenger) SendInlineGrid(chatID, text string, rows [][]chat.InlineButton) error {
+	m.record(Message{Kind: KindInlineGrid, ChatID: chatID, Text: text, Buttons: rows})
+	return nil
+}
+
+func (m *Messenger) EditInlineGrid(chatID, messageID, text string, rows [][]chat.InlineButton) error {
+	m.record(Message{Kind: KindEditInlineGrid, ChatID: chatID, MessageID: messageID, Text: text, Buttons: rows})
+	return nil
+}
+
+func (m *Messenger) SendContactRequest(chatID, text, buttonText string) error {
+	m.record(Message{Kind: KindContactRequest, ChatID: chatID, Text: text, Button: buttonText})
+	return nil
+}
+
+func (m *Messenger) SendTyping(chatID string) error       { return nil }
+func (m *Messenger) SendUploadAction(chatID string) error { return nil }
@@ -0,0 +1,293 @@
+package chattest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"DarkCS/bot/chat"
+)
+
+// Services bundles the stubs required by the onboarding and mainmenu workflows.
+type Services struct {
+	Auth    *AuthService
+	Zoho    *ZohoService
+	AI      *AIService
+	Schools *SchoolRepository
+	QrStats *QrStatRepository
+}
+
+// NewServices creates empty stubs.
+func NewServices() *Services {
+	return &Services{
+		Auth:    NewAuthService(),
+		Zoho:    NewZohoService(),
+		AI:      &AIService{},
+		Schools: &SchoolRepository{},
+		QrStats: &QrStatRepository{},
+	}
+}
+
+// DiscardLogger returns a logger that drops all records.
+func DiscardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// NewEngine creates a ChatEngine over storage with the given workflows registered.
+// It panics when two workflows share an ID.
+func NewEngine(storage chat.ChatStateStorage, log *slog.Logger, workflows ...chat.Workflow) *chat.ChatEngine {
+	engine := chat.NewChatEngine(storage, log)
+	if locales, ok := storage.(chat.LocaleStore); ok {
+		engine.SetLocaleStore(locales)
+	}
+	for _, w := range workflows {
+		if err := engine.RegisterWorkflow(w); err != nil {
+			panic(err)
+		}
+	}
+	return engine
+}
+
+// Conversation drives a ChatEngine as a single user and checks the bot's replies.
+// Every action (Send, Callback, Contact, Attach, Start) replaces the replies that the
+// Expect methods inspect, so a script reads as alternating actions and checks:
+//
+//	c.Send("hi").ExpectText("Привіт").ExpectStep(onboarding.StepRequestPhone)
+type Conversation struct {
+	t         testing.TB
+	ctx       context.Context
+	Engine    *chat.ChatEngine
+	Storage   *MemoryStorage
+	Events    *EventRecorder
+	Messenger *Messenger
+	Platform  string
+	UserID    string
+	ChatID    string
+	replies   []Message
+}
+
+// New creates a conversation for one user on platform, backed by a fresh
+// engine with in-memory storage, an event recorder and the given workflows.
+func New(t testing.TB, platform, userID string, workflows ...chat.Workflow) *Conversation {
+	storage := NewMemoryStorage()
+	events := &EventRecorder{}
+	engine := NewEngine(storage, DiscardLogger(), workflows...)
+	engine.SetEventRecorder(events)
+	return &Conversation{
+		t:         t,
+		ctx:       context.Background(),
+		Engine:    engine,
+		Storage:   storage,
+		Events:    events,
+		Messenger: NewMessenger(),
+		Platform:  platform,
+		UserID:    userID,
+		ChatID:    userID,
+	}
+}
+
+func (c *Conversation) act(what string, fn func() error) *Conversation {
+	c.t.Helper()
+	c.Messenger.Take()
+	if err := fn(); err != nil {
+		c.t.Fatalf("%s: %v", what, err)
+	}
+	c.replies = c.Messenger.Take()
+	return c
+}
+
+// Start starts a workflow for the user, optionally with initial state data.
+func (c *Conversation) Start(workflowID chat.WorkflowID, data ...map[string]any) *Conversation {
+	c.t.Helper()
+	var initial map[string]any
+	if len(data) > 0 {
+		initial = data[0]
+	}
+	return c.act("start "+string(workflowID), func() error {
+		return c.Engine.StartWorkflowWithData(c.ctx, c.Messenger, c.Platform, c.UserID, c.ChatID, workflowID, initial)
+	})
+}
+
+// Send delivers a text message from the user.
+func (c *Conversation) Send(text string) *Conversation {
+	c.t.Helper()
+	return c.act(fmt.Sprintf("send %q", text), func() error {
+		return c.Engine.HandleMessage(c.ctx, c.Messenger, c.Platform, c.UserID, c.ChatID, text)
+	})
+}
+
+// Callback delivers an inline button press. Paginated grids are then re-sent
+// rather than edited, since no message ID is passed.
+func (c *Conversation) Callback(data string) *Conversation {
+	c.t.Helper()
+	return c.act(fmt.Sprintf("callback %q", data), func() error {
+		return c.Engine.HandleCallback(c.ctx, c.Messenger, c.Platform, c.UserID, c.ChatID, data, "")
+	})
+}
+
+// Contact delivers a shared phone number.
+func (c *Conversation) Contact(phone string) *Conversation {
+	c.t.Helper()
+	return c.act(fmt.Sprintf("contact %q", phone), func() error {
+		return c.Engine.HandleContact(c.ctx, c.Messenger, c.Platform, c.UserID, c.ChatID, phone)
+	})
+}
+
+// Attach delivers files sent by the user together with an optional caption.
+func (c *Conversation) Attach(caption string, attachments ...chat.Attachment) *Conversation {
+	c.t.Helper()
+	return c.act(fmt.Sprintf("attach %d file(s) %q", len(attachments), caption), func() error {
+		return c.Engine.HandleAttachments(c.ctx, c.Messenger, c.Platform, c.UserID, c.ChatID, caption, attachments)
+	})
+}
+
+// Replies returns the messages the bot sent in response to the last action.
+func (c *Conversation) Replies() []Message {
+	return c.replies
+}
+
+// State returns the user's stored state, or nil when there is none.
+func (c *Conversation) State() *chat.ChatState {
+	c.t.Helper()
+	state, err := c.Storage.Load(c.ctx, c.Platform, c.UserID)
+	if err != nil {
+		c.t.Fatalf("loading state: %v", err)
+	}
+	return state
+}
+
+// ExpectText checks that a reply contains substr.
+func (c *Conversation) ExpectText(substr string) *Conversation {
+	c.t.Helper()
+	for _, msg := range c.replies {
+		if strings.Contains(msg.Text, substr) {
+			return c
+		}
+	}
+	c.t.Errorf("no reply contains %q; replies:\n%s", substr, c.dump())
+	return c
+}
+
+// ExpectNoReply checks that the bot stayed silent.
+func (c *Conversation) ExpectNoReply() *Conversation {
+	c.t.Helper()
+	if len(c.replies) > 0 {
+		c.t.Errorf("expected no reply; replies:\n%s", c.dump())
+	}
+	return c
+}
+
+// ExpectMenu checks that a reply keyboard with exactly these labels was sent.
+func (c *Conversation) ExpectMenu(labels ...string) *Conversation {
+	c.t.Helper()
+	return c.expectButtons(labels, KindMenu)
+}
+
+// ExpectInline checks that inline options or an inline grid with exactly these labels was sent or edited.
+func (c *Conversation) ExpectInline(labels ...string) *Conversation {
+	c.t.Helper()
+	return c.expectButtons(labels, KindInline, KindInlineGrid, KindEditInlineGrid)
+}
+
+func (c *Conversation) expectButtons(labels []string, kinds ...Kind) *Conversation {
+	c.t.Helper()
+	for _, msg := range c.replies {
+		if slices.Contains(kinds, msg.Kind) && slices.Equal(msg.Labels(), labels) {
+			return c
+		}
+	}
+	c.t.Errorf("no %v with buttons %q; replies:\n%s", kinds, labels, c.dump())
+	return c
+}
+
+// ExpectContactRequest checks that the bot asked the user to share their phone.
+func (c *Conversation) ExpectContactRequest() *Conversation {
+	c.t.Helper()
+	for _, msg := range c.replies {
+		if msg.Kind == KindContactRequest {
+			return c
+		}
+	}
+	c.t.Errorf("no contact request; replies:\n%s", c.dump())
+	return c
+}
+
+// ExpectWorkflow checks the user's current workflow.
+func (c *Conversation) ExpectWorkflow(id chat.WorkflowID) *Conversation {
+	c.t.Helper()
+	state := c.State()
+	if state == nil {
+		c.t.Errorf("expected workflow %s, got no state", id)
+	} else if state.WorkflowID != id {
+		c.t.Errorf("expected workflow %s, got %s", id, state.WorkflowID)
+	}
+	return c
+}
+
+// ExpectStep checks the user's current step.
+func (c *Conversation) ExpectStep(id chat.StepID) *Conversation {
+	c.t.Helper()
+	state := c.State()
+	if state == nil {
+		c.t.Errorf("expected step %s, got no state", id)
+	} else if state.CurrentStep != id {
+		c.t.Errorf("expected step %s, got %s", id, state.CurrentStep)
+	}
+	return c
+}
+
+// ExpectData checks a value in the user's state data, compared by its printed form.
+func (c *Conversation) ExpectData(key string, want any) *Conversation {
+	c.t.Helper()
+	state := c.State()
+	if state == nil {
+		c.t.Errorf("expected data %s=%v, got no state", key, want)
+		return c
+	}
+	if got := fmt.Sprint(state.Data[key]); got != fmt.Sprint(want) {
+		c.t.Errorf("expected data %s=%v, got %s", key, want, got)
+	}
+	return c
+}
+
+// ExpectLocale checks that every reply was sent in locale, including the texts the messenger writes itself.
+func (c *Conversation) ExpectLocale(locale string) *Conversation {
+	c.t.Helper()
+	if len(c.replies) == 0 {
+		c.t.Errorf("expected replies in locale %q, got none", locale)
+	}
+	for _, msg := range c.replies {
+		if msg.Locale != locale {
+			c.t.Errorf("%s reply %q sent in locale %q, want %q", msg.Kind, msg.Text, msg.Locale, locale)
+		}
+	}
+	return c
+}
+
+// ExpectNoState checks that the user has no stored state.
+func (c *Conversation) ExpectNoState() *Conversation {
+	c.t.Helper()
+	if state := c.State(); state != nil {
+		c.t.Errorf("expected no state, got %s/%s", state.WorkflowID, state.CurrentStep)
+	}
+	return c
+}
+
+func (c *Conversation) dump() string {
+	if len(c.replies) == 0 {
+		return "  (none)"
+	}
+	var sb strings.Builder
+	for _, msg := range c.replies {
+		fmt.Fprintf(&sb, "  [%s] %q", msg.Kind, msg.Text)
+		if labels := msg.Labels(); len(labels) > 0 {
+			fmt.Fprintf(&sb, " %q", labels)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
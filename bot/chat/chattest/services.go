@@ -0,0 +1,282 @@
+package chattest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"DarkCS/bot/chat"
+	"DarkCS/entity"
+)
+
+// AuthService is an in-memory user store satisfying the AuthService
+// interfaces of both the onboarding and mainmenu workflows.
+type AuthService struct {
+	mu    sync.Mutex
+	users []*entity.User
+}
+
+// NewAuthService creates a user store seeded with users.
+func NewAuthService(users ...*entity.User) *AuthService {
+	return &AuthService{users: users}
+}
+
+// Users returns a snapshot of all stored users.
+func (s *AuthService) Users() []entity.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := make([]entity.User, 0, len(s.users))
+	for _, u := range s.users {
+		users = append(users, *u)
+	}
+	return users
+}
+
+func (s *AuthService) find(match func(*entity.User) bool) *entity.User {
+	for _, u := range s.users {
+		if match(u) {
+			c := *u
+			return &c
+		}
+	}
+	return nil
+}
+
+func (s *AuthService) UserExists(email, phone string, telegramId int64) (*entity.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	filter := entity.NewUser(email, phone, telegramId)
+	return s.find(func(u *entity.User) bool { return u.SameUser(filter) }), nil
+}
+
+func (s *AuthService) RegisterUser(name, email, phone string, telegramId int64) (*entity.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	filter := entity.NewUser(email, phone, telegramId)
+	if user := s.find(func(u *entity.User) bool { return u.SameUser(filter) }); user != nil {
+		return user, nil
+	}
+	filter.Name = name
+	s.users = append(s.users, filter)
+	c := *filter
+	return &c, nil
+}
+
+func (s *AuthService) UpdateUser(user *entity.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, u := range s.users {
+		if u.UUID == user.UUID {
+			c := *user
+			s.users[i] = &c
+			return nil
+		}
+	}
+	// Like the real store, updating an unknown user inserts it
+	c := *user
+	s.users = append(s.users, &c)
+	return nil
+}
+
+func (s *AuthService) GetUser(email, phone string, telegramId int64) (*entity.User, error) {
+	user, _ := s.UserExists(email, phone, telegramId)
+	if user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	return user, nil
+}
+
+func (s *AuthService) GetUserByInstagramId(instagramId string) (*entity.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.find(func(u *entity.User) bool { return u.InstagramId == instagramId }), nil
+}
+
+func (s *AuthService) GetUserByFacebookId(facebookId string) (*entity.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.find(func(u *entity.User) bool { return u.FacebookId == facebookId }), nil
+}
+
+func (s *AuthService) GetUserByViberId(viberId string) (*entity.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.find(func(u *entity.User) bool { return u.ViberId == viberId }), nil
+}
+
+func (s *AuthService) GetUserByWebId(webId string) (*entity.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.find(func(u *entity.User) bool { return u.WebId == webId }), nil
+}
+
+// ZohoService is a stubbed Zoho CRM. Every user sees the same Orders.
+type ZohoService struct {
+	mu       sync.Mutex
+	Orders   []entity.OrderDetail
+	Products map[string]string // order ID → products text
+	Ratings  []entity.ServiceRating
+	Schools  map[string]string // Zoho contact ID → school name
+	contacts int
+}
+
+// NewZohoService creates a stub returning the given orders.
+func NewZohoService(orders ...entity.OrderDetail) *ZohoService {
+	return &ZohoService{
+		Orders:   orders,
+		Products: make(map[string]string),
+		Schools:  make(map[string]string),
+	}
+}
+
+func (s *ZohoService) CreateContact(user *entity.User) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user.ZohoId != "" {
+		return user.ZohoId, nil
+	}
+	s.contacts++
+	return fmt.Sprintf("zoho-%d", s.contacts), nil
+}
+
+func (s *ZohoService) GetOrdersDetailed(userInfo entity.UserInfo) ([]entity.OrderDetail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]entity.OrderDetail(nil), s.Orders...), nil
+}
+
+func (s *ZohoService) GetOrdersDetailedByZohoId(zohoId string) ([]entity.OrderDetail, error) {
+	return s.GetOrdersDetailed(entity.UserInfo{})
+}
+
+func (s *ZohoService) GetOrderProducts(orderId string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	products, ok := s.Products[orderId]
+	if !ok {
+		return "", fmt.Errorf("order not found: %s", orderId)
+	}
+	return products, nil
+}
+
+func (s *ZohoService) CreateRating(rating entity.ServiceRating) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Ratings = append(s.Ratings, rating)
+	return nil
+}
+
+func (s *ZohoService) UpdateContactSchool(zohoID, schoolName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Schools[zohoID] = schoolName
+	return nil
+}
+
+// AIService is a stubbed assistant that answers with Reply, or echoes the request when Reply is empty.
+// Voice messages are "transcribed" by reading the attachment content as text; longer than
+// VoiceMaxDuration they are rejected, and TranscribeErr makes every transcription fail.
+type AIService struct {
+	mu               sync.Mutex
+	Reply            string
+	Requests         []string
+	VoiceMaxDuration time.Duration
+	TranscribeErr    error
+}
+
+func (s *AIService) ProcessUserRequest(user *entity.User, message string) (*entity.AiAnswer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Requests = append(s.Requests, message)
+	reply := s.Reply
+	if reply == "" {
+		reply = "AI: " + message
+	}
+	return &entity.AiAnswer{Text: reply}, nil
+}
+
+func (s *AIService) TranscribeVoice(platform, userID string, att chat.Attachment) (string, error) {
+	if s.VoiceMaxDuration > 0 && att.Duration > s.VoiceMaxDuration {
+		return "", entity.VoiceTooLongError(att.Duration, s.VoiceMaxDuration)
+	}
+	if s.TranscribeErr != nil {
+		return "", s.TranscribeErr
+	}
+
+	reader, err := att.Open()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// SchoolRepository is an in-memory school list.
+type SchoolRepository struct {
+	Schools []entity.School
+}
+
+func (r *SchoolRepository) GetAllActiveSchools(ctx context.Context) ([]entity.School, error) {
+	var active []entity.School
+	for _, school := range r.Schools {
+		if school.Active {
+			active = append(active, school)
+		}
+	}
+	return active, nil
+}
+
+// QrStatRepository is an in-memory QR statistics store.
+type QrStatRepository struct {
+	mu    sync.Mutex
+	Stats []entity.QrStat
+}
+
+func (r *QrStatRepository) GetAllQrStat() ([]entity.QrStat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]entity.QrStat(nil), r.Stats...), nil
+}
+
+func (r *QrStatRepository) SaveSchoolStat(platform, userID, schoolName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Stats = append(r.Stats, entity.QrStat{Platform: platform, UserID: userID, SchoolName: schoolName})
+	return nil
+}
+
+// Handoff is an in-memory human mode tracker. Human mode never times out.
+type Handoff struct {
+	mu    sync.Mutex
+	human map[string]bool
+}
+
+// NewHandoff creates a tracker with every chat answered by the bot.
+func NewHandoff() *Handoff {
+	return &Handoff{human: make(map[string]bool)}
+}
+
+func (h *Handoff) HumanMode(platform, userID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.human[platform+":"+userID]
+}
+
+func (h *Handoff) RequestHuman(platform, userID string) {
+	h.Set(platform, userID, true)
+}
+
+// Set turns human mode on or off, as a manager reply or closing the chat does.
+func (h *Handoff) Set(platform, userID string, on bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.human[platform+":"+userID] = on
+}
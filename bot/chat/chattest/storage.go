@@ -0,0 +1,133 @@
+package chattest
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"DarkCS/bot/chat"
+)
+
+type stateKey struct {
+	platform string
+	userID   string
+}
+
+// MemoryStorage is an in-memory chat.ChatStateStorage with the same
+// version compare-and-swap semantics as the MongoDB storage.
+// It is also a chat.LocaleStore.
+type MemoryStorage struct {
+	mu      sync.Mutex
+	states  map[stateKey]*chat.ChatState
+	locales map[stateKey]string
+}
+
+// NewMemoryStorage creates an empty in-memory storage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		states:  make(map[stateKey]*chat.ChatState),
+		locales: make(map[stateKey]string),
+	}
+}
+
+func (s *MemoryStorage) GetChatLocale(ctx context.Context, platform, userID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.locales[stateKey{platform, userID}], nil
+}
+
+func (s *MemoryStorage) SetChatLocale(ctx context.Context, platform, userID, locale string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.locales[stateKey{platform, userID}] = locale
+	return nil
+}
+
+func (s *MemoryStorage) Save(ctx context.Context, state *chat.ChatState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := stateKey{state.Platform, state.UserID}
+	var stored int64
+	if prev, ok := s.states[key]; ok {
+		stored = prev.Version
+	}
+	if stored != state.Version {
+		return chat.ErrStateConflict
+	}
+
+	state.Version++
+	state.UpdatedAt = time.Now()
+	s.states[key] = cloneState(state)
+	return nil
+}
+
+func (s *MemoryStorage) Load(ctx context.Context, platform, userID string) (*chat.ChatState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[stateKey{platform, userID}]
+	if !ok {
+		return nil, nil
+	}
+	return cloneState(state), nil
+}
+
+func (s *MemoryStorage) Delete(ctx context.Context, platform, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.states, stateKey{platform, userID})
+	return nil
+}
+
+func (s *MemoryStorage) FindIdle(ctx context.Context, workflowID chat.WorkflowID, step chat.StepID, before time.Time) ([]*chat.ChatState, error) {
+	return s.find(func(state *chat.ChatState) bool {
+		return state.WorkflowID == workflowID && state.CurrentStep == step && state.UpdatedAt.Before(before)
+	}), nil
+}
+
+func (s *MemoryStorage) find(match func(*chat.ChatState) bool) []*chat.ChatState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var states []*chat.ChatState
+	for _, state := range s.states {
+		if match(state) {
+			states = append(states, cloneState(state))
+		}
+	}
+	return states
+}
+
+// cloneState copies a state so callers never share maps or slices with the storage.
+func cloneState(state *chat.ChatState) *chat.ChatState {
+	c := *state
+	c.Data = maps.Clone(state.Data)
+	c.History = slices.Clone(state.History)
+	return &c
+}
+
+// EventRecorder is an in-memory chat.WorkflowEventRecorder.
+type EventRecorder struct {
+	mu     sync.Mutex
+	events []chat.WorkflowEvent
+}
+
+func (r *EventRecorder) SaveWorkflowEvent(ctx context.Context, event chat.WorkflowEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+// Events returns all events recorded so far.
+func (r *EventRecorder) Events() []chat.WorkflowEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.events)
+}
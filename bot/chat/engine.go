@@ -2,25 +2,48 @@ package chat
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 )
 
+// ErrWorkflowExists is returned when a workflow is registered under an ID already in use.
+var ErrWorkflowExists = errors.New("workflow already registered")
+
 // ChatEngine is the platform-agnostic workflow orchestrator.
 type ChatEngine struct {
+	// mu guards workflows, declared, intents, declaredIntents and messengers, which
+	// change when declarative workflows are reloaded while events are being processed
+	// and are read by the timeout sweeper.
+	mu              sync.RWMutex
 	workflows       map[WorkflowID]Workflow
+	declared        map[WorkflowID]bool
+	declaredIntents []Intent
 	storage         ChatStateStorage
 	log             *slog.Logger
 	messageListener MessageListener
+	mailboxes       *mailboxes
+	messengers      map[string]Messenger
+	intents         []Intent
+	eventRecorder   WorkflowEventRecorder
+	handoff         Handoff
+	locales         LocaleStore
+	// now is the clock of the timeout sweeper.
+	now func() time.Time
 }
 
 // NewChatEngine creates a new chat engine.
 func NewChatEngine(storage ChatStateStorage, log *slog.Logger) *ChatEngine {
 	return &ChatEngine{
-		workflows: make(map[WorkflowID]Workflow),
-		storage:   storage,
-		log:       log,
+		workflows:  make(map[WorkflowID]Workflow),
+		declared:   make(map[WorkflowID]bool),
+		storage:    storage,
+		log:        log,
+		mailboxes:  newMailboxes(),
+		messengers: make(map[string]Messenger),
+		now:        time.Now,
 	}
 }
 
@@ -34,82 +57,141 @@ func (e *ChatEngine) GetMessageListener() MessageListener {
 	return e.messageListener
 }
 
-// RegisterWorkflow adds a workflow to the engine.
-func (e *ChatEngine) RegisterWorkflow(w Workflow) {
+// RegisterWorkflow adds a workflow to the engine. An ID that is already registered
+// is rejected with ErrWorkflowExists.
+func (e *ChatEngine) RegisterWorkflow(w Workflow) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.workflows[w.ID()]; ok {
+		return fmt.Errorf("%w: %s", ErrWorkflowExists, w.ID())
+	}
 	e.workflows[w.ID()] = w
 	e.log.Info("chat engine: registered workflow", slog.String("workflow_id", string(w.ID())))
+	return nil
 }
 
-// HandleMessage processes a text message from any platform.
-func (e *ChatEngine) HandleMessage(ctx context.Context, m Messenger, platform, userID, chatID, text string) error {
-	m = newLoggingMessenger(m, e.messageListener, platform, userID)
-
-	state, err := e.storage.Load(ctx, platform, userID)
-	if err != nil {
-		return fmt.Errorf("loading state: %w", err)
+// SetDeclarativeWorkflows replaces the workflows installed by the previous call, together
+// with their intents, by a new set. It is used for workflows defined outside the code,
+// which can be reloaded while the bot runs. A workflow whose ID is taken by a workflow
+// registered with RegisterWorkflow, or repeated in the set, is skipped along with its
+// intents; the skipped workflows are reported in the returned error.
+// It returns the IDs of the installed workflows.
+func (e *ChatEngine) SetDeclarativeWorkflows(workflows []Workflow, intents []Intent) ([]WorkflowID, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for id := range e.declared {
+		delete(e.workflows, id)
 	}
-
-	// No active workflow — start onboarding
-	if state == nil {
-		return e.StartWorkflow(ctx, m, platform, userID, chatID, "onboarding")
+	e.declared = make(map[WorkflowID]bool, len(workflows))
+
+	var installed []WorkflowID
+	var errs []error
+	for _, w := range workflows {
+		if _, ok := e.workflows[w.ID()]; ok {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrWorkflowExists, w.ID()))
+			continue
+		}
+		e.workflows[w.ID()] = w
+		e.declared[w.ID()] = true
+		installed = append(installed, w.ID())
 	}
 
-	w, ok := e.workflows[state.WorkflowID]
-	if !ok {
-		return fmt.Errorf("workflow not found: %s", state.WorkflowID)
+	e.declaredIntents = nil
+	for _, intent := range intents {
+		if intent.Workflow != "" && !e.declared[intent.Workflow] {
+			continue
+		}
+		e.declaredIntents = append(e.declaredIntents, intent)
 	}
 
-	step, ok := w.GetStep(state.CurrentStep)
-	if !ok {
-		return fmt.Errorf("step not found: %s", state.CurrentStep)
+	e.log.Info("chat engine: declarative workflows set",
+		slog.Int("workflows", len(e.declared)),
+		slog.Int("intents", len(e.declaredIntents)),
+	)
+	return installed, errors.Join(errs...)
+}
+
+// workflow returns a registered workflow by ID.
+func (e *ChatEngine) workflow(id WorkflowID) (Workflow, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	w, ok := e.workflows[id]
+	return w, ok
+}
+
+// registeredWorkflows returns a snapshot of all registered workflows.
+func (e *ChatEngine) registeredWorkflows() []Workflow {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	workflows := make([]Workflow, 0, len(e.workflows))
+	for _, w := range e.workflows {
+		workflows = append(workflows, w)
 	}
+	return workflows
+}
 
-	input := UserInput{Text: text}
-	result := step.HandleInput(ctx, m, state, input)
-	return e.processResult(ctx, m, state, w, result)
+// HandleMessage processes a text message from any platform.
+func (e *ChatEngine) HandleMessage(ctx context.Context, m Messenger, platform, userID, chatID, text string) error {
+	return e.mailboxes.do(ctx, platform, userID, func() error {
+		ctx, m := localize(ctx, m)
+		return e.dispatchInput(ctx, m, platform, userID, chatID, UserInput{Text: text}, true)
+	})
 }
 
 // HandleCallback processes a callback/inline button press from any platform.
 // messageID is the ID of the message containing the inline keyboard (used for editing).
 func (e *ChatEngine) HandleCallback(ctx context.Context, m Messenger, platform, userID, chatID, data, messageID string) error {
-	m = newLoggingMessenger(m, e.messageListener, platform, userID)
-
-	state, err := e.storage.Load(ctx, platform, userID)
-	if err != nil {
-		return fmt.Errorf("loading state: %w", err)
-	}
-	if state == nil {
-		return nil
-	}
-
-	w, ok := e.workflows[state.WorkflowID]
-	if !ok {
-		return fmt.Errorf("workflow not found: %s", state.WorkflowID)
-	}
-
-	step, ok := w.GetStep(state.CurrentStep)
-	if !ok {
-		return fmt.Errorf("step not found: %s", state.CurrentStep)
-	}
-
-	input := UserInput{CallbackData: data, MessageID: messageID}
-	result := step.HandleInput(ctx, m, state, input)
-	return e.processResult(ctx, m, state, w, result)
+	return e.mailboxes.do(ctx, platform, userID, func() error {
+		ctx, m := localize(ctx, m)
+		return e.dispatchInput(ctx, m, platform, userID, chatID, UserInput{CallbackData: data, MessageID: messageID}, false)
+	})
 }
 
 // HandleContact processes a contact share (phone number) from any platform.
 func (e *ChatEngine) HandleContact(ctx context.Context, m Messenger, platform, userID, chatID, phone string) error {
+	return e.mailboxes.do(ctx, platform, userID, func() error {
+		ctx, m := localize(ctx, m)
+		return e.dispatchInput(ctx, m, platform, userID, chatID, UserInput{Phone: phone}, false)
+	})
+}
+
+// dispatchInput runs one Load → HandleInput → Save cycle for the user's current step.
+// It must only be called from inside the user's mailbox.
+// When the user has no state, onboarding is started if startIfMissing is set.
+// Input from a chat in human mode is left to the manager.
+func (e *ChatEngine) dispatchInput(ctx context.Context, m Messenger, platform, userID, chatID string, input UserInput, startIfMissing bool) error {
+	if e.humanMode(platform, userID) {
+		return nil
+	}
+
 	m = newLoggingMessenger(m, e.messageListener, platform, userID)
 
 	state, err := e.storage.Load(ctx, platform, userID)
 	if err != nil {
 		return fmt.Errorf("loading state: %w", err)
 	}
+	trackLocale(ctx, state)
+
+	// Global intents take precedence over the current step; a caption never triggers one
+	if input.Text != "" && len(input.Attachments) == 0 {
+		if intent, ok := e.matchIntent(input.Text, state); ok {
+			if handled, err := e.applyIntent(ctx, m, platform, userID, chatID, state, intent); handled {
+				return err
+			}
+		}
+	}
+
+	// No active workflow — start onboarding
 	if state == nil {
-		return nil
+		if !startIfMissing {
+			return nil
+		}
+		return e.startWorkflowWithData(ctx, m, platform, userID, chatID, "onboarding", nil)
 	}
 
-	w, ok := e.workflows[state.WorkflowID]
+	w, ok := e.workflow(state.WorkflowID)
 	if !ok {
 		return fmt.Errorf("workflow not found: %s", state.WorkflowID)
 	}
@@ -119,9 +201,8 @@ func (e *ChatEngine) HandleContact(ctx context.Context, m Messenger, platform, u
 		return fmt.Errorf("step not found: %s", state.CurrentStep)
 	}
 
-	input := UserInput{Phone: phone}
 	result := step.HandleInput(ctx, m, state, input)
-	return e.processResult(ctx, m, state, w, result)
+	return e.processResult(ctx, m, state, w, result, true)
 }
 
 // StartWorkflow begins a new workflow for a user.
@@ -130,15 +211,44 @@ func (e *ChatEngine) StartWorkflow(ctx context.Context, m Messenger, platform, u
 }
 
 // StartWorkflowWithData begins a new workflow for a user with initial state data.
+// Like any input, a start from a chat in human mode (a /start command or a deep link)
+// is left to the manager.
 func (e *ChatEngine) StartWorkflowWithData(ctx context.Context, m Messenger, platform, userID, chatID string, workflowID WorkflowID, initialData map[string]any) error {
+	return e.mailboxes.do(ctx, platform, userID, func() error {
+		if e.humanMode(platform, userID) {
+			return nil
+		}
+		ctx, m := localize(ctx, m)
+		return e.startWorkflowWithData(ctx, m, platform, userID, chatID, workflowID, initialData)
+	})
+}
+
+// startWorkflowWithData is the mailbox-internal implementation of StartWorkflowWithData.
+func (e *ChatEngine) startWorkflowWithData(ctx context.Context, m Messenger, platform, userID, chatID string, workflowID WorkflowID, initialData map[string]any) error {
 	m = newLoggingMessenger(m, e.messageListener, platform, userID)
 
-	w, ok := e.workflows[workflowID]
+	w, ok := e.workflow(workflowID)
 	if !ok {
 		return fmt.Errorf("workflow not found: %s", workflowID)
 	}
 
+	// Carry over the stored version so the new state replaces the old one
+	// instead of being rejected as a concurrent write.
+	prev, err := e.storage.Load(ctx, platform, userID)
+	if err != nil {
+		return fmt.Errorf("loading state: %w", err)
+	}
+
 	state := NewChatState(platform, userID, chatID, workflowID, w.InitialStep())
+	if prev != nil {
+		state.Version = prev.Version
+		state.Locale = prev.Locale
+	}
+	// The stored locale outlives the state and follows a switch made earlier in this event
+	if locale := e.storedLocale(ctx, platform, userID); locale != "" {
+		state.Locale = locale
+	}
+	trackLocale(ctx, state)
 	if initialData != nil {
 		state.MergeData(initialData)
 	}
@@ -157,13 +267,16 @@ func (e *ChatEngine) StartWorkflowWithData(ctx context.Context, m Messenger, pla
 		slog.String("user_id", userID),
 		slog.String("workflow_id", string(workflowID)),
 	)
+	e.recordEvent(ctx, EventStart, state, state.CurrentStep, nil)
 
 	result := step.Enter(ctx, m, state)
-	return e.processResult(ctx, m, state, w, result)
+	return e.processResult(ctx, m, state, w, result, false)
 }
 
 // processResult handles the result of a step handler — transitions, chaining, state saves.
-func (e *ChatEngine) processResult(ctx context.Context, m Messenger, state *ChatState, w Workflow, result StepResult) error {
+// fromInput reports whether result came from a step the user was waiting at
+// (HandleInput or OnTimeout), in which case that step is recorded in the history.
+func (e *ChatEngine) processResult(ctx context.Context, m Messenger, state *ChatState, w Workflow, result StepResult, fromInput bool) error {
 	if result.Error != nil {
 		e.log.Error("chat engine: step error",
 			slog.String("platform", state.Platform),
@@ -171,8 +284,10 @@ func (e *ChatEngine) processResult(ctx context.Context, m Messenger, state *Chat
 			slog.String("step_id", string(state.CurrentStep)),
 			slog.String("error", result.Error.Error()),
 		)
+		e.recordEvent(ctx, EventError, state, "", result.Error)
 		return result.Error
 	}
+	e.saveLocale(ctx, state)
 
 	// Merge any state updates
 	if result.UpdateState != nil {
@@ -187,23 +302,26 @@ func (e *ChatEngine) processResult(ctx context.Context, m Messenger, state *Chat
 			slog.String("user_id", state.UserID),
 			slog.String("workflow_id", string(state.WorkflowID)),
 		)
+		e.recordEvent(ctx, EventComplete, state, "", nil)
 
 		// Check if there's a next workflow to chain to
+		// The chained workflow replaces the stored state, inheriting its version and locale
 		nextWorkflowID := state.GetString("next_workflow")
 		if nextWorkflowID != "" {
-			if err := e.storage.Delete(ctx, state.Platform, state.UserID); err != nil {
-				return err
-			}
-			return e.StartWorkflowWithData(ctx, m, state.Platform, state.UserID, state.ChatID, WorkflowID(nextWorkflowID), deepLinkData(state))
+			return e.startWorkflowWithData(ctx, m, state.Platform, state.UserID, state.ChatID, WorkflowID(nextWorkflowID), deepLinkData(state))
 		}
 
 		return e.storage.Delete(ctx, state.Platform, state.UserID)
 	}
 
 	// Transition to next step if specified, looping through auto-transitions
+	back := resolveBack(w, state, &result)
 	const maxTransitions = 20
 	for i := 0; result.NextStep != "" && result.NextStep != state.CurrentStep && i < maxTransitions; i++ {
+		recordHistory(w, state, result.NextStep, fromInput && i == 0 && !back)
+		e.recordEvent(ctx, EventTransition, state, result.NextStep, nil)
 		state.CurrentStep = result.NextStep
+		state.StepEnteredAt = time.Now()
 
 		if err := e.storage.Save(ctx, state); err != nil {
 			return fmt.Errorf("saving state after transition: %w", err)
@@ -222,8 +340,11 @@ func (e *ChatEngine) processResult(ctx context.Context, m Messenger, state *Chat
 
 		result = step.Enter(ctx, m, state)
 		if result.Error != nil {
+			e.recordEvent(ctx, EventError, state, "", result.Error)
 			return result.Error
 		}
+		e.saveLocale(ctx, state)
+		back = resolveBack(w, state, &result)
 
 		if result.UpdateState != nil {
 			state.MergeData(result.UpdateState)
@@ -236,13 +357,11 @@ func (e *ChatEngine) processResult(ctx context.Context, m Messenger, state *Chat
 				slog.String("user_id", state.UserID),
 				slog.String("workflow_id", string(state.WorkflowID)),
 			)
+			e.recordEvent(ctx, EventComplete, state, "", nil)
 
 			nextWorkflowID := state.GetString("next_workflow")
 			if nextWorkflowID != "" {
-				if err := e.storage.Delete(ctx, state.Platform, state.UserID); err != nil {
-					return err
-				}
-				return e.StartWorkflowWithData(ctx, m, state.Platform, state.UserID, state.ChatID, WorkflowID(nextWorkflowID), deepLinkData(state))
+				return e.startWorkflowWithData(ctx, m, state.Platform, state.UserID, state.ChatID, WorkflowID(nextWorkflowID), deepLinkData(state))
 			}
 
 			return e.storage.Delete(ctx, state.Platform, state.UserID)
@@ -252,30 +371,6 @@ func (e *ChatEngine) processResult(ctx context.Context, m Messenger, state *Chat
 	return e.storage.Save(ctx, state)
 }
 
-// ResetUsersAtSteps moves all users currently at any of the given steps to targetStep.
-// Returns the count of affected states. Intended for admin use only.
-func (e *ChatEngine) ResetUsersAtSteps(ctx context.Context, workflowID WorkflowID, steps []StepID, targetStep StepID) (int, error) {
-	states, err := e.storage.FindBySteps(ctx, workflowID, steps)
-	if err != nil {
-		return 0, fmt.Errorf("finding states by steps: %w", err)
-	}
-
-	for _, state := range states {
-		state.CurrentStep = targetStep
-		if err := e.storage.Save(ctx, state); err != nil {
-			return 0, fmt.Errorf("saving reset state for %s/%s: %w", state.Platform, state.UserID, err)
-		}
-	}
-
-	e.log.Info("chat engine: reset users at steps",
-		slog.String("workflow_id", string(workflowID)),
-		slog.String("target_step", string(targetStep)),
-		slog.Int("count", len(states)),
-	)
-
-	return len(states), nil
-}
-
 // deepLinkData extracts deep link keys from state to carry through workflow chaining.
 func deepLinkData(state *ChatState) map[string]any {
 	dlType := state.GetString("deep_link_type")
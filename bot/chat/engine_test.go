@@ -0,0 +1,202 @@
+package chat_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"DarkCS/bot/chat"
+	"DarkCS/bot/chat/chattest"
+	"DarkCS/internal/lib/i18n"
+)
+
+// counterStep counts the messages it receives in the state and fails the test
+// if two inputs of the same user are ever handled at once.
+type counterStep struct {
+	t      *testing.T
+	active atomic.Int32
+}
+
+func (s *counterStep) ID() chat.StepID { return "count" }
+
+func (s *counterStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+	return chat.StepResult{}
+}
+
+func (s *counterStep) HandleInput(ctx context.Context, m chat.Messenger, state *chat.ChatState, input chat.UserInput) chat.StepResult {
+	if s.active.Add(1) > 1 {
+		s.t.Error("inputs of the same user handled concurrently")
+	}
+	defer s.active.Add(-1)
+
+	// Give a concurrent input the chance to interleave between Load and Save
+	time.Sleep(time.Millisecond)
+	return chat.StepResult{UpdateState: map[string]any{"count": state.GetInt("count") + 1}}
+}
+
+type counterWorkflow struct{ step *counterStep }
+
+func (w counterWorkflow) ID() chat.WorkflowID      { return "counter" }
+func (w counterWorkflow) InitialStep() chat.StepID { return w.step.ID() }
+func (w counterWorkflow) GetStep(id chat.StepID) (chat.Step, bool) {
+	return w.step, id == w.step.ID()
+}
+
+func TestConcurrentInputOfSameUserIsSerialized(t *testing.T) {
+	ctx := context.Background()
+	storage := chattest.NewMemoryStorage()
+	engine := chattest.NewEngine(storage, chattest.DiscardLogger(), counterWorkflow{&counterStep{t: t}})
+	m := chattest.NewMessenger()
+
+	if err := engine.StartWorkflow(ctx, m, "telegram", "100", "100", "counter"); err != nil {
+		t.Fatalf("start workflow: %v", err)
+	}
+
+	const messages = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, messages)
+	for range messages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- engine.HandleMessage(ctx, m, "telegram", "100", "100", "+1")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("handle message: %v", err)
+		}
+	}
+
+	state, err := storage.Load(ctx, "telegram", "100")
+	if err != nil || state == nil {
+		t.Fatalf("load state: %v, %v", state, err)
+	}
+	if got := state.GetInt("count"); got != messages {
+		t.Errorf("count = %d, want %d: updates were lost", got, messages)
+	}
+}
+
+func TestStaleVersionSaveIsRejected(t *testing.T) {
+	ctx := context.Background()
+	storage := chattest.NewMemoryStorage()
+
+	if err := storage.Save(ctx, chat.NewChatState("telegram", "100", "100", "counter", "count")); err != nil {
+		t.Fatalf("initial save: %v", err)
+	}
+
+	first, _ := storage.Load(ctx, "telegram", "100")
+	second, _ := storage.Load(ctx, "telegram", "100")
+
+	first.CurrentStep = "first"
+	if err := storage.Save(ctx, first); err != nil {
+		t.Fatalf("save first: %v", err)
+	}
+
+	second.CurrentStep = "second"
+	if err := storage.Save(ctx, second); !errors.Is(err, chat.ErrStateConflict) {
+		t.Fatalf("stale save error = %v, want ErrStateConflict", err)
+	}
+
+	// A concurrent creation carries version 0 and must not replace the stored state either
+	fresh := chat.NewChatState("telegram", "100", "100", "counter", "fresh")
+	if err := storage.Save(ctx, fresh); !errors.Is(err, chat.ErrStateConflict) {
+		t.Fatalf("version-0 save error = %v, want ErrStateConflict", err)
+	}
+
+	state, _ := storage.Load(ctx, "telegram", "100")
+	if state.CurrentStep != "first" {
+		t.Errorf("stored step = %s, want first", state.CurrentStep)
+	}
+}
+
+// nameStep asks for free text and records the answers.
+type nameStep struct{ answers []string }
+
+func (s *nameStep) ID() chat.StepID { return "name" }
+
+func (s *nameStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+	return chat.StepResult{}
+}
+
+func (s *nameStep) HandleInput(ctx context.Context, m chat.Messenger, state *chat.ChatState, input chat.UserInput) chat.StepResult {
+	s.answers = append(s.answers, input.Text)
+	return chat.StepResult{}
+}
+
+func (s *nameStep) FreeInput() bool { return true }
+
+type nameWorkflow struct{ step *nameStep }
+
+func (w nameWorkflow) ID() chat.WorkflowID      { return "name" }
+func (w nameWorkflow) InitialStep() chat.StepID { return w.step.ID() }
+func (w nameWorkflow) GetStep(id chat.StepID) (chat.Step, bool) {
+	return w.step, id == w.step.ID()
+}
+
+func TestFreeInputStepMatchesOnlyCommands(t *testing.T) {
+	step := &nameStep{}
+	c := chattest.New(t, "telegram", "100", nameWorkflow{step}, counterWorkflow{&counterStep{t: t}})
+	c.Engine.RegisterIntent(chat.Intent{
+		Name:     "counter",
+		Keywords: []string{"/count", "count", "0"},
+		Workflow: "counter",
+	})
+	c.Engine.RegisterIntent(chat.Intent{Name: "name", Keywords: []string{"name"}, Workflow: "name"})
+	c.Start("name")
+
+	// Keywords are answers at a free input step
+	c.Send("count").ExpectStep("name")
+	c.Send("0").ExpectStep("name")
+	if len(step.answers) != 2 {
+		t.Fatalf("answers = %q, want both keywords", step.answers)
+	}
+
+	// Commands still interrupt it
+	c.Send("/count").ExpectWorkflow("counter").ExpectStep("count")
+
+	// Elsewhere every keyword matches
+	c.Send("name").ExpectWorkflow("name")
+}
+
+// fileStep answers every file with a menu.
+type fileStep struct{}
+
+func (s fileStep) ID() chat.StepID { return "file" }
+
+func (s fileStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+	return chat.StepResult{}
+}
+
+func (s fileStep) HandleInput(ctx context.Context, m chat.Messenger, state *chat.ChatState, input chat.UserInput) chat.StepResult {
+	if len(input.Attachments) > 0 {
+		_ = m.SendMenu(state.ChatID, "received", [][]chat.MenuButton{{{Text: "back"}}})
+	}
+	return chat.StepResult{}
+}
+
+type fileWorkflow struct{}
+
+func (w fileWorkflow) ID() chat.WorkflowID      { return "file" }
+func (w fileWorkflow) InitialStep() chat.StepID { return fileStep{}.ID() }
+func (w fileWorkflow) GetStep(id chat.StepID) (chat.Step, bool) {
+	return fileStep{}, id == fileStep{}.ID()
+}
+
+func TestAttachmentRepliesUseUserLocale(t *testing.T) {
+	c := chattest.New(t, "whatsapp", "100", fileWorkflow{})
+	if err := c.Storage.SetChatLocale(context.Background(), "whatsapp", "100", i18n.English); err != nil {
+		t.Fatalf("set locale: %v", err)
+	}
+	c.Start("file")
+
+	c.Attach("", chattest.NewAttachment(chat.AttachmentImage, "photo.jpg", "image/jpeg", []byte("jpeg"))).
+		ExpectMenu("back").
+		ExpectLocale(i18n.English)
+}
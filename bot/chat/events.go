@@ -0,0 +1,145 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+const (
+	// eventBacklog is the number of events that may wait for the database in an EventWriter.
+	eventBacklog = 1024
+	// eventSaveTimeout bounds a single write of an EventWriter.
+	eventSaveTimeout = 5 * time.Second
+)
+
+// errEventBacklogFull is returned by EventWriter when the database falls too far behind.
+var errEventBacklogFull = errors.New("workflow event backlog is full")
+
+// EventType classifies a workflow event.
+type EventType string
+
+const (
+	EventStart      EventType = "start"
+	EventTransition EventType = "transition"
+	EventComplete   EventType = "complete"
+	EventError      EventType = "error"
+)
+
+// WorkflowEvent is one entry of the workflow audit log.
+// FromStep is empty for starts; ToStep is empty for completions and errors.
+// Duration is how long the user stayed in FromStep before leaving it.
+type WorkflowEvent struct {
+	Type       EventType     `json:"type" bson:"type"`
+	Platform   string        `json:"platform" bson:"platform"`
+	UserID     string        `json:"user_id" bson:"user_id"`
+	WorkflowID WorkflowID    `json:"workflow_id" bson:"workflow_id"`
+	FromStep   StepID        `json:"from_step,omitempty" bson:"from_step,omitempty"`
+	ToStep     StepID        `json:"to_step,omitempty" bson:"to_step,omitempty"`
+	Error      string        `json:"error,omitempty" bson:"error,omitempty"`
+	Duration   time.Duration `json:"duration" bson:"duration"`
+	Timestamp  time.Time     `json:"timestamp" bson:"timestamp"`
+}
+
+// WorkflowEventRecorder persists workflow events for auditing and funnel analytics.
+type WorkflowEventRecorder interface {
+	SaveWorkflowEvent(ctx context.Context, event WorkflowEvent) error
+}
+
+// SetEventRecorder sets the recorder that receives every workflow start,
+// step transition, completion and step error.
+func (e *ChatEngine) SetEventRecorder(r WorkflowEventRecorder) {
+	e.eventRecorder = r
+}
+
+// EventWriter is a WorkflowEventRecorder that saves events in the background,
+// so a slow database never holds up the mailbox of the user whose event is
+// being processed. Events arriving while the backlog is full are dropped.
+type EventWriter struct {
+	recorder WorkflowEventRecorder
+	events   chan WorkflowEvent
+	log      *slog.Logger
+}
+
+// NewEventWriter creates a writer that passes events on to r once started.
+func NewEventWriter(r WorkflowEventRecorder, log *slog.Logger) *EventWriter {
+	return &EventWriter{
+		recorder: r,
+		events:   make(chan WorkflowEvent, eventBacklog),
+		log:      log,
+	}
+}
+
+// Start spawns the goroutine that saves queued events until ctx is cancelled.
+func (w *EventWriter) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case event := <-w.events:
+				w.save(ctx, event)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// SaveWorkflowEvent queues event without waiting for the database.
+func (w *EventWriter) SaveWorkflowEvent(_ context.Context, event WorkflowEvent) error {
+	select {
+	case w.events <- event:
+		return nil
+	default:
+		return errEventBacklogFull
+	}
+}
+
+func (w *EventWriter) save(ctx context.Context, event WorkflowEvent) {
+	ctx, cancel := context.WithTimeout(ctx, eventSaveTimeout)
+	defer cancel()
+	if err := w.recorder.SaveWorkflowEvent(ctx, event); err != nil {
+		w.log.Error("event writer: saving workflow event",
+			slog.String("platform", event.Platform),
+			slog.String("user_id", event.UserID),
+			slog.String("type", string(event.Type)),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// recordEvent stores an event about state leaving its current step for to.
+// Failures are logged and never interrupt the conversation.
+func (e *ChatEngine) recordEvent(ctx context.Context, typ EventType, state *ChatState, to StepID, stepErr error) {
+	if e.eventRecorder == nil {
+		return
+	}
+
+	now := time.Now()
+	event := WorkflowEvent{
+		Type:       typ,
+		Platform:   state.Platform,
+		UserID:     state.UserID,
+		WorkflowID: state.WorkflowID,
+		ToStep:     to,
+		Timestamp:  now,
+	}
+	if typ != EventStart {
+		event.FromStep = state.CurrentStep
+		if !state.StepEnteredAt.IsZero() {
+			event.Duration = now.Sub(state.StepEnteredAt)
+		}
+	}
+	if stepErr != nil {
+		event.Error = stepErr.Error()
+	}
+
+	if err := e.eventRecorder.SaveWorkflowEvent(ctx, event); err != nil {
+		e.log.Error("chat engine: saving workflow event",
+			slog.String("platform", state.Platform),
+			slog.String("user_id", state.UserID),
+			slog.String("type", string(typ)),
+			slog.String("error", err.Error()),
+		)
+	}
+}
@@ -0,0 +1,65 @@
+package chat_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"DarkCS/bot/chat"
+	"DarkCS/bot/chat/chattest"
+)
+
+// slowRecorder holds every save until release is closed, then passes the event on.
+type slowRecorder struct {
+	release chan struct{}
+	saved   chan chat.WorkflowEvent
+}
+
+func (r *slowRecorder) SaveWorkflowEvent(ctx context.Context, event chat.WorkflowEvent) error {
+	<-r.release
+	r.saved <- event
+	return nil
+}
+
+func TestEventWriterDoesNotWaitForTheDatabase(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	recorder := &slowRecorder{release: make(chan struct{}), saved: make(chan chat.WorkflowEvent, 3)}
+	writer := chat.NewEventWriter(recorder, chattest.DiscardLogger())
+	writer.Start(ctx)
+
+	steps := []chat.StepID{"first", "second", "third"}
+	for _, step := range steps {
+		event := chat.WorkflowEvent{Type: chat.EventTransition, ToStep: step}
+		if err := writer.SaveWorkflowEvent(ctx, event); err != nil {
+			t.Fatalf("queueing %s: %v", step, err)
+		}
+	}
+
+	close(recorder.release)
+	for _, want := range steps {
+		select {
+		case event := <-recorder.saved:
+			if event.ToStep != want {
+				t.Fatalf("saved %s, want %s", event.ToStep, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %s was never saved", want)
+		}
+	}
+}
+
+func TestEventWriterDropsEventsWhenTheBacklogIsFull(t *testing.T) {
+	ctx := context.Background()
+	writer := chat.NewEventWriter(&chattest.EventRecorder{}, chattest.DiscardLogger())
+
+	for i := 0; i < chat.EventBacklog; i++ {
+		if err := writer.SaveWorkflowEvent(ctx, chat.WorkflowEvent{}); err != nil {
+			t.Fatalf("queueing event %d: %v", i, err)
+		}
+	}
+	if err := writer.SaveWorkflowEvent(ctx, chat.WorkflowEvent{}); err == nil {
+		t.Fatal("expected an error once the backlog is full")
+	}
+}
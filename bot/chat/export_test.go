@@ -0,0 +1,19 @@
+package chat
+
+import (
+	"context"
+	"time"
+)
+
+// EventBacklog is the number of events an EventWriter queues.
+const EventBacklog = eventBacklog
+
+// SweepTimeouts runs one pass of the timeout sweeper.
+func (e *ChatEngine) SweepTimeouts(ctx context.Context) {
+	e.sweepTimeouts(ctx)
+}
+
+// SetClock replaces the clock of the timeout sweeper.
+func (e *ChatEngine) SetClock(now func() time.Time) {
+	e.now = now
+}
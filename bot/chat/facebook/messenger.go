@@ -0,0 +1,146 @@
+package facebook
+
+import (
+	"io"
+	"strings"
+
+	"DarkCS/bot/chat"
+	igmessenger "DarkCS/bot/chat/instagram"
+	"DarkCS/internal/lib/i18n"
+)
+
+// MessageSender can send a text message, media, quick replies or a button template to a recipient.
+// Facebook Messenger and Instagram share the Messenger Platform send API, so quick replies
+// and button templates are built with the Instagram helpers.
+// Text and media sends return the message ID used by delivery and read events.
+type MessageSender interface {
+	SendMessage(recipientID, text string) (string, error)
+	SendMediaMessage(recipientID, mediaURL, mediaType string) (string, error)
+	SendQuickReplies(recipientID, text string, replies []igmessenger.QuickReply) error
+	SendButtonTemplate(recipientID string, template igmessenger.ButtonTemplate) error
+}
+
+// Messenger implements chat.Messenger for Facebook Messenger.
+type Messenger struct {
+	sender MessageSender
+	// locale reports the user's locale for texts the messenger writes itself; nil means i18n.Default.
+	locale func() string
+}
+
+// NewMessenger creates a new Facebook Messenger.
+func NewMessenger(sender MessageSender) *Messenger {
+	return &Messenger{sender: sender}
+}
+
+// WithLocale returns a copy of the messenger that writes its own texts in the locale reported by locale.
+func (m *Messenger) WithLocale(locale func() string) chat.Messenger {
+	return &Messenger{sender: m.sender, locale: locale}
+}
+
+func (m *Messenger) lang() string {
+	if m.locale == nil {
+		return i18n.Default
+	}
+	return m.locale()
+}
+
+func (m *Messenger) SendFile(chatID string, file chat.FileMessage) error {
+	_, err := m.SendFileID(chatID, file)
+	return err
+}
+
+// SendFileID sends a file and returns the message ID of the file message.
+func (m *Messenger) SendFileID(chatID string, file chat.FileMessage) (string, error) {
+	// Attachments are sent by public URL; streaming bytes would need a separate upload.
+	if file.URL != "" && file.MIMEType != "" {
+		if file.Caption != "" {
+			_, _ = m.sender.SendMessage(chatID, file.Caption)
+		}
+		return m.sender.SendMediaMessage(chatID, file.URL, mediaType(file.MIMEType))
+	}
+	// Fallback: send filename as text when no public URL is available.
+	text := "[File: " + file.Filename + "]"
+	if file.Caption != "" {
+		text = file.Caption + "\n" + text
+	}
+	return m.sender.SendMessage(chatID, text)
+}
+
+// SendVideo sends a training video via Facebook Messenger.
+// r and cachedFileID are Telegram-specific and ignored here.
+// publicURL is used when available; otherwise falls back to sending the filename as text.
+func (m *Messenger) SendVideo(chatID string, r io.Reader, cachedFileID, publicURL, filename string, protected bool) (string, error) {
+	if publicURL != "" {
+		_, err := m.sender.SendMediaMessage(chatID, publicURL, "video")
+		return "", err
+	}
+	_, err := m.sender.SendMessage(chatID, i18n.T(m.lang(), "messenger.video", filename))
+	return "", err
+}
+
+func (m *Messenger) SendText(chatID, text string) error {
+	_, err := m.sender.SendMessage(chatID, text)
+	return err
+}
+
+// SendTextID sends a text message and returns its message ID.
+func (m *Messenger) SendTextID(chatID, text string) (string, error) {
+	return m.sender.SendMessage(chatID, text)
+}
+
+// SendMenu sends the buttons as quick replies when they fit, a numbered text menu otherwise.
+func (m *Messenger) SendMenu(chatID, text string, rows [][]chat.MenuButton) error {
+	if replies, ok := igmessenger.MenuQuickReplies(text, rows); ok {
+		return m.sender.SendQuickReplies(chatID, text, replies)
+	}
+	return m.SendText(chatID, chat.FormatNumberedMenu(m.lang(), text, rows))
+}
+
+func (m *Messenger) SendInlineOptions(chatID, text string, buttons []chat.InlineButton) error {
+	return m.sendInline(chatID, text, [][]chat.InlineButton{buttons}, chat.FormatNumberedInline(m.lang(), text, buttons))
+}
+
+func (m *Messenger) SendInlineGrid(chatID, text string, rows [][]chat.InlineButton) error {
+	return m.sendInline(chatID, text, rows, chat.FormatNumberedInlineGrid(m.lang(), text, rows))
+}
+
+// sendInline sends inline buttons as a button template, as quick replies when
+// there are more than a template holds, or as the numbered fallback text.
+func (m *Messenger) sendInline(chatID, text string, rows [][]chat.InlineButton, fallback string) error {
+	if template, ok := igmessenger.InlineButtonTemplate(text, rows); ok {
+		return m.sender.SendButtonTemplate(chatID, template)
+	}
+	if replies, ok := igmessenger.InlineQuickReplies(text, rows); ok {
+		return m.sender.SendQuickReplies(chatID, text, replies)
+	}
+	return m.SendText(chatID, fallback)
+}
+
+func (m *Messenger) EditInlineGrid(chatID, messageID, text string, rows [][]chat.InlineButton) error {
+	return m.SendInlineGrid(chatID, text, rows)
+}
+
+func (m *Messenger) SendContactRequest(chatID, text, buttonText string) error {
+	return m.SendText(chatID, text)
+}
+
+func (m *Messenger) SendTyping(chatID string) error {
+	return nil
+}
+
+func (m *Messenger) SendUploadAction(chatID string) error {
+	return nil
+}
+
+// mediaType maps a MIME type to a Messenger attachment type.
+func mediaType(mimeType string) string {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return "image"
+	case strings.HasPrefix(mimeType, "video/"):
+		return "video"
+	case strings.HasPrefix(mimeType, "audio/"):
+		return "audio"
+	}
+	return "file"
+}
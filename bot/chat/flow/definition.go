@@ -0,0 +1,191 @@
+package flow
+
+import (
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Built-in step types.
+const (
+	TypeMessage      = "message"
+	TypeMenu         = "menu"
+	TypeInlineChoice = "inline_choice"
+	TypeInput        = "input"
+	TypeAction       = "action"
+)
+
+// Definition is a workflow described in YAML.
+//
+//	id: feedback
+//	keywords: ["відгук", "/feedback"]
+//	initial_step: ask_rating
+//	steps:
+//	  - id: ask_rating
+//	    type: inline_choice
+//	    text: "Оцініть нашу роботу"
+//	    save_as: rating
+//	    options:
+//	      - { label: "👍", value: "good", next: thanks }
+//	      - { label: "👎", value: "bad", next: ask_comment }
+//
+// Keywords, when set, register a global intent that starts the workflow.
+type Definition struct {
+	ID          string           `yaml:"id"`
+	Keywords    []string         `yaml:"keywords"`
+	InitialStep string           `yaml:"initial_step"`
+	Steps       []StepDefinition `yaml:"steps"`
+}
+
+// StepDefinition describes a single step. Which fields apply depends on Type:
+//   - message: sends Text, then goes to Next (or completes the workflow);
+//   - menu: sends Text with reply keyboard Buttons and waits for a button;
+//   - inline_choice: sends Text with inline Options and waits for a choice;
+//   - input: sends Text and waits for free text that passes Validate;
+//   - action: runs the Go action registered under Action, then goes to Next
+//     (or completes the workflow) unless the action chose the transition itself.
+//
+// Text may reference state data as {{key}}.
+type StepDefinition struct {
+	ID       string      `yaml:"id"`
+	Type     string      `yaml:"type"`
+	Text     string      `yaml:"text"`
+	Next     string      `yaml:"next"`
+	Complete bool        `yaml:"complete"`
+	SaveAs   string      `yaml:"save_as"`
+	Buttons  [][]Option  `yaml:"buttons"`
+	Options  []Option    `yaml:"options"`
+	Validate *Validation `yaml:"validate"`
+	Action   string      `yaml:"action"`
+	Invalid  string      `yaml:"invalid"` // Reply when the input does not match any button or option
+}
+
+// Option is a menu button or an inline choice.
+// Value defaults to Label and is what gets stored under the step's save_as key.
+// Back returns the user to the previous step, using Next as the fallback.
+type Option struct {
+	Label string `yaml:"label"`
+	Value string `yaml:"value"`
+	Next  string `yaml:"next"`
+	Back  bool   `yaml:"back"`
+}
+
+func (o Option) value() string {
+	if o.Value != "" {
+		return o.Value
+	}
+	return o.Label
+}
+
+// Validation constrains the text accepted by an input step.
+type Validation struct {
+	Pattern   string `yaml:"pattern"`
+	MinLength int    `yaml:"min_length"`
+	MaxLength int    `yaml:"max_length"`
+	Error     string `yaml:"error"`
+}
+
+// Parse decodes a YAML workflow definition.
+func Parse(data []byte) (*Definition, error) {
+	var def Definition
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("decoding workflow: %w", err)
+	}
+	return &def, nil
+}
+
+// validate checks that the definition is complete and that every transition
+// and action it references exists.
+func (d *Definition) validate(actions Actions) error {
+	if d.ID == "" {
+		return fmt.Errorf("workflow id is required")
+	}
+	if len(d.Steps) == 0 {
+		return fmt.Errorf("workflow %s: no steps", d.ID)
+	}
+
+	ids := make(map[string]bool, len(d.Steps))
+	for _, s := range d.Steps {
+		if s.ID == "" {
+			return fmt.Errorf("workflow %s: step id is required", d.ID)
+		}
+		if ids[s.ID] {
+			return fmt.Errorf("workflow %s: duplicate step %s", d.ID, s.ID)
+		}
+		ids[s.ID] = true
+	}
+
+	if d.InitialStep == "" {
+		d.InitialStep = d.Steps[0].ID
+	}
+	if !ids[d.InitialStep] {
+		return fmt.Errorf("workflow %s: initial step %s not found", d.ID, d.InitialStep)
+	}
+
+	checkNext := func(step, next string) error {
+		if next != "" && !ids[next] {
+			return fmt.Errorf("workflow %s: step %s: unknown next step %s", d.ID, step, next)
+		}
+		return nil
+	}
+	checkOption := func(s StepDefinition, o Option) error {
+		if o.Next == "" && s.Next == "" && !s.Complete && !o.Back {
+			return fmt.Errorf("workflow %s: step %s: option %q needs next", d.ID, s.ID, o.Label)
+		}
+		return checkNext(s.ID, o.Next)
+	}
+
+	for _, s := range d.Steps {
+		if err := checkNext(s.ID, s.Next); err != nil {
+			return err
+		}
+
+		switch s.Type {
+		case TypeMessage:
+			if s.Next == "" && !s.Complete {
+				return fmt.Errorf("workflow %s: step %s: message needs next or complete", d.ID, s.ID)
+			}
+		case TypeMenu:
+			if len(s.Buttons) == 0 {
+				return fmt.Errorf("workflow %s: step %s: menu needs buttons", d.ID, s.ID)
+			}
+			for _, row := range s.Buttons {
+				for _, o := range row {
+					if err := checkOption(s, o); err != nil {
+						return err
+					}
+				}
+			}
+		case TypeInlineChoice:
+			if len(s.Options) == 0 {
+				return fmt.Errorf("workflow %s: step %s: inline_choice needs options", d.ID, s.ID)
+			}
+			for _, o := range s.Options {
+				if err := checkOption(s, o); err != nil {
+					return err
+				}
+			}
+		case TypeInput:
+			if s.Next == "" && !s.Complete {
+				return fmt.Errorf("workflow %s: step %s: input needs next or complete", d.ID, s.ID)
+			}
+			if s.Validate != nil && s.Validate.Pattern != "" {
+				if _, err := regexp.Compile(s.Validate.Pattern); err != nil {
+					return fmt.Errorf("workflow %s: step %s: invalid pattern: %w", d.ID, s.ID, err)
+				}
+			}
+		case TypeAction:
+			if s.Next == "" && !s.Complete {
+				return fmt.Errorf("workflow %s: step %s: action needs next or complete", d.ID, s.ID)
+			}
+			if _, ok := actions[s.Action]; !ok {
+				return fmt.Errorf("workflow %s: step %s: action %q is not registered", d.ID, s.ID, s.Action)
+			}
+		default:
+			return fmt.Errorf("workflow %s: step %s: unknown type %q", d.ID, s.ID, s.Type)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package flow_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"DarkCS/bot/chat"
+	"DarkCS/bot/chat/chattest"
+	"DarkCS/bot/chat/flow"
+)
+
+const feedbackYAML = `
+id: feedback
+keywords: ["/feedback"]
+steps:
+  - id: ask_rating
+    type: inline_choice
+    text: "How do you like us?"
+    save_as: rating
+    options:
+      - { label: "Good", value: "good", next: thanks }
+      - { label: "Bad", value: "bad", next: ask_comment }
+  - id: ask_comment
+    type: input
+    text: "What should we improve?"
+    save_as: comment
+    validate: { min_length: 5 }
+    next: thanks
+  - id: thanks
+    type: message
+    text: "Thanks, {{rating}}!"
+    complete: true
+`
+
+var actions = flow.Actions{
+	"noop": func(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+		return chat.StepResult{}
+	},
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func parse(t *testing.T, yaml string) (*flow.Workflow, error) {
+	t.Helper()
+	def, err := flow.Parse([]byte(yaml))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return flow.New(def, actions)
+}
+
+func TestFeedbackWorkflow(t *testing.T) {
+	w, err := parse(t, feedbackYAML)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	c := chattest.New(t, "telegram", "100", w)
+	c.Start("feedback").
+		ExpectInline("Good", "Bad").
+		ExpectStep("ask_rating")
+	c.Callback("bad").
+		ExpectText("What should we improve?").
+		ExpectData("rating", "bad")
+	c.Send("meh").
+		ExpectStep("ask_comment")
+	c.Send("Faster delivery").
+		ExpectText("Thanks, bad!").
+		ExpectNoState()
+}
+
+func TestValidateRejectsIncompleteDefinitions(t *testing.T) {
+	tests := map[string]string{
+		"action without next": `
+id: broken
+steps:
+  - { id: run, type: action, action: noop }
+`,
+		"unknown action": `
+id: broken
+steps:
+  - { id: run, type: action, action: missing, complete: true }
+`,
+		"dangling next": `
+id: broken
+steps:
+  - { id: hello, type: message, text: "hi", next: nowhere }
+`,
+		"option without next": `
+id: broken
+steps:
+  - id: choose
+    type: inline_choice
+    options: [{ label: "A" }]
+`,
+	}
+	for name, yaml := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parse(t, yaml); err == nil {
+				t.Error("definition accepted, want an error")
+			}
+		})
+	}
+}
+
+func TestLoadDirSkipsBadFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a-broken.yml":   "id: broken\nsteps:\n  - { id: run, type: action, action: noop }\n",
+		"b-feedback.yml": feedbackYAML,
+		"c-invalid.yaml": "id: [",
+	})
+
+	workflows, err := flow.LoadDir(dir, actions)
+	if len(workflows) != 1 || workflows[0].ID() != "feedback" {
+		t.Fatalf("loaded %d workflows, want only feedback", len(workflows))
+	}
+	if err == nil || !strings.Contains(err.Error(), "a-broken.yml") || !strings.Contains(err.Error(), "c-invalid.yaml") {
+		t.Errorf("error = %v, want both bad files named", err)
+	}
+}
+
+func TestReloadRejectsBuiltInIDs(t *testing.T) {
+	mainmenuYAML := strings.Replace(feedbackYAML, "id: feedback", "id: mainmenu", 1)
+	builtin, err := parse(t, mainmenuYAML)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	c := chattest.New(t, "telegram", "100", builtin)
+
+	dir := writeFiles(t, map[string]string{
+		"mainmenu.yml": strings.Replace(mainmenuYAML, "Thanks", "Overridden", 1),
+	})
+	ids, problems := flow.NewSource(dir, actions, c.Engine, chattest.DiscardLogger()).Reload()
+	if len(ids) != 0 || len(problems) != 1 || !errors.Is(problems[0], chat.ErrWorkflowExists) {
+		t.Fatalf("reload = %v, %v; want the built-in id rejected", ids, problems)
+	}
+
+	if err := c.Engine.RegisterWorkflow(builtin); !errors.Is(err, chat.ErrWorkflowExists) {
+		t.Errorf("registering a workflow twice: %v, want ErrWorkflowExists", err)
+	}
+
+	c.Start("mainmenu").Callback("good").ExpectText("Thanks, good!")
+}
+
+func TestReloadReplacesWorkflows(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"feedback.yml": feedbackYAML})
+	c := chattest.New(t, "telegram", "100")
+	source := flow.NewSource(dir, actions, c.Engine, chattest.DiscardLogger())
+
+	if ids, problems := source.Reload(); len(ids) != 1 || len(problems) != 0 {
+		t.Fatalf("reload = %v, %v", ids, problems)
+	}
+	c.Send("/feedback").ExpectStep("ask_rating")
+
+	// Edited text is picked up by the next reload
+	edited := strings.Replace(feedbackYAML, "How do you like us?", "Rate us", 1)
+	if err := os.WriteFile(filepath.Join(dir, "feedback.yml"), []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	source.Reload()
+	c.Send("/feedback").ExpectText("Rate us")
+
+	// A removed file takes its workflow and keyword intent with it
+	if err := os.Remove(filepath.Join(dir, "feedback.yml")); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := source.Reload(); len(ids) != 0 {
+		t.Fatalf("reload after removal installed %v", ids)
+	}
+	if err := c.Engine.StartWorkflow(context.Background(), c.Messenger, "telegram", "200", "200", "feedback"); err == nil {
+		t.Error("removed workflow still starts")
+	}
+}
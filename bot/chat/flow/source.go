@@ -0,0 +1,80 @@
+package flow
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+
+	"DarkCS/bot/chat"
+)
+
+// Source installs the workflows of a directory in a ChatEngine and reloads them on request,
+// so flows can be added or edited without a redeploy.
+type Source struct {
+	mu      sync.Mutex
+	dir     string
+	actions Actions
+	engine  *chat.ChatEngine
+	log     *slog.Logger
+}
+
+// NewSource creates a source for the YAML workflows in dir.
+func NewSource(dir string, actions Actions, engine *chat.ChatEngine, log *slog.Logger) *Source {
+	return &Source{
+		dir:     dir,
+		actions: actions,
+		engine:  engine,
+		log:     log,
+	}
+}
+
+// Reload loads the directory again and replaces the workflows installed by the previous
+// load, with their keyword intents. Invalid files and workflows whose id is taken by a
+// built-in workflow are skipped and logged; the rest are installed. It returns the ids
+// of the installed workflows and the problems found.
+//
+// Users in the middle of a workflow continue on the new definition; a removed step or
+// workflow fails their next input like any unknown step.
+func (s *Source) Reload() ([]string, []error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loaded, loadErr := LoadDir(s.dir, s.actions)
+
+	workflows := make([]chat.Workflow, 0, len(loaded))
+	var intents []chat.Intent
+	for _, w := range loaded {
+		workflows = append(workflows, w)
+		if intent, ok := w.Intent(); ok {
+			intents = append(intents, intent)
+		}
+	}
+	installed, setErr := s.engine.SetDeclarativeWorkflows(workflows, intents)
+
+	var problems []error
+	for _, err := range []error{loadErr, setErr} {
+		problems = append(problems, unjoin(err)...)
+	}
+	for _, err := range problems {
+		s.log.Error("skipped yaml workflow", slog.String("dir", s.dir), slog.String("error", err.Error()))
+	}
+
+	ids := make([]string, 0, len(installed))
+	for _, id := range installed {
+		ids = append(ids, string(id))
+	}
+	s.log.Info("yaml workflows loaded", slog.String("dir", s.dir), slog.Any("workflows", ids))
+	return ids, problems
+}
+
+// unjoin splits an error made by errors.Join back into its parts.
+func unjoin(err error) []error {
+	if err == nil {
+		return nil
+	}
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
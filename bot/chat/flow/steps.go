@@ -0,0 +1,207 @@
+package flow
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"DarkCS/bot/chat"
+)
+
+// Action is Go code invoked by an action step. A zero result continues with
+// the step's next (or completes the workflow when the step is marked complete).
+type Action func(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult
+
+// Actions maps action names used in YAML to their implementations.
+type Actions map[string]Action
+
+var placeholderRe = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// render substitutes {{key}} placeholders with values from the state data.
+func render(text string, state *chat.ChatState) string {
+	return placeholderRe.ReplaceAllStringFunc(text, func(ph string) string {
+		key := placeholderRe.FindStringSubmatch(ph)[1]
+		return state.GetString(key)
+	})
+}
+
+// Step is a chat.Step built from a StepDefinition.
+type Step struct {
+	def     StepDefinition
+	action  Action
+	pattern *regexp.Regexp
+}
+
+func newStep(def StepDefinition, actions Actions) *Step {
+	s := &Step{def: def, action: actions[def.Action]}
+	if def.Validate != nil && def.Validate.Pattern != "" {
+		s.pattern = regexp.MustCompile(def.Validate.Pattern)
+	}
+	return s
+}
+
+func (s *Step) ID() chat.StepID { return chat.StepID(s.def.ID) }
+
+// FreeInput reports whether the step is an input step, whose answer may be any text.
+func (s *Step) FreeInput() bool { return s.def.Type == TypeInput }
+
+func (s *Step) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+	text := render(s.def.Text, state)
+
+	switch s.def.Type {
+	case TypeMessage:
+		if err := m.SendText(state.ChatID, text); err != nil {
+			return chat.StepResult{Error: err}
+		}
+		return s.proceed(s.def.Next)
+
+	case TypeMenu:
+		_ = m.SendMenu(state.ChatID, text, s.menuRows())
+
+	case TypeInlineChoice:
+		_ = m.SendInlineOptions(state.ChatID, text, s.inlineButtons())
+
+	case TypeInput:
+		_ = m.SendText(state.ChatID, text)
+
+	case TypeAction:
+		if text != "" {
+			_ = m.SendText(state.ChatID, text)
+		}
+		result := s.action(ctx, m, state)
+		if result.NextStep == "" && !result.Complete && !result.Back && result.Error == nil {
+			next := s.proceed(s.def.Next)
+			result.NextStep, result.Complete = next.NextStep, next.Complete
+		}
+		return result
+	}
+
+	return chat.StepResult{}
+}
+
+func (s *Step) HandleInput(ctx context.Context, m chat.Messenger, state *chat.ChatState, input chat.UserInput) chat.StepResult {
+	switch s.def.Type {
+	case TypeMenu:
+		text := strings.TrimSpace(input.Text)
+		rows := s.menuRows()
+		if matched := chat.MatchNumberToOption(text, rows); matched != "" {
+			text = matched
+		}
+		for _, row := range s.def.Buttons {
+			for _, o := range row {
+				if o.Label == text {
+					return s.choose(o)
+				}
+			}
+		}
+		s.replyInvalid(m, state)
+
+	case TypeInlineChoice:
+		data := input.CallbackData
+		if data == "" {
+			data = chat.MatchNumberToInline(input.Text, s.inlineButtons())
+		}
+		for _, o := range s.def.Options {
+			if o.value() == data || (data == "" && o.Label == strings.TrimSpace(input.Text)) {
+				return s.choose(o)
+			}
+		}
+		s.replyInvalid(m, state)
+
+	case TypeInput:
+		text := strings.TrimSpace(input.Text)
+		if text == "" {
+			text = input.Phone
+		}
+		if !s.valid(text) {
+			msg := state.T("error.invalid_input")
+			if s.def.Validate != nil && s.def.Validate.Error != "" {
+				msg = s.def.Validate.Error
+			}
+			_ = m.SendText(state.ChatID, msg)
+			return chat.StepResult{}
+		}
+		result := s.proceed(s.def.Next)
+		if s.def.SaveAs != "" {
+			result.UpdateState = map[string]any{s.def.SaveAs: text}
+		}
+		return result
+	}
+
+	return chat.StepResult{}
+}
+
+// choose applies a selected menu button or inline option.
+func (s *Step) choose(o Option) chat.StepResult {
+	next := o.Next
+	if next == "" {
+		next = s.def.Next
+	}
+
+	var result chat.StepResult
+	if o.Back {
+		result = chat.StepResult{Back: true, NextStep: chat.StepID(next)}
+	} else {
+		result = s.proceed(next)
+	}
+	if s.def.SaveAs != "" && !o.Back {
+		result.UpdateState = map[string]any{s.def.SaveAs: o.value()}
+	}
+	return result
+}
+
+// proceed moves to next, or completes the workflow when there is nowhere to go.
+func (s *Step) proceed(next string) chat.StepResult {
+	if next == "" {
+		return chat.StepResult{Complete: s.def.Complete}
+	}
+	return chat.StepResult{NextStep: chat.StepID(next)}
+}
+
+func (s *Step) valid(text string) bool {
+	if text == "" {
+		return false
+	}
+	v := s.def.Validate
+	if v == nil {
+		return true
+	}
+	n := utf8.RuneCountInString(text)
+	if v.MinLength > 0 && n < v.MinLength {
+		return false
+	}
+	if v.MaxLength > 0 && n > v.MaxLength {
+		return false
+	}
+	if s.pattern != nil && !s.pattern.MatchString(text) {
+		return false
+	}
+	return true
+}
+
+func (s *Step) replyInvalid(m chat.Messenger, state *chat.ChatState) {
+	if s.def.Invalid != "" {
+		_ = m.SendText(state.ChatID, render(s.def.Invalid, state))
+	}
+}
+
+func (s *Step) menuRows() [][]chat.MenuButton {
+	rows := make([][]chat.MenuButton, 0, len(s.def.Buttons))
+	for _, row := range s.def.Buttons {
+		buttons := make([]chat.MenuButton, 0, len(row))
+		for _, o := range row {
+			buttons = append(buttons, chat.MenuButton{Text: o.Label})
+		}
+		rows = append(rows, buttons)
+	}
+	return rows
+}
+
+func (s *Step) inlineButtons() []chat.InlineButton {
+	buttons := make([]chat.InlineButton, 0, len(s.def.Options))
+	for _, o := range s.def.Options {
+		buttons = append(buttons, chat.InlineButton{Text: o.Label, Data: o.value()})
+	}
+	return buttons
+}
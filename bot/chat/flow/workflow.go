@@ -0,0 +1,114 @@
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"DarkCS/bot/chat"
+)
+
+// Workflow is a chat.Workflow built from a YAML definition.
+// It is registered with ChatEngine.RegisterWorkflow like hand-written workflows.
+type Workflow struct {
+	def   *Definition
+	steps map[chat.StepID]chat.Step
+	order []chat.Step
+}
+
+// New validates the definition and builds a workflow from it.
+// Every action referenced by an action step must be present in actions.
+func New(def *Definition, actions Actions) (*Workflow, error) {
+	if err := def.validate(actions); err != nil {
+		return nil, err
+	}
+
+	w := &Workflow{
+		def:   def,
+		steps: make(map[chat.StepID]chat.Step, len(def.Steps)),
+	}
+	for _, sd := range def.Steps {
+		step := newStep(sd, actions)
+		w.steps[step.ID()] = step
+		w.order = append(w.order, step)
+	}
+	return w, nil
+}
+
+// Load reads and builds a workflow from a YAML file.
+func Load(path string, actions Actions) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading workflow: %w", err)
+	}
+	def, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	w, err := New(def, actions)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return w, nil
+}
+
+// LoadDir builds a workflow from every *.yml and *.yaml file in dir, in file name order.
+// A file that fails to load is skipped; the failures are returned joined in the error
+// next to the workflows that did load. Two files declaring the same workflow id keep
+// the first one.
+func LoadDir(dir string, actions Actions) ([]*Workflow, error) {
+	var paths []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	slices.Sort(paths)
+
+	workflows := make([]*Workflow, 0, len(paths))
+	seen := make(map[chat.WorkflowID]string, len(paths))
+	var errs []error
+	for _, path := range paths {
+		w, err := Load(path, actions)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if first, ok := seen[w.ID()]; ok {
+			errs = append(errs, fmt.Errorf("%s: workflow %s is already defined in %s", path, w.ID(), first))
+			continue
+		}
+		seen[w.ID()] = path
+		workflows = append(workflows, w)
+	}
+	return workflows, errors.Join(errs...)
+}
+
+func (w *Workflow) ID() chat.WorkflowID      { return chat.WorkflowID(w.def.ID) }
+func (w *Workflow) InitialStep() chat.StepID { return chat.StepID(w.def.InitialStep) }
+
+func (w *Workflow) GetStep(id chat.StepID) (chat.Step, bool) {
+	step, ok := w.steps[id]
+	return step, ok
+}
+
+// Steps returns all steps of the workflow in definition order.
+func (w *Workflow) Steps() []chat.Step {
+	return w.order
+}
+
+// Intent returns the global intent that starts this workflow, if the definition declares keywords.
+func (w *Workflow) Intent() (chat.Intent, bool) {
+	if len(w.def.Keywords) == 0 {
+		return chat.Intent{}, false
+	}
+	return chat.Intent{
+		Name:     w.def.ID,
+		Keywords: w.def.Keywords,
+		Workflow: w.ID(),
+	}, true
+}
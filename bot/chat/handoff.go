@@ -0,0 +1,33 @@
+package chat
+
+import (
+	"log/slog"
+)
+
+// Handoff tracks the chats a manager has taken over from the bot ("human mode").
+// While a chat is in human mode the platform bots still store the user's messages
+// for the CRM, but the engine does not pass them to any step and step timeouts
+// do not fire, so the user only hears the manager.
+type Handoff interface {
+	// HumanMode reports whether a manager is handling the chat.
+	HumanMode(platform, userID string) bool
+	// RequestHuman turns human mode on because the user asked for a manager.
+	RequestHuman(platform, userID string)
+}
+
+// SetHandoff sets the human mode tracker. Without one the bot answers every message.
+func (e *ChatEngine) SetHandoff(h Handoff) {
+	e.handoff = h
+}
+
+// humanMode reports whether the user's chat is left to a manager.
+func (e *ChatEngine) humanMode(platform, userID string) bool {
+	if e.handoff == nil || !e.handoff.HumanMode(platform, userID) {
+		return false
+	}
+	e.log.Debug("chat engine: human mode, input left to the manager",
+		slog.String("platform", platform),
+		slog.String("user_id", userID),
+	)
+	return true
+}
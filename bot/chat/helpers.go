@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"DarkCS/internal/lib/i18n"
 )
 
 // normalizePhone strips non-digit characters and prepends "+".
@@ -57,9 +59,9 @@ func MatchNumberToOption(text string, buttons [][]MenuButton) string {
 	return ""
 }
 
-// FormatNumberedMenu creates a numbered text menu from button rows.
+// FormatNumberedMenu creates a numbered text menu from button rows, ending with a prompt in locale.
 // Example output: "1. Option A\n2. Option B\n\nОберіть опцію:"
-func FormatNumberedMenu(text string, rows [][]MenuButton) string {
+func FormatNumberedMenu(locale, text string, rows [][]MenuButton) string {
 	var sb strings.Builder
 	sb.WriteString(text)
 	sb.WriteString("\n\n")
@@ -71,12 +73,12 @@ func FormatNumberedMenu(text string, rows [][]MenuButton) string {
 			idx++
 		}
 	}
-	sb.WriteString("\nОберіть опцію:")
+	sb.WriteString("\n" + i18n.T(locale, "messenger.choose_option"))
 	return sb.String()
 }
 
 // FormatNumberedInline creates a numbered text list from inline buttons.
-func FormatNumberedInline(text string, buttons []InlineButton) string {
+func FormatNumberedInline(locale, text string, buttons []InlineButton) string {
 	var sb strings.Builder
 	sb.WriteString(text)
 	sb.WriteString("\n\n")
@@ -84,13 +86,13 @@ func FormatNumberedInline(text string, buttons []InlineButton) string {
 	for i, btn := range buttons {
 		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, btn.Text))
 	}
-	sb.WriteString("\nОберіть опцію:")
+	sb.WriteString("\n" + i18n.T(locale, "messenger.choose_option"))
 	return sb.String()
 }
 
 // FormatNumberedInlineGrid creates a numbered text list from a multi-row inline grid.
 // Rows are flattened into a single numbered list for text-only platforms.
-func FormatNumberedInlineGrid(text string, rows [][]InlineButton) string {
+func FormatNumberedInlineGrid(locale, text string, rows [][]InlineButton) string {
 	var sb strings.Builder
 	sb.WriteString(text)
 	sb.WriteString("\n\n")
@@ -102,7 +104,7 @@ func FormatNumberedInlineGrid(text string, rows [][]InlineButton) string {
 			idx++
 		}
 	}
-	sb.WriteString("\nОберіть опцію:")
+	sb.WriteString("\n" + i18n.T(locale, "messenger.choose_option"))
 	return sb.String()
 }
 
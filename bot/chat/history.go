@@ -0,0 +1,63 @@
+package chat
+
+import "slices"
+
+// maxHistory bounds the number of steps kept in ChatState.History.
+const maxHistory = 10
+
+// PushHistory records a step as visited, dropping the oldest entry when the history is full.
+func (s *ChatState) PushHistory(step StepID) {
+	s.History = append(s.History, step)
+	if len(s.History) > maxHistory {
+		s.History = s.History[len(s.History)-maxHistory:]
+	}
+}
+
+// PopHistory removes and returns the most recently visited step.
+func (s *ChatState) PopHistory() (StepID, bool) {
+	if len(s.History) == 0 {
+		return "", false
+	}
+	step := s.History[len(s.History)-1]
+	s.History = s.History[:len(s.History)-1]
+	return step, true
+}
+
+// recordHistory updates the history for a transition to next. The step being
+// left is pushed when it was interactive and not transient. If next is already
+// in the history, everything from it onwards is dropped, so revisiting a menu
+// does not grow the stack.
+func recordHistory(w Workflow, state *ChatState, next StepID, leavingInteractive bool) {
+	if leavingInteractive && state.CurrentStep != "" {
+		step, ok := w.GetStep(state.CurrentStep)
+		transient := false
+		if ts, isTS := step.(TransientStep); ok && isTS {
+			transient = ts.Transient()
+		}
+		if ok && !transient {
+			state.PushHistory(state.CurrentStep)
+		}
+	}
+	if i := slices.Index(state.History, next); i >= 0 {
+		state.History = state.History[:i]
+	}
+}
+
+// resolveBack turns a Back result into a transition to the most recent step in
+// the history that still exists in the workflow. It reports whether a step was
+// popped; otherwise result.NextStep is left as the fallback.
+func resolveBack(w Workflow, state *ChatState, result *StepResult) bool {
+	if !result.Back {
+		return false
+	}
+	for {
+		prev, ok := state.PopHistory()
+		if !ok {
+			return false
+		}
+		if _, exists := w.GetStep(prev); exists {
+			result.NextStep = prev
+			return true
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// historyStep is a step that does nothing; transient marks it as never returned to via Back.
+type historyStep struct {
+	id        StepID
+	transient bool
+}
+
+func (s historyStep) ID() StepID { return s.id }
+func (s historyStep) Enter(context.Context, Messenger, *ChatState) StepResult {
+	return StepResult{}
+}
+func (s historyStep) HandleInput(context.Context, Messenger, *ChatState, UserInput) StepResult {
+	return StepResult{}
+}
+func (s historyStep) Transient() bool { return s.transient }
+
+type historyWorkflow map[StepID]historyStep
+
+func (w historyWorkflow) ID() WorkflowID      { return "history" }
+func (w historyWorkflow) InitialStep() StepID { return "menu" }
+func (w historyWorkflow) GetStep(id StepID) (Step, bool) {
+	step, ok := w[id]
+	return step, ok
+}
+
+func newHistoryWorkflow() historyWorkflow {
+	return historyWorkflow{
+		"menu":    {id: "menu"},
+		"catalog": {id: "catalog"},
+		"product": {id: "product"},
+		"waiting": {id: "waiting", transient: true},
+	}
+}
+
+func TestPushHistoryKeepsTheLatestSteps(t *testing.T) {
+	state := &ChatState{}
+	for i := range maxHistory + 3 {
+		state.PushHistory(StepID(fmt.Sprint(i)))
+	}
+
+	if len(state.History) != maxHistory {
+		t.Fatalf("history has %d steps, want %d", len(state.History), maxHistory)
+	}
+	if state.History[0] != "3" || state.History[maxHistory-1] != StepID(fmt.Sprint(maxHistory+2)) {
+		t.Errorf("history = %v, want the last %d steps", state.History, maxHistory)
+	}
+}
+
+func TestRecordHistory(t *testing.T) {
+	tests := []struct {
+		name        string
+		history     []StepID
+		current     StepID
+		next        StepID
+		interactive bool
+		want        []StepID
+	}{
+		{name: "interactive step is pushed", current: "menu", next: "catalog", interactive: true, want: []StepID{"menu"}},
+		{name: "step left without input is not pushed", current: "menu", next: "catalog", want: nil},
+		{name: "transient step is skipped", history: []StepID{"menu"}, current: "waiting", next: "catalog", interactive: true, want: []StepID{"menu"}},
+		{name: "unknown step is skipped", current: "removed", next: "catalog", interactive: true, want: nil},
+		{name: "revisiting a step truncates the history", history: []StepID{"menu", "catalog"}, current: "product", next: "catalog", interactive: true, want: []StepID{"menu"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &ChatState{CurrentStep: tt.current, History: slices.Clone(tt.history)}
+			recordHistory(newHistoryWorkflow(), state, tt.next, tt.interactive)
+			if !slices.Equal(state.History, tt.want) {
+				t.Errorf("history = %v, want %v", state.History, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveBack(t *testing.T) {
+	tests := []struct {
+		name     string
+		history  []StepID
+		fallback StepID
+		popped   bool
+		want     StepID
+		left     []StepID
+	}{
+		{name: "returns to the previous step", history: []StepID{"menu", "catalog"}, popped: true, want: "catalog", left: []StepID{"menu"}},
+		{name: "skips steps removed from the workflow", history: []StepID{"menu", "removed"}, popped: true, want: "menu"},
+		{name: "empty history keeps the fallback", fallback: "menu", want: "menu"},
+		{name: "empty history without fallback", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &ChatState{History: slices.Clone(tt.history)}
+			result := StepResult{Back: true, NextStep: tt.fallback}
+			if popped := resolveBack(newHistoryWorkflow(), state, &result); popped != tt.popped {
+				t.Fatalf("popped = %v, want %v", popped, tt.popped)
+			}
+			if result.NextStep != tt.want {
+				t.Errorf("next step = %q, want %q", result.NextStep, tt.want)
+			}
+			if !slices.Equal(state.History, tt.left) {
+				t.Errorf("history left = %v, want %v", state.History, tt.left)
+			}
+		})
+	}
+}
@@ -4,17 +4,23 @@ import (
 	"io"
 
 	"DarkCS/bot/chat"
+	"DarkCS/internal/lib/i18n"
 )
 
-// MessageSender can send a text message or media to a recipient.
+// MessageSender can send a text message, media, quick replies or a button template to a recipient.
+// Text and media sends return the Instagram message ID used by delivery and read events.
 type MessageSender interface {
-	SendMessage(recipientID, text string) error
-	SendMediaMessage(recipientID, mediaURL, mediaType string) error
+	SendMessage(recipientID, text string) (string, error)
+	SendMediaMessage(recipientID, mediaURL, mediaType string) (string, error)
+	SendQuickReplies(recipientID, text string, replies []QuickReply) error
+	SendButtonTemplate(recipientID string, template ButtonTemplate) error
 }
 
 // Messenger implements chat.Messenger for Instagram.
 type Messenger struct {
 	sender MessageSender
+	// locale reports the user's locale for texts the messenger writes itself; nil means i18n.Default.
+	locale func() string
 }
 
 // NewMessenger creates a new Instagram Messenger.
@@ -22,7 +28,25 @@ func NewMessenger(sender MessageSender) *Messenger {
 	return &Messenger{sender: sender}
 }
 
+// WithLocale returns a copy of the messenger that writes its own texts in the locale reported by locale.
+func (m *Messenger) WithLocale(locale func() string) chat.Messenger {
+	return &Messenger{sender: m.sender, locale: locale}
+}
+
+func (m *Messenger) lang() string {
+	if m.locale == nil {
+		return i18n.Default
+	}
+	return m.locale()
+}
+
 func (m *Messenger) SendFile(chatID string, file chat.FileMessage) error {
+	_, err := m.SendFileID(chatID, file)
+	return err
+}
+
+// SendFileID sends a file and returns the Instagram message ID of the file message.
+func (m *Messenger) SendFileID(chatID string, file chat.FileMessage) (string, error) {
 	// Instagram requires a publicly accessible URL — streaming bytes is not supported.
 	if file.URL != "" && file.MIMEType != "" {
 		mediaType := "file"
@@ -30,7 +54,7 @@ func (m *Messenger) SendFile(chatID string, file chat.FileMessage) error {
 			mediaType = "image"
 		}
 		if file.Caption != "" {
-			_ = m.sender.SendMessage(chatID, file.Caption)
+			_, _ = m.sender.SendMessage(chatID, file.Caption)
 		}
 		return m.sender.SendMediaMessage(chatID, file.URL, mediaType)
 	}
@@ -47,28 +71,49 @@ func (m *Messenger) SendFile(chatID string, file chat.FileMessage) error {
 // publicURL is used when available; otherwise falls back to sending the filename as text.
 func (m *Messenger) SendVideo(chatID string, r io.Reader, cachedFileID, publicURL, filename string, protected bool) (string, error) {
 	if publicURL != "" {
-		return "", m.sender.SendMediaMessage(chatID, publicURL, "video")
+		_, err := m.sender.SendMediaMessage(chatID, publicURL, "video")
+		return "", err
 	}
-	return "", m.sender.SendMessage(chatID, "[Відео: "+filename+"]")
+	_, err := m.sender.SendMessage(chatID, i18n.T(m.lang(), "messenger.video", filename))
+	return "", err
 }
 
 func (m *Messenger) SendText(chatID, text string) error {
+	_, err := m.sender.SendMessage(chatID, text)
+	return err
+}
+
+// SendTextID sends a text message and returns its Instagram message ID.
+func (m *Messenger) SendTextID(chatID, text string) (string, error) {
 	return m.sender.SendMessage(chatID, text)
 }
 
+// SendMenu sends the buttons as quick replies when they fit, a numbered text menu otherwise.
 func (m *Messenger) SendMenu(chatID, text string, rows [][]chat.MenuButton) error {
-	formatted := chat.FormatNumberedMenu(text, rows)
-	return m.sender.SendMessage(chatID, formatted)
+	if replies, ok := MenuQuickReplies(text, rows); ok {
+		return m.sender.SendQuickReplies(chatID, text, replies)
+	}
+	return m.SendText(chatID, chat.FormatNumberedMenu(m.lang(), text, rows))
 }
 
 func (m *Messenger) SendInlineOptions(chatID, text string, buttons []chat.InlineButton) error {
-	formatted := chat.FormatNumberedInline(text, buttons)
-	return m.sender.SendMessage(chatID, formatted)
+	return m.sendInline(chatID, text, [][]chat.InlineButton{buttons}, chat.FormatNumberedInline(m.lang(), text, buttons))
 }
 
 func (m *Messenger) SendInlineGrid(chatID, text string, rows [][]chat.InlineButton) error {
-	formatted := chat.FormatNumberedInlineGrid(text, rows)
-	return m.sender.SendMessage(chatID, formatted)
+	return m.sendInline(chatID, text, rows, chat.FormatNumberedInlineGrid(m.lang(), text, rows))
+}
+
+// sendInline sends inline buttons as a button template, as quick replies when
+// there are more than a template holds, or as the numbered fallback text.
+func (m *Messenger) sendInline(chatID, text string, rows [][]chat.InlineButton, fallback string) error {
+	if template, ok := InlineButtonTemplate(text, rows); ok {
+		return m.sender.SendButtonTemplate(chatID, template)
+	}
+	if replies, ok := InlineQuickReplies(text, rows); ok {
+		return m.sender.SendQuickReplies(chatID, text, replies)
+	}
+	return m.SendText(chatID, fallback)
 }
 
 func (m *Messenger) EditInlineGrid(chatID, messageID, text string, rows [][]chat.InlineButton) error {
@@ -76,7 +121,7 @@ func (m *Messenger) EditInlineGrid(chatID, messageID, text string, rows [][]chat
 }
 
 func (m *Messenger) SendContactRequest(chatID, text, buttonText string) error {
-	return m.sender.SendMessage(chatID, text)
+	return m.SendText(chatID, text)
 }
 
 func (m *Messenger) SendTyping(chatID string) error {
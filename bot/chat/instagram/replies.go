@@ -0,0 +1,122 @@
+package instagram
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"DarkCS/bot/chat"
+)
+
+// Instagram Messaging limits for quick replies and button templates.
+const (
+	maxQuickReplies       = 13
+	maxTemplateButtons    = 3
+	maxButtonTitleLen     = 20
+	maxPayloadLen         = 1000
+	maxQuickReplyTextLen  = 1000
+	maxTemplateTextLen    = 640
+	menuPayloadPrefix     = "menu:"
+	callbackPayloadPrefix = "cb:"
+)
+
+// QuickReply is a button shown above the keyboard; it disappears after a tap.
+// ContentType is always "text".
+type QuickReply struct {
+	ContentType string `json:"content_type"`
+	Title       string `json:"title"`
+	Payload     string `json:"payload"`
+}
+
+// ButtonTemplate is a text with up to three postback buttons attached to the message.
+type ButtonTemplate struct {
+	Text    string           `json:"text"`
+	Buttons []PostbackButton `json:"buttons"`
+}
+
+// PostbackButton is a template button; Type is always "postback".
+type PostbackButton struct {
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Payload string `json:"payload"`
+}
+
+// MenuQuickReplies builds quick replies for a reply keyboard.
+// A tap comes back as a menu payload carrying the button text (see ParseReplyPayload).
+// It reports false when the buttons do not fit; the caller then sends a numbered text menu.
+func MenuQuickReplies(text string, rows [][]chat.MenuButton) ([]QuickReply, bool) {
+	var replies []QuickReply
+	for _, row := range rows {
+		for _, btn := range row {
+			replies = append(replies, QuickReply{ContentType: "text", Title: btn.Text, Payload: menuPayloadPrefix + btn.Text})
+		}
+	}
+	return replies, quickRepliesFit(text, replies)
+}
+
+// InlineQuickReplies builds quick replies for inline buttons that are too many
+// for a button template. Rows are flattened; taps come back as callback payloads.
+func InlineQuickReplies(text string, rows [][]chat.InlineButton) ([]QuickReply, bool) {
+	var replies []QuickReply
+	for _, row := range rows {
+		for _, btn := range row {
+			replies = append(replies, QuickReply{ContentType: "text", Title: btn.Text, Payload: callbackPayloadPrefix + btn.Data})
+		}
+	}
+	return replies, quickRepliesFit(text, replies)
+}
+
+// InlineButtonTemplate builds a button template for up to three inline buttons.
+// A tap comes back as a postback carrying the button data (see ParseReplyPayload).
+func InlineButtonTemplate(text string, rows [][]chat.InlineButton) (ButtonTemplate, bool) {
+	template := ButtonTemplate{Text: text}
+	for _, row := range rows {
+		for _, btn := range row {
+			template.Buttons = append(template.Buttons, PostbackButton{Type: "postback", Title: btn.Text, Payload: callbackPayloadPrefix + btn.Data})
+		}
+	}
+
+	n := utf8.RuneCountInString(text)
+	if n == 0 || n > maxTemplateTextLen || len(template.Buttons) == 0 || len(template.Buttons) > maxTemplateButtons {
+		return ButtonTemplate{}, false
+	}
+	for _, btn := range template.Buttons {
+		if !titleFits(btn.Title) || len(btn.Payload) > maxPayloadLen {
+			return ButtonTemplate{}, false
+		}
+	}
+	return template, true
+}
+
+// ParseReplyPayload decodes the payload of a quick reply or postback. Menu payloads
+// return the button text, to be handled like a typed message; others return callback data.
+func ParseReplyPayload(payload string) (text, data string) {
+	if t, ok := strings.CutPrefix(payload, menuPayloadPrefix); ok {
+		return t, ""
+	}
+	return "", strings.TrimPrefix(payload, callbackPayloadPrefix)
+}
+
+// IsReplyPayload reports whether a payload was built by the helpers above. Postbacks
+// configured on the page itself, such as the Get Started button, carry other payloads.
+func IsReplyPayload(payload string) bool {
+	return strings.HasPrefix(payload, menuPayloadPrefix) || strings.HasPrefix(payload, callbackPayloadPrefix)
+}
+
+// quickRepliesFit reports whether the text and replies are within the API limits.
+func quickRepliesFit(text string, replies []QuickReply) bool {
+	n := utf8.RuneCountInString(text)
+	if n == 0 || n > maxQuickReplyTextLen || len(replies) == 0 || len(replies) > maxQuickReplies {
+		return false
+	}
+	for _, qr := range replies {
+		if !titleFits(qr.Title) || len(qr.Payload) > maxPayloadLen {
+			return false
+		}
+	}
+	return true
+}
+
+func titleFits(title string) bool {
+	n := utf8.RuneCountInString(title)
+	return n > 0 && n <= maxButtonTitleLen
+}
@@ -0,0 +1,72 @@
+package instagram
+
+import (
+	"strings"
+	"testing"
+
+	"DarkCS/bot/chat"
+)
+
+func TestReplyPayloads(t *testing.T) {
+	tests := []struct {
+		payload    string
+		isReply    bool
+		text, data string
+	}{
+		{payload: "menu:🛍 Catalog", isReply: true, text: "🛍 Catalog"},
+		{payload: "cb:order_42", isReply: true, data: "order_42"},
+		{payload: "cb:", isReply: true},
+		{payload: "GET_STARTED", isReply: false, data: "GET_STARTED"},
+		{payload: "", isReply: false},
+	}
+	for _, tt := range tests {
+		if got := IsReplyPayload(tt.payload); got != tt.isReply {
+			t.Errorf("IsReplyPayload(%q) = %v, want %v", tt.payload, got, tt.isReply)
+		}
+		text, data := ParseReplyPayload(tt.payload)
+		if text != tt.text || data != tt.data {
+			t.Errorf("ParseReplyPayload(%q) = %q, %q; want %q, %q", tt.payload, text, data, tt.text, tt.data)
+		}
+	}
+}
+
+func TestBuiltPayloadsRoundTrip(t *testing.T) {
+	replies, ok := MenuQuickReplies("Menu", [][]chat.MenuButton{{{Text: "Orders"}}})
+	if !ok {
+		t.Fatal("menu quick replies do not fit")
+	}
+	if text, _ := ParseReplyPayload(replies[0].Payload); text != "Orders" {
+		t.Errorf("menu reply decodes to %q, want %q", text, "Orders")
+	}
+
+	template, ok := InlineButtonTemplate("Pick", [][]chat.InlineButton{{{Text: "Yes", Data: "confirm"}}})
+	if !ok {
+		t.Fatal("button template does not fit")
+	}
+	payload := template.Buttons[0].Payload
+	if _, data := ParseReplyPayload(payload); !IsReplyPayload(payload) || data != "confirm" {
+		t.Errorf("postback %q decodes to %q, want a reply payload with %q", payload, data, "confirm")
+	}
+}
+
+func TestInlineButtonTemplateLimits(t *testing.T) {
+	button := func(title, data string) chat.InlineButton { return chat.InlineButton{Text: title, Data: data} }
+	tests := []struct {
+		name string
+		text string
+		rows [][]chat.InlineButton
+		ok   bool
+	}{
+		{name: "three buttons", text: "Pick", rows: [][]chat.InlineButton{{button("A", "a"), button("B", "b")}, {button("C", "c")}}, ok: true},
+		{name: "four buttons", text: "Pick", rows: [][]chat.InlineButton{{button("A", "a"), button("B", "b"), button("C", "c"), button("D", "d")}}},
+		{name: "long title", text: "Pick", rows: [][]chat.InlineButton{{button(strings.Repeat("a", maxButtonTitleLen+1), "a")}}},
+		{name: "long payload", text: "Pick", rows: [][]chat.InlineButton{{button("A", strings.Repeat("a", maxPayloadLen))}}},
+		{name: "long text", text: strings.Repeat("a", maxTemplateTextLen+1), rows: [][]chat.InlineButton{{button("A", "a")}}},
+		{name: "no text", rows: [][]chat.InlineButton{{button("A", "a")}}},
+	}
+	for _, tt := range tests {
+		if _, ok := InlineButtonTemplate(tt.text, tt.rows); ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Intent is a global command that is recognised on every platform before the
+// input is dispatched to the user's current step.
+//
+// An intent performs exactly one action, checked in this order:
+//   - Step set: jump to Step, but only while the user is inside Workflow;
+//   - Workflow set: (re)start Workflow from its initial step;
+//   - Handle set: run Handle against the user's current state.
+//
+// An intent whose action does not apply (e.g. a jump for a user in another
+// workflow) is ignored and the input reaches the current step as usual.
+type Intent struct {
+	Name string
+	// Keywords are matched case-insensitively against the whole trimmed message
+	// text. Numbers (e.g. "0") work the same way on text-only platforms.
+	// At a FreeInputStep only keywords starting with "/" are matched.
+	Keywords []string
+	Workflow WorkflowID
+	Step     StepID
+	Handle   func(ctx context.Context, m Messenger, state *ChatState) StepResult
+	// Handoff puts the chat in human mode once the action has run.
+	Handoff bool
+}
+
+// matches reports whether text triggers the intent. With commandsOnly only
+// keywords starting with "/" are matched.
+func (i Intent) matches(text string, commandsOnly bool) bool {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		return false
+	}
+	for _, kw := range i.Keywords {
+		if commandsOnly && !strings.HasPrefix(kw, "/") {
+			continue
+		}
+		if text == strings.ToLower(kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterIntent adds a global intent to the engine. Intents are checked in registration order,
+// before the intents of declarative workflows.
+func (e *ChatEngine) RegisterIntent(intent Intent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.intents = append(e.intents, intent)
+	e.log.Info("chat engine: registered intent", slog.String("intent", intent.Name))
+}
+
+// matchIntent returns the first registered intent triggered by text. At a step that
+// takes free input only commands starting with "/" are matched.
+func (e *ChatEngine) matchIntent(text string, state *ChatState) (Intent, bool) {
+	commandsOnly := e.freeInput(state)
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, intents := range [][]Intent{e.intents, e.declaredIntents} {
+		for _, intent := range intents {
+			if intent.matches(text, commandsOnly) {
+				return intent, true
+			}
+		}
+	}
+	return Intent{}, false
+}
+
+// freeInput reports whether the user's current step takes free text.
+func (e *ChatEngine) freeInput(state *ChatState) bool {
+	if state == nil {
+		return false
+	}
+	w, ok := e.workflow(state.WorkflowID)
+	if !ok {
+		return false
+	}
+	step, ok := w.GetStep(state.CurrentStep)
+	if !ok {
+		return false
+	}
+	free, ok := step.(FreeInputStep)
+	return ok && free.FreeInput()
+}
+
+// applyIntent performs the intent's action. It reports false when the intent
+// does not apply to the user's current state and the input should be handled
+// by the current step instead. Must only be called from inside the user's mailbox.
+func (e *ChatEngine) applyIntent(ctx context.Context, m Messenger, platform, userID, chatID string, state *ChatState, intent Intent) (bool, error) {
+	handled, err := e.runIntent(ctx, m, platform, userID, chatID, state, intent)
+	if handled && err == nil && intent.Handoff && e.handoff != nil {
+		e.handoff.RequestHuman(platform, userID)
+	}
+	return handled, err
+}
+
+// runIntent is applyIntent without the handoff.
+func (e *ChatEngine) runIntent(ctx context.Context, m Messenger, platform, userID, chatID string, state *ChatState, intent Intent) (bool, error) {
+	switch {
+	case intent.Step != "":
+		if state == nil || state.WorkflowID != intent.Workflow {
+			return false, nil
+		}
+		w, ok := e.workflow(state.WorkflowID)
+		if !ok {
+			return false, nil
+		}
+		e.logIntent(state.Platform, state.UserID, intent)
+		if state.CurrentStep == intent.Step {
+			// Already there: re-enter so the user sees the step again.
+			step, ok := w.GetStep(intent.Step)
+			if !ok {
+				return true, fmt.Errorf("intent step not found: %s", intent.Step)
+			}
+			return true, e.processResult(ctx, m, state, w, step.Enter(ctx, m, state), false)
+		}
+		return true, e.processResult(ctx, m, state, w, StepResult{NextStep: intent.Step}, true)
+
+	case intent.Workflow != "":
+		e.logIntent(platform, userID, intent)
+		return true, e.startWorkflowWithData(ctx, m, platform, userID, chatID, intent.Workflow, nil)
+
+	case intent.Handle != nil:
+		if state == nil {
+			return false, nil
+		}
+		w, ok := e.workflow(state.WorkflowID)
+		if !ok {
+			return false, nil
+		}
+		e.logIntent(state.Platform, state.UserID, intent)
+		return true, e.processResult(ctx, m, state, w, intent.Handle(ctx, m, state), true)
+	}
+
+	return false, nil
+}
+
+func (e *ChatEngine) logIntent(platform, userID string, intent Intent) {
+	e.log.Info("chat engine: intent matched",
+		slog.String("platform", platform),
+		slog.String("user_id", userID),
+		slog.String("intent", intent.Name),
+	)
+}
@@ -2,8 +2,14 @@ package chat
 
 import (
 	"context"
+	"errors"
+	"time"
 )
 
+// ErrStateConflict is returned by ChatStateStorage.Save when the stored state
+// was modified by another writer since it was loaded.
+var ErrStateConflict = errors.New("chat state was modified concurrently")
+
 // StepID is a unique identifier for a step within a workflow.
 type StepID string
 
@@ -16,6 +22,9 @@ type StepResult struct {
 	UpdateState map[string]any
 	Complete    bool
 	Error       error
+	// Back returns the user to the previous interactive step from the state's
+	// history and re-enters it. When the history is empty, NextStep (if set) is used instead.
+	Back bool
 }
 
 // Step defines the interface for a single workflow step.
@@ -30,6 +39,19 @@ type Step interface {
 	HandleInput(ctx context.Context, m Messenger, state *ChatState, input UserInput) StepResult
 }
 
+// TransientStep is implemented by steps that wait for input but should never be
+// returned to via Back, such as silent placeholder steps.
+type TransientStep interface {
+	Transient() bool
+}
+
+// FreeInputStep is implemented by steps that take free text, such as a name, a phone
+// number or a question to the AI. At such a step only intent keywords starting with "/"
+// are recognised, so an answer that happens to be a keyword still reaches the step.
+type FreeInputStep interface {
+	FreeInput() bool
+}
+
 // Workflow defines the interface for a complete workflow.
 type Workflow interface {
 	// ID returns the unique identifier for this workflow.
@@ -44,9 +66,11 @@ type Workflow interface {
 
 // ChatStateStorage handles persistence of chat states.
 type ChatStateStorage interface {
+	// Save persists the state if its Version matches the stored one and increments
+	// state.Version on success. Returns ErrStateConflict on a version mismatch.
 	Save(ctx context.Context, state *ChatState) error
 	Load(ctx context.Context, platform, userID string) (*ChatState, error)
 	Delete(ctx context.Context, platform, userID string) error
-	// FindBySteps returns all states in the given workflow whose current step matches any of the provided step IDs.
-	FindBySteps(ctx context.Context, workflowID WorkflowID, steps []StepID) ([]*ChatState, error)
+	// FindIdle returns all states in the given workflow at the given step that were last updated before the cutoff.
+	FindIdle(ctx context.Context, workflowID WorkflowID, step StepID, before time.Time) ([]*ChatState, error)
 }
@@ -2,6 +2,9 @@ package chat
 
 import (
 	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"DarkCS/entity"
 )
@@ -14,5 +17,14 @@ type MessageListener interface {
 	// UpdateUserPlatformInfo saves a platform-specific username (e.g. @username) for the user.
 	UpdateUserPlatformInfo(platform, userID, messengerName string)
 	// UploadAndSaveFile uploads a file to GridFS, saves a ChatMessage with the attachment, and broadcasts via WebSocket.
-	UploadAndSaveFile(platform, userID string, reader io.Reader, filename, mimeType string, size int64, caption string) error
+	// It returns the stored attachment so the file can be passed on to the ChatEngine.
+	UploadAndSaveFile(platform, userID string, reader io.Reader, filename, mimeType string, size int64, caption string) (entity.Attachment, error)
+	// DownloadFile opens a stored file by its GridFS ID. The caller must close the reader.
+	DownloadFile(fileID primitive.ObjectID) (filename, mimeType string, reader io.ReadCloser, err error)
+	// UpdateMessageStatus applies a delivery or read receipt to the outgoing message with the
+	// given platform message ID. errText describes why a message failed.
+	UpdateMessageStatus(platform, messageID string, status entity.MessageStatus, errText string)
+	// MarkMessagesReadUntil applies a read receipt that carries a timestamp instead of message IDs:
+	// every outgoing message sent to the user up to the watermark has been read.
+	MarkMessagesReadUntil(platform, userID string, watermark time.Time)
 }
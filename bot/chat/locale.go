@@ -0,0 +1,97 @@
+package chat
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LocaleStore keeps the locale each user chose apart from the chat state, which is
+// deleted when a workflow completes or the user stops the conversation.
+type LocaleStore interface {
+	GetChatLocale(ctx context.Context, platform, userID string) (string, error)
+	SetChatLocale(ctx context.Context, platform, userID, locale string) error
+}
+
+// LocalizedMessenger is implemented by messengers that write texts of their own,
+// such as the footer of a numbered menu on platforms without buttons.
+type LocalizedMessenger interface {
+	// WithLocale returns a messenger that writes those texts in the locale
+	// reported by locale at the time of sending.
+	WithLocale(locale func() string) Messenger
+}
+
+// SetLocaleStore sets where user locales are kept. Without one a locale lasts
+// only as long as the user's chat state.
+func (e *ChatEngine) SetLocaleStore(s LocaleStore) {
+	e.locales = s
+}
+
+type eventLocaleKey struct{}
+
+// eventLocale is the locale of the user whose event is being processed.
+// It follows the user's state, so a locale switch applies to the rest of the event.
+type eventLocale struct {
+	locale string
+}
+
+// localize prepares an event: messengers that write texts of their own get the user's locale.
+func localize(ctx context.Context, m Messenger) (context.Context, Messenger) {
+	current := &eventLocale{}
+	ctx = context.WithValue(ctx, eventLocaleKey{}, current)
+	if lm, ok := m.(LocalizedMessenger); ok {
+		m = lm.WithLocale(func() string { return current.locale })
+	}
+	return ctx, m
+}
+
+// trackLocale makes state's locale the locale of the event.
+func trackLocale(ctx context.Context, state *ChatState) {
+	if current, ok := ctx.Value(eventLocaleKey{}).(*eventLocale); ok && state != nil {
+		current.locale = state.Locale
+	}
+}
+
+// UserLocale returns the locale of the user's chat, or "" when unknown.
+// Bots use it for replies sent outside a workflow step.
+func (e *ChatEngine) UserLocale(ctx context.Context, platform, userID string) string {
+	state, err := e.storage.Load(ctx, platform, userID)
+	if err == nil && state != nil && state.Locale != "" {
+		return state.Locale
+	}
+	return e.storedLocale(ctx, platform, userID)
+}
+
+// storedLocale returns the locale the user chose, or "" when unknown.
+func (e *ChatEngine) storedLocale(ctx context.Context, platform, userID string) string {
+	if e.locales == nil {
+		return ""
+	}
+	locale, err := e.locales.GetChatLocale(ctx, platform, userID)
+	if err != nil {
+		e.log.Error("chat engine: loading locale",
+			slog.String("platform", platform),
+			slog.String("user_id", userID),
+			slog.String("error", err.Error()),
+		)
+	}
+	return locale
+}
+
+// saveLocale keeps a locale switched during the event for the user's later chats.
+func (e *ChatEngine) saveLocale(ctx context.Context, state *ChatState) {
+	trackLocale(ctx, state)
+	if !state.localeChanged {
+		return
+	}
+	state.localeChanged = false
+	if e.locales == nil {
+		return
+	}
+	if err := e.locales.SetChatLocale(ctx, state.Platform, state.UserID, state.Locale); err != nil {
+		e.log.Error("chat engine: saving locale",
+			slog.String("platform", state.Platform),
+			slog.String("user_id", state.UserID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
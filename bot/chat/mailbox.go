@@ -0,0 +1,132 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// mailboxSize is the number of events that may wait behind the one being processed for a single user.
+	mailboxSize = 16
+	// mailboxEnqueueTimeout bounds how long a caller blocks when the user's mailbox is full.
+	mailboxEnqueueTimeout = 10 * time.Second
+)
+
+// ErrMailboxFull is returned when a user's mailbox stays full for longer than mailboxEnqueueTimeout.
+var ErrMailboxFull = errors.New("chat mailbox is full")
+
+type mailboxKey struct {
+	platform string
+	userID   string
+}
+
+// mailbox is a bounded FIFO queue of events for a single (platform, userID) pair,
+// drained by exactly one worker goroutine.
+type mailbox struct {
+	jobs    chan func()
+	pending int // queued + running jobs, guarded by mailboxes.mu
+}
+
+// mailboxes serializes event processing per (platform, userID).
+// A worker goroutine is started on the first event for a key and exits once its
+// mailbox drains, so idle users cost nothing.
+type mailboxes struct {
+	mu    sync.Mutex
+	boxes map[mailboxKey]*mailbox
+}
+
+func newMailboxes() *mailboxes {
+	return &mailboxes{boxes: make(map[mailboxKey]*mailbox)}
+}
+
+// do enqueues fn into the user's mailbox and waits for it to finish.
+// Events for the same user run one at a time in arrival order; events for
+// different users run concurrently.
+func (mb *mailboxes) do(ctx context.Context, platform, userID string, fn func() error) error {
+	_, err := doValue(ctx, mb, platform, userID, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
+
+// jobResult is what a job passes back over its completion channel.
+type jobResult[T any] struct {
+	value T
+	err   error
+}
+
+// doValue is do for a job that returns a value. The value is passed back over the
+// job's completion channel, so a caller that stops waiting never shares it with the job.
+func doValue[T any](ctx context.Context, mb *mailboxes, platform, userID string, fn func() (T, error)) (T, error) {
+	var zero T
+	key := mailboxKey{platform: platform, userID: userID}
+
+	mb.mu.Lock()
+	box, ok := mb.boxes[key]
+	if !ok {
+		box = &mailbox{jobs: make(chan func(), mailboxSize)}
+		mb.boxes[key] = box
+		go mb.run(key, box)
+	}
+	box.pending++
+	mb.mu.Unlock()
+
+	done := make(chan jobResult[T], 1)
+	job := func() {
+		value, err := fn()
+		done <- jobResult[T]{value: value, err: err}
+	}
+
+	timer := time.NewTimer(mailboxEnqueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case box.jobs <- job:
+	case <-ctx.Done():
+		mb.cancel(key, box)
+		return zero, ctx.Err()
+	case <-timer.C:
+		mb.cancel(key, box)
+		return zero, ErrMailboxFull
+	}
+
+	select {
+	case result := <-done:
+		return result.value, result.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// run drains a mailbox until no jobs are pending, then removes it.
+func (mb *mailboxes) run(key mailboxKey, box *mailbox) {
+	for job := range box.jobs {
+		job()
+
+		mb.mu.Lock()
+		box.pending--
+		if box.pending == 0 {
+			delete(mb.boxes, key)
+			mb.mu.Unlock()
+			return
+		}
+		mb.mu.Unlock()
+	}
+}
+
+// cancel withdraws a job that was never enqueued. If it was the last pending
+// job the worker is idle, so the mailbox is closed and removed.
+func (mb *mailboxes) cancel(key mailboxKey, box *mailbox) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	box.pending--
+	if box.pending == 0 {
+		if mb.boxes[key] == box {
+			delete(mb.boxes, key)
+		}
+		close(box.jobs)
+	}
+}
@@ -0,0 +1,90 @@
+package chat
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMailboxRunsEventsOfOneUserInOrder(t *testing.T) {
+	mb := newMailboxes()
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = mb.do(ctx, "telegram", "100", func() error {
+				mu.Lock()
+				order = append(order, i)
+				mu.Unlock()
+				return nil
+			})
+		}()
+		// Let each event reach the mailbox before the next one is sent
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+
+	for i, got := range order {
+		if got != i {
+			t.Fatalf("events ran in order %v, want arrival order", order)
+		}
+	}
+}
+
+func TestMailboxRunsDifferentUsersConcurrently(t *testing.T) {
+	mb := newMailboxes()
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = mb.do(ctx, "telegram", "100", func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	done := make(chan error, 1)
+	go func() {
+		done <- mb.do(ctx, "telegram", "200", func() error { return nil })
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("do: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("another user's event waited for a busy mailbox")
+	}
+	close(release)
+}
+
+func TestMailboxIsRemovedWhenDrained(t *testing.T) {
+	mb := newMailboxes()
+	if err := mb.do(context.Background(), "telegram", "100", func() error { return nil }); err != nil {
+		t.Fatalf("do: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mb.mu.Lock()
+		n := len(mb.boxes)
+		mb.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d mailboxes left after draining", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,57 @@
+package mainmenu
+
+import (
+	"context"
+
+	"DarkCS/bot/chat"
+	"DarkCS/bot/chat/flow"
+)
+
+// Intents returns the global commands available to users of the main menu.
+func Intents() []chat.Intent {
+	return []chat.Intent{
+		{
+			Name:     "menu",
+			Keywords: []string{"/menu", "menu", "меню", "головне меню", "0"},
+			Workflow: WorkflowID,
+			Step:     StepMainMenu,
+		},
+		{
+			Name:     "language",
+			Keywords: []string{"/lang", "/language", "language", "мова"},
+			Workflow: WorkflowID,
+			Step:     StepLanguage,
+		},
+		{
+			Name:     "operator",
+			Keywords: []string{"/operator", "operator", "оператор", "менеджер"},
+			Handle: func(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+				_ = m.SendText(state.ChatID, state.T("operator.requested"))
+				return chat.StepResult{}
+			},
+			// The bot stays silent while a manager handles the chat
+			Handoff: true,
+		},
+		{
+			Name:     "stop",
+			Keywords: []string{"/stop", "stop", "стоп"},
+			Handle: func(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+				// Ending the conversation must not chain into another workflow
+				delete(state.Data, "next_workflow")
+				_ = m.SendText(state.ChatID, state.T("stop.done"))
+				return chat.StepResult{Complete: true}
+			},
+		},
+	}
+}
+
+// Actions returns the Go actions that YAML workflows may call from action steps.
+func Actions() flow.Actions {
+	return flow.Actions{
+		// main_menu ends the YAML workflow and returns the user to the main menu.
+		"main_menu": func(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+			state.Set("next_workflow", string(WorkflowID))
+			return chat.StepResult{Complete: true}
+		},
+	}
+}
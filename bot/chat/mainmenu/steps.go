@@ -2,6 +2,7 @@ package mainmenu
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sort"
@@ -11,6 +12,7 @@ import (
 
 	"DarkCS/bot/chat"
 	"DarkCS/entity"
+	"DarkCS/internal/lib/i18n"
 	"DarkCS/internal/lib/sl"
 )
 
@@ -39,7 +41,7 @@ func (s *SelectSchoolStep) Enter(ctx context.Context, m chat.Messenger, state *c
 	}
 
 	rows := s.buildPage(schools, 0)
-	_ = m.SendInlineGrid(state.ChatID, "Розкажи, будь ласка, з якої школи ти дізнався/дізналася про нас 🖤\n\nОберіть школу:", rows)
+	_ = m.SendInlineGrid(state.ChatID, state.T("school.select_intro"), rows)
 	return chat.StepResult{}
 }
 
@@ -79,9 +81,9 @@ func (s *SelectSchoolStep) HandleInput(ctx context.Context, m chat.Messenger, st
 
 		rows := s.buildPage(schools, page)
 		if input.MessageID != "" {
-			_ = m.EditInlineGrid(state.ChatID, input.MessageID, "Оберіть школу:", rows)
+			_ = m.EditInlineGrid(state.ChatID, input.MessageID, state.T("school.select"), rows)
 		} else {
-			_ = m.SendInlineGrid(state.ChatID, "Оберіть школу:", rows)
+			_ = m.SendInlineGrid(state.ChatID, state.T("school.select"), rows)
 		}
 		return chat.StepResult{
 			UpdateState: map[string]any{"school_page": page},
@@ -100,10 +102,7 @@ func (s *SelectSchoolStep) HandleInput(ctx context.Context, m chat.Messenger, st
 			return chat.StepResult{NextStep: StepMainMenu}
 		}
 		name := schools[idx].Name
-		_ = m.SendText(state.ChatID, fmt.Sprintf(
-			"Вітаємо, %s!\n\nОтримай -15%% на перше замовлення з промо-кодом *DARKSCHOOL* 🖤\nСкористайся протягом 14 днів на сайті 👉 riornails.com\n\nНатискай кнопку 📚Навчання та отримуй додаткові поради від нашого бренд-майстра 🖤\n\nP.S. Твоя особиста знижка -7%% вже активна, і з часом може стати ще більшою ✨",
-			name,
-		))
+		_ = m.SendText(state.ChatID, state.T("school.welcome", name))
 
 		// Sync school to Zoho CRM
 		if user, err := getUser(state, s.authService); err == nil && user != nil && user.ZohoId != "" {
@@ -167,45 +166,99 @@ func (s *SelectSchoolStep) buildPage(schools []entity.School, page int) [][]chat
 
 // mainMenuButtonsForRole builds the main menu layout, appending the manager-only
 // "School statistic" button when the caller has manager privileges.
-func mainMenuButtonsForRole(isManager bool) [][]chat.MenuButton {
-	buttons := [][]chat.MenuButton{
-		{{Text: BtnMyOffice}, {Text: BtnServiceRate}},
-		{{Text: BtnOrderStatus}},
-		{{Text: BtnAIConsultant}, {Text: BtnMakeOrder}},
-		{{Text: BtnLearning}},
+func mainMenuButtonsForRole(isManager bool) [][]string {
+	buttons := [][]string{
+		{BtnMyOffice, BtnServiceRate},
+		{BtnOrderStatus},
+		{BtnAIConsultant, BtnMakeOrder},
+		{BtnLearning},
 	}
 	if isManager {
-		buttons = append(buttons, []chat.MenuButton{{Text: BtnSchoolStat}})
+		buttons = append(buttons, []string{BtnSchoolStat})
 	}
 	return buttons
 }
 
 // myOfficeButtons defines the "my office" sub-menu layout.
-var myOfficeButtons = [][]chat.MenuButton{
-	{{Text: BtnCurrentOrder}, {Text: BtnCompletedOrders}},
-	{{Text: BtnBack}},
+var myOfficeButtons = [][]string{
+	{BtnCurrentOrder, BtnCompletedOrders},
+	{BtnBack},
 }
 
-// getUser resolves a user from state depending on platform.
-func getUser(state *chat.ChatState, authService AuthService) (*entity.User, error) {
-	if state.Platform == "instagram" {
-		user, err := authService.GetUserByInstagramId(state.UserID)
-		if err == nil && user != nil {
-			return user, nil
+// localizeMenu resolves rows of button keys to labels in the user's locale.
+func localizeMenu(state *chat.ChatState, keys [][]string) [][]chat.MenuButton {
+	rows := make([][]chat.MenuButton, 0, len(keys))
+	for _, row := range keys {
+		buttons := make([]chat.MenuButton, 0, len(row))
+		for _, key := range row {
+			buttons = append(buttons, chat.MenuButton{Text: state.T(key)})
 		}
+		rows = append(rows, buttons)
 	}
-	if state.Platform == "telegram" {
-		telegramId, err := strconv.ParseInt(state.UserID, 10, 64)
-		if err == nil && telegramId != 0 {
-			user, err := authService.GetUser("", "", telegramId)
-			if err == nil && user != nil {
-				return user, nil
+	return rows
+}
+
+// matchMenu resolves input text, either a button label or its number on
+// text-only platforms, to the key of the pressed button. Returns "" if nothing matches.
+func matchMenu(state *chat.ChatState, text string, keys [][]string) string {
+	if label := chat.MatchNumberToOption(text, localizeMenu(state, keys)); label != "" {
+		text = label
+	}
+	for _, row := range keys {
+		for _, key := range row {
+			if state.Is(text, key) {
+				return key
 			}
 		}
 	}
-	// Fallback: try by phone stored in state
+	return ""
+}
+
+// isBack reports whether text asks to go back, either via the Back button or the plain word.
+func isBack(state *chat.ChatState, text string) bool {
+	return state.Is(text, BtnBack) || strings.EqualFold(strings.TrimSpace(text), state.T("word.back"))
+}
+
+// transcribeInput appends the transcripts of spoken attachments to text.
+// files reports whether there were other attachments, which the assistant cannot read.
+// A voice message that cannot be transcribed is reported to the user and skipped.
+func transcribeInput(m chat.Messenger, state *chat.ChatState, aiService AIService, text string, attachments []chat.Attachment) (string, bool) {
+	parts := make([]string, 0, len(attachments)+1)
+	if text != "" {
+		parts = append(parts, text)
+	}
+
+	files := false
+	for _, att := range attachments {
+		if !att.IsVoice() {
+			files = true
+			continue
+		}
+
+		_ = m.SendTyping(state.ChatID)
+		transcript, err := aiService.TranscribeVoice(state.Platform, state.UserID, att)
+		switch {
+		case errors.Is(err, entity.ErrVoiceTooLong):
+			_ = m.SendText(state.ChatID, state.T("voice.too_long"))
+		case err != nil:
+			_ = m.SendText(state.ChatID, state.T("voice.failed"))
+		case transcript != "":
+			parts = append(parts, transcript)
+		}
+	}
+
+	return strings.Join(parts, "\n"), files
+}
+
+// getUser resolves a user from state depending on platform.
+func getUser(state *chat.ChatState, authService AuthService) (*entity.User, error) {
+	user, err := entity.FindChatUser(authService, state.Platform, state.UserID)
+	if err == nil && user != nil {
+		return user, nil
+	}
+	// Fallback: try by phone stored in state, which anonymous visitors typed unverified
 	phone := state.GetString("phone")
-	if phone != "" {
+	if phone != "" && !entity.AnonymousChatPlatform(state.Platform) {
 		return authService.GetUser("", phone, 0)
 	}
 	return nil, fmt.Errorf("user not found")
@@ -224,6 +277,9 @@ func (s *PreMainMenuStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 	return chat.StepResult{NextStep: StepMainMenu}
 }
 
+// Transient keeps the silent pre-menu step out of the Back history.
+func (s *PreMainMenuStep) Transient() bool { return true }
+
 // MainMenuStep — Show main menu. Manager-role users additionally see the
 // "School statistic" button. Non-managers cannot navigate to that step even
 // if they somehow send the correct button text.
@@ -239,8 +295,8 @@ func (s *MainMenuStep) Enter(ctx context.Context, m chat.Messenger, state *chat.
 		isManager = user.IsManager()
 	}
 
-	buttons := mainMenuButtonsForRole(isManager)
-	if err := m.SendMenu(state.ChatID, "Натисніть на потрібний варіант, щоб перейти у бажаний розділ 👇", buttons); err != nil {
+	buttons := localizeMenu(state, mainMenuButtonsForRole(isManager))
+	if err := m.SendMenu(state.ChatID, state.T("menu.prompt"), buttons); err != nil {
 		return chat.StepResult{Error: err}
 	}
 	return chat.StepResult{}
@@ -249,14 +305,14 @@ func (s *MainMenuStep) Enter(ctx context.Context, m chat.Messenger, state *chat.
 func (s *MainMenuStep) HandleInput(ctx context.Context, m chat.Messenger, state *chat.ChatState, input chat.UserInput) chat.StepResult {
 	text := strings.TrimSpace(input.Text)
 
-	// Resolve role once for both exact-match and number-match paths.
 	var isManager bool
 	if user, err := getUser(state, s.authService); err == nil && user != nil {
 		isManager = user.IsManager()
 	}
 
-	// Exact button text match.
-	switch text {
+	// Match against the role-appropriate button set so numbers line up correctly
+	// and non-managers never match the statistics button.
+	switch matchMenu(state, text, mainMenuButtonsForRole(isManager)) {
 	case BtnMyOffice:
 		return chat.StepResult{NextStep: StepMyOffice}
 	case BtnServiceRate:
@@ -270,30 +326,6 @@ func (s *MainMenuStep) HandleInput(ctx context.Context, m chat.Messenger, state
 	case BtnLearning:
 		return chat.StepResult{NextStep: StepSelectVideo}
 	case BtnSchoolStat:
-		if isManager {
-			return chat.StepResult{NextStep: StepSchoolStat}
-		}
-		return chat.StepResult{}
-	}
-
-	// Number-based match (for text-only platforms like Instagram/WhatsApp).
-	// Use the role-appropriate button set so numbers line up correctly.
-	buttons := mainMenuButtonsForRole(isManager)
-	switch chat.MatchNumberToOption(text, buttons) {
-	case BtnMyOffice:
-		return chat.StepResult{NextStep: StepMyOffice}
-	case BtnServiceRate:
-		return chat.StepResult{NextStep: StepServiceRate}
-	case BtnOrderStatus:
-		return chat.StepResult{NextStep: StepCurrentOrder}
-	case BtnAIConsultant:
-		return chat.StepResult{NextStep: StepAIConsultant}
-	case BtnMakeOrder:
-		return chat.StepResult{NextStep: StepMakeOrder}
-	case BtnLearning:
-		return chat.StepResult{NextStep: StepSelectVideo}
-	case BtnSchoolStat:
-		// Button only appears in the list for managers, so no extra check needed.
 		return chat.StepResult{NextStep: StepSchoolStat}
 	}
 
@@ -306,7 +338,7 @@ type MyOfficeStep struct{}
 func (s *MyOfficeStep) ID() chat.StepID { return StepMyOffice }
 
 func (s *MyOfficeStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
-	err := m.SendMenu(state.ChatID, "Що саме цікавить?", myOfficeButtons)
+	err := m.SendMenu(state.ChatID, state.T("office.prompt"), localizeMenu(state, myOfficeButtons))
 	if err != nil {
 		return chat.StepResult{Error: err}
 	}
@@ -316,23 +348,13 @@ func (s *MyOfficeStep) Enter(ctx context.Context, m chat.Messenger, state *chat.
 func (s *MyOfficeStep) HandleInput(ctx context.Context, m chat.Messenger, state *chat.ChatState, input chat.UserInput) chat.StepResult {
 	text := strings.TrimSpace(input.Text)
 
-	switch text {
-	case BtnCurrentOrder:
-		return chat.StepResult{NextStep: StepCurrentOrder}
-	case BtnCompletedOrders:
-		return chat.StepResult{NextStep: StepCompletedOrders}
-	case BtnBack:
-		return chat.StepResult{NextStep: StepMainMenu}
-	}
-
-	matched := chat.MatchNumberToOption(text, myOfficeButtons)
-	switch matched {
+	switch matchMenu(state, text, myOfficeButtons) {
 	case BtnCurrentOrder:
 		return chat.StepResult{NextStep: StepCurrentOrder}
 	case BtnCompletedOrders:
 		return chat.StepResult{NextStep: StepCompletedOrders}
 	case BtnBack:
-		return chat.StepResult{NextStep: StepMainMenu}
+		return chat.StepResult{Back: true, NextStep: StepMainMenu}
 	}
 
 	return chat.StepResult{}
@@ -349,7 +371,7 @@ func (s *CurrentOrderStep) ID() chat.StepID { return StepCurrentOrder }
 func (s *CurrentOrderStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
 	user, err := getUser(state, s.authService)
 	if err != nil || user == nil {
-		_ = m.SendText(state.ChatID, "Не вдалося отримати інформацію про користувача.")
+		_ = m.SendText(state.ChatID, state.T("error.user_info"))
 		return chat.StepResult{NextStep: StepMainMenu}
 	}
 
@@ -360,7 +382,7 @@ func (s *CurrentOrderStep) Enter(ctx context.Context, m chat.Messenger, state *c
 		orders, err = s.zohoService.GetOrdersDetailed(*user.GetInfo())
 	}
 	if err != nil {
-		_ = m.SendText(state.ChatID, "Не вдалося отримати інформацію про замовлення.")
+		_ = m.SendText(state.ChatID, state.T("error.orders"))
 		return chat.StepResult{NextStep: StepMainMenu}
 	}
 
@@ -373,15 +395,15 @@ func (s *CurrentOrderStep) Enter(ctx context.Context, m chat.Messenger, state *c
 	}
 
 	if activeOrder == nil {
-		_ = m.SendText(state.ChatID, "У вас немає активних замовлень.")
+		_ = m.SendText(state.ChatID, state.T("orders.no_active"))
 		return chat.StepResult{NextStep: StepMainMenu}
 	}
 
 	state.Set("current_order_id", activeOrder.ID)
 
-	msg := formatOrderMessage(activeOrder, user.Name, state.Platform)
+	msg := formatOrderMessage(state, activeOrder, user.Name)
 	buttons := []chat.InlineButton{
-		{Text: "📋 Товари", Data: "products:" + activeOrder.ID},
+		{Text: state.T("orders.products"), Data: "products:" + activeOrder.ID},
 	}
 	_ = m.SendInlineOptions(state.ChatID, msg, buttons)
 	return chat.StepResult{}
@@ -393,7 +415,7 @@ func (s *CurrentOrderStep) HandleInput(ctx context.Context, m chat.Messenger, st
 		orderID := state.GetString("current_order_id")
 		if orderID != "" {
 			buttons := []chat.InlineButton{
-				{Text: "📋 Товари", Data: "products:" + orderID},
+				{Text: state.T("orders.products"), Data: "products:" + orderID},
 			}
 			data = chat.MatchNumberToInline(input.Text, buttons)
 		}
@@ -403,7 +425,7 @@ func (s *CurrentOrderStep) HandleInput(ctx context.Context, m chat.Messenger, st
 		orderID := strings.TrimPrefix(data, "products:")
 		products, err := s.zohoService.GetOrderProducts(orderID)
 		if err != nil {
-			_ = m.SendText(state.ChatID, "Не вдалося отримати товари.")
+			_ = m.SendText(state.ChatID, state.T("error.products"))
 		} else {
 			_ = m.SendText(state.ChatID, products)
 		}
@@ -424,7 +446,7 @@ func (s *CompletedOrdersStep) ID() chat.StepID { return StepCompletedOrders }
 func (s *CompletedOrdersStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
 	user, err := getUser(state, s.authService)
 	if err != nil || user == nil {
-		_ = m.SendText(state.ChatID, "Не вдалося отримати інформацію про користувача.")
+		_ = m.SendText(state.ChatID, state.T("error.user_info"))
 		return chat.StepResult{NextStep: StepMyOffice}
 	}
 
@@ -435,7 +457,7 @@ func (s *CompletedOrdersStep) Enter(ctx context.Context, m chat.Messenger, state
 		orders, err = s.zohoService.GetOrdersDetailed(*user.GetInfo())
 	}
 	if err != nil {
-		_ = m.SendText(state.ChatID, "Не вдалося отримати інформацію про замовлення.")
+		_ = m.SendText(state.ChatID, state.T("error.orders"))
 		return chat.StepResult{NextStep: StepMyOffice}
 	}
 
@@ -447,7 +469,7 @@ func (s *CompletedOrdersStep) Enter(ctx context.Context, m chat.Messenger, state
 	}
 
 	if len(completedOrders) == 0 {
-		_ = m.SendText(state.ChatID, "У вас немає виконаних замовлень.")
+		_ = m.SendText(state.ChatID, state.T("orders.no_completed"))
 		return chat.StepResult{NextStep: StepMyOffice}
 	}
 
@@ -456,7 +478,7 @@ func (s *CompletedOrdersStep) Enter(ctx context.Context, m chat.Messenger, state
 	}
 
 	for i, order := range completedOrders {
-		msg := formatOrderMessageNumbered(&order, user.Name, i+1, state.Platform)
+		msg := formatOrderMessageNumbered(state, &order, user.Name, i+1)
 		_ = m.SendText(state.ChatID, msg)
 	}
 
@@ -464,14 +486,14 @@ func (s *CompletedOrdersStep) Enter(ctx context.Context, m chat.Messenger, state
 	orderIDs := make([]string, len(completedOrders))
 	for i, order := range completedOrders {
 		buttons[i] = chat.InlineButton{
-			Text: fmt.Sprintf("📋 Товари №%d", i+1),
+			Text: state.T("orders.products_numbered", i+1),
 			Data: "products:" + order.ID,
 		}
 		orderIDs[i] = order.ID
 	}
 	state.Set("completed_order_ids", strings.Join(orderIDs, ","))
 
-	_ = m.SendInlineOptions(state.ChatID, "Оберіть замовлення для перегляду товарів:", buttons)
+	_ = m.SendInlineOptions(state.ChatID, state.T("orders.choose_products"), buttons)
 	return chat.StepResult{}
 }
 
@@ -484,7 +506,7 @@ func (s *CompletedOrdersStep) HandleInput(ctx context.Context, m chat.Messenger,
 			buttons := make([]chat.InlineButton, len(orderIDs))
 			for i, id := range orderIDs {
 				buttons[i] = chat.InlineButton{
-					Text: fmt.Sprintf("📋 Товари №%d", i+1),
+					Text: state.T("orders.products_numbered", i+1),
 					Data: "products:" + id,
 				}
 			}
@@ -496,7 +518,7 @@ func (s *CompletedOrdersStep) HandleInput(ctx context.Context, m chat.Messenger,
 		orderID := strings.TrimPrefix(data, "products:")
 		products, err := s.zohoService.GetOrderProducts(orderID)
 		if err != nil {
-			_ = m.SendText(state.ChatID, "Не вдалося отримати товари.")
+			_ = m.SendText(state.ChatID, state.T("error.products"))
 		} else {
 			_ = m.SendText(state.ChatID, products)
 		}
@@ -517,7 +539,7 @@ func (s *ServiceRateStep) ID() chat.StepID { return StepServiceRate }
 func (s *ServiceRateStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
 	user, err := getUser(state, s.authService)
 	if err != nil || user == nil {
-		_ = m.SendText(state.ChatID, "Не вдалося отримати інформацію про користувача.")
+		_ = m.SendText(state.ChatID, state.T("error.user_info"))
 		return chat.StepResult{NextStep: StepMainMenu}
 	}
 
@@ -528,7 +550,7 @@ func (s *ServiceRateStep) Enter(ctx context.Context, m chat.Messenger, state *ch
 		orders, err = s.zohoService.GetOrdersDetailed(*user.GetInfo())
 	}
 	if err != nil || len(orders) == 0 {
-		_ = m.SendText(state.ChatID, "У вас немає замовлень для оцінки.")
+		_ = m.SendText(state.ChatID, state.T("rate.no_orders"))
 		return chat.StepResult{NextStep: StepMainMenu}
 	}
 
@@ -543,8 +565,7 @@ func (s *ServiceRateStep) Enter(ctx context.Context, m chat.Messenger, state *ch
 		{Text: "5", Data: "rate:5"},
 	}
 
-	msg := "Як вам сервіс? 🙌\nЗалиште, будь ласка, оцінку — це допоможе нам ставати кращими.\n\nВаш відгук важливий для нас!"
-	_ = m.SendInlineOptions(state.ChatID, msg, buttons)
+	_ = m.SendInlineOptions(state.ChatID, state.T("rate.prompt"), buttons)
 	return chat.StepResult{}
 }
 
@@ -563,8 +584,8 @@ func (s *ServiceRateStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 	}
 
 	if !strings.HasPrefix(data, "rate:") {
-		if strings.TrimSpace(input.Text) == BtnBack {
-			return chat.StepResult{NextStep: StepMainMenu}
+		if state.Is(input.Text, BtnBack) {
+			return chat.StepResult{Back: true, NextStep: StepMainMenu}
 		}
 		return chat.StepResult{}
 	}
@@ -577,7 +598,7 @@ func (s *ServiceRateStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 
 	user, err := getUser(state, s.authService)
 	if err != nil || user == nil {
-		_ = m.SendText(state.ChatID, "Не вдалося отримати інформацію про користувача.")
+		_ = m.SendText(state.ChatID, state.T("error.user_info"))
 		return chat.StepResult{NextStep: StepMainMenu}
 	}
 
@@ -585,7 +606,7 @@ func (s *ServiceRateStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 	if contactID == "" {
 		contactID, err = s.zohoService.CreateContact(user)
 		if err != nil {
-			_ = m.SendText(state.ChatID, "Не вдалося зберегти оцінку.")
+			_ = m.SendText(state.ChatID, state.T("rate.save_failed"))
 			return chat.StepResult{NextStep: StepMainMenu}
 		}
 	}
@@ -599,11 +620,21 @@ func (s *ServiceRateStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 
 	err = s.zohoService.CreateRating(serviceRating)
 	if err != nil {
-		_ = m.SendText(state.ChatID, "Не вдалося зберегти оцінку. Спробуйте пізніше.")
+		_ = m.SendText(state.ChatID, state.T("rate.save_retry"))
 		return chat.StepResult{NextStep: StepMainMenu}
 	}
 
-	_ = m.SendText(state.ChatID, "Ваша оцінка успішно створена! 🎉\n\nДякуємо за ваш відгук!")
+	_ = m.SendText(state.ChatID, state.T("rate.saved"))
+	return chat.StepResult{NextStep: StepMainMenu}
+}
+
+// sessionIdleTimeout is how long a user may stay idle in an AI or video session
+// before being returned to the main menu by the engine's timeout sweeper.
+const sessionIdleTimeout = 30 * time.Minute
+
+// expireToMainMenu sends an inactivity notice and returns the user to the main menu.
+func expireToMainMenu(m chat.Messenger, state *chat.ChatState) chat.StepResult {
+	_ = m.SendText(state.ChatID, state.T("session.expired"))
 	return chat.StepResult{NextStep: StepMainMenu}
 }
 
@@ -615,22 +646,35 @@ type AIConsultantStep struct {
 
 func (s *AIConsultantStep) ID() chat.StepID { return StepAIConsultant }
 
+// FreeInput passes questions such as "меню" to the assistant; "/menu" still works.
+func (s *AIConsultantStep) FreeInput() bool { return true }
+
 func (s *AIConsultantStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
-	backMenu := [][]chat.MenuButton{{{Text: BtnBack}}}
-	_ = m.SendMenu(state.ChatID, "Привіт! Я — консультант бренду DARK 🖤\nДопоможу з вибором товарів, проконсультую щодо продукції та оформлення замовлення.", backMenu)
+	backMenu := [][]chat.MenuButton{{{Text: state.T(BtnBack)}}}
+	_ = m.SendMenu(state.ChatID, state.T("ai.greeting"), backMenu)
 	return chat.StepResult{}
 }
 
 func (s *AIConsultantStep) HandleInput(ctx context.Context, m chat.Messenger, state *chat.ChatState, input chat.UserInput) chat.StepResult {
 	text := strings.TrimSpace(input.Text)
 
-	if text == BtnBack || strings.EqualFold(text, "назад") {
-		return chat.StepResult{NextStep: StepMainMenu}
+	if isBack(state, text) {
+		return chat.StepResult{Back: true, NextStep: StepMainMenu}
+	}
+
+	// The assistant reads text and speech: other files stay in the CRM for managers,
+	// while a caption sent with them is still answered.
+	text, files := transcribeInput(m, state, s.aiService, text, input.Attachments)
+	if files {
+		_ = m.SendText(state.ChatID, state.T("ai.attachment_received"))
+	}
+	if text == "" {
+		return chat.StepResult{}
 	}
 
 	user, err := getUser(state, s.authService)
 	if err != nil || user == nil {
-		_ = m.SendText(state.ChatID, "Не вдалося отримати інформацію про користувача.")
+		_ = m.SendText(state.ChatID, state.T("error.user_info"))
 		return chat.StepResult{}
 	}
 
@@ -638,7 +682,7 @@ func (s *AIConsultantStep) HandleInput(ctx context.Context, m chat.Messenger, st
 
 	response, err := s.aiService.ProcessUserRequest(user, text)
 	if err != nil {
-		_ = m.SendText(state.ChatID, "Виникла помилка при обробці запиту. Спробуйте ще раз.")
+		_ = m.SendText(state.ChatID, state.T("error.request"))
 		return chat.StepResult{}
 	}
 
@@ -646,6 +690,12 @@ func (s *AIConsultantStep) HandleInput(ctx context.Context, m chat.Messenger, st
 	return chat.StepResult{}
 }
 
+func (s *AIConsultantStep) Timeout() time.Duration { return sessionIdleTimeout }
+
+func (s *AIConsultantStep) OnTimeout(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+	return expireToMainMenu(m, state)
+}
+
 // MakeOrderStep — AI mode for making orders.
 type MakeOrderStep struct {
 	authService AuthService
@@ -654,22 +704,35 @@ type MakeOrderStep struct {
 
 func (s *MakeOrderStep) ID() chat.StepID { return StepMakeOrder }
 
+// FreeInput passes the order text to the assistant even when it is a keyword.
+func (s *MakeOrderStep) FreeInput() bool { return true }
+
 func (s *MakeOrderStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
-	backMenu := [][]chat.MenuButton{{{Text: BtnBack}}}
-	_ = m.SendMenu(state.ChatID, "Готові оформити замовлення!", backMenu)
+	backMenu := [][]chat.MenuButton{{{Text: state.T(BtnBack)}}}
+	_ = m.SendMenu(state.ChatID, state.T("order.greeting"), backMenu)
 	return chat.StepResult{}
 }
 
 func (s *MakeOrderStep) HandleInput(ctx context.Context, m chat.Messenger, state *chat.ChatState, input chat.UserInput) chat.StepResult {
 	text := strings.TrimSpace(input.Text)
 
-	if text == BtnBack || strings.EqualFold(text, "назад") {
-		return chat.StepResult{NextStep: StepMainMenu}
+	if isBack(state, text) {
+		return chat.StepResult{Back: true, NextStep: StepMainMenu}
+	}
+
+	// Voice messages are answered as text; payment screenshots and other files
+	// are checked by managers in the CRM.
+	text, files := transcribeInput(m, state, s.aiService, text, input.Attachments)
+	if files {
+		_ = m.SendText(state.ChatID, state.T("order.attachment_received"))
+	}
+	if text == "" {
+		return chat.StepResult{}
 	}
 
 	user, err := getUser(state, s.authService)
 	if err != nil || user == nil {
-		_ = m.SendText(state.ChatID, "Не вдалося отримати інформацію про користувача.")
+		_ = m.SendText(state.ChatID, state.T("error.user_info"))
 		return chat.StepResult{}
 	}
 
@@ -677,7 +740,7 @@ func (s *MakeOrderStep) HandleInput(ctx context.Context, m chat.Messenger, state
 
 	response, err := s.aiService.ProcessUserRequest(user, text)
 	if err != nil {
-		_ = m.SendText(state.ChatID, "Виникла помилка при обробці запиту. Спробуйте ще раз.")
+		_ = m.SendText(state.ChatID, state.T("error.request"))
 		return chat.StepResult{}
 	}
 
@@ -685,36 +748,71 @@ func (s *MakeOrderStep) HandleInput(ctx context.Context, m chat.Messenger, state
 	return chat.StepResult{}
 }
 
-// formatOrderMessage formats an order for display.
-// Telegram gets HTML links; other platforms get a plain URL on its own line.
-func formatOrderMessage(order *entity.OrderDetail, customerName, platform string) string {
-	msg := fmt.Sprintf("Замовник: %s\nСтатус: %s", customerName, order.Status)
-	if order.Subject != "" {
-		msg += fmt.Sprintf("\nНомер замовлення: %s", order.Subject)
+func (s *MakeOrderStep) Timeout() time.Duration { return sessionIdleTimeout }
+
+func (s *MakeOrderStep) OnTimeout(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+	return expireToMainMenu(m, state)
+}
+
+// LanguageStep — Lets the user choose the locale of all bot texts.
+type LanguageStep struct{}
+
+func (s *LanguageStep) ID() chat.StepID { return StepLanguage }
+
+func (s *LanguageStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+	_ = m.SendInlineOptions(state.ChatID, state.T("lang.prompt"), languageButtons())
+	return chat.StepResult{}
+}
+
+func (s *LanguageStep) HandleInput(ctx context.Context, m chat.Messenger, state *chat.ChatState, input chat.UserInput) chat.StepResult {
+	data := input.CallbackData
+	if data == "" {
+		data = chat.MatchNumberToInline(input.Text, languageButtons())
 	}
-	if order.TTN != "" {
-		msg += formatTTN(order.TTN, platform)
+	if !strings.HasPrefix(data, "lang:") {
+		if isBack(state, input.Text) {
+			return chat.StepResult{Back: true, NextStep: StepMainMenu}
+		}
+		return chat.StepResult{}
 	}
-	return msg
+
+	state.SetLocale(i18n.Normalize(strings.TrimPrefix(data, "lang:")))
+	_ = m.SendText(state.ChatID, state.T("lang.changed"))
+	return chat.StepResult{Back: true, NextStep: StepMainMenu}
 }
 
-// formatOrderMessageNumbered formats an order with a number prefix.
-func formatOrderMessageNumbered(order *entity.OrderDetail, customerName string, orderNum int, platform string) string {
-	msg := fmt.Sprintf("Замовлення №%d\n\nЗамовник: %s\nСтатус: %s", orderNum, customerName, order.Status)
+// languageButtons lists every supported locale, each labelled in its own language.
+func languageButtons() []chat.InlineButton {
+	buttons := make([]chat.InlineButton, 0, len(i18n.Supported()))
+	for _, locale := range i18n.Supported() {
+		buttons = append(buttons, chat.InlineButton{Text: i18n.T(locale, "lang.name"), Data: "lang:" + locale})
+	}
+	return buttons
+}
+
+// formatOrderMessage formats an order for display in the user's locale.
+// Telegram gets HTML links; other platforms get a plain URL on its own line.
+func formatOrderMessage(state *chat.ChatState, order *entity.OrderDetail, customerName string) string {
+	msg := state.T("order.details", customerName, order.Status)
 	if order.Subject != "" {
-		msg += fmt.Sprintf("\nНомер замовлення: %s", order.Subject)
+		msg += "\n" + state.T("order.number", order.Subject)
 	}
 	if order.TTN != "" {
-		msg += formatTTN(order.TTN, platform)
+		msg += formatTTN(state, order.TTN)
 	}
 	return msg
 }
 
-func formatTTN(ttn, platform string) string {
-	if platform == "telegram" {
-		return fmt.Sprintf("\nТТН: <a href=\"https://novaposhta.ua/tracking/%s\">%s</a>", ttn, ttn)
+// formatOrderMessageNumbered formats an order with a number prefix.
+func formatOrderMessageNumbered(state *chat.ChatState, order *entity.OrderDetail, customerName string, orderNum int) string {
+	return state.T("order.title", orderNum) + "\n\n" + formatOrderMessage(state, order, customerName)
+}
+
+func formatTTN(state *chat.ChatState, ttn string) string {
+	if state.Platform == "telegram" {
+		return "\n" + state.T("order.ttn", fmt.Sprintf("<a href=\"https://novaposhta.ua/tracking/%s\">%s</a>", ttn, ttn))
 	}
-	return fmt.Sprintf("\nТТН: %s\nhttps://novaposhta.ua/tracking/%s", ttn, ttn)
+	return "\n" + state.T("order.ttn", ttn) + fmt.Sprintf("\nhttps://novaposhta.ua/tracking/%s", ttn)
 }
 
 // SchoolStatStep displays aggregated QR-funnel statistics and, on button press,
@@ -728,7 +826,7 @@ func (s *SchoolStatStep) ID() chat.StepID { return StepSchoolStat }
 func (s *SchoolStatStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
 	stats, err := s.qrStatRepo.GetAllQrStat()
 	if err != nil {
-		_ = m.SendText(state.ChatID, "Не вдалося отримати статистику.")
+		_ = m.SendText(state.ChatID, state.T("error.statistics"))
 		return chat.StepResult{NextStep: StepMainMenu}
 	}
 
@@ -745,14 +843,11 @@ func (s *SchoolStatStep) Enter(ctx context.Context, m chat.Messenger, state *cha
 		}
 	}
 
-	msg := fmt.Sprintf(
-		"📊 Статистика шкіл\n\n🔗 Підписалися через QR: %d\n📝 Зареєстровані: %d\n🏫 Обрали школу: %d",
-		followNum, regNum, schoolNum,
-	)
+	msg := state.T("stat.summary", followNum, regNum, schoolNum)
 
 	buttons := []chat.InlineButton{
-		{Text: "📚 По школах", Data: "stat:schools"},
-		{Text: "📅 По місяцях", Data: "stat:months"},
+		{Text: state.T("stat.by_schools"), Data: "stat:schools"},
+		{Text: state.T("stat.by_months"), Data: "stat:months"},
 	}
 	_ = m.SendInlineOptions(state.ChatID, msg, buttons)
 	return chat.StepResult{}
@@ -760,8 +855,8 @@ func (s *SchoolStatStep) Enter(ctx context.Context, m chat.Messenger, state *cha
 
 func (s *SchoolStatStep) HandleInput(ctx context.Context, m chat.Messenger, state *chat.ChatState, input chat.UserInput) chat.StepResult {
 	allButtons := []chat.InlineButton{
-		{Text: "📚 По школах", Data: "stat:schools"},
-		{Text: "📅 По місяцях", Data: "stat:months"},
+		{Text: state.T("stat.by_schools"), Data: "stat:schools"},
+		{Text: state.T("stat.by_months"), Data: "stat:months"},
 	}
 
 	data := input.CallbackData
@@ -773,7 +868,7 @@ func (s *SchoolStatStep) HandleInput(ctx context.Context, m chat.Messenger, stat
 	case "stat:schools":
 		stats, err := s.qrStatRepo.GetAllQrStat()
 		if err != nil {
-			_ = m.SendText(state.ChatID, "Не вдалося отримати статистику.")
+			_ = m.SendText(state.ChatID, state.T("error.statistics"))
 			return chat.StepResult{NextStep: StepMainMenu}
 		}
 
@@ -785,7 +880,7 @@ func (s *SchoolStatStep) HandleInput(ctx context.Context, m chat.Messenger, stat
 		}
 
 		if len(schoolCounts) == 0 {
-			_ = m.SendText(state.ChatID, "Немає даних по школах.")
+			_ = m.SendText(state.ChatID, state.T("stat.no_schools"))
 			return chat.StepResult{NextStep: StepMainMenu}
 		}
 
@@ -805,7 +900,7 @@ func (s *SchoolStatStep) HandleInput(ctx context.Context, m chat.Messenger, stat
 			return entries[i].name < entries[j].name
 		})
 
-		msg := "📚 По школах:\n"
+		msg := state.T("stat.schools_header")
 		for _, e := range entries {
 			msg += fmt.Sprintf("\n%s — %d", e.name, e.count)
 		}
@@ -814,7 +909,7 @@ func (s *SchoolStatStep) HandleInput(ctx context.Context, m chat.Messenger, stat
 	case "stat:months":
 		stats, err := s.qrStatRepo.GetAllQrStat()
 		if err != nil {
-			_ = m.SendText(state.ChatID, "Не вдалося отримати статистику.")
+			_ = m.SendText(state.ChatID, state.T("error.statistics"))
 			return chat.StepResult{NextStep: StepMainMenu}
 		}
 
@@ -842,7 +937,7 @@ func (s *SchoolStatStep) HandleInput(ctx context.Context, m chat.Messenger, stat
 		}
 
 		if len(monthly) == 0 {
-			_ = m.SendText(state.ChatID, "Немає даних по місяцях.")
+			_ = m.SendText(state.ChatID, state.T("stat.no_months"))
 			return chat.StepResult{NextStep: StepMainMenu}
 		}
 
@@ -858,10 +953,10 @@ func (s *SchoolStatStep) HandleInput(ctx context.Context, m chat.Messenger, stat
 			return keys[i].month < keys[j].month
 		})
 
-		msg := "📊 Статистика по місяцях:\n"
+		msg := state.T("stat.months_header")
 		for _, k := range keys {
 			st := monthly[k]
-			label := entity.GetMonthName(time.Date(k.year, k.month, 1, 0, 0, 0, 0, time.UTC))
+			label := entity.GetLocalizedMonthName(time.Date(k.year, k.month, 1, 0, 0, 0, 0, time.UTC), state.Locale)
 			msg += fmt.Sprintf("\n%s %d: 🔗 %d | 📝 %d", label, k.year, st.subscribed, st.registered)
 		}
 		_ = m.SendText(state.ChatID, msg)
@@ -39,7 +39,7 @@ func (s *SelectVideoStep) Enter(ctx context.Context, m chat.Messenger, state *ch
 
 	if s.driveService == nil {
 		log.Warn("select_video: drive service not configured")
-		_ = m.SendText(state.ChatID, "Навчальні матеріали тимчасово недоступні.")
+		_ = m.SendText(state.ChatID, state.T("video.unavailable"))
 		return chat.StepResult{NextStep: StepMainMenu}
 	}
 
@@ -63,30 +63,30 @@ func (s *SelectVideoStep) Enter(ctx context.Context, m chat.Messenger, state *ch
 	case r := <-ch:
 		if r.err != nil {
 			log.Error("select_video: list videos failed", sl.Err(r.err))
-			_ = m.SendText(state.ChatID, "Помилка завантаження списку відео. Спробуйте пізніше.")
+			_ = m.SendText(state.ChatID, state.T("video.list_failed"))
 			return chat.StepResult{Error: r.err}
 		}
 		videos = r.videos
 	case <-time.After(25 * time.Second):
 		log.Error("select_video: list videos timed out — Drive unreachable or credentials invalid")
-		_ = m.SendText(state.ChatID, "Помилка завантаження списку відео. Спробуйте пізніше.")
+		_ = m.SendText(state.ChatID, state.T("video.list_failed"))
 		return chat.StepResult{}
 	}
 
 	log.Info("select_video: videos loaded", slog.Int("count", len(videos)))
 
 	if len(videos) == 0 {
-		_ = m.SendText(state.ChatID, "Наразі відео-матеріали відсутні.")
+		_ = m.SendText(state.ChatID, state.T("video.none"))
 		return chat.StepResult{NextStep: StepMainMenu}
 	}
 
 	// Show a persistent bottom-menu button so users have an obvious way back.
-	_ = m.SendMenu(state.ChatID, "📚 Навчальні відео", [][]chat.MenuButton{
-		{{Text: BtnBack + " до меню"}},
+	_ = m.SendMenu(state.ChatID, state.T("video.title"), [][]chat.MenuButton{
+		{{Text: state.T(BtnBackToMenu)}},
 	})
 
-	rows := s.buildPage(videos, 0)
-	if err := m.SendInlineGrid(state.ChatID, "Оберіть відео для перегляду:", rows); err != nil {
+	rows := s.buildPage(state, videos, 0)
+	if err := m.SendInlineGrid(state.ChatID, state.T("video.choose"), rows); err != nil {
 		log.Error("select_video: send inline grid failed", sl.Err(err))
 		return chat.StepResult{Error: err}
 	}
@@ -104,8 +104,8 @@ func (s *SelectVideoStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 	data := input.CallbackData
 
 	// Bottom-menu back button (reply keyboard).
-	if input.Text == BtnBack+" до меню" {
-		return chat.StepResult{NextStep: StepMainMenu}
+	if state.Is(input.Text, BtnBackToMenu) {
+		return chat.StepResult{Back: true, NextStep: StepMainMenu}
 	}
 
 	// For text-only platforms: match number input to the current page.
@@ -115,7 +115,7 @@ func (s *SelectVideoStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 			return chat.StepResult{NextStep: StepMainMenu}
 		}
 		page := state.GetInt("vid_page")
-		rows := s.buildPage(videos, page)
+		rows := s.buildPage(state, videos, page)
 		data = chat.MatchNumberToInlineGrid(input.Text, rows)
 	}
 
@@ -125,7 +125,7 @@ func (s *SelectVideoStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 
 	// Inline back button.
 	if data == "vid_back" {
-		return chat.StepResult{NextStep: StepMainMenu}
+		return chat.StepResult{Back: true, NextStep: StepMainMenu}
 	}
 
 	// Pagination — edit the existing message in place.
@@ -142,11 +142,11 @@ func (s *SelectVideoStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 		if err != nil || len(videos) == 0 {
 			return chat.StepResult{NextStep: StepMainMenu}
 		}
-		rows := s.buildPage(videos, page)
+		rows := s.buildPage(state, videos, page)
 		if input.MessageID != "" {
-			_ = m.EditInlineGrid(state.ChatID, input.MessageID, "📚 Навчальні відео\n\nОберіть відео для перегляду:", rows)
+			_ = m.EditInlineGrid(state.ChatID, input.MessageID, state.T("video.title")+"\n\n"+state.T("video.choose"), rows)
 		} else {
-			_ = m.SendInlineGrid(state.ChatID, "📚 Навчальні відео\n\nОберіть відео для перегляду:", rows)
+			_ = m.SendInlineGrid(state.ChatID, state.T("video.title")+"\n\n"+state.T("video.choose"), rows)
 		}
 		return chat.StepResult{
 			UpdateState: map[string]any{"vid_page": page},
@@ -162,7 +162,7 @@ func (s *SelectVideoStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 		}
 		videos, err := s.driveService.ListVideos()
 		if err != nil || idx < 0 || idx >= len(videos) {
-			_ = m.SendText(state.ChatID, "Помилка завантаження відео. Спробуйте пізніше.")
+			_ = m.SendText(state.ChatID, state.T("video.load_failed"))
 			return chat.StepResult{}
 		}
 		video := videos[idx]
@@ -197,7 +197,7 @@ func (s *SelectVideoStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 			if dlErr != nil {
 				close(uploadDone)
 				log.Error("select_video: download failed", slog.String("file_id", video.ID), sl.Err(dlErr))
-				_ = m.SendText(state.ChatID, "Помилка завантаження відео. Спробуйте пізніше.")
+				_ = m.SendText(state.ChatID, state.T("video.load_failed"))
 				return chat.StepResult{}
 			}
 			defer rc.Close()
@@ -236,11 +236,11 @@ func (s *SelectVideoStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 				}
 				// Final fallback: send a Drive viewer link.
 				driveLink := fmt.Sprintf("https://drive.google.com/file/d/%s/view", video.ID)
-				_ = m.SendText(state.ChatID, fmt.Sprintf("Відео \"%s\" занадто велике для надсилання через Telegram.\n\nВідкрийте за посиланням:\n%s", video.Name, driveLink))
+				_ = m.SendText(state.ChatID, state.T("video.too_large", video.Name, driveLink))
 				return chat.StepResult{}
 			}
 			log.Error("select_video: send video failed", slog.String("name", video.Name), sl.Err(sendErr))
-			_ = m.SendText(state.ChatID, "Помилка відправки відео. Спробуйте пізніше.")
+			_ = m.SendText(state.ChatID, state.T("video.send_failed"))
 			return chat.StepResult{}
 		}
 
@@ -256,9 +256,15 @@ func (s *SelectVideoStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 	return chat.StepResult{}
 }
 
+func (s *SelectVideoStep) Timeout() time.Duration { return sessionIdleTimeout }
+
+func (s *SelectVideoStep) OnTimeout(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+	return expireToMainMenu(m, state)
+}
+
 // buildPage constructs a page of inline video buttons plus navigation and a
 // "Back to menu" row. Page numbering is zero-based.
-func (s *SelectVideoStep) buildPage(videos []gdrive.VideoItem, page int) [][]chat.InlineButton {
+func (s *SelectVideoStep) buildPage(state *chat.ChatState, videos []gdrive.VideoItem, page int) [][]chat.InlineButton {
 	start := page * videosPerPage
 	if start >= len(videos) {
 		start = 0
@@ -295,7 +301,7 @@ func (s *SelectVideoStep) buildPage(videos []gdrive.VideoItem, page int) [][]cha
 	}
 
 	rows = append(rows, []chat.InlineButton{
-		{Text: BtnBack + " до меню", Data: "vid_back"},
+		{Text: state.T(BtnBackToMenu), Data: "vid_back"},
 	})
 
 	return rows
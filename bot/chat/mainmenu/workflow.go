@@ -26,26 +26,32 @@ const (
 	StepMakeOrder       chat.StepID = "make_order"
 	StepSchoolStat      chat.StepID = "school_stat"
 	StepSelectVideo     chat.StepID = "select_video"
+	StepLanguage        chat.StepID = "language"
 )
 
-// Menu button texts (same as Telegram)
+// Menu button message keys. Labels are resolved in the user's locale with
+// state.T, and incoming text is matched back to a key with state.Is.
 const (
-	BtnMyOffice        = "📦Особистий кабінет"
-	BtnServiceRate     = "⭐Оцінка сервісу"
-	BtnOrderStatus     = "🛒Статус замовлення"
-	BtnAIConsultant    = "👋 AI-консультація"
-	BtnMakeOrder       = "Зробити замовлення😎"
-	BtnCurrentOrder    = "🛍️Поточні замовлення"
-	BtnCompletedOrders = "✅Виконані замовлення"
-	BtnBack            = "↩️Назад"
-	BtnSchoolStat      = "📊Статистика шкіл"
-	BtnLearning        = "📚Навчання"
+	BtnMyOffice        = "btn.my_office"
+	BtnServiceRate     = "btn.service_rate"
+	BtnOrderStatus     = "btn.order_status"
+	BtnAIConsultant    = "btn.ai_consultant"
+	BtnMakeOrder       = "btn.make_order"
+	BtnCurrentOrder    = "btn.current_order"
+	BtnCompletedOrders = "btn.completed_orders"
+	BtnBack            = "btn.back"
+	BtnBackToMenu      = "btn.back_to_menu"
+	BtnSchoolStat      = "btn.school_stat"
+	BtnLearning        = "btn.learning"
 )
 
 // AuthService defines the interface for user operations.
 type AuthService interface {
 	GetUser(email, phone string, telegramId int64) (*entity.User, error)
 	GetUserByInstagramId(instagramId string) (*entity.User, error)
+	GetUserByFacebookId(facebookId string) (*entity.User, error)
+	GetUserByViberId(viberId string) (*entity.User, error)
+	GetUserByWebId(webId string) (*entity.User, error)
 }
 
 // ZohoService defines the interface for Zoho CRM operations.
@@ -61,6 +67,7 @@ type ZohoService interface {
 // AIService defines the interface for AI assistant operations.
 type AIService interface {
 	ProcessUserRequest(user *entity.User, message string) (*entity.AiAnswer, error)
+	TranscribeVoice(platform, userID string, att chat.Attachment) (string, error)
 }
 
 // SchoolRepository defines the interface for school data access.
@@ -97,6 +104,7 @@ func NewMainMenuWorkflow(authService AuthService, zohoService ZohoService, aiSer
 	w.steps[StepMakeOrder] = &MakeOrderStep{authService: authService, aiService: aiService}
 	w.steps[StepSchoolStat] = &SchoolStatStep{qrStatRepo: qrStatRepo}
 	w.steps[StepSelectVideo] = &SelectVideoStep{driveService: driveService, log: log, fileIDCache: make(map[string]string)}
+	w.steps[StepLanguage] = &LanguageStep{}
 
 	return w
 }
@@ -108,3 +116,12 @@ func (w *MainMenuWorkflow) GetStep(id chat.StepID) (chat.Step, bool) {
 	step, ok := w.steps[id]
 	return step, ok
 }
+
+// Steps returns all steps of the workflow.
+func (w *MainMenuWorkflow) Steps() []chat.Step {
+	steps := make([]chat.Step, 0, len(w.steps))
+	for _, step := range w.steps {
+		steps = append(steps, step)
+	}
+	return steps
+}
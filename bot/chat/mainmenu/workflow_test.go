@@ -0,0 +1,321 @@
+package mainmenu_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"DarkCS/bot/chat"
+	"DarkCS/bot/chat/chattest"
+	"DarkCS/bot/chat/mainmenu"
+	"DarkCS/entity"
+	"DarkCS/internal/lib/i18n"
+)
+
+func label(key string) string {
+	return i18n.T(i18n.Default, key)
+}
+
+var customerMenu = []string{
+	label(mainmenu.BtnMyOffice), label(mainmenu.BtnServiceRate),
+	label(mainmenu.BtnOrderStatus),
+	label(mainmenu.BtnAIConsultant), label(mainmenu.BtnMakeOrder),
+	label(mainmenu.BtnLearning),
+}
+
+// newConversation starts the main menu for a registered Telegram user with the given role.
+func newConversation(t *testing.T, role string, svc *chattest.Services) *chattest.Conversation {
+	user := entity.NewUser("", "+380501234567", 100)
+	user.Name = "Олена"
+	user.Role = role
+	user.ZohoId = "zoho-1"
+	svc.Auth = chattest.NewAuthService(user)
+
+	c := chattest.New(t, "telegram", "100",
+		mainmenu.NewMainMenuWorkflow(svc.Auth, svc.Zoho, svc.AI, svc.Schools, svc.QrStats, nil, chattest.DiscardLogger()),
+	)
+	for _, intent := range mainmenu.Intents() {
+		c.Engine.RegisterIntent(intent)
+	}
+	return c
+}
+
+func TestMainMenuShowsRoleSpecificButtons(t *testing.T) {
+	newConversation(t, entity.UserRole, chattest.NewServices()).
+		Start(mainmenu.WorkflowID).
+		ExpectMenu(customerMenu...).
+		ExpectStep(mainmenu.StepMainMenu)
+
+	c := newConversation(t, entity.ManagerRole, chattest.NewServices())
+	c.Start(mainmenu.WorkflowID).
+		ExpectMenu(append(customerMenu, label(mainmenu.BtnSchoolStat))...)
+	c.Send(label(mainmenu.BtnSchoolStat)).
+		ExpectText("📊 Статистика шкіл").
+		ExpectStep(mainmenu.StepSchoolStat)
+}
+
+func TestMainMenuHidesSchoolStatFromCustomers(t *testing.T) {
+	c := newConversation(t, entity.UserRole, chattest.NewServices())
+	c.Start(mainmenu.WorkflowID)
+	c.Send(label(mainmenu.BtnSchoolStat)).
+		ExpectNoReply().
+		ExpectStep(mainmenu.StepMainMenu)
+}
+
+func TestMyOfficeNavigationAndBack(t *testing.T) {
+	c := newConversation(t, entity.UserRole, chattest.NewServices())
+	c.Start(mainmenu.WorkflowID)
+
+	c.Send(label(mainmenu.BtnMyOffice)).
+		ExpectMenu(label(mainmenu.BtnCurrentOrder), label(mainmenu.BtnCompletedOrders), label(mainmenu.BtnBack)).
+		ExpectStep(mainmenu.StepMyOffice)
+
+	// Numbered input works the same way as on text-only platforms.
+	c.Send("3").
+		ExpectMenu(customerMenu...).
+		ExpectStep(mainmenu.StepMainMenu)
+}
+
+func TestCurrentOrderShowsProducts(t *testing.T) {
+	svc := chattest.NewServices()
+	svc.Zoho = chattest.NewZohoService(entity.OrderDetail{ID: "o-1", Subject: "SO-42", Status: entity.OrderStatusNew, TTN: "20450000000000"})
+	svc.Zoho.Products["o-1"] = "Гель-лак x2"
+	c := newConversation(t, entity.UserRole, svc)
+	c.Start(mainmenu.WorkflowID)
+
+	c.Send(label(mainmenu.BtnOrderStatus)).
+		ExpectText("Замовник: Олена\nСтатус: Нове\nНомер замовлення: SO-42").
+		ExpectText(`ТТН: <a href="https://novaposhta.ua/tracking/20450000000000">`).
+		ExpectInline("📋 Товари").
+		ExpectStep(mainmenu.StepCurrentOrder)
+
+	c.Callback("products:o-1").
+		ExpectText("Гель-лак x2").
+		ExpectStep(mainmenu.StepMainMenu)
+}
+
+func TestCompletedOrdersWithoutOrders(t *testing.T) {
+	c := newConversation(t, entity.UserRole, chattest.NewServices())
+	c.Start(mainmenu.WorkflowID)
+	c.Send(label(mainmenu.BtnMyOffice))
+
+	c.Send(label(mainmenu.BtnCompletedOrders)).
+		ExpectText("У вас немає виконаних замовлень.").
+		ExpectStep(mainmenu.StepMyOffice)
+}
+
+func TestServiceRateCreatesRating(t *testing.T) {
+	svc := chattest.NewServices()
+	svc.Zoho = chattest.NewZohoService(entity.OrderDetail{ID: "o-7", Status: entity.OrderStatusReceived})
+	c := newConversation(t, entity.UserRole, svc)
+	c.Start(mainmenu.WorkflowID)
+
+	c.Send(label(mainmenu.BtnServiceRate)).
+		ExpectInline("1", "2", "3", "4", "5").
+		ExpectStep(mainmenu.StepServiceRate)
+
+	c.Callback("rate:5").
+		ExpectText("Ваша оцінка успішно створена!").
+		ExpectStep(mainmenu.StepMainMenu)
+
+	if len(svc.Zoho.Ratings) != 1 || svc.Zoho.Ratings[0].ServiceRating != 5 || svc.Zoho.Ratings[0].OrderNumber != "o-7" {
+		t.Errorf("unexpected ratings: %+v", svc.Zoho.Ratings)
+	}
+}
+
+func TestAIConsultantAnswersAndGoesBack(t *testing.T) {
+	svc := chattest.NewServices()
+	svc.AI.Reply = "Рекомендую базу DARK"
+	c := newConversation(t, entity.UserRole, svc)
+	c.Start(mainmenu.WorkflowID)
+
+	c.Send(label(mainmenu.BtnAIConsultant)).
+		ExpectMenu(label(mainmenu.BtnBack)).
+		ExpectStep(mainmenu.StepAIConsultant)
+
+	c.Send("Яку базу обрати?").
+		ExpectText("Рекомендую базу DARK").
+		ExpectStep(mainmenu.StepAIConsultant)
+
+	c.Send("Назад").
+		ExpectMenu(customerMenu...).
+		ExpectStep(mainmenu.StepMainMenu)
+
+	if len(svc.AI.Requests) != 1 || svc.AI.Requests[0] != "Яку базу обрати?" {
+		t.Errorf("unexpected AI requests: %q", svc.AI.Requests)
+	}
+}
+
+func TestMenuIntentInterruptsAIMode(t *testing.T) {
+	svc := chattest.NewServices()
+	c := newConversation(t, entity.UserRole, svc)
+	c.Start(mainmenu.WorkflowID)
+	c.Send(label(mainmenu.BtnMakeOrder)).ExpectStep(mainmenu.StepMakeOrder)
+
+	// In AI mode a plain keyword is a question for the assistant; the command leaves
+	c.Send("меню").ExpectStep(mainmenu.StepMakeOrder)
+	c.Send("/menu").
+		ExpectMenu(customerMenu...).
+		ExpectStep(mainmenu.StepMainMenu)
+
+	if len(svc.AI.Requests) != 1 || svc.AI.Requests[0] != "меню" {
+		t.Errorf("AI requests = %q, want only the plain keyword", svc.AI.Requests)
+	}
+}
+
+func TestOperatorIntentHandsChatToManager(t *testing.T) {
+	svc := chattest.NewServices()
+	c := newConversation(t, entity.UserRole, svc)
+	handoff := chattest.NewHandoff()
+	c.Engine.SetHandoff(handoff)
+	c.Start(mainmenu.WorkflowID)
+	c.Send(label(mainmenu.BtnMakeOrder)).ExpectStep(mainmenu.StepMakeOrder)
+
+	c.Send("/operator").ExpectText(label("operator.requested"))
+	if !handoff.HumanMode(c.Platform, c.UserID) {
+		t.Fatal("operator intent must turn human mode on")
+	}
+
+	// Neither the AI consultant nor intents answer while the manager handles the chat
+	c.Send("Яку базу обрати?").ExpectNoReply().ExpectStep(mainmenu.StepMakeOrder)
+	c.Send("/menu").ExpectNoReply().ExpectStep(mainmenu.StepMakeOrder)
+	c.Start(mainmenu.WorkflowID).ExpectNoReply().ExpectStep(mainmenu.StepMakeOrder)
+	if len(svc.AI.Requests) != 0 {
+		t.Errorf("human mode input must not reach the AI, got %q", svc.AI.Requests)
+	}
+
+	// Closing the chat hands it back to the bot
+	handoff.Set(c.Platform, c.UserID, false)
+	c.Send("/menu").ExpectMenu(customerMenu...).ExpectStep(mainmenu.StepMainMenu)
+}
+
+func TestSwitchToEnglish(t *testing.T) {
+	c := newConversation(t, entity.UserRole, chattest.NewServices())
+	c.Start(mainmenu.WorkflowID)
+
+	c.Send("/lang").
+		ExpectInline("🇺🇦 Українська", "🇬🇧 English").
+		ExpectStep(mainmenu.StepLanguage)
+
+	c.Callback("lang:en").
+		ExpectText("Language switched to English.").
+		ExpectMenu(
+			i18n.T(i18n.English, mainmenu.BtnMyOffice), i18n.T(i18n.English, mainmenu.BtnServiceRate),
+			i18n.T(i18n.English, mainmenu.BtnOrderStatus),
+			i18n.T(i18n.English, mainmenu.BtnAIConsultant), i18n.T(i18n.English, mainmenu.BtnMakeOrder),
+			i18n.T(i18n.English, mainmenu.BtnLearning),
+		).
+		ExpectStep(mainmenu.StepMainMenu)
+
+	c.Send(i18n.T(i18n.English, mainmenu.BtnMyOffice)).
+		ExpectText("What are you interested in?").
+		ExpectStep(mainmenu.StepMyOffice)
+}
+
+func TestLanguageSurvivesStop(t *testing.T) {
+	c := newConversation(t, entity.UserRole, chattest.NewServices())
+	c.Start(mainmenu.WorkflowID)
+	c.Send("/lang")
+	c.Callback("lang:en").ExpectStep(mainmenu.StepMainMenu)
+
+	c.Send("/stop").
+		ExpectText(i18n.T(i18n.English, "stop.done")).
+		ExpectNoState()
+
+	c.Start(mainmenu.WorkflowID).
+		ExpectMenu(
+			i18n.T(i18n.English, mainmenu.BtnMyOffice), i18n.T(i18n.English, mainmenu.BtnServiceRate),
+			i18n.T(i18n.English, mainmenu.BtnOrderStatus),
+			i18n.T(i18n.English, mainmenu.BtnAIConsultant), i18n.T(i18n.English, mainmenu.BtnMakeOrder),
+			i18n.T(i18n.English, mainmenu.BtnLearning),
+		)
+	if got := c.State().Locale; got != i18n.English {
+		t.Errorf("locale after restart = %q, want %q", got, i18n.English)
+	}
+}
+
+func TestSchoolSelectionFromDeepLink(t *testing.T) {
+	svc := chattest.NewServices()
+	svc.Schools.Schools = []entity.School{{Name: "Nail School", Active: true}, {Name: "Closed", Active: false}}
+	c := newConversation(t, entity.UserRole, svc)
+
+	c.Start(mainmenu.WorkflowID, map[string]any{"deep_link_type": "dl"}).
+		ExpectInline("Nail School").
+		ExpectStep(mainmenu.StepSelectSchool)
+
+	c.Callback("school_sel:0").
+		ExpectText("Вітаємо, Nail School!").
+		ExpectStep(mainmenu.StepMainMenu)
+
+	if svc.Zoho.Schools["zoho-1"] != "Nail School" {
+		t.Errorf("school was not synced to Zoho: %v", svc.Zoho.Schools)
+	}
+	if len(svc.QrStats.Stats) != 1 || svc.QrStats.Stats[0].SchoolName != "Nail School" {
+		t.Errorf("school stat was not saved: %+v", svc.QrStats.Stats)
+	}
+}
+
+func TestAIConsultantAcknowledgesAttachments(t *testing.T) {
+	svc := chattest.NewServices()
+	c := newConversation(t, entity.UserRole, svc)
+	c.Start(mainmenu.WorkflowID)
+	c.Send(label(mainmenu.BtnAIConsultant))
+
+	photo := chattest.NewAttachment(chat.AttachmentImage, "photo.jpg", "image/jpeg", []byte("jpeg"))
+
+	c.Attach("", photo).
+		ExpectText(i18n.T(i18n.Default, "ai.attachment_received")).
+		ExpectStep(mainmenu.StepAIConsultant)
+	if len(svc.AI.Requests) != 0 {
+		t.Errorf("a file without caption must not reach the AI, got %q", svc.AI.Requests)
+	}
+
+	c.Attach("Що це за відтінок?", photo).
+		ExpectText("AI: Що це за відтінок?").
+		ExpectStep(mainmenu.StepAIConsultant)
+}
+
+func TestVoiceMessagesAreAnsweredAsText(t *testing.T) {
+	svc := chattest.NewServices()
+	svc.AI.VoiceMaxDuration = time.Minute
+	c := newConversation(t, entity.UserRole, svc)
+	c.Start(mainmenu.WorkflowID)
+	c.Send(label(mainmenu.BtnAIConsultant))
+
+	voice := chattest.NewAttachment(chat.AttachmentVoice, "voice.ogg", "audio/ogg", []byte("Який шампунь для сухого волосся?"))
+	voice.Duration = 10 * time.Second
+
+	c.Attach("", voice).
+		ExpectText("AI: Який шампунь для сухого волосся?").
+		ExpectStep(mainmenu.StepAIConsultant)
+	for _, msg := range c.Replies() {
+		if msg.Text == i18n.T(i18n.Default, "ai.attachment_received") {
+			t.Errorf("a voice message must not be acknowledged as a file")
+		}
+	}
+
+	voice.Duration = 2 * time.Minute
+	c.Attach("", voice).
+		ExpectText(i18n.T(i18n.Default, "voice.too_long")).
+		ExpectStep(mainmenu.StepAIConsultant)
+	if len(svc.AI.Requests) != 1 {
+		t.Errorf("a voice message over the limit must not reach the AI, got %q", svc.AI.Requests)
+	}
+
+	c.Send(label(mainmenu.BtnBack))
+	c.Send(label(mainmenu.BtnMakeOrder))
+	svc.AI.TranscribeErr = errors.New("whisper unavailable")
+	c.Attach("", chattest.NewAttachment(chat.AttachmentVoice, "voice.ogg", "audio/ogg", []byte("два флакони"))).
+		ExpectText(i18n.T(i18n.Default, "voice.failed")).
+		ExpectStep(mainmenu.StepMakeOrder)
+}
+
+func TestCaptionDoesNotTriggerIntents(t *testing.T) {
+	c := newConversation(t, entity.UserRole, chattest.NewServices())
+	c.Start(mainmenu.WorkflowID)
+	c.Send(label(mainmenu.BtnMakeOrder))
+
+	c.Attach("меню", chattest.NewAttachment(chat.AttachmentImage, "receipt.png", "image/png", nil)).
+		ExpectText(i18n.T(i18n.Default, "order.attachment_received")).
+		ExpectStep(mainmenu.StepMakeOrder)
+}
@@ -12,6 +12,7 @@ type FileMessage struct {
 	Reader   io.Reader
 	Filename string
 	MIMEType string
+	Size     int64 // Size in bytes when known; Viber requires it for files.
 	Caption  string
 	URL      string // Public download URL for platforms that require a link (Instagram, WhatsApp).
 }
@@ -41,6 +42,29 @@ type Messenger interface {
 	SendUploadAction(chatID string) error
 }
 
+// TrackingMessenger is implemented by messengers that report the platform ID of
+// each sent message, so its delivery and read status can be tracked.
+type TrackingMessenger interface {
+	SendTextID(chatID, text string) (messageID string, err error)
+	SendFileID(chatID string, file FileMessage) (messageID string, err error)
+}
+
+// SendTrackedText sends text and returns the platform message ID when the messenger reports it.
+func SendTrackedText(m Messenger, chatID, text string) (string, error) {
+	if tm, ok := m.(TrackingMessenger); ok {
+		return tm.SendTextID(chatID, text)
+	}
+	return "", m.SendText(chatID, text)
+}
+
+// SendTrackedFile sends a file and returns the platform message ID when the messenger reports it.
+func SendTrackedFile(m Messenger, chatID string, file FileMessage) (string, error) {
+	if tm, ok := m.(TrackingMessenger); ok {
+		return tm.SendFileID(chatID, file)
+	}
+	return "", m.SendFile(chatID, file)
+}
+
 // loggingMessenger wraps a Messenger and saves outgoing bot messages to CRM.
 type loggingMessenger struct {
 	inner    Messenger
@@ -60,36 +84,50 @@ func newLoggingMessenger(inner Messenger, listener MessageListener, platform, us
 	return &loggingMessenger{inner: inner, listener: listener, platform: platform, userID: userID}
 }
 
-func (m *loggingMessenger) saveOutgoing(text string) {
+func (m *loggingMessenger) saveOutgoing(text string, messageIDs ...string) {
 	m.listener.SaveAndBroadcastChatMessage(entity.ChatMessage{
-		Platform:  m.platform,
-		UserID:    m.userID,
-		ChatID:    m.userID,
-		Direction: "outgoing",
-		Sender:    "bot",
-		Text:      text,
-		CreatedAt: time.Now(),
+		Platform:           m.platform,
+		UserID:             m.userID,
+		ChatID:             m.userID,
+		Direction:          "outgoing",
+		Sender:             "bot",
+		Text:               text,
+		CreatedAt:          time.Now(),
+		PlatformMessageIDs: entity.PlatformMessageIDs(messageIDs...),
+		Status:             entity.MessageSent,
 	})
 }
 
 func (m *loggingMessenger) SendText(chatID, text string) error {
-	if err := m.inner.SendText(chatID, text); err != nil {
-		return err
+	_, err := m.SendTextID(chatID, text)
+	return err
+}
+
+func (m *loggingMessenger) SendTextID(chatID, text string) (string, error) {
+	messageID, err := SendTrackedText(m.inner, chatID, text)
+	if err != nil {
+		return "", err
 	}
-	m.saveOutgoing(text)
-	return nil
+	m.saveOutgoing(text, messageID)
+	return messageID, nil
 }
 
 func (m *loggingMessenger) SendFile(chatID string, file FileMessage) error {
-	if err := m.inner.SendFile(chatID, file); err != nil {
-		return err
+	_, err := m.SendFileID(chatID, file)
+	return err
+}
+
+func (m *loggingMessenger) SendFileID(chatID string, file FileMessage) (string, error) {
+	messageID, err := SendTrackedFile(m.inner, chatID, file)
+	if err != nil {
+		return "", err
 	}
 	text := file.Caption
 	if text == "" {
 		text = "[File: " + file.Filename + "]"
 	}
-	m.saveOutgoing(text)
-	return nil
+	m.saveOutgoing(text, messageID)
+	return messageID, nil
 }
 
 func (m *loggingMessenger) SendVideo(chatID string, r io.Reader, cachedFileID, publicURL, filename string, protected bool) (string, error) {
@@ -145,6 +183,27 @@ func (m *loggingMessenger) SendUploadAction(chatID string) error {
 	return m.inner.SendUploadAction(chatID)
 }
 
+// discardMessenger drops every message. It is used for engine-initiated
+// transitions on platforms without a registered messenger.
+type discardMessenger struct{}
+
+func (discardMessenger) SendText(chatID, text string) error             { return nil }
+func (discardMessenger) SendFile(chatID string, file FileMessage) error { return nil }
+func (discardMessenger) SendVideo(chatID string, r io.Reader, cachedFileID, publicURL, filename string, protected bool) (string, error) {
+	return "", nil
+}
+func (discardMessenger) SendMenu(chatID, text string, rows [][]MenuButton) error { return nil }
+func (discardMessenger) SendInlineOptions(chatID, text string, buttons []InlineButton) error {
+	return nil
+}
+func (discardMessenger) SendInlineGrid(chatID, text string, rows [][]InlineButton) error { return nil }
+func (discardMessenger) EditInlineGrid(chatID, messageID, text string, rows [][]InlineButton) error {
+	return nil
+}
+func (discardMessenger) SendContactRequest(chatID, text, buttonText string) error { return nil }
+func (discardMessenger) SendTyping(chatID string) error                           { return nil }
+func (discardMessenger) SendUploadAction(chatID string) error                     { return nil }
+
 // MenuButton represents a button in a reply/menu keyboard.
 type MenuButton struct {
 	Text string
@@ -158,8 +217,9 @@ type InlineButton struct {
 
 // UserInput represents a normalized event from any platform.
 type UserInput struct {
-	Text         string // Regular message text
-	CallbackData string // Inline button press or matched number
-	Phone        string // Contact share or typed phone
-	MessageID    string // ID of the message that triggered the callback (for editing)
+	Text         string       // Regular message text
+	CallbackData string       // Inline button press or matched number
+	Phone        string       // Contact share or typed phone
+	MessageID    string       // ID of the message that triggered the callback (for editing)
+	Attachments  []Attachment // Files sent by the user; Text holds their caption
 }
@@ -0,0 +1,13 @@
+package onboarding
+
+import "DarkCS/bot/chat"
+
+// StartIntent restarts onboarding from the beginning on any platform.
+// Telegram's /start command with deep-link payloads is still handled by the bot itself.
+func StartIntent() chat.Intent {
+	return chat.Intent{
+		Name:     "start",
+		Keywords: []string{"/start", "start", "старт", "почати"},
+		Workflow: WorkflowID,
+	}
+}
@@ -2,23 +2,32 @@ package onboarding
 
 import (
 	"context"
-	"fmt"
 	"strconv"
 	"strings"
 
 	"DarkCS/bot/chat"
+	"DarkCS/entity"
 )
 
 // HelloStep — Welcome message, then auto-transition to request phone.
+// Anonymous visitors are asked only for their name.
 type HelloStep struct{}
 
 func (s *HelloStep) ID() chat.StepID { return StepHello }
 
 func (s *HelloStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
-	if err := m.SendText(state.ChatID, "Привіт! 🖤\nДля швидкої перевірки в системі, будь ласка, надайте свій номер телефону у міжнародному форматі, починаючи з +380... 📱"); err != nil {
+	if err := m.SendText(state.ChatID, state.T("onboarding.hello")); err != nil {
 		return chat.StepResult{Error: err}
 	}
 
+	// An anonymous visitor could type any customer's phone number, so it is not asked for
+	if entity.AnonymousChatPlatform(state.Platform) {
+		return chat.StepResult{
+			NextStep:    StepRequestName,
+			UpdateState: map[string]any{KeyUserExists: false},
+		}
+	}
+
 	// WhatsApp: offer choice — use WhatsApp phone or enter manually
 	if state.Platform == "whatsapp" {
 		waPhone := chat.NormalizePhone(state.UserID)
@@ -43,10 +52,10 @@ func (s *ChoosePhoneStep) ID() chat.StepID { return StepChoosePhone }
 func (s *ChoosePhoneStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
 	waPhone := state.GetString("wa_phone")
 	buttons := []chat.InlineButton{
-		{Text: fmt.Sprintf("📱 Використати %s", waPhone), Data: "use_wa_phone"},
-		{Text: "✏️ Ввести інший номер", Data: "enter_manual"},
+		{Text: state.T("onboarding.use_wa_phone", waPhone), Data: "use_wa_phone"},
+		{Text: state.T("onboarding.enter_other_phone"), Data: "enter_manual"},
 	}
-	_ = m.SendInlineOptions(state.ChatID, "Бажаєте використати номер телефону з WhatsApp або ввести інший?", buttons)
+	_ = m.SendInlineOptions(state.ChatID, state.T("onboarding.choose_phone"), buttons)
 	return chat.StepResult{}
 }
 
@@ -55,8 +64,8 @@ func (s *ChoosePhoneStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 	if data == "" {
 		waPhone := state.GetString("wa_phone")
 		buttons := []chat.InlineButton{
-			{Text: fmt.Sprintf("📱 Використати %s", waPhone), Data: "use_wa_phone"},
-			{Text: "✏️ Ввести інший номер", Data: "enter_manual"},
+			{Text: state.T("onboarding.use_wa_phone", waPhone), Data: "use_wa_phone"},
+			{Text: state.T("onboarding.enter_other_phone"), Data: "enter_manual"},
 		}
 		data = chat.MatchNumberToInline(input.Text, buttons)
 	}
@@ -64,7 +73,7 @@ func (s *ChoosePhoneStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 	switch data {
 	case "use_wa_phone":
 		waPhone := state.GetString("wa_phone")
-		_ = m.SendText(state.ChatID, fmt.Sprintf("✅ Номер телефону: %s", waPhone))
+		_ = m.SendText(state.ChatID, state.T("onboarding.phone_accepted", waPhone))
 		return chat.StepResult{
 			NextStep:    StepCheckUser,
 			UpdateState: map[string]any{KeyPhone: waPhone},
@@ -81,12 +90,16 @@ type RequestPhoneStep struct{}
 
 func (s *RequestPhoneStep) ID() chat.StepID { return StepRequestPhone }
 
+// FreeInput leaves a typed phone number such as "0" to the step.
+func (s *RequestPhoneStep) FreeInput() bool { return true }
+
 func (s *RequestPhoneStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
-	if state.Platform == "telegram" {
-		_ = m.SendContactRequest(state.ChatID, "Натисніть кнопку нижче, щоб поділитися номером телефону:", "📱 Поділитися номером телефону")
+	// Telegram and Viber share the phone number with a native button
+	if state.Platform == "telegram" || state.Platform == "viber" {
+		_ = m.SendContactRequest(state.ChatID, state.T("onboarding.share_phone_prompt"), state.T("onboarding.share_phone_button"))
 		return chat.StepResult{}
 	}
-	_ = m.SendText(state.ChatID, "Введіть номер телефону (наприклад +380XXXXXXXXX):")
+	_ = m.SendText(state.ChatID, state.T("onboarding.enter_phone"))
 	return chat.StepResult{} // Wait for input
 }
 
@@ -99,12 +112,12 @@ func (s *RequestPhoneStep) HandleInput(ctx context.Context, m chat.Messenger, st
 	}
 
 	if !chat.IsValidPhone(text) {
-		_ = m.SendText(state.ChatID, "❌ Невірний формат номера телефону. Спробуйте ще раз (наприклад +380XXXXXXXXX):")
+		_ = m.SendText(state.ChatID, state.T("onboarding.invalid_phone"))
 		return chat.StepResult{}
 	}
 
 	phone := chat.NormalizePhone(text)
-	_ = m.SendText(state.ChatID, fmt.Sprintf("✅ Номер телефону: %s", phone))
+	_ = m.SendText(state.ChatID, state.T("onboarding.phone_accepted", phone))
 
 	return chat.StepResult{
 		NextStep:    StepCheckUser,
@@ -123,28 +136,11 @@ func (s *CheckUserStep) ID() chat.StepID { return StepCheckUser }
 func (s *CheckUserStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
 	phone := state.GetString(KeyPhone)
 
-	// Parse telegramId when platform is telegram
-	var telegramId int64
-	if state.Platform == "telegram" {
-		telegramId, _ = strconv.ParseInt(state.UserID, 10, 64)
-	}
-
 	user, _ := s.authService.UserExists("", phone, 0)
 
 	if user != nil && user.Name != "" {
-		needsUpdate := false
-
-		// Link Instagram ID if missing
-		if state.Platform == "instagram" && user.InstagramId == "" {
-			user.InstagramId = state.UserID
-			needsUpdate = true
-		}
-
-		// Link Telegram ID if missing
-		if state.Platform == "telegram" && user.TelegramId == 0 && telegramId != 0 && telegramId != user.TelegramId {
-			user.TelegramId = telegramId
-			needsUpdate = true
-		}
+		// Link the platform's user ID if missing
+		needsUpdate := user.LinkChatUser(state.Platform, state.UserID)
 
 		// Ensure Zoho contact exists
 		if s.zohoService != nil {
@@ -185,15 +181,18 @@ type RequestNameStep struct{}
 
 func (s *RequestNameStep) ID() chat.StepID { return StepRequestName }
 
+// FreeInput takes any name, even one that is an intent keyword.
+func (s *RequestNameStep) FreeInput() bool { return true }
+
 func (s *RequestNameStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
-	_ = m.SendText(state.ChatID, "Будь ласка, залиште ваші ім'я та прізвище для знайомства 😎")
+	_ = m.SendText(state.ChatID, state.T("onboarding.request_name"))
 	return chat.StepResult{}
 }
 
 func (s *RequestNameStep) HandleInput(ctx context.Context, m chat.Messenger, state *chat.ChatState, input chat.UserInput) chat.StepResult {
 	name := strings.TrimSpace(input.Text)
 	if name == "" || len(name) < 2 {
-		_ = m.SendText(state.ChatID, "Будь ласка, введіть коректне ім'я (мінімум 2 символи).")
+		_ = m.SendText(state.ChatID, state.T("onboarding.invalid_name"))
 		return chat.StepResult{}
 	}
 
@@ -215,11 +214,14 @@ func (s *ConfirmDataStep) Enter(ctx context.Context, m chat.Messenger, state *ch
 	name := state.GetString(KeyName)
 	phone := state.GetString(KeyPhone)
 
-	msg := fmt.Sprintf("📋 Перевірте дані:\n\n👤 Ім'я: %s\n📱 Телефон: %s\n\nВсе вірно?", name, phone)
+	msg := state.T("onboarding.confirm_data", name, phone)
+	if entity.AnonymousChatPlatform(state.Platform) {
+		msg = state.T("onboarding.confirm_name", name)
+	}
 
 	buttons := []chat.InlineButton{
-		{Text: "✅ Так", Data: "confirm_yes"},
-		{Text: "❌ Ні, змінити", Data: "confirm_no"},
+		{Text: state.T("onboarding.confirm_yes"), Data: "confirm_yes"},
+		{Text: state.T("onboarding.confirm_no"), Data: "confirm_no"},
 	}
 	_ = m.SendInlineOptions(state.ChatID, msg, buttons)
 	return chat.StepResult{}
@@ -230,63 +232,87 @@ func (s *ConfirmDataStep) HandleInput(ctx context.Context, m chat.Messenger, sta
 	if data == "" {
 		// Try matching numbered input
 		buttons := []chat.InlineButton{
-			{Text: "✅ Так", Data: "confirm_yes"},
-			{Text: "❌ Ні, змінити", Data: "confirm_no"},
+			{Text: state.T("onboarding.confirm_yes"), Data: "confirm_yes"},
+			{Text: state.T("onboarding.confirm_no"), Data: "confirm_no"},
 		}
 		data = chat.MatchNumberToInline(input.Text, buttons)
 	}
 
 	switch data {
 	case "confirm_no":
+		if entity.AnonymousChatPlatform(state.Platform) {
+			return chat.StepResult{NextStep: StepRequestName}
+		}
 		return chat.StepResult{NextStep: StepRequestPhone}
 
 	case "confirm_yes":
-		name := state.GetString(KeyName)
-		phone := state.GetString(KeyPhone)
-
-		// Parse telegramId when platform is telegram
-		var telegramId int64
-		if state.Platform == "telegram" {
-			telegramId, _ = strconv.ParseInt(state.UserID, 10, 64)
-		}
-
-		user, err := s.authService.RegisterUser(name, "", phone, telegramId)
+		user, err := s.saveUser(state)
 		if err != nil {
-			_ = m.SendText(state.ChatID, "Виникла помилка при збереженні даних. Спробуйте пізніше.")
+			_ = m.SendText(state.ChatID, state.T("onboarding.save_failed"))
 			return chat.StepResult{Error: err}
 		}
 
-		// Sync fields from onboarding state
-		if user.Phone != phone {
-			user.Phone = phone
-		}
-		if state.Platform == "instagram" {
-			user.InstagramId = state.UserID
+		state.Set(KeyUserUUID, user.UUID)
+		_ = m.SendText(state.ChatID, state.T("onboarding.saved"))
+
+		return chat.StepResult{NextStep: StepDone}
+	}
+
+	return chat.StepResult{}
+}
+
+// saveUser registers the user with the confirmed name and phone number. Anonymous
+// visitors confirm only their name and get a user of their own.
+func (s *ConfirmDataStep) saveUser(state *chat.ChatState) (*entity.User, error) {
+	name := state.GetString(KeyName)
+
+	if entity.AnonymousChatPlatform(state.Platform) {
+		user, err := entity.FindChatUser(s.authService, state.Platform, state.UserID)
+		if err != nil {
+			return nil, err
 		}
-		if state.Platform == "telegram" && user.TelegramId == 0 && telegramId != 0 {
-			user.TelegramId = telegramId
+		if user == nil {
+			user = entity.NewChatUser(state.Platform, state.UserID, name)
 		}
-		if user.Name != name {
-			user.Name = name
+		user.Name = name
+		if err := s.authService.UpdateUser(user); err != nil {
+			return nil, err
 		}
+		return user, nil
+	}
 
-		// Create or update Zoho contact
-		if s.zohoService != nil {
-			zohoId, zohoErr := s.zohoService.CreateContact(user)
-			if zohoErr == nil && zohoId != "" {
-				user.ZohoId = zohoId
-			}
-		}
+	phone := state.GetString(KeyPhone)
 
-		_ = s.authService.UpdateUser(user)
+	// Parse telegramId when platform is telegram
+	var telegramId int64
+	if state.Platform == "telegram" {
+		telegramId, _ = strconv.ParseInt(state.UserID, 10, 64)
+	}
 
-		state.Set(KeyUserUUID, user.UUID)
-		_ = m.SendText(state.ChatID, "✅ Дані збережено!")
+	user, err := s.authService.RegisterUser(name, "", phone, telegramId)
+	if err != nil {
+		return nil, err
+	}
 
-		return chat.StepResult{NextStep: StepDone}
+	// Sync fields from onboarding state
+	if user.Phone != phone {
+		user.Phone = phone
+	}
+	user.LinkChatUser(state.Platform, state.UserID)
+	if user.Name != name {
+		user.Name = name
 	}
 
-	return chat.StepResult{}
+	// Create or update Zoho contact
+	if s.zohoService != nil {
+		zohoId, zohoErr := s.zohoService.CreateContact(user)
+		if zohoErr == nil && zohoId != "" {
+			user.ZohoId = zohoId
+		}
+	}
+
+	_ = s.authService.UpdateUser(user)
+	return user, nil
 }
 
 // DoneStep — Finish onboarding, chain to mainmenu.
@@ -296,7 +322,7 @@ func (s *DoneStep) ID() chat.StepID { return StepDone }
 
 func (s *DoneStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
 	name := state.GetString(KeyName)
-	_ = m.SendText(state.ChatID, fmt.Sprintf("%s, цей чат-бот для того, щоб зробити нашу взаємодію ще зручнішою!", name))
+	_ = m.SendText(state.ChatID, state.T("onboarding.done", name))
 
 	return chat.StepResult{
 		Complete: true,
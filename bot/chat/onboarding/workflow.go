@@ -34,7 +34,11 @@ type AuthService interface {
 	UserExists(email, phone string, telegramId int64) (*entity.User, error)
 	RegisterUser(name, email, phone string, telegramId int64) (*entity.User, error)
 	UpdateUser(user *entity.User) error
+	GetUser(email, phone string, telegramId int64) (*entity.User, error)
 	GetUserByInstagramId(instagramId string) (*entity.User, error)
+	GetUserByFacebookId(facebookId string) (*entity.User, error)
+	GetUserByViberId(viberId string) (*entity.User, error)
+	GetUserByWebId(webId string) (*entity.User, error)
 }
 
 // ZohoService defines the interface for Zoho CRM operations.
@@ -70,3 +74,12 @@ func (w *OnboardingWorkflow) GetStep(id chat.StepID) (chat.Step, bool) {
 	step, ok := w.steps[id]
 	return step, ok
 }
+
+// Steps returns all steps of the workflow.
+func (w *OnboardingWorkflow) Steps() []chat.Step {
+	steps := make([]chat.Step, 0, len(w.steps))
+	for _, step := range w.steps {
+		steps = append(steps, step)
+	}
+	return steps
+}
@@ -0,0 +1,234 @@
+package onboarding_test
+
+import (
+	"slices"
+	"testing"
+
+	"DarkCS/bot/chat"
+	"DarkCS/bot/chat/chattest"
+	"DarkCS/bot/chat/mainmenu"
+	"DarkCS/bot/chat/onboarding"
+	"DarkCS/entity"
+	"DarkCS/internal/lib/i18n"
+)
+
+func newConversation(t *testing.T, platform, userID string, svc *chattest.Services) *chattest.Conversation {
+	log := chattest.DiscardLogger()
+	return chattest.New(t, platform, userID,
+		onboarding.NewOnboardingWorkflow(svc.Auth, svc.Zoho, log),
+		mainmenu.NewMainMenuWorkflow(svc.Auth, svc.Zoho, svc.AI, svc.Schools, svc.QrStats, nil, log),
+	)
+}
+
+func TestOnboardingRegistersNewTelegramUser(t *testing.T) {
+	svc := chattest.NewServices()
+	c := newConversation(t, "telegram", "100", svc)
+
+	c.Send("hello").
+		ExpectText("Привіт").
+		ExpectContactRequest().
+		ExpectWorkflow(onboarding.WorkflowID).
+		ExpectStep(onboarding.StepRequestPhone)
+
+	c.Contact("380501234567").
+		ExpectText("+380501234567").
+		ExpectStep(onboarding.StepRequestName).
+		ExpectData(onboarding.KeyPhone, "+380501234567")
+
+	c.Send("Олена Коваль").
+		ExpectText("Олена Коваль").
+		ExpectInline("✅ Так", "❌ Ні, змінити").
+		ExpectStep(onboarding.StepConfirmData)
+
+	c.Callback("confirm_yes").
+		ExpectText("✅ Дані збережено!").
+		ExpectText("Олена Коваль, цей чат-бот").
+		ExpectWorkflow(mainmenu.WorkflowID).
+		ExpectStep(mainmenu.StepMainMenu)
+
+	users := svc.Auth.Users()
+	if len(users) != 1 {
+		t.Fatalf("expected 1 registered user, got %d", len(users))
+	}
+	if users[0].Name != "Олена Коваль" || users[0].TelegramId != 100 || users[0].ZohoId == "" {
+		t.Errorf("unexpected registered user: %+v", users[0])
+	}
+}
+
+func TestOnboardingRejectsInvalidPhone(t *testing.T) {
+	c := newConversation(t, "instagram", "ig-1", chattest.NewServices())
+
+	c.Send("hi").
+		ExpectText(i18n.T(i18n.Default, "onboarding.enter_phone")).
+		ExpectStep(onboarding.StepRequestPhone)
+
+	c.Send("12345").
+		ExpectText("Невірний формат").
+		ExpectStep(onboarding.StepRequestPhone)
+
+	c.Send("+380 50 123 45 67").
+		ExpectStep(onboarding.StepRequestName)
+}
+
+func TestOnboardingChangeDataRestartsPhoneRequest(t *testing.T) {
+	c := newConversation(t, "instagram", "ig-2", chattest.NewServices())
+
+	c.Send("hi")
+	c.Send("+380501234567")
+	c.Send("Ім'я")
+	c.Send("2").ExpectStep(onboarding.StepRequestPhone)
+}
+
+func TestOnboardingSkipsNameForExistingUser(t *testing.T) {
+	svc := chattest.NewServices()
+	existing := entity.NewUser("", "+380671112233", 0)
+	existing.Name = "Марія"
+	svc.Auth = chattest.NewAuthService(existing)
+	c := newConversation(t, "telegram", "200", svc)
+
+	c.Send("hi")
+	c.Contact("+380671112233").
+		ExpectText("Марія, цей чат-бот").
+		ExpectWorkflow(mainmenu.WorkflowID).
+		ExpectStep(mainmenu.StepMainMenu)
+
+	if got := svc.Auth.Users()[0].TelegramId; got != 200 {
+		t.Errorf("expected telegram id to be linked, got %d", got)
+	}
+}
+
+func TestOnboardingLinksFacebookId(t *testing.T) {
+	svc := chattest.NewServices()
+	existing := entity.NewUser("", "+380671112233", 0)
+	existing.Name = "Марія"
+	svc.Auth = chattest.NewAuthService(existing)
+	c := newConversation(t, "facebook", "fb-1", svc)
+
+	c.Send("hi")
+	c.Send("+380671112233").
+		ExpectWorkflow(mainmenu.WorkflowID).
+		ExpectStep(mainmenu.StepMainMenu)
+
+	if got := svc.Auth.Users()[0].FacebookId; got != "fb-1" {
+		t.Errorf("expected facebook id to be linked, got %q", got)
+	}
+}
+
+func TestOnboardingViberSharesPhone(t *testing.T) {
+	svc := chattest.NewServices()
+	existing := entity.NewUser("", "+380671112233", 0)
+	existing.Name = "Марія"
+	svc.Auth = chattest.NewAuthService(existing)
+	c := newConversation(t, "viber", "vb-1", svc)
+
+	c.Send("hi").
+		ExpectContactRequest().
+		ExpectStep(onboarding.StepRequestPhone)
+	c.Contact("380671112233").
+		ExpectWorkflow(mainmenu.WorkflowID).
+		ExpectStep(mainmenu.StepMainMenu)
+
+	if got := svc.Auth.Users()[0].ViberId; got != "vb-1" {
+		t.Errorf("expected viber id to be linked, got %q", got)
+	}
+}
+
+func TestOnboardingWebVisitorNeverSignsIntoCustomer(t *testing.T) {
+	svc := chattest.NewServices()
+	existing := entity.NewUser("", "+380671112233", 0)
+	existing.Name = "Марія"
+	existing.ZohoId = "zoho-1"
+	svc.Auth = chattest.NewAuthService(existing)
+	c := newConversation(t, "web", "visitor-1", svc)
+
+	c.Send("hi").
+		ExpectText(i18n.T(i18n.Default, "onboarding.request_name")).
+		ExpectStep(onboarding.StepRequestName).
+		ExpectData(onboarding.KeyUserExists, false)
+	for _, msg := range c.Replies() {
+		if msg.Kind == chattest.KindContactRequest || msg.Text == i18n.T(i18n.Default, "onboarding.enter_phone") {
+			t.Errorf("a visitor must not be asked for a phone number: %q", msg.Text)
+		}
+	}
+
+	c.Send("Олена").
+		ExpectText(i18n.T(i18n.Default, "onboarding.confirm_name", "Олена")).
+		ExpectStep(onboarding.StepConfirmData)
+	c.Callback("confirm_no").ExpectStep(onboarding.StepRequestName)
+	c.Send("Олена")
+	c.Callback("confirm_yes").
+		ExpectText("Олена, цей чат-бот").
+		ExpectWorkflow(mainmenu.WorkflowID)
+
+	users := svc.Auth.Users()
+	if len(users) != 2 {
+		t.Fatalf("expected the visitor to get a user of their own, got %d users", len(users))
+	}
+	if users[0].WebId != "" {
+		t.Errorf("expected the customer to stay unlinked, got web id %q", users[0].WebId)
+	}
+	if visitor := users[1]; visitor.WebId != "visitor-1" || visitor.Phone != "" || visitor.ZohoId != "" {
+		t.Errorf("unexpected visitor user: %+v", visitor)
+	}
+}
+
+func TestOnboardingOffersWhatsAppPhone(t *testing.T) {
+	c := newConversation(t, "whatsapp", "380931234567", chattest.NewServices())
+
+	c.Send("hi").
+		ExpectInline("📱 Використати +380931234567", "✏️ Ввести інший номер").
+		ExpectStep(onboarding.StepChoosePhone)
+
+	c.Send("1").
+		ExpectText("✅ Номер телефону: +380931234567").
+		ExpectStep(onboarding.StepRequestName)
+}
+
+func TestOnboardingStartIntentRestarts(t *testing.T) {
+	c := newConversation(t, "instagram", "ig-3", chattest.NewServices())
+	c.Engine.RegisterIntent(onboarding.StartIntent())
+
+	c.Send("hi")
+	c.Send("+380501234567").ExpectStep(onboarding.StepRequestName)
+	c.Send("/start").
+		ExpectText("Привіт").
+		ExpectStep(onboarding.StepRequestPhone)
+}
+
+func TestOnboardingRecordsWorkflowEvents(t *testing.T) {
+	c := newConversation(t, "instagram", "ig-5", chattest.NewServices())
+
+	c.Send("hi")
+	c.Send("+380501234567")
+	c.Send("Олена")
+	c.Send("1").ExpectWorkflow(mainmenu.WorkflowID)
+
+	type transition struct {
+		typ      chat.EventType
+		workflow chat.WorkflowID
+		from, to chat.StepID
+	}
+	want := []transition{
+		{chat.EventStart, onboarding.WorkflowID, "", onboarding.StepHello},
+		{chat.EventTransition, onboarding.WorkflowID, onboarding.StepHello, onboarding.StepRequestPhone},
+		{chat.EventTransition, onboarding.WorkflowID, onboarding.StepRequestPhone, onboarding.StepCheckUser},
+		{chat.EventTransition, onboarding.WorkflowID, onboarding.StepCheckUser, onboarding.StepRequestName},
+		{chat.EventTransition, onboarding.WorkflowID, onboarding.StepRequestName, onboarding.StepConfirmData},
+		{chat.EventTransition, onboarding.WorkflowID, onboarding.StepConfirmData, onboarding.StepDone},
+		{chat.EventComplete, onboarding.WorkflowID, onboarding.StepDone, ""},
+	}
+
+	var got []transition
+	for _, e := range c.Events.Events() {
+		if e.WorkflowID != onboarding.WorkflowID {
+			continue
+		}
+		if e.Platform != "instagram" || e.UserID != "ig-5" || e.Timestamp.IsZero() {
+			t.Errorf("event without user or timestamp: %+v", e)
+		}
+		got = append(got, transition{e.Type, e.WorkflowID, e.FromStep, e.ToStep})
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("unexpected onboarding events:\n got %v\nwant %v", got, want)
+	}
+}
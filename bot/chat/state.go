@@ -1,6 +1,10 @@
 package chat
 
-import "time"
+import (
+	"time"
+
+	"DarkCS/internal/lib/i18n"
+)
 
 // ChatState represents the platform-agnostic workflow state for a user.
 type ChatState struct {
@@ -11,21 +15,52 @@ type ChatState struct {
 	CurrentStep StepID         `json:"current_step" bson:"current_step"`
 	Data        map[string]any `json:"data" bson:"data"`
 	UpdatedAt   time.Time      `json:"updated_at" bson:"updated_at"`
+	// StepEnteredAt is when the user entered CurrentStep; used to measure time spent in a step.
+	StepEnteredAt time.Time `json:"step_entered_at" bson:"step_entered_at"`
+	// History holds previously visited interactive steps, most recent last.
+	// Not omitted when empty: states are saved with $set, so an emptied history must overwrite the stored one.
+	History []StepID `json:"history,omitempty" bson:"history"`
+	// Version is incremented on every successful save and used for optimistic
+	// concurrency control: a save based on a stale version fails with ErrStateConflict.
+	Version int64 `json:"version" bson:"version"`
+	// Locale selects the message catalog used for this user; empty means i18n.Default.
+	// It is carried over when the user moves to another workflow and kept in the
+	// engine's LocaleStore once the user switches it.
+	Locale string `json:"locale,omitempty" bson:"locale"`
+
+	localeChanged bool
+}
+
+// SetLocale switches the user's locale. The engine keeps it beyond this state.
+func (s *ChatState) SetLocale(locale string) {
+	s.Locale = locale
+	s.localeChanged = true
 }
 
 // NewChatState creates a new ChatState with default values.
 func NewChatState(platform, userID, chatID string, workflowID WorkflowID, initialStep StepID) *ChatState {
 	return &ChatState{
-		Platform:    platform,
-		UserID:      userID,
-		ChatID:      chatID,
-		WorkflowID:  workflowID,
-		CurrentStep: initialStep,
-		Data:        make(map[string]any),
-		UpdatedAt:   time.Now(),
+		Platform:      platform,
+		UserID:        userID,
+		ChatID:        chatID,
+		WorkflowID:    workflowID,
+		CurrentStep:   initialStep,
+		Data:          make(map[string]any),
+		UpdatedAt:     time.Now(),
+		StepEnteredAt: time.Now(),
 	}
 }
 
+// T returns the localized message for key in the user's locale.
+func (s *ChatState) T(key string, args ...any) string {
+	return i18n.T(s.Locale, key, args...)
+}
+
+// Is reports whether text is the localized label of key.
+func (s *ChatState) Is(text, key string) bool {
+	return i18n.Matches(s.Locale, text, key)
+}
+
 // GetString retrieves a string value from the state data.
 func (s *ChatState) GetString(key string) string {
 	if v, ok := s.Data[key]; ok {
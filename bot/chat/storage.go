@@ -1,13 +1,16 @@
 package chat
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // ChatStateRepository defines the database operations for chat state.
 type ChatStateRepository interface {
 	SaveChatState(ctx context.Context, state *ChatState) error
 	LoadChatState(ctx context.Context, platform, userID string) (*ChatState, error)
 	DeleteChatState(ctx context.Context, platform, userID string) error
-	FindChatStatesBySteps(ctx context.Context, workflowID WorkflowID, steps []StepID) ([]*ChatState, error)
+	FindIdleChatStates(ctx context.Context, workflowID WorkflowID, step StepID, before time.Time) ([]*ChatState, error)
 }
 
 // MongoChatStateStorage adapts the database repository to the ChatStateStorage interface.
@@ -32,6 +35,6 @@ func (s *MongoChatStateStorage) Delete(ctx context.Context, platform, userID str
 	return s.repo.DeleteChatState(ctx, platform, userID)
 }
 
-func (s *MongoChatStateStorage) FindBySteps(ctx context.Context, workflowID WorkflowID, steps []StepID) ([]*ChatState, error) {
-	return s.repo.FindChatStatesBySteps(ctx, workflowID, steps)
+func (s *MongoChatStateStorage) FindIdle(ctx context.Context, workflowID WorkflowID, step StepID, before time.Time) ([]*ChatState, error) {
+	return s.repo.FindIdleChatStates(ctx, workflowID, step, before)
 }
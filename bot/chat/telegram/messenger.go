@@ -59,26 +59,44 @@ func (m *Messenger) SendVideo(chatID string, r io.Reader, cachedFileID, publicUR
 }
 
 func (m *Messenger) SendFile(chatID string, file chat.FileMessage) error {
+	_, err := m.SendFileID(chatID, file)
+	return err
+}
+
+// SendFileID sends a document and returns its Telegram message ID.
+func (m *Messenger) SendFileID(chatID string, file chat.FileMessage) (string, error) {
 	id, err := strconv.ParseInt(chatID, 10, 64)
 	if err != nil {
-		return err
+		return "", err
 	}
 	doc := tgbotapi.InputFileByReader(file.Filename, file.Reader)
-	_, err = m.api.SendDocument(id, doc, &tgbotapi.SendDocumentOpts{
+	msg, err := m.api.SendDocument(id, doc, &tgbotapi.SendDocumentOpts{
 		Caption: file.Caption,
 	})
-	return err
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(msg.MessageId, 10), nil
 }
 
 func (m *Messenger) SendText(chatID, text string) error {
+	_, err := m.SendTextID(chatID, text)
+	return err
+}
+
+// SendTextID sends an HTML text message and returns its Telegram message ID.
+func (m *Messenger) SendTextID(chatID, text string) (string, error) {
 	id, err := strconv.ParseInt(chatID, 10, 64)
 	if err != nil {
-		return err
+		return "", err
 	}
-	_, err = m.api.SendMessage(id, text, &tgbotapi.SendMessageOpts{
+	msg, err := m.api.SendMessage(id, text, &tgbotapi.SendMessageOpts{
 		ParseMode: "HTML",
 	})
-	return err
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(msg.MessageId, 10), nil
 }
 
 func (m *Messenger) SendMenu(chatID, text string, rows [][]chat.MenuButton) error {
@@ -0,0 +1,135 @@
+package chat
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// TimeoutStep is implemented by steps that expire when the user stays idle.
+// The sweeper started by StartTimeoutSweeper calls OnTimeout for every state
+// that has not been updated for longer than Timeout().
+type TimeoutStep interface {
+	Step
+
+	// Timeout returns how long a user may stay idle at this step. Zero disables expiry.
+	Timeout() time.Duration
+
+	// OnTimeout is called when the step expires. It may send a notice through m
+	// and returns the transition to apply, exactly like Enter or HandleInput.
+	OnTimeout(ctx context.Context, m Messenger, state *ChatState) StepResult
+}
+
+// StepLister is implemented by workflows that can enumerate their steps.
+// Only workflows implementing it are scanned by the timeout sweeper.
+type StepLister interface {
+	Steps() []Step
+}
+
+// SetPlatformMessenger registers the messenger used for engine-initiated
+// messages on a platform, such as timeout notices sent by the sweeper.
+func (e *ChatEngine) SetPlatformMessenger(platform string, m Messenger) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.messengers[platform] = m
+}
+
+// platformMessenger returns the messenger registered for a platform.
+func (e *ChatEngine) platformMessenger(platform string) (Messenger, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	m, ok := e.messengers[platform]
+	return m, ok
+}
+
+// StartTimeoutSweeper spawns a background goroutine that expires idle states
+// every interval until ctx is cancelled.
+func (e *ChatEngine) StartTimeoutSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				e.sweepTimeouts(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// sweepTimeouts runs one pass over all timeout-capable steps of all registered workflows.
+func (e *ChatEngine) sweepTimeouts(ctx context.Context) {
+	for _, w := range e.registeredWorkflows() {
+		lister, ok := w.(StepLister)
+		if !ok {
+			continue
+		}
+		for _, step := range lister.Steps() {
+			ts, ok := step.(TimeoutStep)
+			if !ok || ts.Timeout() <= 0 {
+				continue
+			}
+			e.expireStep(ctx, w, ts)
+		}
+	}
+}
+
+// expireStep applies OnTimeout to every user idle at the given step for longer than its timeout.
+func (e *ChatEngine) expireStep(ctx context.Context, w Workflow, step TimeoutStep) {
+	cutoff := e.now().Add(-step.Timeout())
+
+	states, err := e.storage.FindIdle(ctx, w.ID(), step.ID(), cutoff)
+	if err != nil {
+		e.log.Error("chat engine: finding idle states",
+			slog.String("workflow_id", string(w.ID())),
+			slog.String("step_id", string(step.ID())),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	for _, found := range states {
+		err := e.mailboxes.do(ctx, found.Platform, found.UserID, func() error {
+			// Re-check against the latest state: the user may have moved on
+			// between the query and acquiring the mailbox.
+			state, err := e.storage.Load(ctx, found.Platform, found.UserID)
+			if err != nil || state == nil {
+				return err
+			}
+			if state.WorkflowID != w.ID() || state.CurrentStep != step.ID() || state.UpdatedAt.After(cutoff) {
+				return nil
+			}
+			if e.humanMode(state.Platform, state.UserID) {
+				return nil
+			}
+
+			// Without a registered messenger the transition still happens, just silently.
+			var m Messenger = discardMessenger{}
+			ctx := ctx
+			if pm, ok := e.platformMessenger(state.Platform); ok {
+				ctx, pm = localize(ctx, pm)
+				m = newLoggingMessenger(pm, e.messageListener, state.Platform, state.UserID)
+			}
+			trackLocale(ctx, state)
+
+			e.log.Info("chat engine: step timed out",
+				slog.String("platform", state.Platform),
+				slog.String("user_id", state.UserID),
+				slog.String("step_id", string(state.CurrentStep)),
+			)
+
+			result := step.OnTimeout(ctx, m, state)
+			return e.processResult(ctx, m, state, w, result, true)
+		})
+		if err != nil {
+			e.log.Error("chat engine: expiring idle state",
+				slog.String("platform", found.Platform),
+				slog.String("user_id", found.UserID),
+				slog.String("step_id", string(step.ID())),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package chat_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"DarkCS/bot/chat"
+	"DarkCS/bot/chat/chattest"
+)
+
+const idleTimeout = 10 * time.Minute
+
+// idleStep waits for input and sends the user to "expired" after idleTimeout.
+type idleStep struct{}
+
+func (s idleStep) ID() chat.StepID { return "idle" }
+
+func (s idleStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+	return chat.StepResult{}
+}
+
+func (s idleStep) HandleInput(ctx context.Context, m chat.Messenger, state *chat.ChatState, input chat.UserInput) chat.StepResult {
+	return chat.StepResult{}
+}
+
+func (s idleStep) Timeout() time.Duration { return idleTimeout }
+
+func (s idleStep) OnTimeout(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+	_ = m.SendText(state.ChatID, "session closed")
+	return chat.StepResult{NextStep: "expired"}
+}
+
+// expiredStep is where timed out users end up.
+type expiredStep struct{}
+
+func (s expiredStep) ID() chat.StepID { return "expired" }
+
+func (s expiredStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+	return chat.StepResult{}
+}
+
+func (s expiredStep) HandleInput(ctx context.Context, m chat.Messenger, state *chat.ChatState, input chat.UserInput) chat.StepResult {
+	return chat.StepResult{}
+}
+
+// idleWorkflow lists its steps, so the sweeper scans it.
+type idleWorkflow struct{}
+
+func (w idleWorkflow) ID() chat.WorkflowID      { return "idle" }
+func (w idleWorkflow) InitialStep() chat.StepID { return "idle" }
+func (w idleWorkflow) Steps() []chat.Step       { return []chat.Step{idleStep{}, expiredStep{}} }
+func (w idleWorkflow) GetStep(id chat.StepID) (chat.Step, bool) {
+	for _, step := range w.Steps() {
+		if step.ID() == id {
+			return step, true
+		}
+	}
+	return nil, false
+}
+
+// sweep runs the timeout sweeper once and returns the notices it sent.
+func sweep(c *chattest.Conversation) []chattest.Message {
+	c.Messenger.Take()
+	c.Engine.SweepTimeouts(context.Background())
+	return c.Messenger.Take()
+}
+
+func TestSweeperExpiresIdleSteps(t *testing.T) {
+	c := chattest.New(t, "telegram", "100", idleWorkflow{})
+	c.Engine.SetPlatformMessenger("telegram", c.Messenger)
+	now := time.Now()
+	c.Engine.SetClock(func() time.Time { return now })
+
+	c.Start("idle").ExpectStep("idle")
+
+	// Not idle for long enough yet
+	now = now.Add(idleTimeout - time.Minute)
+	if sent := sweep(c); len(sent) != 0 {
+		t.Errorf("sent %+v before the timeout", sent)
+	}
+	c.ExpectStep("idle")
+
+	now = now.Add(2 * time.Minute)
+	if sent := sweep(c); len(sent) != 1 || sent[0].Text != "session closed" {
+		t.Errorf("sent %+v, want the timeout notice", sent)
+	}
+	c.ExpectStep("expired")
+
+	// The expired step has no timeout and keeps the user
+	now = now.Add(24 * time.Hour)
+	if sent := sweep(c); len(sent) != 0 {
+		t.Errorf("sent %+v at a step without timeout", sent)
+	}
+	c.ExpectStep("expired")
+}
+
+func TestSweeperSkipsHumanModeChats(t *testing.T) {
+	c := chattest.New(t, "telegram", "100", idleWorkflow{})
+	c.Engine.SetPlatformMessenger("telegram", c.Messenger)
+	handoff := chattest.NewHandoff()
+	c.Engine.SetHandoff(handoff)
+	now := time.Now()
+	c.Engine.SetClock(func() time.Time { return now })
+
+	c.Start("idle").ExpectStep("idle")
+	handoff.Set(c.Platform, c.UserID, true)
+
+	now = now.Add(2 * idleTimeout)
+	if sent := sweep(c); len(sent) != 0 {
+		t.Errorf("sent %+v while a manager handles the chat", sent)
+	}
+	c.ExpectStep("idle")
+}
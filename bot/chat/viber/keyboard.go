@@ -0,0 +1,133 @@
+package viber
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"DarkCS/bot/chat"
+)
+
+// Viber keyboard limits. A keyboard row is six columns wide.
+const (
+	gridColumns           = 6
+	maxKeyboardRows       = 24
+	maxButtonTextLen      = 250
+	maxActionBodyLen      = 4096
+	callbackPayloadPrefix = "cb:"
+)
+
+// Keyboard is a custom keyboard shown below the message it is sent with.
+// It stays until the next message from the bot replaces or removes it.
+type Keyboard struct {
+	Type          string   `json:"Type"`
+	DefaultHeight bool     `json:"DefaultHeight"`
+	Buttons       []Button `json:"Buttons"`
+}
+
+// Button is a keyboard button. A "reply" button sends ActionBody as a user message;
+// Silent hides that message from the chat. A "share-phone" button sends the user's number.
+type Button struct {
+	Columns    int    `json:"Columns"`
+	Rows       int    `json:"Rows"`
+	ActionType string `json:"ActionType"`
+	ActionBody string `json:"ActionBody"`
+	Text       string `json:"Text"`
+	Silent     bool   `json:"Silent,omitempty"`
+}
+
+// MenuKeyboard builds a keyboard for a reply keyboard, keeping its rows.
+// A tap sends the button text as if typed. It reports false when the
+// buttons do not fit; the caller then sends a numbered text menu.
+func MenuKeyboard(rows [][]chat.MenuButton) (Keyboard, bool) {
+	var grid [][]Button
+	for _, row := range rows {
+		buttons := make([]Button, 0, len(row))
+		for _, btn := range row {
+			buttons = append(buttons, Button{ActionType: "reply", ActionBody: btn.Text, Text: btn.Text})
+		}
+		grid = append(grid, buttons)
+	}
+	return layout(grid)
+}
+
+// InlineKeyboard builds a keyboard for inline buttons, keeping their rows.
+// A tap silently sends a callback payload carrying the button data and text
+// (see ParseCallback), so the chat does not fill with button presses.
+func InlineKeyboard(rows [][]chat.InlineButton) (Keyboard, bool) {
+	var grid [][]Button
+	for _, row := range rows {
+		buttons := make([]Button, 0, len(row))
+		for _, btn := range row {
+			buttons = append(buttons, Button{
+				ActionType: "reply",
+				ActionBody: callbackPayloadPrefix + btn.Data + "\n" + btn.Text,
+				Text:       btn.Text,
+				Silent:     true,
+			})
+		}
+		grid = append(grid, buttons)
+	}
+	return layout(grid)
+}
+
+// ContactKeyboard builds a keyboard with a single button that shares the user's phone number.
+func ContactKeyboard(buttonText string) Keyboard {
+	return Keyboard{
+		Type: "keyboard",
+		Buttons: []Button{{
+			Columns:    gridColumns,
+			Rows:       1,
+			ActionType: "share-phone",
+			ActionBody: "phone",
+			Text:       buttonText,
+		}},
+	}
+}
+
+// ParseCallback decodes a message sent by an inline keyboard button.
+// It reports false for other messages, which are handled as typed text.
+func ParseCallback(text string) (data, title string, ok bool) {
+	payload, ok := strings.CutPrefix(text, callbackPayloadPrefix)
+	if !ok {
+		return "", "", false
+	}
+	data, title, _ = strings.Cut(payload, "\n")
+	return data, title, true
+}
+
+// layout spreads each row's buttons over the six grid columns.
+// A row with more than six buttons, or too many rows, does not fit.
+func layout(grid [][]Button) (Keyboard, bool) {
+	keyboard := Keyboard{Type: "keyboard"}
+	rows := 0
+	for _, row := range grid {
+		if len(row) == 0 {
+			continue
+		}
+		if len(row) > gridColumns {
+			return Keyboard{}, false
+		}
+		rows++
+		for i, btn := range row {
+			if !buttonFits(btn) {
+				return Keyboard{}, false
+			}
+			// Buttons must fill the row: the first ones take the spare columns
+			btn.Columns = gridColumns / len(row)
+			if i < gridColumns%len(row) {
+				btn.Columns++
+			}
+			btn.Rows = 1
+			keyboard.Buttons = append(keyboard.Buttons, btn)
+		}
+	}
+	if rows == 0 || rows > maxKeyboardRows {
+		return Keyboard{}, false
+	}
+	return keyboard, true
+}
+
+func buttonFits(btn Button) bool {
+	n := utf8.RuneCountInString(btn.Text)
+	return n > 0 && n <= maxButtonTextLen && len(btn.ActionBody) <= maxActionBodyLen
+}
@@ -0,0 +1,86 @@
+package viber
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"DarkCS/bot/chat"
+)
+
+func menuRow(n int) []chat.MenuButton {
+	row := make([]chat.MenuButton, n)
+	for i := range row {
+		row[i] = chat.MenuButton{Text: fmt.Sprint(i + 1)}
+	}
+	return row
+}
+
+func TestMenuKeyboardFillsEveryRow(t *testing.T) {
+	tests := []struct {
+		buttons int
+		columns []int
+	}{
+		{buttons: 1, columns: []int{6}},
+		{buttons: 2, columns: []int{3, 3}},
+		{buttons: 4, columns: []int{2, 2, 1, 1}},
+		{buttons: 5, columns: []int{2, 1, 1, 1, 1}},
+		{buttons: 6, columns: []int{1, 1, 1, 1, 1, 1}},
+	}
+	for _, tt := range tests {
+		keyboard, ok := MenuKeyboard([][]chat.MenuButton{menuRow(tt.buttons)})
+		if !ok {
+			t.Fatalf("%d buttons do not fit", tt.buttons)
+		}
+		var columns []int
+		for _, btn := range keyboard.Buttons {
+			columns = append(columns, btn.Columns)
+		}
+		if !slices.Equal(columns, tt.columns) {
+			t.Errorf("%d buttons: columns = %v, want %v", tt.buttons, columns, tt.columns)
+		}
+	}
+}
+
+func TestMenuKeyboardLimits(t *testing.T) {
+	tooManyRows := make([][]chat.MenuButton, maxKeyboardRows+1)
+	for i := range tooManyRows {
+		tooManyRows[i] = menuRow(1)
+	}
+	tests := []struct {
+		name string
+		rows [][]chat.MenuButton
+		ok   bool
+	}{
+		{name: "empty rows are skipped", rows: [][]chat.MenuButton{{}, menuRow(2)}, ok: true},
+		{name: "no buttons", rows: [][]chat.MenuButton{{}}},
+		{name: "seven buttons in a row", rows: [][]chat.MenuButton{menuRow(7)}},
+		{name: "too many rows", rows: tooManyRows},
+		{name: "empty text", rows: [][]chat.MenuButton{{{Text: ""}}}},
+		{name: "long text", rows: [][]chat.MenuButton{{{Text: strings.Repeat("a", maxButtonTextLen+1)}}}},
+	}
+	for _, tt := range tests {
+		if _, ok := MenuKeyboard(tt.rows); ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+		}
+	}
+}
+
+func TestInlineKeyboardRoundTrip(t *testing.T) {
+	keyboard, ok := InlineKeyboard([][]chat.InlineButton{{{Text: "Order #42", Data: "order:42"}}})
+	if !ok {
+		t.Fatal("inline keyboard does not fit")
+	}
+	btn := keyboard.Buttons[0]
+	if !btn.Silent {
+		t.Error("inline button is not silent")
+	}
+	data, title, ok := ParseCallback(btn.ActionBody)
+	if !ok || data != "order:42" || title != "Order #42" {
+		t.Errorf("ParseCallback(%q) = %q, %q, %v", btn.ActionBody, data, title, ok)
+	}
+	if _, _, ok := ParseCallback("Order #42"); ok {
+		t.Error("typed text parsed as a callback")
+	}
+}
@@ -0,0 +1,152 @@
+package viber
+
+import (
+	"io"
+
+	"DarkCS/bot/chat"
+	"DarkCS/internal/lib/i18n"
+)
+
+// maxPictureSize is the largest image Viber shows inline on every client; larger ones are sent as files.
+const maxPictureSize = 1 << 20
+
+// Media describes a picture, file or link message. Type is "picture", "file" or "url".
+// Viber requires FileName and Size for files.
+type Media struct {
+	Type     string
+	URL      string
+	FileName string
+	Size     int64
+}
+
+// MessageSender can send a text message, a text with a keyboard or media to a receiver.
+// Text and media sends return the Viber message token used by delivered and seen events.
+type MessageSender interface {
+	SendMessage(receiverID, text string) (string, error)
+	SendKeyboard(receiverID, text string, keyboard Keyboard) error
+	SendMediaMessage(receiverID string, media Media) (string, error)
+}
+
+// Messenger implements chat.Messenger for Viber.
+type Messenger struct {
+	sender MessageSender
+	// locale reports the user's locale for texts the messenger writes itself; nil means i18n.Default.
+	locale func() string
+}
+
+// NewMessenger creates a new Viber Messenger.
+func NewMessenger(sender MessageSender) *Messenger {
+	return &Messenger{sender: sender}
+}
+
+// WithLocale returns a copy of the messenger that writes its own texts in the locale reported by locale.
+func (m *Messenger) WithLocale(locale func() string) chat.Messenger {
+	return &Messenger{sender: m.sender, locale: locale}
+}
+
+func (m *Messenger) lang() string {
+	if m.locale == nil {
+		return i18n.Default
+	}
+	return m.locale()
+}
+
+func (m *Messenger) SendFile(chatID string, file chat.FileMessage) error {
+	_, err := m.SendFileID(chatID, file)
+	return err
+}
+
+// SendFileID sends a file and returns the Viber message token of the file message.
+func (m *Messenger) SendFileID(chatID string, file chat.FileMessage) (string, error) {
+	// Viber downloads media by public URL — streaming bytes is not supported.
+	if file.URL != "" {
+		if file.Caption != "" {
+			_, _ = m.sender.SendMessage(chatID, file.Caption)
+		}
+		return m.sender.SendMediaMessage(chatID, mediaFor(file))
+	}
+	// Fallback: send filename as text when no public URL is available.
+	text := "[File: " + file.Filename + "]"
+	if file.Caption != "" {
+		text = file.Caption + "\n" + text
+	}
+	return m.sender.SendMessage(chatID, text)
+}
+
+// SendVideo sends a training video via Viber as a link, since the video size is unknown.
+// r and cachedFileID are Telegram-specific and ignored here.
+func (m *Messenger) SendVideo(chatID string, r io.Reader, cachedFileID, publicURL, filename string, protected bool) (string, error) {
+	if publicURL != "" {
+		_, err := m.sender.SendMediaMessage(chatID, Media{Type: "url", URL: publicURL})
+		return "", err
+	}
+	_, err := m.sender.SendMessage(chatID, i18n.T(m.lang(), "messenger.video", filename))
+	return "", err
+}
+
+func (m *Messenger) SendText(chatID, text string) error {
+	_, err := m.sender.SendMessage(chatID, text)
+	return err
+}
+
+// SendTextID sends a text message and returns its Viber message token.
+func (m *Messenger) SendTextID(chatID, text string) (string, error) {
+	return m.sender.SendMessage(chatID, text)
+}
+
+// SendMenu sends the buttons as a Viber keyboard when they fit, a numbered text menu otherwise.
+func (m *Messenger) SendMenu(chatID, text string, rows [][]chat.MenuButton) error {
+	if keyboard, ok := MenuKeyboard(rows); ok {
+		return m.sender.SendKeyboard(chatID, text, keyboard)
+	}
+	return m.SendText(chatID, chat.FormatNumberedMenu(m.lang(), text, rows))
+}
+
+func (m *Messenger) SendInlineOptions(chatID, text string, buttons []chat.InlineButton) error {
+	return m.sendInline(chatID, text, [][]chat.InlineButton{buttons}, chat.FormatNumberedInline(m.lang(), text, buttons))
+}
+
+func (m *Messenger) SendInlineGrid(chatID, text string, rows [][]chat.InlineButton) error {
+	return m.sendInline(chatID, text, rows, chat.FormatNumberedInlineGrid(m.lang(), text, rows))
+}
+
+// sendInline sends inline buttons as a keyboard of silent buttons, or the numbered fallback text.
+func (m *Messenger) sendInline(chatID, text string, rows [][]chat.InlineButton, fallback string) error {
+	if keyboard, ok := InlineKeyboard(rows); ok {
+		return m.sender.SendKeyboard(chatID, text, keyboard)
+	}
+	return m.SendText(chatID, fallback)
+}
+
+// EditInlineGrid sends the grid again; Viber messages cannot be edited.
+func (m *Messenger) EditInlineGrid(chatID, messageID, text string, rows [][]chat.InlineButton) error {
+	return m.SendInlineGrid(chatID, text, rows)
+}
+
+// SendContactRequest sends a keyboard with Viber's share-phone button.
+func (m *Messenger) SendContactRequest(chatID, text, buttonText string) error {
+	return m.sender.SendKeyboard(chatID, text, ContactKeyboard(buttonText))
+}
+
+func (m *Messenger) SendTyping(chatID string) error {
+	return nil
+}
+
+func (m *Messenger) SendUploadAction(chatID string) error {
+	return nil
+}
+
+// mediaFor picks the Viber message type for a file. Small JPEG, PNG and GIF images
+// are shown as pictures; other files need their size, and are sent as a link without it.
+func mediaFor(file chat.FileMessage) Media {
+	switch file.MIMEType {
+	case "image/jpeg", "image/png", "image/gif":
+		if file.Size <= maxPictureSize {
+			return Media{Type: "picture", URL: file.URL}
+		}
+	}
+	if file.Size > 0 {
+		return Media{Type: "file", URL: file.URL, FileName: file.Filename, Size: file.Size}
+	}
+	return Media{Type: "url", URL: file.URL}
+}
@@ -0,0 +1,191 @@
+package web
+
+import (
+	"io"
+
+	"github.com/google/uuid"
+
+	"DarkCS/bot/chat"
+	"DarkCS/internal/lib/i18n"
+)
+
+// Message kinds sent to the widget.
+const (
+	KindText           = "text"
+	KindMenu           = "menu"
+	KindInline         = "inline"
+	KindFile           = "file"
+	KindVideo          = "video"
+	KindContactRequest = "contact_request"
+)
+
+// Message is a bot message as the widget renders it. Menu buttons are sent back
+// as the visitor's text; inline buttons are sent back as a callback with their Data
+// and the message ID. A message with Replaces set replaces that earlier message.
+type Message struct {
+	ID            string           `json:"id"`
+	Kind          string           `json:"kind"`
+	Text          string           `json:"text,omitempty"`
+	Menu          [][]MenuButton   `json:"menu,omitempty"`
+	Buttons       [][]InlineButton `json:"buttons,omitempty"`
+	ContactButton string           `json:"contact_button,omitempty"`
+	File          *File            `json:"file,omitempty"`
+	Replaces      string           `json:"replaces,omitempty"`
+}
+
+// MenuButton is a reply keyboard button.
+type MenuButton struct {
+	Text string `json:"text"`
+}
+
+// InlineButton is a button attached to a message.
+type InlineButton struct {
+	Text string `json:"text"`
+	Data string `json:"data"`
+}
+
+// File is a file or video the widget downloads by URL.
+type File struct {
+	URL      string `json:"url"`
+	Filename string `json:"filename"`
+	MIMEType string `json:"mime_type,omitempty"`
+}
+
+// Sender delivers widget events to a visitor's open connections.
+type Sender interface {
+	SendMessage(visitorID string, msg Message) error
+	SendTyping(visitorID string) error
+}
+
+// Messenger implements chat.Messenger for the website chat widget.
+type Messenger struct {
+	sender Sender
+	// locale reports the user's locale for texts the messenger writes itself; nil means i18n.Default.
+	locale func() string
+}
+
+// NewMessenger creates a new website chat Messenger.
+func NewMessenger(sender Sender) *Messenger {
+	return &Messenger{sender: sender}
+}
+
+// WithLocale returns a copy of the messenger that writes its own texts in the locale reported by locale.
+func (m *Messenger) WithLocale(locale func() string) chat.Messenger {
+	return &Messenger{sender: m.sender, locale: locale}
+}
+
+func (m *Messenger) lang() string {
+	if m.locale == nil {
+		return i18n.Default
+	}
+	return m.locale()
+}
+
+func (m *Messenger) SendFile(chatID string, file chat.FileMessage) error {
+	_, err := m.SendFileID(chatID, file)
+	return err
+}
+
+// SendFileID sends a file and returns the ID of the widget message.
+func (m *Messenger) SendFileID(chatID string, file chat.FileMessage) (string, error) {
+	// The widget downloads files by URL — streaming bytes is not supported.
+	if file.URL == "" {
+		text := "[File: " + file.Filename + "]"
+		if file.Caption != "" {
+			text = file.Caption + "\n" + text
+		}
+		return m.SendTextID(chatID, text)
+	}
+	return m.send(chatID, Message{
+		Kind: KindFile,
+		Text: file.Caption,
+		File: &File{URL: file.URL, Filename: file.Filename, MIMEType: file.MIMEType},
+	})
+}
+
+// SendVideo sends a training video as a link the widget can play.
+// r and cachedFileID are Telegram-specific and ignored here.
+func (m *Messenger) SendVideo(chatID string, r io.Reader, cachedFileID, publicURL, filename string, protected bool) (string, error) {
+	if publicURL == "" {
+		return "", m.SendText(chatID, i18n.T(m.lang(), "messenger.video", filename))
+	}
+	_, err := m.send(chatID, Message{Kind: KindVideo, File: &File{URL: publicURL, Filename: filename}})
+	return "", err
+}
+
+func (m *Messenger) SendText(chatID, text string) error {
+	_, err := m.SendTextID(chatID, text)
+	return err
+}
+
+// SendTextID sends a text message and returns the ID of the widget message.
+func (m *Messenger) SendTextID(chatID, text string) (string, error) {
+	return m.send(chatID, Message{Kind: KindText, Text: text})
+}
+
+func (m *Messenger) SendMenu(chatID, text string, rows [][]chat.MenuButton) error {
+	menu := make([][]MenuButton, 0, len(rows))
+	for _, row := range rows {
+		buttons := make([]MenuButton, 0, len(row))
+		for _, btn := range row {
+			buttons = append(buttons, MenuButton{Text: btn.Text})
+		}
+		menu = append(menu, buttons)
+	}
+	_, err := m.send(chatID, Message{Kind: KindMenu, Text: text, Menu: menu})
+	return err
+}
+
+func (m *Messenger) SendInlineOptions(chatID, text string, buttons []chat.InlineButton) error {
+	return m.SendInlineGrid(chatID, text, [][]chat.InlineButton{buttons})
+}
+
+func (m *Messenger) SendInlineGrid(chatID, text string, rows [][]chat.InlineButton) error {
+	_, err := m.send(chatID, Message{Kind: KindInline, Text: text, Buttons: inlineButtons(rows)})
+	return err
+}
+
+// EditInlineGrid replaces the buttons and text of an earlier message in place.
+func (m *Messenger) EditInlineGrid(chatID, messageID, text string, rows [][]chat.InlineButton) error {
+	_, err := m.send(chatID, Message{Kind: KindInline, Text: text, Buttons: inlineButtons(rows), Replaces: messageID})
+	return err
+}
+
+// SendContactRequest asks the widget for the visitor's phone number.
+// The widget sends the number back as a contact event.
+func (m *Messenger) SendContactRequest(chatID, text, buttonText string) error {
+	_, err := m.send(chatID, Message{Kind: KindContactRequest, Text: text, ContactButton: buttonText})
+	return err
+}
+
+func (m *Messenger) SendTyping(chatID string) error {
+	return m.sender.SendTyping(chatID)
+}
+
+func (m *Messenger) SendUploadAction(chatID string) error {
+	return nil
+}
+
+// send assigns the message an ID, unless it replaces an earlier one, and delivers it.
+func (m *Messenger) send(chatID string, msg Message) (string, error) {
+	msg.ID = msg.Replaces
+	if msg.ID == "" {
+		msg.ID = uuid.NewString()
+	}
+	if err := m.sender.SendMessage(chatID, msg); err != nil {
+		return "", err
+	}
+	return msg.ID, nil
+}
+
+func inlineButtons(rows [][]chat.InlineButton) [][]InlineButton {
+	grid := make([][]InlineButton, 0, len(rows))
+	for _, row := range rows {
+		buttons := make([]InlineButton, 0, len(row))
+		for _, btn := range row {
+			buttons = append(buttons, InlineButton{Text: btn.Text, Data: btn.Data})
+		}
+		grid = append(grid, buttons)
+	}
+	return grid
+}
@@ -0,0 +1,141 @@
+package whatsapp
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"DarkCS/bot/chat"
+	"DarkCS/internal/lib/i18n"
+)
+
+// WhatsApp Cloud API limits for interactive messages.
+const (
+	maxReplyButtons     = 3
+	maxButtonTitleLen   = 20
+	maxListRows         = 10
+	maxRowTitleLen      = 24
+	maxButtonIDLen      = 256
+	maxRowIDLen         = 200
+	maxButtonBodyLen    = 1024
+	maxListBodyLen      = 4096
+	menuReplyPrefix     = "menu:"
+	callbackReplyPrefix = "cb:"
+)
+
+// Interactive is the "interactive" object of a Cloud API message:
+// either reply buttons (Type "button") or a list (Type "list").
+type Interactive struct {
+	Type   string            `json:"type"`
+	Body   InteractiveBody   `json:"body"`
+	Action InteractiveAction `json:"action"`
+}
+
+// InteractiveBody is the message text shown above the buttons or the list.
+type InteractiveBody struct {
+	Text string `json:"text"`
+}
+
+// InteractiveAction holds either Buttons (reply buttons) or Button and Sections (list).
+type InteractiveAction struct {
+	Button   string        `json:"button,omitempty"`
+	Buttons  []ReplyButton `json:"buttons,omitempty"`
+	Sections []ListSection `json:"sections,omitempty"`
+}
+
+// ReplyButton is one reply button; Type is always "reply".
+type ReplyButton struct {
+	Type  string `json:"type"`
+	Reply Option `json:"reply"`
+}
+
+// ListSection groups list rows.
+type ListSection struct {
+	Title string   `json:"title,omitempty"`
+	Rows  []Option `json:"rows"`
+}
+
+// Option is a selectable button or list row. ID comes back in the user's reply.
+type Option struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// MenuInteractive builds the interactive message for a reply keyboard.
+// A tap comes back as a menu reply carrying the button text (see ParseReplyID).
+// It reports false when the buttons do not fit; the caller then sends a numbered text menu.
+// The button that opens a list is labelled in locale.
+func MenuInteractive(locale, text string, rows [][]chat.MenuButton) (Interactive, bool) {
+	var options []Option
+	for _, row := range rows {
+		for _, btn := range row {
+			options = append(options, Option{ID: menuReplyPrefix + btn.Text, Title: btn.Text})
+		}
+	}
+	return newInteractive(locale, text, options)
+}
+
+// InlineInteractive builds the interactive message for inline buttons.
+// A tap comes back as a callback reply carrying the button data (see ParseReplyID).
+// Rows are flattened; it reports false when the buttons do not fit.
+func InlineInteractive(locale, text string, rows [][]chat.InlineButton) (Interactive, bool) {
+	var options []Option
+	for _, row := range rows {
+		for _, btn := range row {
+			options = append(options, Option{ID: callbackReplyPrefix + btn.Data, Title: btn.Text})
+		}
+	}
+	return newInteractive(locale, text, options)
+}
+
+// ParseReplyID decodes the ID of an interactive reply. Menu replies return the
+// button text, to be handled like a typed message; other replies return callback data.
+func ParseReplyID(id string) (text, data string) {
+	if t, ok := strings.CutPrefix(id, menuReplyPrefix); ok {
+		return t, ""
+	}
+	return "", strings.TrimPrefix(id, callbackReplyPrefix)
+}
+
+// newInteractive picks reply buttons for up to three short options and a
+// single-section list for up to ten, keeping every title within the API limits.
+func newInteractive(locale, text string, options []Option) (Interactive, bool) {
+	if len(options) == 0 || text == "" {
+		return Interactive{}, false
+	}
+
+	body := InteractiveBody{Text: text}
+	if len(options) <= maxReplyButtons && fits(options, maxButtonTitleLen, maxButtonIDLen) && utf8.RuneCountInString(text) <= maxButtonBodyLen {
+		buttons := make([]ReplyButton, 0, len(options))
+		for _, opt := range options {
+			buttons = append(buttons, ReplyButton{Type: "reply", Reply: opt})
+		}
+		return Interactive{Type: "button", Body: body, Action: InteractiveAction{Buttons: buttons}}, true
+	}
+
+	if len(options) <= maxListRows && fits(options, maxRowTitleLen, maxRowIDLen) && utf8.RuneCountInString(text) <= maxListBodyLen {
+		return Interactive{
+			Type: "list",
+			Body: body,
+			Action: InteractiveAction{
+				Button:   i18n.T(locale, "messenger.list_button"),
+				Sections: []ListSection{{Rows: options}},
+			},
+		}, true
+	}
+
+	return Interactive{}, false
+}
+
+// fits reports whether every option has a non-empty title of at most maxLen
+// characters and a unique ID of at most maxIDLen bytes.
+func fits(options []Option, maxLen, maxIDLen int) bool {
+	seen := make(map[string]bool, len(options))
+	for _, opt := range options {
+		n := utf8.RuneCountInString(opt.Title)
+		if n == 0 || n > maxLen || len(opt.ID) > maxIDLen || seen[opt.ID] {
+			return false
+		}
+		seen[opt.ID] = true
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package whatsapp
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"DarkCS/bot/chat"
+)
+
+func inlineButtons(n int, data func(i int) string) [][]chat.InlineButton {
+	row := make([]chat.InlineButton, n)
+	for i := range row {
+		row[i] = chat.InlineButton{Text: fmt.Sprintf("Option %d", i+1), Data: data(i)}
+	}
+	return [][]chat.InlineButton{row}
+}
+
+func TestInlineInteractive(t *testing.T) {
+	short := func(i int) string { return fmt.Sprintf("opt_%d", i) }
+	tests := []struct {
+		name     string
+		text     string
+		rows     [][]chat.InlineButton
+		wantType string
+		ok       bool
+	}{
+		{name: "up to three buttons", text: "Pick", rows: inlineButtons(3, short), wantType: "button", ok: true},
+		{name: "more than three becomes a list", text: "Pick", rows: inlineButtons(4, short), wantType: "list", ok: true},
+		{name: "more than ten rows", text: "Pick", rows: inlineButtons(11, short), ok: false},
+		{name: "no buttons", text: "Pick", ok: false},
+		{name: "no text", rows: inlineButtons(2, short), ok: false},
+		{name: "duplicate data", text: "Pick", rows: inlineButtons(2, func(int) string { return "same" }), ok: false},
+		{
+			name:     "long button title falls back to a list",
+			text:     "Pick",
+			rows:     [][]chat.InlineButton{{{Text: "A title of twenty-two", Data: "a"}}},
+			wantType: "list", ok: true,
+		},
+		{
+			name: "button data over the list row id limit",
+			text: "Pick",
+			rows: inlineButtons(4, func(i int) string { return fmt.Sprint(i) + strings.Repeat("x", maxRowIDLen) }),
+			ok:   false,
+		},
+		{
+			name: "row id exactly at the limit",
+			text: "Pick",
+			rows: inlineButtons(4, func(i int) string {
+				return fmt.Sprint(i) + strings.Repeat("x", maxRowIDLen-len(callbackReplyPrefix)-1)
+			}),
+			wantType: "list", ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := InlineInteractive("en", tt.text, tt.rows)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if got.Type != tt.wantType {
+				t.Errorf("type = %q, want %q", got.Type, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestListInteractiveUsesLocalizedButton(t *testing.T) {
+	rows := inlineButtons(5, func(i int) string { return fmt.Sprint(i) })
+	got, ok := InlineInteractive("uk", "Оберіть", rows)
+	if !ok {
+		t.Fatal("expected a list")
+	}
+	if got.Action.Button != "Обрати" {
+		t.Errorf("list button = %q, want %q", got.Action.Button, "Обрати")
+	}
+	if n := len(got.Action.Sections[0].Rows); n != 5 {
+		t.Errorf("list has %d rows, want 5", n)
+	}
+}
+
+func TestParseReplyID(t *testing.T) {
+	tests := []struct {
+		id, text, data string
+	}{
+		{id: "menu:📦 Orders", text: "📦 Orders"},
+		{id: "cb:order_42", data: "order_42"},
+		{id: "legacy_data", data: "legacy_data"},
+	}
+	for _, tt := range tests {
+		text, data := ParseReplyID(tt.id)
+		if text != tt.text || data != tt.data {
+			t.Errorf("ParseReplyID(%q) = %q, %q; want %q, %q", tt.id, text, data, tt.text, tt.data)
+		}
+	}
+}
+
+func TestMenuInteractiveRoundTrip(t *testing.T) {
+	rows := [][]chat.MenuButton{{{Text: "Orders"}, {Text: "Help"}}}
+	got, ok := MenuInteractive("en", "Menu", rows)
+	if !ok || got.Type != "button" {
+		t.Fatalf("MenuInteractive = %+v, %v; want reply buttons", got, ok)
+	}
+	text, data := ParseReplyID(got.Action.Buttons[1].Reply.ID)
+	if text != "Help" || data != "" {
+		t.Errorf("reply decodes to %q, %q; want the button text", text, data)
+	}
+}
@@ -2,19 +2,30 @@ package whatsapp
 
 import (
 	"io"
+	"time"
 
 	"DarkCS/bot/chat"
+	"DarkCS/entity"
+	"DarkCS/internal/lib/i18n"
 )
 
-// MessageSender can send a text message or media to a recipient.
+// MessageSender can send a text message, media, an interactive message or a template to a recipient.
+// Text, media and template sends return the WhatsApp message ID used by status webhooks.
+// Free-form sends fail with entity.ErrMessagingWindowClosed outside the customer service window.
 type MessageSender interface {
-	SendMessage(recipientPhone, text string) error
-	SendMediaMessage(recipientPhone, mediaType, mediaURL, caption, filename string) error
+	SendMessage(recipientPhone, text string) (string, error)
+	SendMediaMessage(recipientPhone, mediaType, mediaURL, caption, filename string) (string, error)
+	SendInteractive(recipientPhone string, interactive Interactive) error
+	SendTemplate(recipientPhone, name string, values map[string]string) (text, messageID string, err error)
+	Templates() []entity.MessageTemplate
+	WindowExpiresAt(recipientPhone string) time.Time
 }
 
 // Messenger implements chat.Messenger for WhatsApp.
 type Messenger struct {
 	sender MessageSender
+	// locale reports the user's locale for texts the messenger writes itself; nil means i18n.Default.
+	locale func() string
 }
 
 // NewMessenger creates a new WhatsApp Messenger.
@@ -22,7 +33,25 @@ func NewMessenger(sender MessageSender) *Messenger {
 	return &Messenger{sender: sender}
 }
 
+// WithLocale returns a copy of the messenger that writes its own texts in the locale reported by locale.
+func (m *Messenger) WithLocale(locale func() string) chat.Messenger {
+	return &Messenger{sender: m.sender, locale: locale}
+}
+
+func (m *Messenger) lang() string {
+	if m.locale == nil {
+		return i18n.Default
+	}
+	return m.locale()
+}
+
 func (m *Messenger) SendFile(chatID string, file chat.FileMessage) error {
+	_, err := m.SendFileID(chatID, file)
+	return err
+}
+
+// SendFileID sends a file and returns its WhatsApp message ID.
+func (m *Messenger) SendFileID(chatID string, file chat.FileMessage) (string, error) {
 	// WhatsApp requires a publicly accessible URL — streaming bytes is not supported.
 	if file.URL == "" {
 		text := "[File: " + file.Filename + "]"
@@ -47,28 +76,43 @@ func (m *Messenger) SendFile(chatID string, file chat.FileMessage) error {
 // publicURL is used when available; otherwise falls back to sending the filename as text.
 func (m *Messenger) SendVideo(chatID string, r io.Reader, cachedFileID, publicURL, filename string, protected bool) (string, error) {
 	if publicURL != "" {
-		return "", m.sender.SendMediaMessage(chatID, "video", publicURL, "", filename)
+		_, err := m.sender.SendMediaMessage(chatID, "video", publicURL, "", filename)
+		return "", err
 	}
-	return "", m.sender.SendMessage(chatID, "[Відео: "+filename+"]")
+	_, err := m.sender.SendMessage(chatID, i18n.T(m.lang(), "messenger.video", filename))
+	return "", err
 }
 
 func (m *Messenger) SendText(chatID, text string) error {
+	_, err := m.sender.SendMessage(chatID, text)
+	return err
+}
+
+// SendTextID sends a text message and returns its WhatsApp message ID.
+func (m *Messenger) SendTextID(chatID, text string) (string, error) {
 	return m.sender.SendMessage(chatID, text)
 }
 
+// SendMenu sends reply buttons or a list when the buttons fit, a numbered text menu otherwise.
 func (m *Messenger) SendMenu(chatID, text string, rows [][]chat.MenuButton) error {
-	formatted := chat.FormatNumberedMenu(text, rows)
-	return m.sender.SendMessage(chatID, formatted)
+	if interactive, ok := MenuInteractive(m.lang(), text, rows); ok {
+		return m.sender.SendInteractive(chatID, interactive)
+	}
+	return m.SendText(chatID, chat.FormatNumberedMenu(m.lang(), text, rows))
 }
 
 func (m *Messenger) SendInlineOptions(chatID, text string, buttons []chat.InlineButton) error {
-	formatted := chat.FormatNumberedInline(text, buttons)
-	return m.sender.SendMessage(chatID, formatted)
+	if interactive, ok := InlineInteractive(m.lang(), text, [][]chat.InlineButton{buttons}); ok {
+		return m.sender.SendInteractive(chatID, interactive)
+	}
+	return m.SendText(chatID, chat.FormatNumberedInline(m.lang(), text, buttons))
 }
 
 func (m *Messenger) SendInlineGrid(chatID, text string, rows [][]chat.InlineButton) error {
-	formatted := chat.FormatNumberedInlineGrid(text, rows)
-	return m.sender.SendMessage(chatID, formatted)
+	if interactive, ok := InlineInteractive(m.lang(), text, rows); ok {
+		return m.sender.SendInteractive(chatID, interactive)
+	}
+	return m.SendText(chatID, chat.FormatNumberedInlineGrid(m.lang(), text, rows))
 }
 
 func (m *Messenger) EditInlineGrid(chatID, messageID, text string, rows [][]chat.InlineButton) error {
@@ -76,7 +120,7 @@ func (m *Messenger) EditInlineGrid(chatID, messageID, text string, rows [][]chat
 }
 
 func (m *Messenger) SendContactRequest(chatID, text, buttonText string) error {
-	return m.sender.SendMessage(chatID, text)
+	return m.SendText(chatID, text)
 }
 
 func (m *Messenger) SendTyping(chatID string) error {
@@ -86,3 +130,18 @@ func (m *Messenger) SendTyping(chatID string) error {
 func (m *Messenger) SendUploadAction(chatID string) error {
 	return nil
 }
+
+// WindowExpiresAt returns when free-form messages to chatID stop being accepted.
+func (m *Messenger) WindowExpiresAt(chatID string) time.Time {
+	return m.sender.WindowExpiresAt(chatID)
+}
+
+// Templates returns the message templates that can be sent outside the window.
+func (m *Messenger) Templates() []entity.MessageTemplate {
+	return m.sender.Templates()
+}
+
+// SendTemplate sends a registered template and returns its rendered text and message ID.
+func (m *Messenger) SendTemplate(chatID, name string, values map[string]string) (text, messageID string, err error) {
+	return m.sender.SendTemplate(chatID, name, values)
+}
@@ -1,28 +1,22 @@
 package insta
 
 import (
-	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
-	"net/url"
-	"path"
 	"sync"
 	"time"
 
 	"DarkCS/bot/chat"
 	igmessenger "DarkCS/bot/chat/instagram"
-	"DarkCS/entity"
+	"DarkCS/bot/meta"
 	"DarkCS/internal/lib/sl"
 )
 
+// graphAPIURL is the Instagram Send API endpoint.
 const graphAPIURL = "https://graph.instagram.com/v24.0/me/messages"
 
 // tokenRefreshURL is the Instagram endpoint for refreshing long-lived access tokens.
@@ -32,14 +26,13 @@ const tokenRefreshURL = "https://graph.instagram.com/refresh_access_token"
 // Instagram long-lived tokens expire after 60 days; 30 days gives a safe buffer.
 const tokenRefreshInterval = 30 * 24 * time.Hour
 
-// InstaBot handles Instagram messaging via the Graph API
+// InstaBot handles Instagram messaging via the Graph API. Sending and webhook
+// handling are done by the embedded Messenger Platform client.
 type InstaBot struct {
+	*meta.Client
 	log            *slog.Logger
 	mu             sync.RWMutex
 	accessToken    string
-	verifyToken    string
-	appSecret      string
-	chatEngine     *chat.ChatEngine
 	tokenPersister func(token string) error // nil = no persistence
 }
 
@@ -49,58 +42,25 @@ func (b *InstaBot) SetTokenPersister(fn func(string) error) {
 	b.tokenPersister = fn
 }
 
-// WebhookPayload represents the incoming webhook payload from Instagram
-type WebhookPayload struct {
-	Object string `json:"object"`
-	Entry  []struct {
-		ID        string `json:"id"`
-		Time      int64  `json:"time"`
-		Messaging []struct {
-			Sender struct {
-				ID string `json:"id"`
-			} `json:"sender"`
-			Recipient struct {
-				ID string `json:"id"`
-			} `json:"recipient"`
-			Timestamp int64 `json:"timestamp"`
-			Message   *struct {
-				Mid         string `json:"mid"`
-				Text        string `json:"text"`
-				IsEcho      bool   `json:"is_echo,omitempty"`
-				Attachments []struct {
-					Type    string `json:"type"`
-					Payload struct {
-						URL string `json:"url"`
-					} `json:"payload"`
-				} `json:"attachments,omitempty"`
-			} `json:"message,omitempty"`
-		} `json:"messaging"`
-	} `json:"entry"`
-}
-
-// SendMessageRequest represents the request body for sending a message
-type SendMessageRequest struct {
-	Recipient struct {
-		ID string `json:"id"`
-	} `json:"recipient"`
-	Message struct {
-		Text string `json:"text"`
-	} `json:"message"`
-}
-
 // NewInstaBot creates a new Instagram bot instance
 func NewInstaBot(accessToken, verifyToken, appSecret string, log *slog.Logger) *InstaBot {
-	return &InstaBot{
+	b := &InstaBot{
 		log:         log.With(sl.Module("instabot")),
 		accessToken: accessToken,
-		verifyToken: verifyToken,
-		appSecret:   appSecret,
 	}
-}
-
-// SetChatEngine sets the unified chat engine for this bot.
-func (b *InstaBot) SetChatEngine(engine *chat.ChatEngine) {
-	b.chatEngine = engine
+	b.Client = meta.NewClient(meta.Config{
+		Platform:    "instagram",
+		Object:      "instagram",
+		MessagesURL: graphAPIURL,
+		VerifyToken: verifyToken,
+		AppSecret:   appSecret,
+		Token:       b.token,
+		NewMessenger: func(c *meta.Client) chat.Messenger {
+			return igmessenger.NewMessenger(c)
+		},
+		UpdateProfile: b.updateUsername,
+	}, b.log)
+	return b
 }
 
 // token returns the current access token under a read lock.
@@ -175,173 +135,11 @@ func (b *InstaBot) StartTokenRefresh(ctx context.Context) {
 	}()
 }
 
-// HandleWebhookVerification handles the GET request for webhook verification
-func (b *InstaBot) HandleWebhookVerification(w http.ResponseWriter, r *http.Request) {
-	mode := r.URL.Query().Get("hub.mode")
-	token := r.URL.Query().Get("hub.verify_token")
-	challenge := r.URL.Query().Get("hub.challenge")
-
-	if mode == "subscribe" && token == b.verifyToken {
-		b.log.Info("webhook verified")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(challenge))
-		return
+// updateUsername saves the sender's Instagram @username as the messenger name.
+func (b *InstaBot) updateUsername(listener chat.MessageListener, senderID string) {
+	if username, err := b.GetUserUsername(senderID); err == nil && username != "" {
+		listener.UpdateUserPlatformInfo("instagram", senderID, "@"+username)
 	}
-
-	b.log.Warn("webhook verification failed",
-		slog.String("mode", mode),
-		slog.Bool("token_match", token == b.verifyToken),
-	)
-	http.Error(w, "Forbidden", http.StatusForbidden)
-}
-
-// HandleWebhook handles incoming webhook POST requests
-func (b *InstaBot) HandleWebhook(w http.ResponseWriter, r *http.Request) {
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		b.log.Error("failed to read request body", sl.Err(err))
-		http.Error(w, "Bad Request", http.StatusBadRequest)
-		return
-	}
-	defer r.Body.Close()
-
-	// Always respond with 200 OK to acknowledge receipt (Meta requires quick response)
-	w.WriteHeader(http.StatusOK)
-
-	// Verify signature if app secret is configured
-	sigValid := true
-	if b.appSecret != "" {
-		signature := r.Header.Get("X-Hub-Signature-256")
-		if !b.verifySignature(body, signature) {
-			b.log.With(
-				slog.String("body", string(body)),
-			).Warn("invalid webhook signature")
-			sigValid = false
-		}
-	}
-
-	var payload WebhookPayload
-	if err := json.Unmarshal(body, &payload); err != nil {
-		b.log.Error("failed to parse webhook payload", sl.Err(err))
-		return
-	}
-
-	// Only process messages with valid signatures
-	if !sigValid {
-		return
-	}
-
-	// Process messages asynchronously
-	go b.processPayload(payload)
-}
-
-// processPayload processes the webhook payload
-func (b *InstaBot) processPayload(payload WebhookPayload) {
-	if payload.Object != "instagram" {
-		return
-	}
-
-	for _, entry := range payload.Entry {
-		for _, messaging := range entry.Messaging {
-			if messaging.Message == nil || messaging.Message.IsEcho {
-				continue
-			}
-
-			senderID := messaging.Sender.ID
-			text := messaging.Message.Text
-
-			// Handle attachments (photos, files, etc.)
-			if b.chatEngine != nil && len(messaging.Message.Attachments) > 0 {
-				if listener := b.chatEngine.GetMessageListener(); listener != nil {
-					for _, att := range messaging.Message.Attachments {
-						if att.Payload.URL == "" {
-							continue
-						}
-						b.downloadAndUploadAttachment(listener, senderID, att.Payload.URL, att.Type, text)
-						// Caption only with first attachment
-						text = ""
-					}
-
-					if username, err := b.GetUserUsername(senderID); err == nil && username != "" {
-						listener.UpdateUserPlatformInfo("instagram", senderID, "@"+username)
-					}
-				}
-				continue
-			}
-
-			if text == "" {
-				continue
-			}
-
-			// Save incoming message for CRM
-			if b.chatEngine != nil {
-				if listener := b.chatEngine.GetMessageListener(); listener != nil {
-					listener.SaveAndBroadcastChatMessage(entity.ChatMessage{
-						Platform:  "instagram",
-						UserID:    senderID,
-						ChatID:    senderID,
-						Direction: "incoming",
-						Sender:    "user",
-						Text:      text,
-						CreatedAt: time.Now(),
-					})
-
-					// Fetch and save Instagram @username
-					if username, err := b.GetUserUsername(senderID); err == nil && username != "" {
-						listener.UpdateUserPlatformInfo("instagram", senderID, "@"+username)
-					}
-				}
-			}
-
-			// Delegate to ChatEngine if available
-			if b.chatEngine != nil {
-				messenger := igmessenger.NewMessenger(b)
-				if err := b.chatEngine.HandleMessage(context.Background(), messenger, "instagram", senderID, senderID, text); err != nil {
-					b.log.Error("chat engine error",
-						slog.String("sender_id", senderID),
-						sl.Err(err),
-					)
-				}
-				continue
-			}
-
-			// Fallback: echo
-			echoText := fmt.Sprintf("Echo: %s", text)
-			if err := b.SendMessage(senderID, echoText); err != nil {
-				b.log.Error("failed to send echo message",
-					slog.String("sender_id", senderID),
-					sl.Err(err),
-				)
-			}
-		}
-	}
-}
-
-// SendMessage sends a text message to the specified recipient
-func (b *InstaBot) SendMessage(recipientID, text string) error {
-	reqBody := SendMessageRequest{}
-	reqBody.Recipient.ID = recipientID
-	reqBody.Message.Text = text
-
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	url := fmt.Sprintf("%s?access_token=%s", graphAPIURL, b.token())
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	b.log.Info("message sent successfully", slog.String("recipient_id", recipientID))
-	return nil
 }
 
 // GetUserUsername fetches the Instagram username for a given user ID via Graph API.
@@ -366,96 +164,3 @@ func (b *InstaBot) GetUserUsername(userID string) (string, error) {
 
 	return result.Username, nil
 }
-
-// downloadAndUploadAttachment downloads a file from a URL and uploads it to GridFS via the listener.
-func (b *InstaBot) downloadAndUploadAttachment(listener chat.MessageListener, senderID, fileURL, attType, caption string) {
-	resp, err := http.Get(fileURL)
-	if err != nil {
-		b.log.Error("failed to download Instagram attachment",
-			slog.String("sender_id", senderID),
-			slog.String("url", fileURL),
-			sl.Err(err),
-		)
-		return
-	}
-	defer resp.Body.Close()
-
-	// Determine filename and MIME type
-	mimeType := resp.Header.Get("Content-Type")
-	if mimeType == "" {
-		mimeType = "application/octet-stream"
-	}
-
-	filename := "attachment"
-	if parsed, err := url.Parse(fileURL); err == nil {
-		base := path.Base(parsed.Path)
-		if base != "" && base != "." && base != "/" {
-			filename = base
-		}
-	}
-
-	if err := listener.UploadAndSaveFile("instagram", senderID, resp.Body, filename, mimeType, resp.ContentLength, caption); err != nil {
-		b.log.Error("failed to upload Instagram attachment",
-			slog.String("sender_id", senderID),
-			sl.Err(err),
-		)
-		if errors.Is(err, entity.ErrFileTooLarge) {
-			limitMB := entity.MaxFileSize >> 20
-			text := fmt.Sprintf("Файл занадто великий. Максимально дозволений розмір - %d MB.", limitMB)
-			_ = b.SendMessage(senderID, text)
-		}
-	}
-}
-
-// SendMediaMessage sends a media attachment to a recipient via Instagram Graph API.
-func (b *InstaBot) SendMediaMessage(recipientID, mediaURL, mediaType string) error {
-	payload := map[string]interface{}{
-		"recipient": map[string]string{"id": recipientID},
-		"message": map[string]interface{}{
-			"attachment": map[string]interface{}{
-				"type": mediaType,
-				"payload": map[string]string{
-					"url": mediaURL,
-				},
-			},
-		},
-	}
-
-	jsonBody, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal media request: %w", err)
-	}
-
-	apiURL := fmt.Sprintf("%s?access_token=%s", graphAPIURL, b.token())
-	resp, err := http.Post(apiURL, "application/json", bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return fmt.Errorf("failed to send media message: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	return nil
-}
-
-// verifySignature verifies the X-Hub-Signature-256 header
-func (b *InstaBot) verifySignature(body []byte, signature string) bool {
-	if signature == "" {
-		return false
-	}
-
-	// Signature format: "sha256=<hex_signature>"
-	if len(signature) < 8 || signature[:7] != "sha256=" {
-		return false
-	}
-
-	expectedSig := signature[7:]
-	mac := hmac.New(sha256.New, []byte(b.appSecret))
-	mac.Write(body)
-	actualSig := hex.EncodeToString(mac.Sum(nil))
-
-	return hmac.Equal([]byte(expectedSig), []byte(actualSig))
-}
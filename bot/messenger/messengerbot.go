@@ -0,0 +1,88 @@
+package messenger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"DarkCS/bot/chat"
+	fbmessenger "DarkCS/bot/chat/facebook"
+	"DarkCS/bot/meta"
+	"DarkCS/internal/lib/sl"
+)
+
+// platform is the chat platform name used in chat states, CRM messages and routes.
+const platform = "facebook"
+
+const graphAPIURL = "https://graph.facebook.com/v24.0"
+
+// MessengerBot handles Facebook Page conversations via the Messenger Platform.
+// Users are identified by their page-scoped ID (PSID). Sending and webhook
+// handling are done by the embedded Messenger Platform client.
+type MessengerBot struct {
+	*meta.Client
+	log         *slog.Logger
+	accessToken string
+}
+
+// NewMessengerBot creates a new Facebook Messenger bot instance
+func NewMessengerBot(pageAccessToken, verifyToken, appSecret string, log *slog.Logger) *MessengerBot {
+	b := &MessengerBot{
+		log:         log.With(sl.Module("messengerbot")),
+		accessToken: pageAccessToken,
+	}
+	b.Client = meta.NewClient(meta.Config{
+		Platform:      platform,
+		Object:        "page",
+		MessagesURL:   graphAPIURL + "/me/messages",
+		VerifyToken:   verifyToken,
+		AppSecret:     appSecret,
+		Token:         func() string { return b.accessToken },
+		MessagingType: "RESPONSE",
+		ReusableMedia: true,
+		NewMessenger: func(c *meta.Client) chat.Messenger {
+			return fbmessenger.NewMessenger(c)
+		},
+		UpdateProfile: b.updateProfileName,
+	}, b.log)
+	return b
+}
+
+// updateProfileName saves the sender's Facebook name as the messenger name.
+func (b *MessengerBot) updateProfileName(listener chat.MessageListener, senderID string) {
+	name, err := b.GetUserName(senderID)
+	if err != nil {
+		b.log.Debug("failed to fetch user profile", slog.String("sender_id", senderID), sl.Err(err))
+		return
+	}
+	if name != "" {
+		listener.UpdateUserPlatformInfo(platform, senderID, name)
+	}
+}
+
+// GetUserName fetches the user's first and last name for a page-scoped ID via Graph API.
+// The name is only available when the page has access to the user profile.
+func (b *MessengerBot) GetUserName(psid string) (string, error) {
+	reqURL := fmt.Sprintf("%s/%s?fields=first_name,last_name&access_token=%s", graphAPIURL, psid, b.accessToken)
+	resp, err := http.Get(reqURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch user profile: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("API error (status %d)", resp.StatusCode)
+	}
+
+	var result struct {
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return strings.TrimSpace(result.FirstName + " " + result.LastName), nil
+}
@@ -0,0 +1,311 @@
+// Package meta is the Messenger Platform client shared by the Instagram and
+// Facebook Messenger bots: both platforms use the same Send API, webhook
+// payloads and signatures, and differ only in endpoints and profile lookups.
+package meta
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"DarkCS/bot/chat"
+	igmessenger "DarkCS/bot/chat/instagram"
+	"DarkCS/bot/webhook"
+	"DarkCS/entity"
+	"DarkCS/internal/lib/i18n"
+	"DarkCS/internal/lib/sl"
+)
+
+// Config describes one Messenger Platform integration.
+type Config struct {
+	// Platform is the chat platform name used in chat states, CRM messages and routes.
+	Platform string
+	// Object is the webhook object of the platform's payloads: "instagram" or "page".
+	Object string
+	// MessagesURL is the Send API endpoint, without the access token.
+	MessagesURL string
+	VerifyToken string
+	AppSecret   string
+	// Token returns the current access token.
+	Token func() string
+	// MessagingType is sent with every message when set; the Facebook Send API expects "RESPONSE".
+	MessagingType string
+	// ReusableMedia lets the platform cache media sent by URL.
+	ReusableMedia bool
+	// NewMessenger wraps the client in the platform's chat.Messenger.
+	NewMessenger func(*Client) chat.Messenger
+	// UpdateProfile refreshes the sender's name in the CRM after an incoming message; nil skips it.
+	UpdateProfile func(listener chat.MessageListener, senderID string)
+}
+
+// Client sends messages through the Send API and routes webhook events to the chat engine.
+type Client struct {
+	conf       Config
+	log        *slog.Logger
+	chatEngine *chat.ChatEngine
+	queue      *webhook.Queue
+}
+
+// NewClient creates a client for the platform described by conf.
+func NewClient(conf Config, log *slog.Logger) *Client {
+	return &Client{conf: conf, log: log}
+}
+
+// SetChatEngine sets the unified chat engine for this bot.
+func (c *Client) SetChatEngine(engine *chat.ChatEngine) {
+	c.chatEngine = engine
+}
+
+// StartWebhookQueue makes HandleWebhook store payloads in a durable queue and
+// process them in the background, skipping messages that Meta delivers again.
+func (c *Client) StartWebhookQueue(ctx context.Context, store webhook.Store) {
+	c.queue = webhook.NewQueue(store, c.conf.Platform, payloadSender, c.processQueued, c.log)
+	c.queue.Start(ctx)
+}
+
+// processQueued processes a payload taken from the webhook queue.
+func (c *Client) processQueued(body []byte) error {
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return fmt.Errorf("failed to parse queued payload: %w", err)
+	}
+	return c.processPayload(payload)
+}
+
+// payloadSender returns the sender of the first event in a queued payload.
+func payloadSender(body []byte) string {
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	for _, entry := range payload.Entry {
+		for _, messaging := range entry.Messaging {
+			return messaging.Sender.ID
+		}
+	}
+	return ""
+}
+
+// listener returns the CRM message listener, or nil without a chat engine.
+func (c *Client) listener() chat.MessageListener {
+	if c.chatEngine == nil {
+		return nil
+	}
+	return c.chatEngine.GetMessageListener()
+}
+
+// updateStatus passes a read or delivery receipt for outgoing messages on to the CRM.
+func (c *Client) updateStatus(status entity.MessageStatus, mids ...string) {
+	listener := c.listener()
+	if listener == nil {
+		return
+	}
+	for _, mid := range mids {
+		listener.UpdateMessageStatus(c.conf.Platform, mid, status, "")
+	}
+}
+
+// markRead passes a read receipt on to the CRM. Messenger reports reads with a
+// watermark: every message sent to the user before it has been seen.
+func (c *Client) markRead(senderID string, watermark int64) {
+	listener := c.listener()
+	if listener == nil || watermark == 0 {
+		return
+	}
+	listener.MarkMessagesReadUntil(c.conf.Platform, senderID, time.UnixMilli(watermark))
+}
+
+// saveIncomingText stores an incoming text message for the CRM and refreshes the sender's name.
+func (c *Client) saveIncomingText(senderID, text string) {
+	listener := c.listener()
+	if listener == nil || text == "" {
+		return
+	}
+
+	listener.SaveAndBroadcastChatMessage(entity.ChatMessage{
+		Platform:  c.conf.Platform,
+		UserID:    senderID,
+		ChatID:    senderID,
+		Direction: "incoming",
+		Sender:    "user",
+		Text:      text,
+		CreatedAt: time.Now(),
+	})
+
+	c.updateProfile(listener, senderID)
+}
+
+func (c *Client) updateProfile(listener chat.MessageListener, senderID string) {
+	if c.conf.UpdateProfile != nil {
+		c.conf.UpdateProfile(listener, senderID)
+	}
+}
+
+// downloadAndUploadAttachment downloads a file from a URL and uploads it to GridFS via the listener.
+// It returns the stored attachment and reports false when the file could not be stored.
+func (c *Client) downloadAndUploadAttachment(listener chat.MessageListener, senderID, fileURL, caption string) (entity.Attachment, bool) {
+	resp, err := http.Get(fileURL)
+	if err != nil {
+		c.log.Error("failed to download attachment",
+			slog.String("sender_id", senderID),
+			slog.String("url", fileURL),
+			sl.Err(err),
+		)
+		return entity.Attachment{}, false
+	}
+	defer resp.Body.Close()
+
+	// Determine filename and MIME type
+	mimeType := resp.Header.Get("Content-Type")
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	filename := "attachment"
+	if parsed, err := url.Parse(fileURL); err == nil {
+		base := path.Base(parsed.Path)
+		if base != "" && base != "." && base != "/" {
+			filename = base
+		}
+	}
+
+	stored, err := listener.UploadAndSaveFile(c.conf.Platform, senderID, resp.Body, filename, mimeType, resp.ContentLength, caption)
+	if err != nil {
+		c.log.Error("failed to upload attachment",
+			slog.String("sender_id", senderID),
+			sl.Err(err),
+		)
+		if errors.Is(err, entity.ErrFileTooLarge) {
+			limitMB := entity.MaxFileSize >> 20
+			locale := c.chatEngine.UserLocale(context.Background(), c.conf.Platform, senderID)
+			text := i18n.T(locale, "error.file_too_large", limitMB)
+			_, _ = c.SendMessage(senderID, text)
+		}
+		return entity.Attachment{}, false
+	}
+
+	return stored, true
+}
+
+// attachmentType maps a Messenger Platform attachment type to the engine's type.
+// Audio attachments in Instagram and Messenger DMs are voice messages.
+func attachmentType(attType, mimeType string) chat.AttachmentType {
+	switch attType {
+	case "image":
+		return chat.AttachmentImage
+	case "video", "ig_reel":
+		return chat.AttachmentVideo
+	case "audio":
+		return chat.AttachmentVoice
+	case "file":
+		return chat.AttachmentDocument
+	}
+	return chat.AttachmentTypeFromMIME(mimeType)
+}
+
+// SendMessage sends a text message to the specified recipient and returns its message ID.
+func (c *Client) SendMessage(recipientID, text string) (string, error) {
+	return c.postMessage(recipientID, map[string]interface{}{"text": text})
+}
+
+// SendMediaMessage sends a media attachment by public URL and returns its message ID.
+func (c *Client) SendMediaMessage(recipientID, mediaURL, mediaType string) (string, error) {
+	payload := map[string]interface{}{"url": mediaURL}
+	if c.conf.ReusableMedia {
+		payload["is_reusable"] = true
+	}
+	return c.postMessage(recipientID, map[string]interface{}{
+		"attachment": map[string]interface{}{
+			"type":    mediaType,
+			"payload": payload,
+		},
+	})
+}
+
+// SendQuickReplies sends a text message with quick reply buttons.
+func (c *Client) SendQuickReplies(recipientID, text string, replies []igmessenger.QuickReply) error {
+	_, err := c.postMessage(recipientID, map[string]interface{}{
+		"text":          text,
+		"quick_replies": replies,
+	})
+	return err
+}
+
+// SendButtonTemplate sends a button template with postback buttons.
+func (c *Client) SendButtonTemplate(recipientID string, template igmessenger.ButtonTemplate) error {
+	_, err := c.postMessage(recipientID, map[string]interface{}{
+		"attachment": map[string]interface{}{
+			"type": "template",
+			"payload": map[string]interface{}{
+				"template_type": "button",
+				"text":          template.Text,
+				"buttons":       template.Buttons,
+			},
+		},
+	})
+	return err
+}
+
+// postMessage sends a message to the Send API and returns the message ID (mid) of the sent message.
+func (c *Client) postMessage(recipientID string, message any) (string, error) {
+	payload := map[string]interface{}{
+		"recipient": map[string]string{"id": recipientID},
+		"message":   message,
+	}
+	if c.conf.MessagingType != "" {
+		payload["messaging_type"] = c.conf.MessagingType
+	}
+
+	jsonBody, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	apiURL := fmt.Sprintf("%s?access_token=%s", c.conf.MessagesURL, c.conf.Token())
+	resp, err := http.Post(apiURL, "application/json", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		MessageID string `json:"message_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || result.MessageID == "" {
+		// The message was accepted; only its ID is unknown
+		c.log.Warn("no message ID in send response")
+		return "", nil
+	}
+	return result.MessageID, nil
+}
+
+// verifySignature verifies the X-Hub-Signature-256 header
+func (c *Client) verifySignature(body []byte, signature string) bool {
+	expectedSig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok || expectedSig == "" {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(c.conf.AppSecret))
+	mac.Write(body)
+	actualSig := hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expectedSig), []byte(actualSig))
+}
@@ -0,0 +1,272 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"DarkCS/bot/chat"
+	igmessenger "DarkCS/bot/chat/instagram"
+	"DarkCS/entity"
+	"DarkCS/internal/lib/sl"
+)
+
+// WebhookPayload represents the incoming webhook payload of an Instagram account or a Facebook Page
+type WebhookPayload struct {
+	Object string `json:"object"`
+	Entry  []struct {
+		ID        string `json:"id"`
+		Time      int64  `json:"time"`
+		Messaging []struct {
+			Sender struct {
+				ID string `json:"id"`
+			} `json:"sender"`
+			Recipient struct {
+				ID string `json:"id"`
+			} `json:"recipient"`
+			Timestamp int64 `json:"timestamp"`
+			Message   *struct {
+				Mid         string              `json:"mid"`
+				Text        string              `json:"text"`
+				IsEcho      bool                `json:"is_echo,omitempty"`
+				Attachments []messageAttachment `json:"attachments,omitempty"`
+				QuickReply  *struct {
+					Payload string `json:"payload"`
+				} `json:"quick_reply,omitempty"`
+			} `json:"message,omitempty"`
+			// Postback is a tap on a button template button, the Get Started button or an ice breaker
+			Postback *struct {
+				Mid     string `json:"mid"`
+				Title   string `json:"title"`
+				Payload string `json:"payload"`
+			} `json:"postback,omitempty"`
+			// Read reports that the user has seen the messages up to and including Mid (Instagram)
+			// or every message sent before Watermark in ms (Messenger)
+			Read *struct {
+				Mid       string `json:"mid"`
+				Watermark int64  `json:"watermark"`
+			} `json:"read,omitempty"`
+			// Delivery reports that messages sent to the user have reached them
+			Delivery *struct {
+				Mids      []string `json:"mids"`
+				Watermark int64    `json:"watermark"`
+			} `json:"delivery,omitempty"`
+		} `json:"messaging"`
+	} `json:"entry"`
+}
+
+// messageAttachment is a file sent with an incoming message.
+type messageAttachment struct {
+	Type    string `json:"type"`
+	Payload struct {
+		URL string `json:"url"`
+	} `json:"payload"`
+}
+
+// HandleWebhookVerification handles the GET request for webhook verification
+func (c *Client) HandleWebhookVerification(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("hub.mode")
+	token := r.URL.Query().Get("hub.verify_token")
+	challenge := r.URL.Query().Get("hub.challenge")
+
+	if mode == "subscribe" && token == c.conf.VerifyToken {
+		c.log.Info("webhook verified")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(challenge))
+		return
+	}
+
+	c.log.Warn("webhook verification failed",
+		slog.String("mode", mode),
+		slog.Bool("token_match", token == c.conf.VerifyToken),
+	)
+	http.Error(w, "Forbidden", http.StatusForbidden)
+}
+
+// HandleWebhook handles incoming webhook POST requests
+func (c *Client) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		c.log.Error("failed to read request body", sl.Err(err))
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	// Verify signature if app secret is configured; Meta does not retry a rejected delivery
+	if c.conf.AppSecret != "" && !c.verifySignature(body, r.Header.Get("X-Hub-Signature-256")) {
+		c.log.Warn("invalid webhook signature")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		c.log.Error("failed to parse webhook payload", sl.Err(err))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Store the payload before acknowledging; a failed store makes Meta retry the delivery
+	if c.queue != nil {
+		if err := c.queue.Enqueue(body); err != nil {
+			c.log.Error("failed to queue webhook payload", sl.Err(err))
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Always respond with 200 OK to acknowledge receipt (Meta requires quick response)
+	w.WriteHeader(http.StatusOK)
+
+	// Process messages asynchronously
+	go func() {
+		if err := c.processPayload(payload); err != nil {
+			c.log.Error("failed to process webhook payload", sl.Err(err))
+		}
+	}()
+}
+
+// processPayload processes the webhook payload. It returns the errors of the
+// messages that could not be stored; messages stored before are skipped when it
+// is retried.
+func (c *Client) processPayload(payload WebhookPayload) error {
+	if payload.Object != c.conf.Object {
+		return nil
+	}
+
+	var errs []error
+	for _, entry := range payload.Entry {
+		for _, messaging := range entry.Messaging {
+			senderID := messaging.Sender.ID
+
+			// Handle message_reads and message_deliveries events
+			if read := messaging.Read; read != nil {
+				if read.Mid != "" {
+					c.updateStatus(entity.MessageRead, read.Mid)
+				} else {
+					c.markRead(senderID, read.Watermark)
+				}
+				continue
+			}
+			if messaging.Delivery != nil {
+				c.updateStatus(entity.MessageDelivered, messaging.Delivery.Mids...)
+				continue
+			}
+
+			// Handle button template taps, Get Started and ice breakers
+			if postback := messaging.Postback; postback != nil {
+				err := c.queue.Deliver(postback.Mid, func() error {
+					c.saveIncomingText(senderID, postback.Title)
+					return nil
+				}, func() error {
+					return c.handleReply(senderID, postback.Title, postback.Payload)
+				})
+				if err != nil {
+					errs = append(errs, fmt.Errorf("postback %s from %s: %w", postback.Mid, senderID, err))
+				}
+				continue
+			}
+
+			message := messaging.Message
+			if message == nil || message.IsEcho {
+				continue
+			}
+			var attachments []chat.Attachment
+			err := c.queue.Deliver(message.Mid, func() error {
+				// Store the files once; the engine gets them after the message is marked seen
+				if len(message.Attachments) > 0 {
+					var err error
+					attachments, err = c.storeAttachments(senderID, message.Text, message.Attachments)
+					return err
+				}
+				c.saveIncomingText(senderID, message.Text)
+				return nil
+			}, func() error {
+				text := message.Text
+
+				// Handle quick reply taps
+				if qr := message.QuickReply; qr != nil && qr.Payload != "" {
+					return c.handleReply(senderID, text, qr.Payload)
+				}
+
+				if c.chatEngine == nil {
+					return nil
+				}
+
+				// Route the files and the caption to the current step
+				if len(message.Attachments) > 0 {
+					if len(attachments) == 0 {
+						return nil
+					}
+					return c.chatEngine.HandleAttachments(context.Background(), c.conf.NewMessenger(c), c.conf.Platform, senderID, senderID, text, attachments)
+				}
+
+				if text == "" {
+					return nil
+				}
+				return c.chatEngine.HandleMessage(context.Background(), c.conf.NewMessenger(c), c.conf.Platform, senderID, senderID, text)
+			})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("message %s from %s: %w", message.Mid, senderID, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// storeAttachments uploads the files of a message for the CRM; the caption is saved
+// with the first one. It fails only when none of the files could be stored.
+func (c *Client) storeAttachments(senderID, caption string, files []messageAttachment) ([]chat.Attachment, error) {
+	listener := c.listener()
+	if listener == nil {
+		return nil, nil
+	}
+	var attachments []chat.Attachment
+	failed := 0
+	for _, att := range files {
+		if att.Payload.URL == "" {
+			continue
+		}
+		if stored, ok := c.downloadAndUploadAttachment(listener, senderID, att.Payload.URL, caption); ok {
+			attachments = append(attachments, chat.NewStoredAttachment(listener, attachmentType(att.Type, stored.MIMEType), stored))
+		} else {
+			failed++
+		}
+		// Caption only with first attachment
+		caption = ""
+	}
+	if len(attachments) == 0 && failed > 0 {
+		return nil, fmt.Errorf("failed to store %d attachments", failed)
+	}
+
+	c.updateProfile(listener, senderID)
+	return attachments, nil
+}
+
+// handleReply routes a quick reply or postback tap to the chat engine.
+// Menu payloads are handled as the typed button text, other payloads as callbacks.
+// Postbacks without a reply payload, such as Get Started and ice breakers, are
+// handled as the typed button title.
+func (c *Client) handleReply(senderID, title, payload string) error {
+	if c.chatEngine == nil {
+		return nil
+	}
+
+	messenger := c.conf.NewMessenger(c)
+	text, data := igmessenger.ParseReplyPayload(payload)
+	if !igmessenger.IsReplyPayload(payload) {
+		text, data = title, ""
+	}
+
+	if text != "" {
+		return c.chatEngine.HandleMessage(context.Background(), messenger, c.conf.Platform, senderID, senderID, text)
+	}
+	return c.chatEngine.HandleCallback(context.Background(), messenger, c.conf.Platform, senderID, senderID, data, "")
+}
@@ -0,0 +1,120 @@
+package meta
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"DarkCS/bot/chat"
+	"DarkCS/bot/chat/chattest"
+)
+
+// inputStep records the inputs routed to it.
+type inputStep struct{ inputs []chat.UserInput }
+
+func (s *inputStep) ID() chat.StepID { return "input" }
+
+func (s *inputStep) Enter(ctx context.Context, m chat.Messenger, state *chat.ChatState) chat.StepResult {
+	return chat.StepResult{}
+}
+
+func (s *inputStep) HandleInput(ctx context.Context, m chat.Messenger, state *chat.ChatState, input chat.UserInput) chat.StepResult {
+	s.inputs = append(s.inputs, input)
+	return chat.StepResult{}
+}
+
+type inputWorkflow struct{ step *inputStep }
+
+func (w inputWorkflow) ID() chat.WorkflowID      { return "input" }
+func (w inputWorkflow) InitialStep() chat.StepID { return w.step.ID() }
+func (w inputWorkflow) GetStep(id chat.StepID) (chat.Step, bool) {
+	return w.step, id == w.step.ID()
+}
+
+func TestRepliesReachTheEngine(t *testing.T) {
+	tests := []struct {
+		name      string
+		messaging string
+		want      chat.UserInput
+	}{
+		{
+			name:      "menu quick reply",
+			messaging: `{"sender":{"id":"u1"},"message":{"mid":"m1","text":"Меню","quick_reply":{"payload":"menu:Меню"}}}`,
+			want:      chat.UserInput{Text: "Меню"},
+		},
+		{
+			name:      "callback postback",
+			messaging: `{"sender":{"id":"u1"},"postback":{"mid":"m2","title":"Так","payload":"cb:yes"}}`,
+			want:      chat.UserInput{CallbackData: "yes"},
+		},
+		{
+			name:      "get started postback",
+			messaging: `{"sender":{"id":"u1"},"postback":{"mid":"m3","title":"Get Started","payload":"GET_STARTED"}}`,
+			want:      chat.UserInput{Text: "Get Started"},
+		},
+		{
+			name:      "text",
+			messaging: `{"sender":{"id":"u1"},"message":{"mid":"m4","text":"привіт"}}`,
+			want:      chat.UserInput{Text: "привіт"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := &inputStep{}
+			engine := chattest.NewEngine(chattest.NewMemoryStorage(), chattest.DiscardLogger(), inputWorkflow{step})
+			messenger := chattest.NewMessenger()
+			if err := engine.StartWorkflow(context.Background(), messenger, "instagram", "u1", "u1", "input"); err != nil {
+				t.Fatalf("start workflow: %v", err)
+			}
+
+			c := NewClient(Config{
+				Platform:     "instagram",
+				Object:       "instagram",
+				NewMessenger: func(*Client) chat.Messenger { return messenger },
+			}, chattest.DiscardLogger())
+			c.SetChatEngine(engine)
+
+			var payload WebhookPayload
+			body := `{"object":"instagram","entry":[{"messaging":[` + tt.messaging + `]}]}`
+			if err := json.Unmarshal([]byte(body), &payload); err != nil {
+				t.Fatalf("parse payload: %v", err)
+			}
+			if err := c.processPayload(payload); err != nil {
+				t.Fatalf("process payload: %v", err)
+			}
+
+			if len(step.inputs) != 1 {
+				t.Fatalf("inputs = %+v, want one", step.inputs)
+			}
+			got := step.inputs[0]
+			if got.Text != tt.want.Text || got.CallbackData != tt.want.CallbackData {
+				t.Errorf("input = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	c := NewClient(Config{AppSecret: "secret"}, chattest.DiscardLogger())
+	body := []byte(`{"object":"page"}`)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	valid := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	for signature, want := range map[string]bool{
+		valid:      true,
+		"":         false,
+		"sha256=":  false,
+		"sha1=abc": false,
+		valid[:20]: false,
+	} {
+		if got := c.verifySignature(body, signature); got != want {
+			t.Errorf("verifySignature(%q) = %v, want %v", signature, got, want)
+		}
+	}
+}
@@ -9,9 +9,9 @@ import (
 	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers/filters/message"
 	"log"
 	"log/slog"
+	"net/http"
 	"strings"
 	"sync"
-	"time"
 )
 
 type TgBot struct {
@@ -24,6 +24,9 @@ type TgBot struct {
 
 	driveAuthCfg     *DriveAuthConfig
 	pendingDriveAuth sync.Map // int64 admin ID → *oauth2.Config
+
+	webhook  *WebhookConfig
+	receiver webhookReceiver
 }
 
 func NewTgBot(botName, apiKey string, adminIds []int64, log *slog.Logger) (*TgBot, error) {
@@ -81,17 +84,8 @@ func (t *TgBot) Start() error {
 	}))
 
 	// Start receiving updates.
-	err := updater.StartPolling(t.api, &ext.PollingOpts{
-		DropPendingUpdates: true,
-		GetUpdatesOpts: &tgbotapi.GetUpdatesOpts{
-			Timeout: 9,
-			RequestOpts: &tgbotapi.RequestOpts{
-				Timeout: time.Second * 10,
-			},
-		},
-	})
-	if err != nil {
-		panic("failed to start polling: " + err.Error())
+	if err := startUpdates(t.api, updater, &t.receiver, "telegram", t.webhook); err != nil {
+		return err
 	}
 
 	// Idle, to keep updates coming in, and avoid bot stopping.
@@ -101,6 +95,16 @@ func (t *TgBot) Start() error {
 	return nil
 }
 
+// UseWebhook makes Start receive updates through WebhookHandler instead of long polling.
+func (t *TgBot) UseWebhook(cfg WebhookConfig) {
+	t.webhook = &cfg
+}
+
+// WebhookHandler serves the bot's webhook route, /webhook/telegram.
+func (t *TgBot) WebhookHandler() http.Handler {
+	return &t.receiver
+}
+
 // SetMinLogLevel sets the minimum log level for all admin notifications
 func (t *TgBot) SetMinLogLevel(level slog.Level) {
 	t.minLogLevel = level
@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// webhookPrefix is where Telegram webhook routes live on the HTTP server,
+// next to the Instagram and WhatsApp webhooks.
+const webhookPrefix = "/webhook/"
+
+// secretTokenHeader carries the secret token set with setWebhook on every update.
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// WebhookConfig switches a Telegram bot from long polling to webhook delivery.
+type WebhookConfig struct {
+	// PublicURL is the externally reachable base URL of the HTTP server.
+	PublicURL string
+	// SecretToken is required; updates without it in the header are rejected.
+	SecretToken string
+}
+
+// webhookReceiver serves a bot's webhook route. The route is registered on the
+// router before the bot starts; until then updates are refused with 503 and
+// Telegram delivers them again later.
+type webhookReceiver struct {
+	mu      sync.RWMutex
+	secret  string
+	handler http.HandlerFunc
+}
+
+func (wr *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	wr.mu.RLock()
+	secret, handler := wr.secret, wr.handler
+	wr.mu.RUnlock()
+
+	if handler == nil {
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(secretTokenHeader)), []byte(secret)) != 1 {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	handler(w, r)
+}
+
+func (wr *webhookReceiver) set(secret string, handler http.HandlerFunc) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	wr.secret = secret
+	wr.handler = handler
+}
+
+// startUpdates starts receiving updates for the bot: through the webhook route
+// webhookPrefix+name when webhook is set, by long polling otherwise.
+// Polling drops updates sent while the bot was down; a webhook keeps them.
+func startUpdates(api *tgbotapi.Bot, updater *ext.Updater, receiver *webhookReceiver, name string, webhook *WebhookConfig) error {
+	if webhook == nil {
+		err := updater.StartPolling(api, &ext.PollingOpts{
+			DropPendingUpdates: true,
+			GetUpdatesOpts: &tgbotapi.GetUpdatesOpts{
+				Timeout: 9,
+				RequestOpts: &tgbotapi.RequestOpts{
+					Timeout: time.Second * 10,
+				},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to start polling: %w", err)
+		}
+		return nil
+	}
+
+	if webhook.PublicURL == "" || webhook.SecretToken == "" {
+		return errors.New("webhook public url and secret token are required")
+	}
+
+	// The receiver checks the secret itself, so the updater is not given it
+	if err := updater.AddWebhook(api, name, nil); err != nil {
+		return fmt.Errorf("failed to add webhook: %w", err)
+	}
+	receiver.set(webhook.SecretToken, updater.GetHandlerFunc(webhookPrefix))
+
+	url := strings.TrimSuffix(webhook.PublicURL, "/") + webhookPrefix + name
+	_, err := api.SetWebhook(url, &tgbotapi.SetWebhookOpts{
+		SecretToken: webhook.SecretToken,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+	return nil
+}
@@ -14,9 +14,9 @@ import (
 	"time"
 
 	"DarkCS/bot/chat"
-	chatmainmenu "DarkCS/bot/chat/mainmenu"
 	tgmessenger "DarkCS/bot/chat/telegram"
 	"DarkCS/entity"
+	"DarkCS/internal/lib/i18n"
 	"DarkCS/internal/lib/sl"
 
 	tgbotapi "github.com/PaulSonOfLars/gotgbot/v2"
@@ -37,6 +37,8 @@ type UserBot struct {
 	botUsername string
 	chatEngine  *chat.ChatEngine
 	authService userBotAuthService
+	webhook     *WebhookConfig
+	receiver    webhookReceiver
 }
 
 // NewUserBot creates a new user bot instance.
@@ -70,7 +72,17 @@ func (b *UserBot) GetAPI() *tgbotapi.Bot {
 	return b.api
 }
 
-// Start begins polling for updates and handling them.
+// UseWebhook makes Start receive updates through WebhookHandler instead of long polling.
+func (b *UserBot) UseWebhook(cfg WebhookConfig) {
+	b.webhook = &cfg
+}
+
+// WebhookHandler serves the bot's webhook route, /webhook/userbot.
+func (b *UserBot) WebhookHandler() http.Handler {
+	return &b.receiver
+}
+
+// Start begins receiving updates (by webhook or polling) and handling them.
 func (b *UserBot) Start() error {
 	dispatcher := ext.NewDispatcher(&ext.DispatcherOpts{
 		Error: func(bot *tgbotapi.Bot, ctx *ext.Context, err error) ext.DispatcherAction {
@@ -82,7 +94,6 @@ func (b *UserBot) Start() error {
 	updater := ext.NewUpdater(dispatcher, nil)
 
 	dispatcher.AddHandler(handlers.NewCommand("start", b.handleStart))
-	dispatcher.AddHandler(handlers.NewCommand("reset", b.handleReset))
 	dispatcher.AddHandler(handlers.NewCallback(func(cq *tgbotapi.CallbackQuery) bool { return true }, b.handleCallback))
 	dispatcher.AddHandler(handlers.NewMessage(message.Contact, b.handleContact))
 	dispatcher.AddHandler(handlers.NewMessage(message.Photo, b.handleMedia))
@@ -92,20 +103,14 @@ func (b *UserBot) Start() error {
 	dispatcher.AddHandler(handlers.NewMessage(message.Voice, b.handleMedia))
 	dispatcher.AddHandler(handlers.NewMessage(message.Text, b.handleMessage))
 
-	err := updater.StartPolling(b.api, &ext.PollingOpts{
-		DropPendingUpdates: true,
-		GetUpdatesOpts: &tgbotapi.GetUpdatesOpts{
-			Timeout: 9,
-			RequestOpts: &tgbotapi.RequestOpts{
-				Timeout: time.Second * 10,
-			},
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to start polling: %w", err)
+	if err := startUpdates(b.api, updater, &b.receiver, "userbot", b.webhook); err != nil {
+		return err
 	}
 
-	b.log.Info("user bot started", slog.String("username", b.botUsername))
+	b.log.Info("user bot started",
+		slog.String("username", b.botUsername),
+		slog.Bool("webhook", b.webhook != nil),
+	)
 
 	updater.Idle()
 
@@ -116,31 +121,6 @@ func (b *UserBot) newMessenger() *tgmessenger.Messenger {
 	return tgmessenger.NewMessenger(b.api)
 }
 
-// handleReset is an admin-only command that resets all users currently in the
-// ai_consultant or make_order steps back to the main_menu step.
-func (b *UserBot) handleReset(bot *tgbotapi.Bot, ctx *ext.Context) error {
-	if b.authService == nil || b.chatEngine == nil {
-		return nil
-	}
-
-	user, err := b.authService.GetUser("", "", ctx.EffectiveUser.Id)
-	if err != nil || user == nil || !user.IsAdmin() {
-		return nil
-	}
-
-	steps := []chat.StepID{chatmainmenu.StepAIConsultant, chatmainmenu.StepMakeOrder, chatmainmenu.StepSelectVideo}
-	count, err := b.chatEngine.ResetUsersAtSteps(context.Background(), chatmainmenu.WorkflowID, steps, chatmainmenu.StepMainMenu)
-	if err != nil {
-		b.log.Error("handleReset: failed to reset users", sl.Err(err))
-		_, _ = ctx.EffectiveMessage.Reply(bot, "Помилка при скиданні кроків.", nil)
-		return err
-	}
-
-	reply := fmt.Sprintf("Скинуто %d користувачів на головне меню.", count)
-	_, _ = ctx.EffectiveMessage.Reply(bot, reply, nil)
-	return nil
-}
-
 // handleStart handles the /start command — always starts onboarding.
 // If the message contains a deep link payload (e.g. /start ZGw6Mjg5MjM0),
 // it is decoded from base64 as "type:id" and passed into the workflow state.
@@ -294,8 +274,10 @@ func (b *UserBot) handleMedia(bot *tgbotapi.Bot, ctx *ext.Context) error {
 	userID := strconv.FormatInt(ctx.EffectiveUser.Id, 10)
 	msg := ctx.EffectiveMessage
 
-	// Determine the file ID, filename, and caption
+	// Determine the file ID, filename, caption, attachment type and duration
 	var fileID, filename, caption string
+	var attType chat.AttachmentType
+	var duration time.Duration
 	switch {
 	case msg.Photo != nil && len(msg.Photo) > 0:
 		// Use largest photo size
@@ -303,10 +285,12 @@ func (b *UserBot) handleMedia(bot *tgbotapi.Bot, ctx *ext.Context) error {
 		fileID = photo.FileId
 		filename = "photo.jpg"
 		caption = msg.Caption
+		attType = chat.AttachmentImage
 	case msg.Document != nil:
 		fileID = msg.Document.FileId
 		filename = msg.Document.FileName
 		caption = msg.Caption
+		attType = chat.AttachmentDocument
 	case msg.Audio != nil:
 		fileID = msg.Audio.FileId
 		filename = msg.Audio.FileName
@@ -314,6 +298,8 @@ func (b *UserBot) handleMedia(bot *tgbotapi.Bot, ctx *ext.Context) error {
 			filename = "audio.mp3"
 		}
 		caption = msg.Caption
+		attType = chat.AttachmentAudio
+		duration = time.Duration(msg.Audio.Duration) * time.Second
 	case msg.Video != nil:
 		fileID = msg.Video.FileId
 		filename = msg.Video.FileName
@@ -321,10 +307,14 @@ func (b *UserBot) handleMedia(bot *tgbotapi.Bot, ctx *ext.Context) error {
 			filename = "video.mp4"
 		}
 		caption = msg.Caption
+		attType = chat.AttachmentVideo
+		duration = time.Duration(msg.Video.Duration) * time.Second
 	case msg.Voice != nil:
 		fileID = msg.Voice.FileId
 		filename = "voice.ogg"
 		caption = msg.Caption
+		attType = chat.AttachmentVoice
+		duration = time.Duration(msg.Voice.Duration) * time.Second
 	default:
 		return nil
 	}
@@ -377,7 +367,8 @@ func (b *UserBot) handleMedia(bot *tgbotapi.Bot, ctx *ext.Context) error {
 	}
 
 	// Upload to GridFS and save message
-	if err := listener.UploadAndSaveFile("telegram", userID, resp.Body, filename, mimeType, file.FileSize, caption); err != nil {
+	stored, err := listener.UploadAndSaveFile("telegram", userID, resp.Body, filename, mimeType, file.FileSize, caption)
+	if err != nil {
 		b.log.Error("failed to upload and save file",
 			slog.String("user_id", userID),
 			sl.Err(err),
@@ -385,7 +376,8 @@ func (b *UserBot) handleMedia(bot *tgbotapi.Bot, ctx *ext.Context) error {
 		if errors.Is(err, entity.ErrFileTooLarge) {
 			chatID := ctx.EffectiveChat.Id
 			limitMB := entity.MaxFileSize >> 20
-			text := fmt.Sprintf("Файл занадто великий. Максимально дозволений розмір - %d MB.", limitMB)
+			locale := b.chatEngine.UserLocale(context.Background(), "telegram", userID)
+			text := i18n.T(locale, "error.file_too_large", limitMB)
 			_, _ = bot.SendMessage(chatID, text, nil)
 		}
 		return err
@@ -396,12 +388,16 @@ func (b *UserBot) handleMedia(bot *tgbotapi.Bot, ctx *ext.Context) error {
 		listener.UpdateUserPlatformInfo("telegram", userID, "@"+username)
 	}
 
-	// Route caption text to ChatEngine if present
-	if caption != "" {
-		chatIDStr := strconv.FormatInt(ctx.EffectiveChat.Id, 10)
-		messenger := b.newMessenger()
-		_ = b.chatEngine.HandleMessage(context.Background(), messenger, "telegram", userID, chatIDStr, caption)
+	// Route the file and its caption to the current step
+	chatIDStr := strconv.FormatInt(ctx.EffectiveChat.Id, 10)
+	att := chat.NewStoredAttachment(listener, attType, stored)
+	att.Duration = duration
+	err = b.chatEngine.HandleAttachments(context.Background(), b.newMessenger(), "telegram", userID, chatIDStr, caption, []chat.Attachment{att})
+	if err != nil {
+		b.log.Error("attachment error",
+			slog.String("user_id", userID),
+			sl.Err(err),
+		)
 	}
-
-	return nil
+	return err
 }
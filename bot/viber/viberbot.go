@@ -0,0 +1,502 @@
+package viber
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"DarkCS/bot/chat"
+	vbmessenger "DarkCS/bot/chat/viber"
+	"DarkCS/bot/webhook"
+	"DarkCS/entity"
+	"DarkCS/internal/lib/i18n"
+	"DarkCS/internal/lib/sl"
+)
+
+// platform is the chat platform name used in chat states, CRM messages and routes.
+const platform = "viber"
+
+const apiURL = "https://chatapi.viber.com/pa"
+
+// signatureHeader carries the HMAC-SHA256 of the body, keyed with the auth token.
+const signatureHeader = "X-Viber-Content-Signature"
+
+// keyboardAPIVersion is the minimum client API version for messages with a keyboard.
+// Older clients cannot show silent or share-phone buttons.
+const keyboardAPIVersion = 7
+
+// webhookRetryInterval is how long RegisterWebhook waits between attempts.
+const webhookRetryInterval = 10 * time.Second
+
+// maxSenderNameLen is the longest bot name Viber accepts in a message.
+const maxSenderNameLen = 28
+
+// ViberBot handles Viber chats via the Viber REST bot API
+type ViberBot struct {
+	log        *slog.Logger
+	authToken  string
+	senderName string
+	chatEngine *chat.ChatEngine
+	queue      *webhook.Queue
+}
+
+// Callback represents an incoming webhook callback from Viber
+type Callback struct {
+	Event        string      `json:"event"`
+	Timestamp    int64       `json:"timestamp"`
+	MessageToken json.Number `json:"message_token"`
+	// UserID is set on delivered, seen and failed events
+	UserID string `json:"user_id,omitempty"`
+	// Desc explains a failed event
+	Desc   string `json:"desc,omitempty"`
+	Sender *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"sender,omitempty"`
+	Message *struct {
+		Type     string `json:"type"`
+		Text     string `json:"text"`
+		Media    string `json:"media"`
+		FileName string `json:"file_name"`
+		Size     int64  `json:"size"`
+		Contact  *struct {
+			Name        string `json:"name"`
+			PhoneNumber string `json:"phone_number"`
+		} `json:"contact,omitempty"`
+	} `json:"message,omitempty"`
+}
+
+// NewViberBot creates a new Viber bot instance
+func NewViberBot(authToken, senderName string, log *slog.Logger) *ViberBot {
+	if len([]rune(senderName)) > maxSenderNameLen {
+		senderName = string([]rune(senderName)[:maxSenderNameLen])
+	}
+	return &ViberBot{
+		log:        log.With(sl.Module("viberbot")),
+		authToken:  authToken,
+		senderName: senderName,
+	}
+}
+
+// SetChatEngine sets the unified chat engine for this bot.
+func (b *ViberBot) SetChatEngine(engine *chat.ChatEngine) {
+	b.chatEngine = engine
+}
+
+// StartWebhookQueue makes HandleWebhook store callbacks in a durable queue and
+// process them in the background, skipping messages that Viber delivers again.
+func (b *ViberBot) StartWebhookQueue(ctx context.Context, store webhook.Store) {
+	b.queue = webhook.NewQueue(store, platform, callbackSender, b.processQueued, b.log)
+	b.queue.Start(ctx)
+}
+
+// processQueued processes a callback taken from the webhook queue.
+func (b *ViberBot) processQueued(body []byte) error {
+	var callback Callback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return fmt.Errorf("failed to parse queued callback: %w", err)
+	}
+	return b.processCallback(callback)
+}
+
+// callbackSender returns the user a queued callback comes from.
+func callbackSender(body []byte) string {
+	var callback Callback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return ""
+	}
+	if callback.Sender != nil {
+		return callback.Sender.ID
+	}
+	return callback.UserID
+}
+
+// RegisterWebhook points the bot's callbacks at webhookURL. Viber checks the URL by
+// calling it while the request is in progress, so it is retried until the HTTP
+// server answers or ctx is cancelled.
+func (b *ViberBot) RegisterWebhook(ctx context.Context, webhookURL string) {
+	go func() {
+		for {
+			err := b.setWebhook(webhookURL)
+			if err == nil {
+				b.log.Info("webhook registered", slog.String("url", webhookURL))
+				return
+			}
+			b.log.Warn("failed to register webhook", sl.Err(err))
+
+			select {
+			case <-time.After(webhookRetryInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (b *ViberBot) setWebhook(webhookURL string) error {
+	payload := map[string]interface{}{
+		"url":         webhookURL,
+		"event_types": []string{"delivered", "seen", "failed", "subscribed", "unsubscribed", "conversation_started"},
+		"send_name":   true,
+	}
+	_, err := b.post("set_webhook", payload)
+	return err
+}
+
+// HandleWebhook handles incoming webhook POST requests
+func (b *ViberBot) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		b.log.Error("failed to read request body", sl.Err(err))
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if !b.verifySignature(body, r.Header.Get(signatureHeader)) {
+		b.log.Warn("invalid webhook signature")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	var callback Callback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		b.log.Error("failed to parse webhook callback", sl.Err(err))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// The URL check sent by set_webhook only needs a 200
+	if callback.Event == "webhook" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Store the callback before acknowledging; a failed store makes Viber retry the delivery
+	if b.queue != nil {
+		if err := b.queue.Enqueue(body); err != nil {
+			b.log.Error("failed to queue webhook callback", sl.Err(err))
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	// Process messages asynchronously
+	go func() {
+		if err := b.processCallback(callback); err != nil {
+			b.log.Error("failed to process webhook callback", sl.Err(err))
+		}
+	}()
+}
+
+// processCallback processes a webhook callback. A message that could not be stored
+// is handled again when the callback is retried.
+func (b *ViberBot) processCallback(callback Callback) error {
+	switch callback.Event {
+	case "delivered":
+		b.updateStatus(callback.MessageToken.String(), entity.MessageDelivered, "")
+	case "seen":
+		b.updateStatus(callback.MessageToken.String(), entity.MessageRead, "")
+	case "failed":
+		b.updateStatus(callback.MessageToken.String(), entity.MessageFailed, callback.Desc)
+	case "message":
+		if callback.Sender == nil || callback.Message == nil {
+			return nil
+		}
+		var attachments []chat.Attachment
+		return b.queue.Deliver(callback.MessageToken.String(), func() error {
+			var err error
+			attachments, err = b.saveMessage(callback)
+			return err
+		}, func() error {
+			return b.handleMessage(callback, attachments)
+		})
+	case "subscribed", "unsubscribed", "conversation_started":
+		b.log.Debug("viber event", slog.String("event", callback.Event))
+	}
+	return nil
+}
+
+// saveMessage stores a user message for the CRM and returns its stored files.
+func (b *ViberBot) saveMessage(callback Callback) ([]chat.Attachment, error) {
+	if b.chatEngine == nil {
+		return nil, nil
+	}
+	listener := b.chatEngine.GetMessageListener()
+	if listener == nil {
+		return nil, nil
+	}
+	senderID := callback.Sender.ID
+	message := callback.Message
+
+	if callback.Sender.Name != "" {
+		listener.UpdateUserPlatformInfo(platform, senderID, callback.Sender.Name)
+	}
+
+	switch message.Type {
+	case "picture", "video", "file":
+		if message.Media == "" {
+			return nil, nil
+		}
+		stored, ok := b.downloadAndUploadAttachment(listener, senderID, message.Media, message.FileName, message.Text)
+		if !ok {
+			return nil, fmt.Errorf("failed to store attachment from %s", senderID)
+		}
+		return []chat.Attachment{chat.NewStoredAttachment(listener, attachmentType(message.Type, stored.MIMEType), stored)}, nil
+
+	case "text", "url":
+		// Inline buttons are silent: save the button text
+		text := messageText(callback)
+		if _, title, ok := vbmessenger.ParseCallback(text); ok {
+			text = title
+		}
+		b.saveIncomingText(senderID, text)
+	}
+	return nil, nil
+}
+
+// handleMessage routes a user message and its stored files to the chat engine.
+func (b *ViberBot) handleMessage(callback Callback, attachments []chat.Attachment) error {
+	if b.chatEngine == nil {
+		return nil
+	}
+	senderID := callback.Sender.ID
+	message := callback.Message
+	messenger := vbmessenger.NewMessenger(b)
+	ctx := context.Background()
+
+	switch message.Type {
+	case "contact":
+		// Shared with the share-phone button
+		if message.Contact == nil || message.Contact.PhoneNumber == "" {
+			return nil
+		}
+		return b.chatEngine.HandleContact(ctx, messenger, platform, senderID, senderID, message.Contact.PhoneNumber)
+
+	case "picture", "video", "file":
+		if len(attachments) == 0 {
+			return nil
+		}
+		return b.chatEngine.HandleAttachments(ctx, messenger, platform, senderID, senderID, message.Text, attachments)
+
+	case "text", "url":
+		text := messageText(callback)
+		if text == "" {
+			return nil
+		}
+
+		// Pass the data of an inline button as a callback
+		if data, _, ok := vbmessenger.ParseCallback(text); ok {
+			return b.chatEngine.HandleCallback(ctx, messenger, platform, senderID, senderID, data, "")
+		}
+		return b.chatEngine.HandleMessage(ctx, messenger, platform, senderID, senderID, text)
+	}
+
+	// Stickers and locations are not supported by the workflows
+	return nil
+}
+
+// messageText returns the text of a text message or the link of a url message.
+func messageText(callback Callback) string {
+	if callback.Message.Text != "" {
+		return callback.Message.Text
+	}
+	return callback.Message.Media
+}
+
+// updateStatus passes a delivered, seen or failed receipt on to the CRM.
+func (b *ViberBot) updateStatus(token string, status entity.MessageStatus, errText string) {
+	if b.chatEngine == nil {
+		return
+	}
+	listener := b.chatEngine.GetMessageListener()
+	if listener == nil {
+		return
+	}
+	listener.UpdateMessageStatus(platform, token, status, errText)
+}
+
+// saveIncomingText stores an incoming text message for the CRM.
+func (b *ViberBot) saveIncomingText(senderID, text string) {
+	if text == "" {
+		return
+	}
+	listener := b.chatEngine.GetMessageListener()
+	if listener == nil {
+		return
+	}
+
+	listener.SaveAndBroadcastChatMessage(entity.ChatMessage{
+		Platform:  platform,
+		UserID:    senderID,
+		ChatID:    senderID,
+		Direction: "incoming",
+		Sender:    "user",
+		Text:      text,
+		CreatedAt: time.Now(),
+	})
+}
+
+// downloadAndUploadAttachment downloads a file from a URL and uploads it to GridFS via the listener.
+// It returns the stored attachment and reports false when the file could not be stored.
+func (b *ViberBot) downloadAndUploadAttachment(listener chat.MessageListener, senderID, fileURL, filename, caption string) (entity.Attachment, bool) {
+	resp, err := http.Get(fileURL)
+	if err != nil {
+		b.log.Error("failed to download Viber attachment",
+			slog.String("sender_id", senderID),
+			slog.String("url", fileURL),
+			sl.Err(err),
+		)
+		return entity.Attachment{}, false
+	}
+	defer resp.Body.Close()
+
+	// Determine filename and MIME type
+	mimeType := resp.Header.Get("Content-Type")
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	if filename == "" {
+		filename = "attachment"
+		if parsed, err := url.Parse(fileURL); err == nil {
+			base := path.Base(parsed.Path)
+			if base != "" && base != "." && base != "/" {
+				filename = base
+			}
+		}
+	}
+
+	stored, err := listener.UploadAndSaveFile(platform, senderID, resp.Body, filename, mimeType, resp.ContentLength, caption)
+	if err != nil {
+		b.log.Error("failed to upload Viber attachment",
+			slog.String("sender_id", senderID),
+			sl.Err(err),
+		)
+		if errors.Is(err, entity.ErrFileTooLarge) {
+			limitMB := entity.MaxFileSize >> 20
+			locale := b.chatEngine.UserLocale(context.Background(), platform, senderID)
+			text := i18n.T(locale, "error.file_too_large", limitMB)
+			_, _ = b.SendMessage(senderID, text)
+		}
+		return entity.Attachment{}, false
+	}
+
+	return stored, true
+}
+
+// attachmentType maps a Viber message type to the engine's type.
+func attachmentType(messageType, mimeType string) chat.AttachmentType {
+	switch messageType {
+	case "picture":
+		return chat.AttachmentImage
+	case "video":
+		return chat.AttachmentVideo
+	}
+	return chat.AttachmentTypeFromMIME(mimeType)
+}
+
+// SendMessage sends a text message to the receiver and returns its message token.
+func (b *ViberBot) SendMessage(receiverID, text string) (string, error) {
+	payload := b.message(receiverID, "text")
+	payload["text"] = text
+	return b.post("send_message", payload)
+}
+
+// SendKeyboard sends a text message with a custom keyboard.
+func (b *ViberBot) SendKeyboard(receiverID, text string, keyboard vbmessenger.Keyboard) error {
+	payload := b.message(receiverID, "text")
+	payload["text"] = text
+	payload["keyboard"] = keyboard
+	payload["min_api_version"] = keyboardAPIVersion
+	_, err := b.post("send_message", payload)
+	return err
+}
+
+// SendMediaMessage sends a picture, file or link and returns its message token.
+func (b *ViberBot) SendMediaMessage(receiverID string, media vbmessenger.Media) (string, error) {
+	payload := b.message(receiverID, media.Type)
+	payload["media"] = media.URL
+	if media.Type == "file" {
+		payload["file_name"] = media.FileName
+		payload["size"] = media.Size
+	}
+	return b.post("send_message", payload)
+}
+
+// message returns the fields shared by every outgoing message.
+func (b *ViberBot) message(receiverID, messageType string) map[string]interface{} {
+	return map[string]interface{}{
+		"receiver": receiverID,
+		"type":     messageType,
+		"sender":   map[string]string{"name": b.senderName},
+	}
+}
+
+// post calls a Viber API method and returns the message token from the response, if any.
+// Viber answers 200 for failed calls too; a non-zero status is the error.
+func (b *ViberBot) post(method string, payload any) (string, error) {
+	jsonBody, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, apiURL+"/"+method, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Viber-Auth-Token", b.authToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Status        int         `json:"status"`
+		StatusMessage string      `json:"status_message"`
+		MessageToken  json.Number `json:"message_token"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.Status != 0 {
+		return "", fmt.Errorf("API error (status %d): %s", result.Status, result.StatusMessage)
+	}
+	return result.MessageToken.String(), nil
+}
+
+// verifySignature verifies the X-Viber-Content-Signature header
+func (b *ViberBot) verifySignature(body []byte, signature string) bool {
+	if signature == "" {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(b.authToken))
+	mac.Write(body)
+	actualSig := hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(signature), []byte(actualSig))
+}
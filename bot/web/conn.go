@@ -0,0 +1,110 @@
+package web
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = 30 * time.Second
+	maxMessageSize = 4096
+	// eventLimit is how many events a socket may send per eventPeriod; a socket that
+	// sends more is closed. Session requests are rate limited per address on top of it.
+	eventLimit  = 30
+	eventPeriod = time.Minute
+)
+
+// visitorConn is a single widget connection. A visitor may have several, one per open tab.
+type visitorConn struct {
+	wc        *WebChat
+	visitorID string
+	conn      *websocket.Conn
+	send      chan []byte
+	// incoming queues visitor events so they are handled in order while
+	// readPump keeps answering pings during slow replies.
+	incoming chan []byte
+}
+
+// readPump pumps events from the connection to processIncoming.
+// It handles ping/pong keepalive and detects disconnects.
+func (vc *visitorConn) readPump() {
+	defer func() {
+		vc.wc.unregister(vc)
+		close(vc.incoming)
+		vc.conn.Close()
+	}()
+
+	vc.conn.SetReadLimit(maxMessageSize)
+	vc.conn.SetReadDeadline(time.Now().Add(pongWait))
+	vc.conn.SetPongHandler(func(string) error {
+		vc.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	windowStart := time.Now()
+	events := 0
+	for {
+		_, msg, err := vc.conn.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		if now := time.Now(); now.Sub(windowStart) >= eventPeriod {
+			windowStart, events = now, 0
+		}
+		events++
+		if events > eventLimit {
+			vc.wc.log.Warn("closing visitor socket, too many events", slog.String("visitor_id", vc.visitorID))
+			closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "too many messages")
+			vc.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(writeWait))
+			break
+		}
+
+		select {
+		case vc.incoming <- msg:
+		default:
+			// The visitor is sending faster than the bot answers
+			vc.wc.log.Warn("dropping visitor event, queue is full")
+		}
+	}
+}
+
+// processIncoming hands visitor events to the chat engine one at a time.
+func (vc *visitorConn) processIncoming() {
+	for msg := range vc.incoming {
+		vc.wc.handleEvent(vc.visitorID, msg)
+	}
+}
+
+// writePump pumps events from the channel to the connection.
+func (vc *visitorConn) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		vc.conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-vc.send:
+			vc.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				vc.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := vc.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			vc.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := vc.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package web
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"DarkCS/bot/chat"
+	webmessenger "DarkCS/bot/chat/web"
+	"DarkCS/entity"
+	"DarkCS/internal/lib/sl"
+)
+
+// platform is the chat platform name used in chat states, CRM messages and user linking.
+const platform = "web"
+
+const (
+	// maxPendingEvents is how many messages are kept for a visitor with no open connection.
+	maxPendingEvents = 50
+	// maxPendingVisitors bounds the number of offline visitors with kept messages.
+	maxPendingVisitors = 1000
+	// pendingTTL is how long messages are kept for a visitor who does not come back.
+	pendingTTL = 24 * time.Hour
+)
+
+// Session identifies an anonymous website visitor. The token is the visitor ID
+// signed with the session secret; the widget stores it to resume the chat.
+type Session struct {
+	VisitorID string `json:"visitor_id"`
+	Token     string `json:"token"`
+}
+
+// Event is a message on the visitor WebSocket, in either direction.
+type Event struct {
+	Type string `json:"type"`
+	Data any    `json:"data,omitempty"`
+}
+
+// WebChat is the website chat widget channel. Visitors get a session from
+// NewSession and connect to ServeWs; their messages go through the ChatEngine
+// like those of any messenger, and bot replies come back over the socket.
+type WebChat struct {
+	log            *slog.Logger
+	secret         []byte
+	allowedOrigins []string
+	chatEngine     *chat.ChatEngine
+	upgrader       websocket.Upgrader
+
+	mu      sync.Mutex
+	conns   map[string]map[*visitorConn]struct{}
+	pending map[string]*pendingEvents
+}
+
+// pendingEvents holds messages sent while the visitor had no open connection.
+type pendingEvents struct {
+	events  [][]byte
+	updated time.Time
+}
+
+// NewWebChat creates the web chat channel. secret signs visitor tokens.
+// allowedOrigins lists the sites that may open the widget socket; empty rejects every site.
+func NewWebChat(secret string, allowedOrigins []string, log *slog.Logger) *WebChat {
+	wc := &WebChat{
+		log:            log.With(sl.Module("webchat")),
+		secret:         []byte(secret),
+		allowedOrigins: allowedOrigins,
+		conns:          make(map[string]map[*visitorConn]struct{}),
+		pending:        make(map[string]*pendingEvents),
+	}
+	wc.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 4096,
+		CheckOrigin:     wc.checkOrigin,
+	}
+	return wc
+}
+
+// SetChatEngine sets the unified chat engine for this channel.
+func (wc *WebChat) SetChatEngine(engine *chat.ChatEngine) {
+	wc.chatEngine = engine
+}
+
+// NewSession resumes the visitor of a valid token, or starts a new visitor.
+func (wc *WebChat) NewSession(token string) Session {
+	if visitorID, ok := wc.visitorID(token); ok {
+		return Session{VisitorID: visitorID, Token: token}
+	}
+	visitorID := uuid.NewString()
+	return Session{VisitorID: visitorID, Token: visitorID + "." + wc.sign(visitorID)}
+}
+
+// visitorID returns the visitor of a signed token.
+func (wc *WebChat) visitorID(token string) (string, bool) {
+	visitorID, sig, ok := strings.Cut(token, ".")
+	if !ok || visitorID == "" {
+		return "", false
+	}
+	return visitorID, hmac.Equal([]byte(sig), []byte(wc.sign(visitorID)))
+}
+
+func (wc *WebChat) sign(visitorID string) string {
+	mac := hmac.New(sha256.New, wc.secret)
+	mac.Write([]byte(visitorID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (wc *WebChat) checkOrigin(r *http.Request) bool {
+	return slices.Contains(wc.allowedOrigins, r.Header.Get("Origin"))
+}
+
+// ServeWs upgrades a visitor connection. The session token is passed as the token query parameter.
+// Messages sent while the visitor was away are delivered first.
+func (wc *WebChat) ServeWs(w http.ResponseWriter, r *http.Request) {
+	visitorID, ok := wc.visitorID(r.URL.Query().Get("token"))
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := wc.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		wc.log.Error("websocket upgrade failed", sl.Err(err))
+		return
+	}
+
+	vc := &visitorConn{
+		wc:        wc,
+		visitorID: visitorID,
+		conn:      conn,
+		send:      make(chan []byte, 256),
+		incoming:  make(chan []byte, 16),
+	}
+	wc.register(vc)
+
+	go vc.writePump()
+	go vc.processIncoming()
+	go vc.readPump()
+}
+
+func (wc *WebChat) register(vc *visitorConn) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+
+	if wc.conns[vc.visitorID] == nil {
+		wc.conns[vc.visitorID] = make(map[*visitorConn]struct{})
+	}
+	wc.conns[vc.visitorID][vc] = struct{}{}
+
+	if p, ok := wc.pending[vc.visitorID]; ok {
+		delete(wc.pending, vc.visitorID)
+		for _, data := range p.events {
+			vc.send <- data
+		}
+	}
+}
+
+func (wc *WebChat) unregister(vc *visitorConn) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+
+	if _, ok := wc.conns[vc.visitorID][vc]; !ok {
+		return
+	}
+	delete(wc.conns[vc.visitorID], vc)
+	if len(wc.conns[vc.visitorID]) == 0 {
+		delete(wc.conns, vc.visitorID)
+	}
+	close(vc.send)
+}
+
+// SendMessage delivers a bot message to every open connection of the visitor.
+// Without one, the message is kept until the visitor reconnects.
+func (wc *WebChat) SendMessage(visitorID string, msg webmessenger.Message) error {
+	data, err := json.Marshal(Event{Type: "message", Data: msg})
+	if err != nil {
+		return err
+	}
+
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+
+	if len(wc.conns[visitorID]) == 0 {
+		wc.keepLocked(visitorID, data)
+		return nil
+	}
+	wc.broadcastLocked(visitorID, data)
+	return nil
+}
+
+// SendTyping shows the typing indicator on the visitor's open connections.
+func (wc *WebChat) SendTyping(visitorID string) error {
+	data, err := json.Marshal(Event{Type: "typing"})
+	if err != nil {
+		return err
+	}
+
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	wc.broadcastLocked(visitorID, data)
+	return nil
+}
+
+func (wc *WebChat) broadcastLocked(visitorID string, data []byte) {
+	for vc := range wc.conns[visitorID] {
+		select {
+		case vc.send <- data:
+		default:
+			// A connection that does not keep up is dropped; the widget reconnects
+			delete(wc.conns[visitorID], vc)
+			close(vc.send)
+		}
+	}
+}
+
+// keepLocked stores a message for an offline visitor, dropping the oldest ones over the limit.
+func (wc *WebChat) keepLocked(visitorID string, data []byte) {
+	now := time.Now()
+	p, ok := wc.pending[visitorID]
+	if !ok {
+		if len(wc.pending) >= maxPendingVisitors {
+			for id, other := range wc.pending {
+				if now.Sub(other.updated) > pendingTTL {
+					delete(wc.pending, id)
+				}
+			}
+		}
+		if len(wc.pending) >= maxPendingVisitors {
+			wc.log.Warn("dropping message for offline visitor", slog.String("visitor_id", visitorID))
+			return
+		}
+		p = &pendingEvents{}
+		wc.pending[visitorID] = p
+	}
+	p.events = append(p.events, data)
+	if len(p.events) > maxPendingEvents {
+		p.events = p.events[len(p.events)-maxPendingEvents:]
+	}
+	p.updated = now
+}
+
+// visitorEvent is an event sent by the widget.
+type visitorEvent struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// handleEvent routes a widget event to the chat engine:
+//   - message {"text"}: typed text or a menu button;
+//   - callback {"data", "text", "message_id"}: an inline button, with its label for the CRM;
+//   - contact {"phone"}: a phone number entered for a contact request.
+func (wc *WebChat) handleEvent(visitorID string, raw []byte) {
+	if wc.chatEngine == nil {
+		return
+	}
+
+	var event visitorEvent
+	if err := json.Unmarshal(raw, &event); err != nil {
+		wc.log.Warn("failed to parse visitor event", sl.Err(err))
+		return
+	}
+	var data struct {
+		Text      string `json:"text"`
+		Data      string `json:"data"`
+		MessageID string `json:"message_id"`
+		Phone     string `json:"phone"`
+	}
+	if len(event.Data) > 0 {
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			wc.log.Warn("failed to parse visitor event data", slog.String("type", event.Type), sl.Err(err))
+			return
+		}
+	}
+
+	messenger := webmessenger.NewMessenger(wc)
+	ctx := context.Background()
+
+	var err error
+	switch event.Type {
+	case "message":
+		text := strings.TrimSpace(data.Text)
+		if text == "" {
+			return
+		}
+		wc.saveIncomingText(visitorID, text)
+		err = wc.chatEngine.HandleMessage(ctx, messenger, platform, visitorID, visitorID, text)
+	case "callback":
+		if data.Data == "" {
+			return
+		}
+		wc.saveIncomingText(visitorID, data.Text)
+		err = wc.chatEngine.HandleCallback(ctx, messenger, platform, visitorID, visitorID, data.Data, data.MessageID)
+	case "contact":
+		if data.Phone == "" {
+			return
+		}
+		err = wc.chatEngine.HandleContact(ctx, messenger, platform, visitorID, visitorID, data.Phone)
+	default:
+		return
+	}
+
+	if err != nil {
+		wc.log.Error("chat engine error",
+			slog.String("visitor_id", visitorID),
+			sl.Err(err),
+		)
+	}
+}
+
+// saveIncomingText stores a visitor message for the CRM.
+func (wc *WebChat) saveIncomingText(visitorID, text string) {
+	if text == "" {
+		return
+	}
+	listener := wc.chatEngine.GetMessageListener()
+	if listener == nil {
+		return
+	}
+
+	listener.SaveAndBroadcastChatMessage(entity.ChatMessage{
+		Platform:  platform,
+		UserID:    visitorID,
+		ChatID:    visitorID,
+		Direction: "incoming",
+		Sender:    "user",
+		Text:      text,
+		CreatedAt: time.Now(),
+	})
+}
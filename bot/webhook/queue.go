@@ -0,0 +1,308 @@
+package webhook
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"DarkCS/entity"
+	"DarkCS/internal/lib/sl"
+)
+
+const (
+	// workers is the number of payloads processed concurrently. Processing waits for
+	// the chat engine, so one slow AI reply must not hold up other users. Payloads of
+	// one sender always go to the same worker, so they are processed in order.
+	workers = 8
+	// backlog is how many claimed payloads an instance holds at most, waiting or running.
+	// Every lane has room for all of them, so a busy sender never blocks the dispatcher.
+	backlog = 64
+	// lease is how long a claimed payload stays hidden from other instances. The lease
+	// of every held payload is renewed on each poll, so it only expires after a crash.
+	lease = 5 * time.Minute
+	// pollInterval renews held leases and picks up payloads whose lease expired or
+	// that were queued by another instance.
+	pollInterval = 30 * time.Second
+	// maxAttempts is how many times a payload is processed before it is dropped.
+	maxAttempts = 5
+	// retryDelay is the wait before the first retry of a failed payload; it doubles
+	// with every further attempt.
+	retryDelay = 2 * time.Second
+)
+
+// Store persists queued webhook payloads and remembers processed message IDs.
+type Store interface {
+	EnqueueWebhook(platform string, payload []byte) error
+	ClaimWebhook(platform string, lease time.Duration) (*entity.WebhookEvent, error)
+	StartWebhook(id primitive.ObjectID, lease time.Duration) (int, error)
+	RenewWebhooks(ids []primitive.ObjectID, lease time.Duration) error
+	DeleteWebhook(id primitive.ObjectID) error
+	MarkWebhookMessageSeen(platform, messageID string) (bool, error)
+	ForgetWebhookMessage(platform, messageID string) error
+}
+
+// Queue lets a webhook handler acknowledge a delivery as soon as the payload is stored.
+// Payloads are processed in the background and survive a restart; the platform's retries
+// of a delivery are filtered out per message with Deliver.
+type Queue struct {
+	store      Store
+	platform   string
+	sender     func(payload []byte) string
+	process    func(payload []byte) error
+	log        *slog.Logger
+	wake       chan struct{}
+	retryDelay time.Duration
+
+	mu   sync.Mutex
+	held map[primitive.ObjectID]bool
+}
+
+// NewQueue creates a queue for a platform's webhooks. sender returns the ID of the
+// user a payload comes from and process handles one payload. A payload whose
+// processing returns an error is retried by its worker after a growing delay, so
+// the sender's later payloads wait for it and are processed in order.
+func NewQueue(store Store, platform string, sender func(payload []byte) string, process func(payload []byte) error, log *slog.Logger) *Queue {
+	return &Queue{
+		store:      store,
+		platform:   platform,
+		sender:     sender,
+		process:    process,
+		log:        log.With(sl.Module("webhook"), slog.String("platform", platform)),
+		wake:       make(chan struct{}, 1),
+		retryDelay: retryDelay,
+		held:       make(map[primitive.ObjectID]bool),
+	}
+}
+
+// Enqueue stores a payload and wakes the dispatcher.
+func (q *Queue) Enqueue(payload []byte) error {
+	if err := q.store.EnqueueWebhook(q.platform, payload); err != nil {
+		return err
+	}
+	q.notify()
+	return nil
+}
+
+// Deliver handles a platform message unless the message was handled before. The
+// message is marked seen first; save then stores it, e.g. in the CRM, and handle
+// routes it to the chat engine. If save fails the message is forgotten again and the
+// error returned, so the retried payload stores it. handle runs at most once: its
+// errors are only logged, as a retry would store the message a second time.
+// If the store cannot be reached the message counts as new, so it is not lost.
+// A nil queue, used without MongoDB, treats every message as new and returns
+// handle's error.
+func (q *Queue) Deliver(messageID string, save, handle func() error) error {
+	if q == nil {
+		if err := save(); err != nil {
+			return err
+		}
+		return handle()
+	}
+	if messageID != "" {
+		first, err := q.store.MarkWebhookMessageSeen(q.platform, messageID)
+		if err != nil {
+			q.log.Error("failed to check webhook message", slog.String("message_id", messageID), sl.Err(err))
+		} else if !first {
+			q.log.Debug("skipping repeated webhook message", slog.String("message_id", messageID))
+			return nil
+		}
+	}
+
+	if err := save(); err != nil {
+		if messageID != "" {
+			if ferr := q.store.ForgetWebhookMessage(q.platform, messageID); ferr != nil {
+				q.log.Error("failed to forget webhook message", slog.String("message_id", messageID), sl.Err(ferr))
+			}
+		}
+		return err
+	}
+	if err := handle(); err != nil {
+		q.log.Error("failed to handle webhook message", slog.String("message_id", messageID), sl.Err(err))
+	}
+	return nil
+}
+
+// Start spawns the dispatcher and the workers. Payloads left from a previous run
+// are processed first. The workers stop when ctx is cancelled.
+func (q *Queue) Start(ctx context.Context) {
+	lanes := make([]chan *entity.WebhookEvent, workers)
+	for i := range lanes {
+		lanes[i] = make(chan *entity.WebhookEvent, backlog)
+		go q.work(ctx, lanes[i])
+	}
+	go q.run(ctx, lanes)
+	q.notify()
+}
+
+func (q *Queue) run(ctx context.Context, lanes []chan *entity.WebhookEvent) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	dispatch := func(event *entity.WebhookEvent) {
+		lanes[q.lane(event.Payload)] <- event
+	}
+	for {
+		q.drain(ctx, dispatch)
+		select {
+		case <-q.wake:
+		case <-ticker.C:
+			q.renew()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (q *Queue) work(ctx context.Context, lane <-chan *entity.WebhookEvent) {
+	for {
+		select {
+		case event := <-lane:
+			q.handle(ctx, event)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// lane picks the worker of a payload's sender.
+func (q *Queue) lane(payload []byte) int {
+	h := fnv.New32a()
+	h.Write([]byte(q.sender(payload)))
+	return int(h.Sum32() % workers)
+}
+
+// drain claims payloads in the order they were queued and passes each to dispatch,
+// until the queue is empty or backlog payloads are held. A payload this instance
+// already holds is claimed again only when its lease could not be renewed; it is
+// skipped, so it never runs twice.
+func (q *Queue) drain(ctx context.Context, dispatch func(event *entity.WebhookEvent)) {
+	for ctx.Err() == nil && q.holding() < backlog {
+		event, err := q.store.ClaimWebhook(q.platform, lease)
+		if err != nil {
+			q.log.Error("failed to claim webhook payload", sl.Err(err))
+			return
+		}
+		if event == nil {
+			return
+		}
+		if q.hold(event) {
+			dispatch(event)
+		}
+	}
+}
+
+// handle processes a claimed payload and removes it from the queue. A failed payload
+// is retried after a growing delay until it has used its attempts. Only runs of
+// process count as attempts, including runs a crash interrupted.
+func (q *Queue) handle(ctx context.Context, event *entity.WebhookEvent) {
+	defer q.release(event)
+
+	delay := q.retryDelay
+	for {
+		attempts, err := q.store.StartWebhook(event.ID, lease)
+		if err != nil {
+			q.log.Error("failed to start webhook payload", slog.String("event_id", event.ID.Hex()), sl.Err(err))
+			return
+		}
+		if attempts == 0 {
+			// Removed from the queue meanwhile
+			return
+		}
+		// Attempts that never finished, e.g. because the process was restarted
+		if attempts > maxAttempts {
+			q.log.Error("dropping unfinished webhook payload",
+				slog.String("event_id", event.ID.Hex()),
+				slog.Int("attempts", attempts-1),
+			)
+			q.delete(event)
+			return
+		}
+
+		err = q.process(event.Payload)
+		if err == nil {
+			q.delete(event)
+			return
+		}
+		if attempts == maxAttempts {
+			q.log.Error("dropping webhook payload after repeated failures",
+				slog.String("event_id", event.ID.Hex()),
+				slog.Int("attempts", attempts),
+				sl.Err(err),
+			)
+			q.delete(event)
+			return
+		}
+		q.log.Error("failed to process webhook payload",
+			slog.String("event_id", event.ID.Hex()),
+			slog.Int("attempt", attempts),
+			sl.Err(err),
+		)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			// Left in the queue for the next run
+			return
+		}
+		delay *= 2
+	}
+}
+
+// hold records a claimed payload as held by this instance. It reports false when
+// the payload is held already.
+func (q *Queue) hold(event *entity.WebhookEvent) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.held[event.ID] {
+		return false
+	}
+	q.held[event.ID] = true
+	return true
+}
+
+// release forgets a finished payload and wakes the dispatcher, which may have
+// stopped claiming at the backlog limit.
+func (q *Queue) release(event *entity.WebhookEvent) {
+	q.mu.Lock()
+	delete(q.held, event.ID)
+	q.mu.Unlock()
+	q.notify()
+}
+
+func (q *Queue) holding() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.held)
+}
+
+// renew extends the lease of every held payload, so payloads waiting behind a
+// busy sender are not claimed again.
+func (q *Queue) renew() {
+	q.mu.Lock()
+	ids := make([]primitive.ObjectID, 0, len(q.held))
+	for id := range q.held {
+		ids = append(ids, id)
+	}
+	q.mu.Unlock()
+
+	if err := q.store.RenewWebhooks(ids, lease); err != nil {
+		q.log.Error("failed to renew webhook payloads", sl.Err(err))
+	}
+}
+
+func (q *Queue) delete(event *entity.WebhookEvent) {
+	if err := q.store.DeleteWebhook(event.ID); err != nil {
+		q.log.Error("failed to delete webhook payload", sl.Err(err))
+	}
+}
+
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
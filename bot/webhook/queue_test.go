@@ -0,0 +1,477 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"DarkCS/entity"
+)
+
+// memoryStore is a Store with the claim and lease semantics of the MongoDB store
+// and a clock the tests move forward.
+type memoryStore struct {
+	mu     sync.Mutex
+	now    time.Time
+	events []*entity.WebhookEvent
+	seen   map[string]bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{now: time.Now(), seen: make(map[string]bool)}
+}
+
+func (s *memoryStore) advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = s.now.Add(d)
+}
+
+func (s *memoryStore) EnqueueWebhook(platform string, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, &entity.WebhookEvent{
+		ID:          primitive.NewObjectID(),
+		Platform:    platform,
+		Payload:     payload,
+		LockedUntil: s.now,
+		CreatedAt:   s.now,
+	})
+	return nil
+}
+
+func (s *memoryStore) ClaimWebhook(platform string, lease time.Duration) (*entity.WebhookEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, event := range s.events {
+		if event.Platform == platform && !event.LockedUntil.After(s.now) {
+			event.LockedUntil = s.now.Add(lease)
+			claimed := *event
+			return &claimed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) StartWebhook(id primitive.ObjectID, lease time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, event := range s.events {
+		if event.ID == id {
+			event.LockedUntil = s.now.Add(lease)
+			event.Attempts++
+			return event.Attempts, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *memoryStore) RenewWebhooks(ids []primitive.ObjectID, lease time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, event := range s.events {
+		for _, id := range ids {
+			if event.ID == id {
+				event.LockedUntil = s.now.Add(lease)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) DeleteWebhook(id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, event := range s.events {
+		if event.ID == id {
+			s.events = append(s.events[:i], s.events[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) MarkWebhookMessageSeen(platform, messageID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := platform + "/" + messageID
+	if s.seen[key] {
+		return false, nil
+	}
+	s.seen[key] = true
+	return true, nil
+}
+
+func (s *memoryStore) ForgetWebhookMessage(platform, messageID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.seen, platform+"/"+messageID)
+	return nil
+}
+
+func (s *memoryStore) queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.events)
+}
+
+// senderOf treats the payload as the sender ID.
+func senderOf(payload []byte) string {
+	return string(payload)
+}
+
+func newTestQueue(store Store, process func([]byte) error) *Queue {
+	q := NewQueue(store, "test", senderOf, process, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	q.retryDelay = time.Millisecond
+	return q
+}
+
+// drainNow processes the queued payloads one after another.
+func (q *Queue) drainNow(ctx context.Context) {
+	q.drain(ctx, func(event *entity.WebhookEvent) {
+		q.handle(ctx, event)
+	})
+}
+
+// senderPrefix treats the part of a "sender/n" payload before the slash as the sender ID.
+func senderPrefix(payload []byte) string {
+	return strings.Split(string(payload), "/")[0]
+}
+
+func TestFailedPayloadIsRetried(t *testing.T) {
+	store := newMemoryStore()
+	calls := 0
+	q := newTestQueue(store, func([]byte) error {
+		calls++
+		if calls == 1 {
+			return errors.New("engine unavailable")
+		}
+		return nil
+	})
+
+	if err := q.Enqueue([]byte("payload")); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	q.drainNow(context.Background())
+	if calls != 2 || store.queued() != 0 {
+		t.Fatalf("calls = %d, queued = %d, want 2 and 0", calls, store.queued())
+	}
+}
+
+func TestClaimedPayloadIsHiddenDuringLease(t *testing.T) {
+	store := newMemoryStore()
+	if err := store.EnqueueWebhook("test", []byte("payload")); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if event, _ := store.ClaimWebhook("test", lease); event == nil || event.Attempts != 0 {
+		t.Fatalf("claim = %+v, want the payload with no attempts counted", event)
+	}
+	if event, _ := store.ClaimWebhook("test", lease); event != nil {
+		t.Fatalf("claimed %s again within its lease", event.ID.Hex())
+	}
+	store.advance(lease)
+	if event, _ := store.ClaimWebhook("test", lease); event == nil {
+		t.Fatal("payload not claimed after its lease")
+	}
+}
+
+func TestPayloadIsDroppedAfterMaxAttempts(t *testing.T) {
+	store := newMemoryStore()
+	calls := 0
+	q := newTestQueue(store, func([]byte) error {
+		calls++
+		return errors.New("malformed")
+	})
+	ctx := context.Background()
+
+	if err := q.Enqueue([]byte("payload")); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	q.drainNow(ctx)
+	if calls != maxAttempts {
+		t.Errorf("calls = %d, want %d", calls, maxAttempts)
+	}
+	if store.queued() != 0 {
+		t.Errorf("queued = %d, want the payload dropped", store.queued())
+	}
+}
+
+func TestUnfinishedPayloadIsDropped(t *testing.T) {
+	store := newMemoryStore()
+	q := newTestQueue(store, func([]byte) error {
+		t.Error("a payload over the attempt limit must not be processed")
+		return nil
+	})
+
+	if err := q.Enqueue([]byte("payload")); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	// Earlier attempts were claimed but never finished, e.g. the process crashed
+	store.events[0].Attempts = maxAttempts
+
+	q.drainNow(context.Background())
+	if store.queued() != 0 {
+		t.Errorf("queued = %d, want the payload dropped", store.queued())
+	}
+}
+
+func TestPayloadsOfOneSenderAreProcessedInOrder(t *testing.T) {
+	store := newMemoryStore()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan string, 4)
+	q := newTestQueue(store, func(payload []byte) error {
+		if string(payload) == "alice/1" {
+			close(started)
+			<-release
+		}
+		done <- string(payload)
+		return nil
+	})
+	q.sender = senderPrefix
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, payload := range []string{"alice/1", "alice/2", "bob/1"} {
+		if err := q.Enqueue([]byte(payload)); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+	q.Start(ctx)
+
+	<-started
+	// bob has a different worker and is not held up by alice's first payload
+	if got := <-done; got != "bob/1" {
+		t.Fatalf("processed %q while alice/1 was running, want bob/1", got)
+	}
+	close(release)
+	for _, want := range []string{"alice/1", "alice/2"} {
+		if got := <-done; got != want {
+			t.Fatalf("processed %q, want %q", got, want)
+		}
+	}
+}
+
+func TestFailedPayloadHoldsItsSender(t *testing.T) {
+	store := newMemoryStore()
+	var mu sync.Mutex
+	var order []string
+	failed := false
+	q := newTestQueue(store, func(payload []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, string(payload))
+		if string(payload) == "alice/1" && !failed {
+			failed = true
+			return errors.New("engine unavailable")
+		}
+		return nil
+	})
+	q.sender = senderPrefix
+
+	for _, payload := range []string{"alice/1", "alice/2"} {
+		if err := q.Enqueue([]byte(payload)); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+	q.drainNow(context.Background())
+
+	want := []string{"alice/1", "alice/1", "alice/2"}
+	if strings.Join(order, " ") != strings.Join(want, " ") {
+		t.Errorf("processed %v, want %v", order, want)
+	}
+}
+
+func TestSlowSenderDoesNotRepeatOrDropPayloads(t *testing.T) {
+	store := newMemoryStore()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var mu sync.Mutex
+	runs := make(map[string]int)
+	done := make(chan string, 32)
+	q := newTestQueue(store, func(payload []byte) error {
+		if string(payload) == "slow/0" {
+			close(started)
+			<-release
+		}
+		mu.Lock()
+		runs[string(payload)]++
+		mu.Unlock()
+		done <- string(payload)
+		return nil
+	})
+	q.sender = senderPrefix
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var payloads []string
+	for i := 0; i < backlog/2; i++ {
+		payloads = append(payloads, "slow/"+strconv.Itoa(i))
+	}
+	payloads = append(payloads, "fast/0", "fast/1")
+	for _, payload := range payloads {
+		if err := q.Enqueue([]byte(payload)); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+	q.Start(ctx)
+
+	<-started
+	timeout := time.After(5 * time.Second)
+	// The slow sender's backlog does not keep other senders waiting
+	for i := 0; i < 2; i++ {
+		select {
+		case got := <-done:
+			if !strings.HasPrefix(got, "fast/") {
+				t.Fatalf("processed %q while slow/0 was running, want the fast sender", got)
+			}
+		case <-timeout:
+			t.Fatal("the fast sender waited for the slow one")
+		}
+	}
+
+	// Leases run out while the slow sender's payloads wait; polling claims them again
+	for i := 0; i < 3; i++ {
+		store.advance(lease)
+		q.drainNow(ctx)
+	}
+	close(release)
+
+	for i := 0; i < backlog/2; i++ {
+		select {
+		case <-done:
+		case <-timeout:
+			t.Fatalf("only %d of %d slow payloads processed", i, backlog/2)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, payload := range payloads {
+		if runs[payload] != 1 {
+			t.Errorf("%s ran %d times, want 1", payload, runs[payload])
+		}
+	}
+	if store.queued() != 0 {
+		t.Errorf("queued = %d, want every payload processed", store.queued())
+	}
+}
+
+func TestDeliverSkipsRepeatedMessages(t *testing.T) {
+	q := newTestQueue(newMemoryStore(), nil)
+
+	saved, handled := 0, 0
+	save := func() error {
+		saved++
+		return nil
+	}
+	handle := func() error {
+		handled++
+		return nil
+	}
+	for i := 0; i < 3; i++ {
+		if err := q.Deliver("mid.1", save, handle); err != nil {
+			t.Fatalf("deliver: %v", err)
+		}
+	}
+	if saved != 1 || handled != 1 {
+		t.Errorf("saved = %d, handled = %d, want 1 and 1", saved, handled)
+	}
+}
+
+func TestDeliverSavesOnceWhenHandlingFails(t *testing.T) {
+	store := newMemoryStore()
+	saved, handled := 0, 0
+	var q *Queue
+	q = newTestQueue(store, func([]byte) error {
+		return q.Deliver("mid.1", func() error {
+			saved++
+			return nil
+		}, func() error {
+			handled++
+			return errors.New("step failed")
+		})
+	})
+	ctx := context.Background()
+
+	// Meta delivers the payload twice
+	for i := 0; i < 2; i++ {
+		if err := q.Enqueue([]byte("payload")); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+	q.drainNow(ctx)
+	store.advance(lease)
+	q.drainNow(ctx)
+
+	if saved != 1 || handled != 1 {
+		t.Errorf("saved = %d, handled = %d, want 1 and 1", saved, handled)
+	}
+	if store.queued() != 0 {
+		t.Errorf("queued = %d, want a failed step not to be retried", store.queued())
+	}
+}
+
+func TestDeliverForgetsUnsavedMessages(t *testing.T) {
+	q := newTestQueue(newMemoryStore(), nil)
+
+	failure := errors.New("storage unavailable")
+	handle := func() error {
+		t.Error("an unsaved message must not be handled")
+		return nil
+	}
+	if err := q.Deliver("mid.1", func() error { return failure }, handle); !errors.Is(err, failure) {
+		t.Fatalf("deliver error = %v, want %v", err, failure)
+	}
+
+	saved, handled := 0, 0
+	save := func() error {
+		saved++
+		return nil
+	}
+	handle = func() error {
+		handled++
+		return nil
+	}
+	for i := 0; i < 2; i++ {
+		if err := q.Deliver("mid.1", save, handle); err != nil {
+			t.Fatalf("deliver: %v", err)
+		}
+	}
+	if saved != 1 || handled != 1 {
+		t.Errorf("the retried message was saved %d and handled %d times, want 1 and 1", saved, handled)
+	}
+}
+
+func TestNilQueueDeliversEveryMessage(t *testing.T) {
+	var q *Queue
+
+	saved, handled := 0, 0
+	for i := 0; i < 2; i++ {
+		err := q.Deliver("mid.1", func() error {
+			saved++
+			return nil
+		}, func() error {
+			handled++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("deliver: %v", err)
+		}
+	}
+	if saved != 2 || handled != 2 {
+		t.Errorf("saved = %d, handled = %d, want 2 and 2", saved, handled)
+	}
+
+	failure := errors.New("step failed")
+	if err := q.Deliver("mid.2", func() error { return nil }, func() error { return failure }); !errors.Is(err, failure) {
+		t.Errorf("deliver error = %v, want %v", err, failure)
+	}
+}
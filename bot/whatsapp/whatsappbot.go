@@ -12,11 +12,15 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"DarkCS/bot/chat"
 	wamessenger "DarkCS/bot/chat/whatsapp"
+	"DarkCS/bot/webhook"
 	"DarkCS/entity"
+	"DarkCS/internal/lib/i18n"
 	"DarkCS/internal/lib/sl"
 )
 
@@ -30,6 +34,14 @@ type WhatsAppBot struct {
 	appSecret     string
 	phoneNumberID string
 	chatEngine    *chat.ChatEngine
+	queue         *webhook.Queue
+
+	// windowMu guards lastInbound and windowPruned.
+	windowMu          sync.Mutex
+	lastInbound       map[string]time.Time
+	windowPruned      time.Time
+	lastInboundLoader func(phone string) (time.Time, error)
+	templates         map[string]entity.MessageTemplate
 }
 
 // WebhookPayload represents the incoming webhook payload from WhatsApp
@@ -74,6 +86,7 @@ type WebhookPayload struct {
 					Audio *struct {
 						ID       string `json:"id"`
 						MIMEType string `json:"mime_type"`
+						Voice    bool   `json:"voice,omitempty"` // recorded in the app as a voice note
 					} `json:"audio,omitempty"`
 					Video *struct {
 						ID       string `json:"id"`
@@ -88,13 +101,45 @@ type WebhookPayload struct {
 						ID       string `json:"id"`
 						MIMEType string `json:"mime_type"`
 					} `json:"sticker,omitempty"`
+					Interactive *InteractiveReply `json:"interactive,omitempty"`
 				} `json:"messages"`
+				Statuses []MessageStatusUpdate `json:"statuses"`
 			} `json:"value"`
 			Field string `json:"field"`
 		} `json:"changes"`
 	} `json:"entry"`
 }
 
+// InteractiveReply is a tap on a reply button or a list row sent by the bot
+type InteractiveReply struct {
+	Type        string              `json:"type"` // "button_reply" or "list_reply"
+	ButtonReply *wamessenger.Option `json:"button_reply,omitempty"`
+	ListReply   *wamessenger.Option `json:"list_reply,omitempty"`
+}
+
+// option returns the tapped button or list row.
+func (r *InteractiveReply) option() *wamessenger.Option {
+	if r.ButtonReply != nil {
+		return r.ButtonReply
+	}
+	return r.ListReply
+}
+
+// MessageStatusUpdate reports that a message sent by the business was sent, delivered, read or failed
+type MessageStatusUpdate struct {
+	ID          string `json:"id"`
+	Status      string `json:"status"`
+	Timestamp   string `json:"timestamp"`
+	RecipientID string `json:"recipient_id"`
+	Errors      []struct {
+		Code      int    `json:"code"`
+		Title     string `json:"title"`
+		ErrorData struct {
+			Details string `json:"details"`
+		} `json:"error_data"`
+	} `json:"errors,omitempty"`
+}
+
 // SendMessageRequest represents the request body for sending a text message
 type SendMessageRequest struct {
 	MessagingProduct string `json:"messaging_product"`
@@ -115,6 +160,8 @@ func NewWhatsAppBot(accessToken, verifyToken, appSecret, phoneNumberID string, l
 		verifyToken:   verifyToken,
 		appSecret:     appSecret,
 		phoneNumberID: phoneNumberID,
+		lastInbound:   make(map[string]time.Time),
+		templates:     make(map[string]entity.MessageTemplate),
 	}
 }
 
@@ -123,6 +170,41 @@ func (b *WhatsAppBot) SetChatEngine(engine *chat.ChatEngine) {
 	b.chatEngine = engine
 }
 
+// StartWebhookQueue makes HandleWebhook store payloads in a durable queue and
+// process them in the background, skipping messages that Meta delivers again.
+func (b *WhatsAppBot) StartWebhookQueue(ctx context.Context, store webhook.Store) {
+	b.queue = webhook.NewQueue(store, "whatsapp", payloadSender, b.processQueued, b.log)
+	b.queue.Start(ctx)
+}
+
+// processQueued processes a payload taken from the webhook queue.
+func (b *WhatsAppBot) processQueued(body []byte) error {
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return fmt.Errorf("failed to parse queued payload: %w", err)
+	}
+	return b.processPayload(payload)
+}
+
+// payloadSender returns the phone number of the first message or status in a queued payload.
+func payloadSender(body []byte) string {
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	for _, entry := range payload.Entry {
+		for _, change := range entry.Changes {
+			for _, message := range change.Value.Messages {
+				return message.From
+			}
+			for _, status := range change.Value.Statuses {
+				return status.RecipientID
+			}
+		}
+	}
+	return ""
+}
+
 // HandleWebhookVerification handles the GET request for webhook verification
 func (b *WhatsAppBot) HandleWebhookVerification(w http.ResponseWriter, r *http.Request) {
 	mode := r.URL.Query().Get("hub.mode")
@@ -172,30 +254,55 @@ func (b *WhatsAppBot) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Store the payload before acknowledging; a failed store makes Meta retry the delivery
+	if b.queue != nil {
+		if err := b.queue.Enqueue(body); err != nil {
+			b.log.Error("failed to queue webhook payload", sl.Err(err))
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	// Always respond with 200 OK to acknowledge receipt
 	w.WriteHeader(http.StatusOK)
 
 	// Process messages asynchronously
-	go b.processPayload(payload)
+	go func() {
+		if err := b.processPayload(payload); err != nil {
+			b.log.Error("failed to process webhook payload", sl.Err(err))
+		}
+	}()
 }
 
-// processPayload processes the webhook payload
-func (b *WhatsAppBot) processPayload(payload WebhookPayload) {
+// processPayload processes the webhook payload. It returns the errors of the
+// messages that could not be stored; messages stored before are skipped when it
+// is retried.
+func (b *WhatsAppBot) processPayload(payload WebhookPayload) error {
 	if payload.Object != "whatsapp_business_account" {
-		return
+		return nil
 	}
 
+	var errs []error
+
 	for _, entry := range payload.Entry {
 		for _, change := range entry.Changes {
 			if change.Field != "messages" {
 				continue
 			}
 
+			for _, status := range change.Value.Statuses {
+				b.handleStatus(status)
+			}
+
 			for _, message := range change.Value.Messages {
 				senderPhone := message.From
+				b.touchWindow(senderPhone, messageTime(message.Timestamp))
 
 				// Handle media messages
 				var mediaID, mimeType, filename, caption string
+				var attType chat.AttachmentType
 				switch message.Type {
 				case "image":
 					if message.Image != nil {
@@ -203,6 +310,7 @@ func (b *WhatsAppBot) processPayload(payload WebhookPayload) {
 						mimeType = message.Image.MIMEType
 						filename = "image.jpg"
 						caption = message.Image.Caption
+						attType = chat.AttachmentImage
 					}
 				case "document":
 					if message.Document != nil {
@@ -210,12 +318,17 @@ func (b *WhatsAppBot) processPayload(payload WebhookPayload) {
 						mimeType = message.Document.MIMEType
 						filename = message.Document.Filename
 						caption = message.Document.Caption
+						attType = chat.AttachmentDocument
 					}
 				case "audio":
 					if message.Audio != nil {
 						mediaID = message.Audio.ID
 						mimeType = message.Audio.MIMEType
 						filename = "audio.ogg"
+						attType = chat.AttachmentAudio
+						if message.Audio.Voice {
+							attType = chat.AttachmentVoice
+						}
 					}
 				case "video":
 					if message.Video != nil {
@@ -223,77 +336,165 @@ func (b *WhatsAppBot) processPayload(payload WebhookPayload) {
 						mimeType = message.Video.MIMEType
 						filename = "video.mp4"
 						caption = message.Video.Caption
+						attType = chat.AttachmentVideo
 					}
 				case "voice":
 					if message.Voice != nil {
 						mediaID = message.Voice.ID
 						mimeType = message.Voice.MIMEType
 						filename = "voice.ogg"
+						attType = chat.AttachmentVoice
 					}
 				case "sticker":
 					if message.Sticker != nil {
 						mediaID = message.Sticker.ID
 						mimeType = message.Sticker.MIMEType
 						filename = "sticker.webp"
+						attType = chat.AttachmentImage
 					}
 				}
 
-				if mediaID != "" {
-					if b.chatEngine != nil {
-						if listener := b.chatEngine.GetMessageListener(); listener != nil {
-							b.downloadAndUploadMedia(listener, senderPhone, mediaID, mimeType, filename, caption)
+				var attachments []chat.Attachment
+				err := b.queue.Deliver(message.ID, func() error {
+					if b.chatEngine == nil {
+						return nil
+					}
+					listener := b.chatEngine.GetMessageListener()
+					if listener == nil {
+						return nil
+					}
+					if mediaID != "" {
+						stored, ok := b.downloadAndUploadMedia(listener, senderPhone, mediaID, mimeType, filename, caption)
+						if !ok {
+							return fmt.Errorf("failed to store media %s", mediaID)
 						}
+						attachments = []chat.Attachment{chat.NewStoredAttachment(listener, attType, stored)}
+						return nil
 					}
-					continue
-				}
 
-				// Handle text messages
-				if message.Type == "text" && message.Text != nil && message.Text.Body != "" {
-					text := message.Text.Body
-
-					// Save incoming message for CRM
-					if b.chatEngine != nil {
-						if listener := b.chatEngine.GetMessageListener(); listener != nil {
-							listener.SaveAndBroadcastChatMessage(entity.ChatMessage{
-								Platform:  "whatsapp",
-								UserID:    senderPhone,
-								ChatID:    senderPhone,
-								Direction: "incoming",
-								Sender:    "user",
-								Text:      text,
-								CreatedAt: time.Now(),
-							})
+					// Save incoming message or the chosen option for CRM
+					switch {
+					case message.Type == "interactive" && message.Interactive != nil:
+						if option := message.Interactive.option(); option != nil {
+							b.saveIncomingText(senderPhone, option.Title)
 						}
+					case message.Type == "text" && message.Text != nil && message.Text.Body != "":
+						b.saveIncomingText(senderPhone, message.Text.Body)
 					}
-
-					// Delegate to ChatEngine if available
-					if b.chatEngine != nil {
+					return nil
+				}, func() error {
+					// Route the file and its caption to the current step
+					if len(attachments) > 0 {
 						messenger := wamessenger.NewMessenger(b)
-						if err := b.chatEngine.HandleMessage(context.Background(), messenger, "whatsapp", senderPhone, senderPhone, text); err != nil {
-							b.log.Error("chat engine error",
+						return b.chatEngine.HandleAttachments(context.Background(), messenger, "whatsapp", senderPhone, senderPhone, caption, attachments)
+					}
+					if mediaID != "" {
+						return nil
+					}
+
+					// Handle taps on interactive buttons and list rows
+					if message.Type == "interactive" && message.Interactive != nil {
+						return b.handleInteractiveReply(senderPhone, message.Interactive)
+					}
+
+					// Handle text messages
+					if message.Type == "text" && message.Text != nil && message.Text.Body != "" {
+						text := message.Text.Body
+
+						// Delegate to ChatEngine if available
+						if b.chatEngine != nil {
+							messenger := wamessenger.NewMessenger(b)
+							return b.chatEngine.HandleMessage(context.Background(), messenger, "whatsapp", senderPhone, senderPhone, text)
+						}
+
+						// Fallback: echo
+						echoText := fmt.Sprintf("Echo: %s", text)
+						if _, err := b.SendMessage(senderPhone, echoText); err != nil {
+							b.log.Error("failed to send echo message",
 								slog.String("sender_phone", senderPhone),
 								sl.Err(err),
 							)
 						}
-						continue
-					}
-
-					// Fallback: echo
-					echoText := fmt.Sprintf("Echo: %s", text)
-					if err := b.SendMessage(senderPhone, echoText); err != nil {
-						b.log.Error("failed to send echo message",
-							slog.String("sender_phone", senderPhone),
-							sl.Err(err),
-						)
 					}
+					return nil
+				})
+				if err != nil {
+					errs = append(errs, fmt.Errorf("message %s from %s: %w", message.ID, senderPhone, err))
 				}
 			}
 		}
 	}
+	return errors.Join(errs...)
 }
 
-// SendMessage sends a text message to the specified recipient
-func (b *WhatsAppBot) SendMessage(recipientPhone, text string) error {
+// handleInteractiveReply routes a tap on a button or list row to the chat engine.
+// Menu replies are handled as the typed button text, other replies as callbacks.
+func (b *WhatsAppBot) handleInteractiveReply(senderPhone string, reply *InteractiveReply) error {
+	option := reply.option()
+	if option == nil || b.chatEngine == nil {
+		return nil
+	}
+
+	messenger := wamessenger.NewMessenger(b)
+	text, data := wamessenger.ParseReplyID(option.ID)
+
+	if text != "" {
+		return b.chatEngine.HandleMessage(context.Background(), messenger, "whatsapp", senderPhone, senderPhone, text)
+	}
+	return b.chatEngine.HandleCallback(context.Background(), messenger, "whatsapp", senderPhone, senderPhone, data, "")
+}
+
+// handleStatus passes a delivery status of an outgoing message on to the CRM.
+func (b *WhatsAppBot) handleStatus(update MessageStatusUpdate) {
+	if b.chatEngine == nil {
+		return
+	}
+	listener := b.chatEngine.GetMessageListener()
+	if listener == nil {
+		return
+	}
+	status, ok := entity.ParseMessageStatus(update.Status)
+	if !ok {
+		b.log.Debug("unknown message status", slog.String("status", update.Status))
+		return
+	}
+
+	var errText string
+	for _, e := range update.Errors {
+		if errText != "" {
+			errText += "; "
+		}
+		errText += fmt.Sprintf("%d %s", e.Code, e.Title)
+		if e.ErrorData.Details != "" {
+			errText += ": " + e.ErrorData.Details
+		}
+	}
+
+	listener.UpdateMessageStatus("whatsapp", update.ID, status, errText)
+}
+
+// saveIncomingText stores an incoming text message for the CRM.
+func (b *WhatsAppBot) saveIncomingText(senderPhone, text string) {
+	if b.chatEngine == nil {
+		return
+	}
+	listener := b.chatEngine.GetMessageListener()
+	if listener == nil {
+		return
+	}
+	listener.SaveAndBroadcastChatMessage(entity.ChatMessage{
+		Platform:  "whatsapp",
+		UserID:    senderPhone,
+		ChatID:    senderPhone,
+		Direction: "incoming",
+		Sender:    "user",
+		Text:      text,
+		CreatedAt: time.Now(),
+	})
+}
+
+// SendMessage sends a text message to the specified recipient and returns its message ID.
+func (b *WhatsAppBot) SendMessage(recipientPhone, text string) (string, error) {
 	reqBody := SendMessageRequest{
 		MessagingProduct: "whatsapp",
 		RecipientType:    "individual",
@@ -303,50 +504,34 @@ func (b *WhatsAppBot) SendMessage(recipientPhone, text string) error {
 	reqBody.Text.PreviewURL = false
 	reqBody.Text.Body = text
 
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	url := fmt.Sprintf("%s/%s/messages", graphAPIURL, b.phoneNumberID)
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	if err := b.checkWindow(recipientPhone); err != nil {
+		return "", err
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+b.accessToken)
-
-	resp, err := http.DefaultClient.Do(req)
+	messageID, err := b.postMessage(reqBody)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return "", err
 	}
 
 	b.log.Info("message sent successfully", slog.String("recipient_phone", recipientPhone))
-	return nil
+	return messageID, nil
 }
 
 // downloadAndUploadMedia downloads a media file from WhatsApp and uploads it to GridFS.
-func (b *WhatsAppBot) downloadAndUploadMedia(listener chat.MessageListener, senderPhone, mediaID, mimeType, filename, caption string) {
+// It returns the stored attachment and reports false when the file could not be stored.
+func (b *WhatsAppBot) downloadAndUploadMedia(listener chat.MessageListener, senderPhone, mediaID, mimeType, filename, caption string) (entity.Attachment, bool) {
 	// Step 1: Get media URL from WhatsApp
 	mediaURL := fmt.Sprintf("%s/%s", graphAPIURL, mediaID)
 	req, err := http.NewRequest(http.MethodGet, mediaURL, nil)
 	if err != nil {
 		b.log.Error("failed to create media request", slog.String("media_id", mediaID), sl.Err(err))
-		return
+		return entity.Attachment{}, false
 	}
 	req.Header.Set("Authorization", "Bearer "+b.accessToken)
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		b.log.Error("failed to get media URL", slog.String("media_id", mediaID), sl.Err(err))
-		return
+		return entity.Attachment{}, false
 	}
 	defer resp.Body.Close()
 
@@ -355,21 +540,21 @@ func (b *WhatsAppBot) downloadAndUploadMedia(listener chat.MessageListener, send
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&mediaInfo); err != nil {
 		b.log.Error("failed to decode media info", slog.String("media_id", mediaID), sl.Err(err))
-		return
+		return entity.Attachment{}, false
 	}
 
 	// Step 2: Download the actual file
 	dlReq, err := http.NewRequest(http.MethodGet, mediaInfo.URL, nil)
 	if err != nil {
 		b.log.Error("failed to create download request", sl.Err(err))
-		return
+		return entity.Attachment{}, false
 	}
 	dlReq.Header.Set("Authorization", "Bearer "+b.accessToken)
 
 	dlResp, err := http.DefaultClient.Do(dlReq)
 	if err != nil {
 		b.log.Error("failed to download media file", sl.Err(err))
-		return
+		return entity.Attachment{}, false
 	}
 	defer dlResp.Body.Close()
 
@@ -377,21 +562,25 @@ func (b *WhatsAppBot) downloadAndUploadMedia(listener chat.MessageListener, send
 		mimeType = dlResp.Header.Get("Content-Type")
 	}
 
-	if err := listener.UploadAndSaveFile("whatsapp", senderPhone, dlResp.Body, filename, mimeType, dlResp.ContentLength, caption); err != nil {
+	stored, err := listener.UploadAndSaveFile("whatsapp", senderPhone, dlResp.Body, filename, mimeType, dlResp.ContentLength, caption)
+	if err != nil {
 		b.log.Error("failed to upload WhatsApp media",
 			slog.String("sender_phone", senderPhone),
 			sl.Err(err),
 		)
 		if errors.Is(err, entity.ErrFileTooLarge) {
 			limitMB := entity.MaxFileSize >> 20
-			text := fmt.Sprintf("Файл занадто великий. Максимально дозволений розмір - %d MB.", limitMB)
-			_ = b.SendMessage(senderPhone, text)
+			locale := b.chatEngine.UserLocale(context.Background(), "whatsapp", senderPhone)
+			text := i18n.T(locale, "error.file_too_large", limitMB)
+			_, _ = b.SendMessage(senderPhone, text)
 		}
+		return entity.Attachment{}, false
 	}
+	return stored, true
 }
 
-// SendMediaMessage sends a media message to a recipient via WhatsApp Cloud API.
-func (b *WhatsAppBot) SendMediaMessage(recipientPhone, mediaType, mediaURL, caption, filename string) error {
+// SendMediaMessage sends a media message to a recipient via WhatsApp Cloud API and returns its message ID.
+func (b *WhatsAppBot) SendMediaMessage(recipientPhone, mediaType, mediaURL, caption, filename string) (string, error) {
 	media := map[string]interface{}{
 		"link": mediaURL,
 	}
@@ -410,31 +599,75 @@ func (b *WhatsAppBot) SendMediaMessage(recipientPhone, mediaType, mediaURL, capt
 		mediaType:           media,
 	}
 
+	if err := b.checkWindow(recipientPhone); err != nil {
+		return "", err
+	}
+	return b.postMessage(payload)
+}
+
+// SendInteractive sends reply buttons or a list message via WhatsApp Cloud API.
+func (b *WhatsAppBot) SendInteractive(recipientPhone string, interactive wamessenger.Interactive) error {
+	payload := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"recipient_type":    "individual",
+		"to":                recipientPhone,
+		"type":              "interactive",
+		"interactive":       interactive,
+	}
+	if err := b.checkWindow(recipientPhone); err != nil {
+		return err
+	}
+	_, err := b.postMessage(payload)
+	return err
+}
+
+// postMessage sends a message request body to the Cloud API messages endpoint
+// and returns the WhatsApp message ID (wamid) of the sent message.
+func (b *WhatsAppBot) postMessage(payload any) (string, error) {
 	jsonBody, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal media request: %w", err)
+		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	apiURL := fmt.Sprintf("%s/%s/messages", graphAPIURL, b.phoneNumberID)
 	req, err := http.NewRequest(http.MethodPost, apiURL, bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+b.accessToken)
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send media message: %w", err)
+		return "", fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
-	return nil
+	var result struct {
+		Messages []struct {
+			ID string `json:"id"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || len(result.Messages) == 0 {
+		// The message was accepted; only its ID is unknown
+		b.log.Warn("no message ID in send response")
+		return "", nil
+	}
+	return result.Messages[0].ID, nil
+}
+
+// messageTime parses a webhook message timestamp (Unix seconds), falling back to now.
+func messageTime(timestamp string) time.Time {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || sec <= 0 {
+		return time.Now()
+	}
+	return time.Unix(sec, 0)
 }
 
 // verifySignature verifies the X-Hub-Signature-256 header
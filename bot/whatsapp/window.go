@@ -0,0 +1,147 @@
+package whatsapp
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"DarkCS/entity"
+	"DarkCS/internal/lib/sl"
+)
+
+const (
+	// customerServiceWindow is how long after the user's last message WhatsApp
+	// accepts free-form messages. Outside of it only templates can be sent.
+	customerServiceWindow = 24 * time.Hour
+	// windowRecheck is how close to its end a cached window is confirmed with the
+	// loader, since another replica may have received a newer message meanwhile.
+	windowRecheck = time.Hour
+	// windowPruneInterval is how often closed windows are dropped from the cache.
+	windowPruneInterval = time.Hour
+)
+
+// SetLastInboundLoader sets the lookup used for users whose window is not cached
+// or about to close, typically the time of their last stored incoming CRM message.
+func (b *WhatsAppBot) SetLastInboundLoader(fn func(phone string) (time.Time, error)) {
+	b.lastInboundLoader = fn
+}
+
+// touchWindow records an inbound message, reopening the user's customer service window.
+func (b *WhatsAppBot) touchWindow(phone string, at time.Time) {
+	now := time.Now()
+	b.windowMu.Lock()
+	defer b.windowMu.Unlock()
+	if last, ok := b.lastInbound[phone]; !ok || at.After(last) {
+		b.lastInbound[phone] = at
+	}
+	if now.Sub(b.windowPruned) >= windowPruneInterval {
+		b.windowPruned = now
+		for p, last := range b.lastInbound {
+			if now.Sub(last) >= customerServiceWindow {
+				delete(b.lastInbound, p)
+			}
+		}
+	}
+}
+
+// WindowExpiresAt returns when the user's customer service window closes.
+// The zero time means the user has never written.
+func (b *WhatsAppBot) WindowExpiresAt(phone string) time.Time {
+	b.windowMu.Lock()
+	last, ok := b.lastInbound[phone]
+	b.windowMu.Unlock()
+
+	if b.lastInboundLoader != nil && (!ok || time.Until(last.Add(customerServiceWindow)) < windowRecheck) {
+		loaded, err := b.lastInboundLoader(phone)
+		if err != nil {
+			b.log.Error("failed to load last inbound time",
+				slog.String("phone", phone),
+				sl.Err(err),
+			)
+		} else {
+			b.touchWindow(phone, loaded)
+			if loaded.After(last) {
+				last = loaded
+			}
+		}
+	}
+
+	if last.IsZero() {
+		return time.Time{}
+	}
+	return last.Add(customerServiceWindow)
+}
+
+// checkWindow refuses free-form messages outside the customer service window.
+func (b *WhatsAppBot) checkWindow(phone string) error {
+	if expires := b.WindowExpiresAt(phone); time.Now().After(expires) {
+		return fmt.Errorf("%w for %s", entity.ErrMessagingWindowClosed, phone)
+	}
+	return nil
+}
+
+// SetTemplates registers the pre-approved message templates that can be sent outside the window.
+func (b *WhatsAppBot) SetTemplates(templates []entity.MessageTemplate) {
+	b.templates = make(map[string]entity.MessageTemplate, len(templates))
+	for _, t := range templates {
+		if t.Language == "" {
+			t.Language = "uk"
+		}
+		b.templates[t.Name] = t
+	}
+}
+
+// Templates returns the registered message templates sorted by name.
+func (b *WhatsAppBot) Templates() []entity.MessageTemplate {
+	list := make([]entity.MessageTemplate, 0, len(b.templates))
+	for _, t := range b.templates {
+		list = append(list, t)
+	}
+	slices.SortFunc(list, func(a, b entity.MessageTemplate) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return list
+}
+
+// SendTemplate sends a registered template with its body parameters filled from values.
+// Templates are allowed outside the customer service window.
+// It returns the rendered text and the message ID.
+func (b *WhatsAppBot) SendTemplate(recipientPhone, name string, values map[string]string) (text, messageID string, err error) {
+	t, ok := b.templates[name]
+	if !ok {
+		return "", "", fmt.Errorf("%w: %s", entity.ErrTemplateNotFound, name)
+	}
+	params, err := t.Values(values)
+	if err != nil {
+		return "", "", err
+	}
+
+	template := map[string]interface{}{
+		"name":     t.Name,
+		"language": map[string]string{"code": t.Language},
+	}
+	if len(params) > 0 {
+		parameters := make([]map[string]string, 0, len(params))
+		for _, p := range params {
+			parameters = append(parameters, map[string]string{"type": "text", "text": p})
+		}
+		template["components"] = []map[string]interface{}{
+			{"type": "body", "parameters": parameters},
+		}
+	}
+
+	payload := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"recipient_type":    "individual",
+		"to":                recipientPhone,
+		"type":              "template",
+		"template":          template,
+	}
+	messageID, err = b.postMessage(payload)
+	if err != nil {
+		return "", "", err
+	}
+	return t.Render(values), messageID, nil
+}
@@ -0,0 +1,67 @@
+package whatsapp
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func newWindowBot(loaded map[string]time.Time) (*WhatsAppBot, *int) {
+	b := NewWhatsAppBot("", "", "", "", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	loads := new(int)
+	b.SetLastInboundLoader(func(phone string) (time.Time, error) {
+		*loads++
+		return loaded[phone], nil
+	})
+	return b, loads
+}
+
+func TestWindowExpiresAtUsesCachedWindows(t *testing.T) {
+	b, loads := newWindowBot(nil)
+	last := time.Now().Add(-time.Hour)
+	b.touchWindow("380501112233", last)
+
+	if got, want := b.WindowExpiresAt("380501112233"), last.Add(customerServiceWindow); !got.Equal(want) {
+		t.Errorf("expires at %v, want %v", got, want)
+	}
+	if *loads != 0 {
+		t.Errorf("loader called %d times for a fresh window", *loads)
+	}
+}
+
+func TestWindowExpiresAtRechecksWindowsNearTheirEnd(t *testing.T) {
+	// Another replica received a newer message after this one cached the old one.
+	newer := time.Now().Add(-time.Minute)
+	b, loads := newWindowBot(map[string]time.Time{"380501112233": newer})
+	b.touchWindow("380501112233", time.Now().Add(-customerServiceWindow+time.Minute))
+
+	if got, want := b.WindowExpiresAt("380501112233"), newer.Add(customerServiceWindow); !got.Equal(want) {
+		t.Errorf("expires at %v, want %v", got, want)
+	}
+	if *loads != 1 {
+		t.Errorf("loader called %d times, want 1", *loads)
+	}
+	if err := b.checkWindow("380501112233"); err != nil {
+		t.Errorf("checkWindow: %v", err)
+	}
+}
+
+func TestTouchWindowEvictsClosedWindows(t *testing.T) {
+	b, _ := newWindowBot(nil)
+	b.windowPruned = time.Now()
+	b.touchWindow("old", time.Now().Add(-2*customerServiceWindow))
+	if _, ok := b.lastInbound["old"]; !ok {
+		t.Fatal("window evicted before the prune interval passed")
+	}
+
+	b.windowPruned = time.Now().Add(-windowPruneInterval)
+	b.touchWindow("new", time.Now())
+
+	if _, ok := b.lastInbound["old"]; ok {
+		t.Error("closed window is still cached")
+	}
+	if _, ok := b.lastInbound["new"]; !ok {
+		t.Error("open window was evicted")
+	}
+}
@@ -0,0 +1,270 @@
+// Command chatsim walks through the chat workflows in a terminal. It boots a
+// ChatEngine with the real onboarding and mainmenu workflows over in-memory
+// storage and stubbed Zoho/AI services, so no database, bot token or CRM
+// access is needed.
+//
+// Type a message and press Enter to send it. Bot replies are rendered the
+// way the chosen platform shows them: reply keyboards and inline buttons on
+// Telegram, numbered text menus on Instagram and WhatsApp.
+//
+// Usage:
+//
+//	go run ./cmd/chatsim -platform telegram
+//	go run ./cmd/chatsim -platform instagram -registered -role manager
+//	go run ./cmd/chatsim -workflows docs/workflows
+//
+// Commands:
+//
+//	/cb <data>         press an inline button with the given callback data
+//	/contact <phone>   share a phone number
+//	/attach <type> [caption]  send a fake file: image, video, audio, voice or document
+//	/voice <text>      send a voice message that the stub assistant transcribes as text
+//	/state             print the current workflow, step and data
+//	/reset             forget the conversation state
+//	/quit              exit
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"DarkCS/bot/chat"
+	"DarkCS/bot/chat/chattest"
+	chatflow "DarkCS/bot/chat/flow"
+	chatmainmenu "DarkCS/bot/chat/mainmenu"
+	chatonboarding "DarkCS/bot/chat/onboarding"
+	"DarkCS/entity"
+)
+
+const (
+	simUserID = "100"
+	simPhone  = "+380501234567"
+)
+
+func main() {
+	platform := flag.String("platform", "telegram", "platform to simulate: telegram, instagram, facebook, whatsapp, viber or web")
+	registered := flag.Bool("registered", false, "start as an existing user in the main menu instead of onboarding")
+	role := flag.String("role", entity.UserRole, "role of the existing user (user, manager)")
+	workflowsDir := flag.String("workflows", "", "directory with YAML workflows to load")
+	verbose := flag.Bool("v", false, "print engine logs")
+	flag.Parse()
+
+	switch *platform {
+	case "telegram", "instagram", "facebook", "whatsapp", "viber", "web":
+	default:
+		log.Fatalf("unknown platform %q", *platform)
+	}
+
+	lg := chattest.DiscardLogger()
+	if *verbose {
+		lg = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	svc := sampleServices()
+	if *registered {
+		svc.Auth = chattest.NewAuthService(existingUser(*platform, *role))
+	}
+	storage := chattest.NewMemoryStorage()
+	engine := chattest.NewEngine(storage, lg,
+		chatonboarding.NewOnboardingWorkflow(svc.Auth, svc.Zoho, lg),
+		chatmainmenu.NewMainMenuWorkflow(svc.Auth, svc.Zoho, svc.AI, svc.Schools, svc.QrStats, nil, lg),
+	)
+	engine.RegisterIntent(chatonboarding.StartIntent())
+	for _, intent := range chatmainmenu.Intents() {
+		engine.RegisterIntent(intent)
+	}
+
+	if *workflowsDir != "" {
+		ids, problems := chatflow.NewSource(*workflowsDir, chatmainmenu.Actions(), engine, lg).Reload()
+		for _, err := range problems {
+			fmt.Printf("skipped: %v\n", err)
+		}
+		for _, id := range ids {
+			fmt.Printf("loaded workflow %s\n", id)
+		}
+	}
+
+	sim := &simulator{
+		ctx:       context.Background(),
+		engine:    engine,
+		storage:   storage,
+		messenger: chattest.NewMessenger(),
+		platform:  *platform,
+	}
+
+	if *registered {
+		sim.run("start", func() error {
+			return engine.StartWorkflowWithData(sim.ctx, sim.messenger, sim.platform, simUserID, simUserID,
+				chatmainmenu.WorkflowID, map[string]any{"phone": simPhone})
+		})
+	}
+
+	fmt.Printf("chatsim: %s user %s. Type a message, /cb <data>, /contact <phone>, /attach <type> [caption], /voice <text>, /state, /reset or /quit.\n\n", *platform, simUserID)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("> ")
+		if !scanner.Scan() {
+			break
+		}
+		if !sim.handle(strings.TrimSpace(scanner.Text())) {
+			break
+		}
+	}
+}
+
+// existingUser returns a registered user that the main menu resolves for the simulated platform.
+func existingUser(platform, role string) *entity.User {
+	user := entity.NewUser("", simPhone, 0)
+	user.Name = "Олена"
+	user.Role = role
+	user.ZohoId = "zoho-demo"
+	switch platform {
+	case "telegram":
+		user.TelegramId = 100
+	case "instagram":
+		user.InstagramId = simUserID
+	case "facebook":
+		user.FacebookId = simUserID
+	case "viber":
+		user.ViberId = simUserID
+	case "web":
+		user.WebId = simUserID
+	}
+	return user
+}
+
+// sampleServices returns stubs seeded with enough data to reach every main menu branch.
+func sampleServices() *chattest.Services {
+	svc := chattest.NewServices()
+	svc.Zoho = chattest.NewZohoService(
+		entity.OrderDetail{ID: "o-1", Subject: "SO-1042", Status: entity.OrderStatusNew, TTN: "20450000000000"},
+		entity.OrderDetail{ID: "o-2", Subject: "SO-0977", Status: entity.OrderStatusReceived},
+	)
+	svc.Zoho.Products["o-1"] = "Гель-лак DARK 01 × 2\nБаза Rubber × 1"
+	svc.Zoho.Products["o-2"] = "Топ без липкого шару × 1"
+	svc.Schools.Schools = []entity.School{
+		{Name: "Nail Academy Kyiv", Active: true},
+		{Name: "Beauty Lviv", Active: true},
+	}
+	return svc
+}
+
+type simulator struct {
+	ctx       context.Context
+	engine    *chat.ChatEngine
+	storage   *chattest.MemoryStorage
+	messenger *chattest.Messenger
+	platform  string
+}
+
+// handle executes one line of input and reports whether to keep reading.
+func (s *simulator) handle(line string) bool {
+	cmd, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch cmd {
+	case "":
+		return true
+	case "/quit", "/exit":
+		return false
+	case "/cb":
+		if arg == "" {
+			fmt.Println("usage: /cb <data>")
+			return true
+		}
+		s.run("callback", func() error {
+			return s.engine.HandleCallback(s.ctx, s.messenger, s.platform, simUserID, simUserID, arg, "")
+		})
+	case "/contact":
+		if arg == "" {
+			arg = simPhone
+		}
+		s.run("contact", func() error {
+			return s.engine.HandleContact(s.ctx, s.messenger, s.platform, simUserID, simUserID, arg)
+		})
+	case "/attach":
+		typ, caption, _ := strings.Cut(arg, " ")
+		if typ == "" {
+			fmt.Println("usage: /attach <image|video|audio|voice|document> [caption]")
+			return true
+		}
+		file := chattest.NewAttachment(chat.AttachmentType(typ), typ+".bin", "application/octet-stream", []byte("chatsim"))
+		s.run("attach", func() error {
+			return s.engine.HandleAttachments(s.ctx, s.messenger, s.platform, simUserID, simUserID, strings.TrimSpace(caption), []chat.Attachment{file})
+		})
+	case "/voice":
+		if arg == "" {
+			fmt.Println("usage: /voice <text>")
+			return true
+		}
+		voice := chattest.NewAttachment(chat.AttachmentVoice, "voice.ogg", "audio/ogg", []byte(arg))
+		s.run("voice", func() error {
+			return s.engine.HandleAttachments(s.ctx, s.messenger, s.platform, simUserID, simUserID, "", []chat.Attachment{voice})
+		})
+	case "/state":
+		s.printState()
+	case "/reset":
+		if err := s.storage.Delete(s.ctx, s.platform, simUserID); err != nil {
+			fmt.Printf("reset: %v\n", err)
+		}
+		fmt.Println("(state cleared)")
+	default:
+		s.run("message", func() error {
+			return s.engine.HandleMessage(s.ctx, s.messenger, s.platform, simUserID, simUserID, line)
+		})
+	}
+	return true
+}
+
+// run performs an engine call and prints the replies it produced.
+func (s *simulator) run(what string, fn func() error) {
+	if err := fn(); err != nil {
+		fmt.Printf("(%s failed: %v)\n", what, err)
+	}
+	msgs := s.messenger.Take()
+	if len(msgs) == 0 {
+		fmt.Println("(no reply)")
+	}
+	locale := s.locale()
+	for _, msg := range msgs {
+		fmt.Println(render(s.platform, locale, msg))
+		fmt.Println()
+	}
+}
+
+// locale returns the simulated user's current locale, which outlives their state.
+func (s *simulator) locale() string {
+	if state, err := s.storage.Load(s.ctx, s.platform, simUserID); err == nil && state != nil {
+		return state.Locale
+	}
+	locale, _ := s.storage.GetChatLocale(s.ctx, s.platform, simUserID)
+	return locale
+}
+
+func (s *simulator) printState() {
+	state, err := s.storage.Load(s.ctx, s.platform, simUserID)
+	if err != nil {
+		fmt.Printf("state: %v\n", err)
+		return
+	}
+	if state == nil {
+		fmt.Println("(no state)")
+		return
+	}
+	fmt.Printf("workflow: %s\nstep:     %s\nlocale:   %s\n", state.WorkflowID, state.CurrentStep, state.Locale)
+	if len(state.History) > 0 {
+		fmt.Printf("history:  %v\n", state.History)
+	}
+	for _, k := range slices.Sorted(maps.Keys(state.Data)) {
+		fmt.Printf("  %s = %v\n", k, state.Data[k])
+	}
+}
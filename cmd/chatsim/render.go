@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"DarkCS/bot/chat"
+	"DarkCS/bot/chat/chattest"
+	igmessenger "DarkCS/bot/chat/instagram"
+	vbmessenger "DarkCS/bot/chat/viber"
+	wamessenger "DarkCS/bot/chat/whatsapp"
+	"DarkCS/internal/lib/i18n"
+)
+
+// render formats a recorded bot message the way the platform displays it.
+// Texts the messengers write themselves, such as the numbered-menu footer, use locale.
+func render(platform, locale string, msg chattest.Message) string {
+	switch platform {
+	case "telegram", "web":
+		return renderTelegram(msg)
+	case "whatsapp":
+		return renderWhatsApp(locale, msg)
+	case "instagram", "facebook":
+		return renderInstagram(locale, msg)
+	case "viber":
+		return renderViber(locale, msg)
+	}
+	return renderNumbered(locale, msg)
+}
+
+// renderTelegram shows reply keyboards and inline keyboards as button rows.
+// The website widget renders its JSON buttons the same way.
+// Inline buttons carry their callback data so it can be typed with /cb.
+func renderTelegram(msg chattest.Message) string {
+	var sb strings.Builder
+	switch msg.Kind {
+	case chattest.KindFile:
+		fmt.Fprintf(&sb, "📎 %s", msg.Filename)
+		if msg.Text != "" {
+			sb.WriteString("\n" + msg.Text)
+		}
+		return sb.String()
+	case chattest.KindVideo:
+		return "🎬 " + msg.Filename
+	case chattest.KindEditInlineGrid:
+		sb.WriteString("(edited)\n")
+	}
+
+	sb.WriteString(msg.Text)
+	for _, row := range msg.Menu {
+		labels := make([]string, 0, len(row))
+		for _, btn := range row {
+			labels = append(labels, "[ "+btn.Text+" ]")
+		}
+		sb.WriteString("\n  " + strings.Join(labels, " "))
+	}
+	for _, row := range msg.Buttons {
+		labels := make([]string, 0, len(row))
+		for _, btn := range row {
+			labels = append(labels, fmt.Sprintf("( %s → /cb %s )", btn.Text, btn.Data))
+		}
+		sb.WriteString("\n  " + strings.Join(labels, " "))
+	}
+	if msg.Kind == chattest.KindContactRequest {
+		fmt.Fprintf(&sb, "\n  [ %s ] → /contact", msg.Button)
+	}
+	return sb.String()
+}
+
+// renderWhatsApp shows menus the way the WhatsApp messenger sends them:
+// reply buttons or a list when they fit, numbered text otherwise.
+// Menu options are picked by typing their text, inline options with /cb.
+func renderWhatsApp(locale string, msg chattest.Message) string {
+	var interactive wamessenger.Interactive
+	var ok bool
+	switch msg.Kind {
+	case chattest.KindMenu:
+		interactive, ok = wamessenger.MenuInteractive(locale, msg.Text, msg.Menu)
+	case chattest.KindInline, chattest.KindInlineGrid, chattest.KindEditInlineGrid:
+		interactive, ok = wamessenger.InlineInteractive(locale, msg.Text, msg.Buttons)
+	}
+	if !ok {
+		return renderNumbered(locale, msg)
+	}
+
+	var options []wamessenger.Option
+	for _, btn := range interactive.Action.Buttons {
+		options = append(options, btn.Reply)
+	}
+	for _, section := range interactive.Action.Sections {
+		options = append(options, section.Rows...)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(interactive.Body.Text)
+	if interactive.Type == "list" {
+		fmt.Fprintf(&sb, "\n  ≡ %s", interactive.Action.Button)
+	}
+	for _, opt := range options {
+		if _, data := wamessenger.ParseReplyID(opt.ID); data != "" {
+			fmt.Fprintf(&sb, "\n  ( %s → /cb %s )", opt.Title, data)
+		} else {
+			fmt.Fprintf(&sb, "\n  [ %s ]", opt.Title)
+		}
+	}
+	return sb.String()
+}
+
+// renderInstagram shows menus the way the Instagram and Facebook messengers send them:
+// a button template for up to three inline buttons, quick replies for the
+// rest, numbered text when neither fits.
+func renderInstagram(locale string, msg chattest.Message) string {
+	var replies []igmessenger.QuickReply
+	var ok bool
+	switch msg.Kind {
+	case chattest.KindMenu:
+		replies, ok = igmessenger.MenuQuickReplies(msg.Text, msg.Menu)
+	case chattest.KindInline, chattest.KindInlineGrid, chattest.KindEditInlineGrid:
+		if template, isTemplate := igmessenger.InlineButtonTemplate(msg.Text, msg.Buttons); isTemplate {
+			var sb strings.Builder
+			sb.WriteString(template.Text)
+			for _, btn := range template.Buttons {
+				_, data := igmessenger.ParseReplyPayload(btn.Payload)
+				fmt.Fprintf(&sb, "\n  | %s → /cb %s |", btn.Title, data)
+			}
+			return sb.String()
+		}
+		replies, ok = igmessenger.InlineQuickReplies(msg.Text, msg.Buttons)
+	}
+	if !ok {
+		return renderNumbered(locale, msg)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(msg.Text)
+	for _, qr := range replies {
+		if _, data := igmessenger.ParseReplyPayload(qr.Payload); data != "" {
+			fmt.Fprintf(&sb, "\n  ( %s → /cb %s )", qr.Title, data)
+		} else {
+			fmt.Fprintf(&sb, "\n  [ %s ]", qr.Title)
+		}
+	}
+	return sb.String()
+}
+
+// renderViber shows menus the way the Viber messenger sends them: a keyboard
+// laid out in rows of six columns, numbered text when the buttons do not fit.
+func renderViber(locale string, msg chattest.Message) string {
+	var keyboard vbmessenger.Keyboard
+	var ok bool
+	switch msg.Kind {
+	case chattest.KindMenu:
+		keyboard, ok = vbmessenger.MenuKeyboard(msg.Menu)
+	case chattest.KindInline, chattest.KindInlineGrid, chattest.KindEditInlineGrid:
+		keyboard, ok = vbmessenger.InlineKeyboard(msg.Buttons)
+	case chattest.KindContactRequest:
+		keyboard, ok = vbmessenger.ContactKeyboard(msg.Button), true
+	}
+	if !ok {
+		return renderNumbered(locale, msg)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(msg.Text)
+	columns := 0
+	for _, btn := range keyboard.Buttons {
+		if columns == 0 {
+			sb.WriteString("\n ")
+		}
+		switch {
+		case btn.ActionType == "share-phone":
+			fmt.Fprintf(&sb, " [ %s ] → /contact", btn.Text)
+		case btn.Silent:
+			data, _, _ := vbmessenger.ParseCallback(btn.ActionBody)
+			fmt.Fprintf(&sb, " ( %s → /cb %s )", btn.Text, data)
+		default:
+			fmt.Fprintf(&sb, " [ %s ]", btn.Text)
+		}
+		columns = (columns + btn.Columns) % 6
+	}
+	return sb.String()
+}
+
+// renderNumbered produces the plain text that the Instagram and WhatsApp
+// messengers send for menus that do not fit, using the numbered-menu helpers.
+func renderNumbered(locale string, msg chattest.Message) string {
+	switch msg.Kind {
+	case chattest.KindMenu:
+		return chat.FormatNumberedMenu(locale, msg.Text, msg.Menu)
+	case chattest.KindInline:
+		buttons := make([]chat.InlineButton, 0, len(msg.Buttons))
+		for _, row := range msg.Buttons {
+			buttons = append(buttons, row...)
+		}
+		return chat.FormatNumberedInline(locale, msg.Text, buttons)
+	case chattest.KindInlineGrid, chattest.KindEditInlineGrid:
+		return chat.FormatNumberedInlineGrid(locale, msg.Text, msg.Buttons)
+	case chattest.KindFile:
+		if msg.Text != "" {
+			return msg.Text + "\n📎 " + msg.Filename
+		}
+		return "📎 " + msg.Filename
+	case chattest.KindVideo:
+		return i18n.T(locale, "messenger.video", msg.Filename)
+	}
+	return msg.Text
+}
@@ -3,6 +3,7 @@ package entity
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -18,6 +19,14 @@ func FileTooLargeError(filename string, size int64) error {
 	return fmt.Errorf("%w: %q is %d bytes, limit is %d MB", ErrFileTooLarge, filename, size, MaxFileSize>>20)
 }
 
+// ErrVoiceTooLong is returned when a voice message exceeds the transcription duration limit.
+var ErrVoiceTooLong = errors.New("voice message too long")
+
+// VoiceTooLongError wraps ErrVoiceTooLong with the message duration and the limit.
+func VoiceTooLongError(duration, limit time.Duration) error {
+	return fmt.Errorf("%w: %s, limit is %s", ErrVoiceTooLong, duration, limit)
+}
+
 // Attachment represents a file attached to a ChatMessage.
 // The URL field is computed at read-time and not stored in MongoDB.
 type Attachment struct {
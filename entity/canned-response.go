@@ -0,0 +1,85 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrCannedResponseNotFound is returned when a canned response does not exist
+// or is a personal reply of another CRM user.
+var ErrCannedResponseNotFound = errors.New("canned response not found")
+
+// ErrCannedResponseOwner is returned when a CRM user changes the scope of a team reply they did not create.
+var ErrCannedResponseOwner = errors.New("only the owner can change the scope of a canned response")
+
+// ErrCannedVariable is returned when a placeholder of a canned response has no value for the chat.
+var ErrCannedVariable = errors.New("missing canned response variable")
+
+// Canned response scopes: team replies are shared by every CRM user,
+// personal replies are only seen by their owner.
+const (
+	CannedScopeTeam     = "team"
+	CannedScopePersonal = "personal"
+)
+
+// placeholderPattern matches {{name}} and {{last_order.ttn}} placeholders, spaces allowed inside the braces.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-z_]+(?:\.[a-z_]+)*)\s*\}\}`)
+
+// CannedResponse is a saved reply managers send instead of typing it again.
+// Text may contain {{variable}} placeholders that are filled in for the chat at send time;
+// Attachments are GridFS files sent along with it.
+type CannedResponse struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Title       string             `json:"title" bson:"title"`
+	Text        string             `json:"text" bson:"text"`
+	Attachments []Attachment       `json:"attachments" bson:"attachments"`
+	Scope       string             `json:"scope" bson:"scope"`
+	Owner       string             `json:"owner" bson:"owner"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// ValidScope reports whether scope is a known canned response scope.
+func ValidScope(scope string) bool {
+	return scope == CannedScopeTeam || scope == CannedScopePersonal
+}
+
+// VisibleTo reports whether the CRM user may see, edit and send the reply.
+func (r CannedResponse) VisibleTo(username string) bool {
+	return r.Scope == CannedScopeTeam || r.Owner == username
+}
+
+// Variables returns the placeholder names used in the text, each once, in order of appearance.
+func (r CannedResponse) Variables() []string {
+	var names []string
+	for _, match := range placeholderPattern.FindAllStringSubmatch(r.Text, -1) {
+		if !slices.Contains(names, match[1]) {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// Render fills the placeholders with values. Every placeholder must have a non-empty value,
+// so a reply is never sent with a bare {{variable}} in it.
+func (r CannedResponse) Render(values map[string]string) (string, error) {
+	var missing []string
+	for _, name := range r.Variables() {
+		if strings.TrimSpace(values[name]) == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrCannedVariable, strings.Join(missing, ", "))
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(r.Text, func(placeholder string) string {
+		return values[placeholderPattern.FindStringSubmatch(placeholder)[1]]
+	}), nil
+}
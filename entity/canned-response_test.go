@@ -0,0 +1,75 @@
+package entity
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCannedResponseVariables(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "Hello!"},
+		{text: "Hello, {{name}}!", want: []string{"name"}},
+		{text: "{{ name }}, your parcel {{last_order.ttn}} is on its way, {{name}}", want: []string{"name", "last_order.ttn"}},
+		{text: "{{Name}} and {{}} and {name} are not placeholders"},
+	}
+	for _, tt := range tests {
+		got := CannedResponse{Text: tt.text}.Variables()
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Variables(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCannedResponseRender(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		values  map[string]string
+		want    string
+		missing []string
+	}{
+		{
+			name:   "fills every placeholder",
+			text:   "Hello, {{ name }}! TTN: {{last_order.ttn}}",
+			values: map[string]string{"name": "Olena", "last_order.ttn": "2045"},
+			want:   "Hello, Olena! TTN: 2045",
+		},
+		{name: "text without placeholders", text: "Thank you!", want: "Thank you!"},
+		{
+			name:    "missing value",
+			text:    "Hello, {{name}}! TTN: {{last_order.ttn}}",
+			values:  map[string]string{"name": "Olena"},
+			missing: []string{"last_order.ttn"},
+		},
+		{
+			name:    "blank values count as missing",
+			text:    "{{name}} {{phone}}",
+			values:  map[string]string{"name": " ", "phone": ""},
+			missing: []string{"name", "phone"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CannedResponse{Text: tt.text}.Render(tt.values)
+			if len(tt.missing) > 0 {
+				if !errors.Is(err, ErrCannedVariable) {
+					t.Fatalf("error = %v, want ErrCannedVariable", err)
+				}
+				for _, name := range tt.missing {
+					if !strings.Contains(err.Error(), name) {
+						t.Errorf("error %q does not name %s", err, name)
+					}
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Render = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrChatAssigned is returned when a CRM user replies to a chat assigned to another CRM user.
+var ErrChatAssigned = errors.New("chat is assigned to another user")
+
+// ErrInvalidTag is returned for an empty chat tag or one longer than MaxTagLength.
+var ErrInvalidTag = errors.New("invalid chat tag")
+
+// MaxTagLength is the longest chat tag accepted, in characters.
+const MaxTagLength = 50
+
+// NormalizeTag trims the surrounding spaces of a chat tag.
+// An empty or overlong tag fails with ErrInvalidTag.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+	}
+	return tag, nil
+}
+
+// ChatStatus is the handling state of a chat in the CRM.
+type ChatStatus string
+
+const (
+	// ChatOpen chats need a manager's attention.
+	ChatOpen ChatStatus = "open"
+	// ChatPending chats wait for the customer or for a third party.
+	ChatPending ChatStatus = "pending"
+	// ChatClosed chats are resolved; a new customer message opens them again.
+	ChatClosed ChatStatus = "closed"
+)
+
+// Valid reports whether s is a known chat status.
+func (s ChatStatus) Valid() bool {
+	switch s {
+	case ChatOpen, ChatPending, ChatClosed:
+		return true
+	}
+	return false
+}
+
+// Auto-assignment modes for new and reopened chats.
+const (
+	AssignRoundRobin  = "round_robin"
+	AssignLeastLoaded = "least_loaded"
+)
+
+// ChatAssignment records which CRM user handles a chat and its status.
+// Assignee is the UserAuth.Username; an empty Assignee puts the chat in the unassigned queue.
+type ChatAssignment struct {
+	Platform string     `json:"platform" bson:"platform"`
+	UserID   string     `json:"user_id" bson:"user_id"`
+	Assignee string     `json:"assignee" bson:"assignee"`
+	Status   ChatStatus `json:"status" bson:"status"`
+	// HumanMode is set while a manager talks to the user; the bot does not answer meanwhile.
+	HumanMode bool `json:"human_mode" bson:"human_mode"`
+	// HumanActiveAt is when a manager last wrote or the user asked for one.
+	HumanActiveAt time.Time `json:"human_active_at,omitempty" bson:"human_active_at,omitempty"`
+	// Tags are free-form labels managers put on the chat, e.g. "wholesale" or "school:X".
+	Tags      []string  `json:"tags,omitempty" bson:"tags,omitempty"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// HumanModeActive reports whether human mode is on and has not run out.
+// A zero timeout keeps it on until it is turned off.
+func (a ChatAssignment) HumanModeActive(now time.Time, timeout time.Duration) bool {
+	if !a.HumanMode {
+		return false
+	}
+	return timeout <= 0 || now.Sub(a.HumanActiveAt) < timeout
+}
+
+// ChatFilter narrows the CRM chat list. The zero value matches every chat.
+type ChatFilter struct {
+	// Assignee keeps the chats of this CRM user
+	Assignee string
+	// Unassigned keeps the chats in the unassigned queue
+	Unassigned bool
+	Status     ChatStatus
+	// Tags keeps the chats that carry every one of these tags
+	Tags []string
+}
+
+// Match reports whether a chat passes the filter.
+func (f ChatFilter) Match(chat ChatSummary) bool {
+	if f.Unassigned && chat.Assignee != "" {
+		return false
+	}
+	if f.Assignee != "" && chat.Assignee != f.Assignee {
+		return false
+	}
+	if f.Status != "" && chat.Status != f.Status {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(chat.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+		err  bool
+	}{
+		{tag: "wholesale", want: "wholesale"},
+		{tag: "  school:X \n", want: "school:X"},
+		{tag: strings.Repeat("я", MaxTagLength), want: strings.Repeat("я", MaxTagLength)},
+		{tag: strings.Repeat("я", MaxTagLength+1), err: true},
+		{tag: "", err: true},
+		{tag: "   ", err: true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeTag(tt.tag)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidTag) {
+				t.Errorf("NormalizeTag(%q) error = %v, want ErrInvalidTag", tt.tag, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeTag(%q) = %q, %v; want %q", tt.tag, got, err, tt.want)
+		}
+	}
+}
+
+func TestChatFilterMatchesTags(t *testing.T) {
+	chat := ChatSummary{Assignee: "olena", Status: ChatOpen, Tags: []string{"wholesale", "school:X"}}
+	tests := []struct {
+		name   string
+		filter ChatFilter
+		match  bool
+	}{
+		{name: "no filter", match: true},
+		{name: "one tag", filter: ChatFilter{Tags: []string{"wholesale"}}, match: true},
+		{name: "every tag", filter: ChatFilter{Tags: []string{"school:X", "wholesale"}}, match: true},
+		{name: "a missing tag", filter: ChatFilter{Tags: []string{"wholesale", "vip"}}},
+		{name: "tags are case sensitive", filter: ChatFilter{Tags: []string{"Wholesale"}}},
+		{name: "tag and assignee", filter: ChatFilter{Assignee: "olena", Tags: []string{"wholesale"}}, match: true},
+		{name: "tag of another assignee", filter: ChatFilter{Assignee: "ivan", Tags: []string{"wholesale"}}},
+		{name: "tag in the unassigned queue", filter: ChatFilter{Unassigned: true, Tags: []string{"wholesale"}}},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Match(chat); got != tt.match {
+			t.Errorf("%s: Match = %v, want %v", tt.name, got, tt.match)
+		}
+	}
+}
@@ -16,9 +16,63 @@ type ChatMessage struct {
 	Sender        string             `json:"sender" bson:"sender"`       // "user" | "manager" | "bot"
 	Text          string             `json:"text" bson:"text"`
 	Attachments   []Attachment       `json:"attachments,omitempty" bson:"attachments,omitempty"`
+	Transcript    string             `json:"transcript,omitempty" bson:"transcript,omitempty"` // speech-to-text of a voice attachment
 	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
 	UserName      string             `json:"user_name,omitempty" bson:"-"`
 	MessengerName string             `json:"messenger_name,omitempty" bson:"-"`
+
+	// PlatformMessageIDs are the IDs the platform returned for an outgoing message
+	// (one per sent file); delivery and read receipts refer to them.
+	PlatformMessageIDs []string      `json:"platform_message_ids,omitempty" bson:"platform_message_ids,omitempty"`
+	Status             MessageStatus `json:"status,omitempty" bson:"status,omitempty"` // outgoing messages only
+	StatusError        string        `json:"status_error,omitempty" bson:"status_error,omitempty"`
+	StatusUpdatedAt    *time.Time    `json:"status_updated_at,omitempty" bson:"status_updated_at,omitempty"`
+}
+
+// PlatformMessageIDs collects the platform message IDs of a sent message, dropping
+// the empty IDs of platforms that do not report them; nil when none are left.
+func PlatformMessageIDs(ids ...string) []string {
+	var list []string
+	for _, id := range ids {
+		if id != "" {
+			list = append(list, id)
+		}
+	}
+	return list
+}
+
+// MessageStatus is the delivery state of an outgoing message.
+type MessageStatus string
+
+const (
+	MessageSent      MessageStatus = "sent"
+	MessageDelivered MessageStatus = "delivered"
+	MessageRead      MessageStatus = "read"
+	MessageFailed    MessageStatus = "failed"
+)
+
+// Replaces returns the statuses a message may move from to reach s.
+// Statuses only move forward, so a late "delivered" receipt never overrides "read".
+func (s MessageStatus) Replaces() []MessageStatus {
+	switch s {
+	case MessageDelivered:
+		return []MessageStatus{MessageSent}
+	case MessageRead:
+		return []MessageStatus{MessageSent, MessageDelivered}
+	case MessageFailed:
+		return []MessageStatus{MessageSent, MessageDelivered}
+	}
+	return nil
+}
+
+// ParseMessageStatus maps a platform status name to a MessageStatus.
+// It reports false for statuses that are not tracked.
+func ParseMessageStatus(status string) (MessageStatus, bool) {
+	switch s := MessageStatus(status); s {
+	case MessageSent, MessageDelivered, MessageRead, MessageFailed:
+		return s, true
+	}
+	return "", false
 }
 
 // ChatReadReceipt tracks the last time a CRM user marked a chat as read.
@@ -38,4 +92,14 @@ type ChatSummary struct {
 	LastMessage   string    `json:"last_message" bson:"last_message"`
 	LastTime      time.Time `json:"last_time" bson:"last_time"`
 	Unread        int       `json:"unread" bson:"unread"`
+	// Assignee is the CRM user handling the chat, empty while it waits in the unassigned queue.
+	Assignee string     `json:"assignee" bson:"-"`
+	Status   ChatStatus `json:"status" bson:"-"`
+	// HumanMode is set while a manager talks to the user and the bot stays silent.
+	HumanMode bool     `json:"human_mode" bson:"-"`
+	Tags      []string `json:"tags" bson:"-"`
+	// WindowExpiresAt is when free-form replies stop being accepted (WhatsApp only);
+	// after that only message templates can be sent.
+	WindowExpiresAt *time.Time `json:"window_expires_at,omitempty" bson:"-"`
+	WindowOpen      *bool      `json:"window_open,omitempty" bson:"-"`
 }
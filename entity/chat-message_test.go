@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMessageStatusMovesOnlyForward(t *testing.T) {
+	tests := []struct {
+		from, to MessageStatus
+		allowed  bool
+	}{
+		{from: MessageSent, to: MessageDelivered, allowed: true},
+		{from: MessageSent, to: MessageRead, allowed: true},
+		{from: MessageDelivered, to: MessageRead, allowed: true},
+		{from: MessageSent, to: MessageFailed, allowed: true},
+		{from: MessageDelivered, to: MessageFailed, allowed: true},
+		{from: MessageRead, to: MessageDelivered},
+		{from: MessageRead, to: MessageFailed},
+		{from: MessageDelivered, to: MessageSent},
+		{from: MessageFailed, to: MessageRead},
+		{from: MessageRead, to: MessageRead},
+	}
+	for _, tt := range tests {
+		if got := slices.Contains(tt.to.Replaces(), tt.from); got != tt.allowed {
+			t.Errorf("%s -> %s allowed = %v, want %v", tt.from, tt.to, got, tt.allowed)
+		}
+	}
+}
+
+func TestParseMessageStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   MessageStatus
+		ok     bool
+	}{
+		{status: "delivered", want: MessageDelivered, ok: true},
+		{status: "read", want: MessageRead, ok: true},
+		{status: "failed", want: MessageFailed, ok: true},
+		{status: "deleted"},
+		{status: ""},
+	}
+	for _, tt := range tests {
+		got, ok := ParseMessageStatus(tt.status)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseMessageStatus(%q) = %q, %v; want %q, %v", tt.status, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrChatNoteNotFound is returned when a note does not exist or was written by another CRM user.
+var ErrChatNoteNotFound = errors.New("chat note not found")
+
+// ErrInvalidNote is returned for an empty note or one longer than MaxNoteLength.
+var ErrInvalidNote = errors.New("invalid chat note")
+
+// MaxNoteLength is the longest note accepted, in characters.
+const MaxNoteLength = 2000
+
+// ChatNote is an internal note a CRM user attached to a chat. Notes are only
+// shown in the CRM and are never sent to the platform.
+type ChatNote struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Platform  string             `json:"platform" bson:"platform"`
+	UserID    string             `json:"user_id" bson:"user_id"`
+	Author    string             `json:"author" bson:"author"`
+	Text      string             `json:"text" bson:"text"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,141 @@
+package entity
+
+import (
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// UserLookup finds users by the IDs the chat platforms know them by.
+type UserLookup interface {
+	GetUser(email, phone string, telegramId int64) (*User, error)
+	GetUserByInstagramId(instagramId string) (*User, error)
+	GetUserByFacebookId(facebookId string) (*User, error)
+	GetUserByViberId(viberId string) (*User, error)
+	GetUserByWebId(webId string) (*User, error)
+}
+
+// chatPlatform describes how the users of a chat platform are stored.
+type chatPlatform struct {
+	// lookup finds the user with the platform's user ID.
+	lookup func(users UserLookup, userID string) (*User, error)
+	// link stores the platform's user ID on a user not linked yet and reports whether it did.
+	link func(user *User, userID string) bool
+	// name returns the user's name on the platform.
+	name func(user *User) string
+	// setName stores the user's name on the platform and reports whether it changed;
+	// nil when the name is not kept separately.
+	setName func(user *User, name string) bool
+	// anonymous is set when the platform does not authenticate its users, so nothing
+	// they type, such as a phone number, identifies them as an existing customer.
+	anonymous bool
+}
+
+var chatPlatforms = map[string]chatPlatform{
+	"telegram": {
+		lookup: func(users UserLookup, userID string) (*User, error) {
+			telegramId, _ := strconv.ParseInt(userID, 10, 64)
+			if telegramId == 0 {
+				return nil, nil
+			}
+			return users.GetUser("", "", telegramId)
+		},
+		link: func(user *User, userID string) bool {
+			telegramId, _ := strconv.ParseInt(userID, 10, 64)
+			return linkID(&user.TelegramId, telegramId)
+		},
+		name:    func(user *User) string { return user.TelegramUsername },
+		setName: func(user *User, name string) bool { return setField(&user.TelegramUsername, name) },
+	},
+	"instagram": {
+		lookup:  func(users UserLookup, userID string) (*User, error) { return users.GetUserByInstagramId(userID) },
+		link:    func(user *User, userID string) bool { return linkID(&user.InstagramId, userID) },
+		name:    func(user *User) string { return user.InstagramUsername },
+		setName: func(user *User, name string) bool { return setField(&user.InstagramUsername, name) },
+	},
+	"facebook": {
+		lookup:  func(users UserLookup, userID string) (*User, error) { return users.GetUserByFacebookId(userID) },
+		link:    func(user *User, userID string) bool { return linkID(&user.FacebookId, userID) },
+		name:    func(user *User) string { return user.FacebookName },
+		setName: func(user *User, name string) bool { return setField(&user.FacebookName, name) },
+	},
+	"viber": {
+		lookup:  func(users UserLookup, userID string) (*User, error) { return users.GetUserByViberId(userID) },
+		link:    func(user *User, userID string) bool { return linkID(&user.ViberId, userID) },
+		name:    func(user *User) string { return user.ViberName },
+		setName: func(user *User, name string) bool { return setField(&user.ViberName, name) },
+	},
+	"web": {
+		lookup:    func(users UserLookup, userID string) (*User, error) { return users.GetUserByWebId(userID) },
+		link:      func(user *User, userID string) bool { return linkID(&user.WebId, userID) },
+		name:      func(user *User) string { return "" },
+		anonymous: true,
+	},
+	// WhatsApp users are known by their phone number
+	"whatsapp": {
+		lookup: func(users UserLookup, userID string) (*User, error) { return users.GetUser("", userID, 0) },
+		link:   func(user *User, userID string) bool { return false },
+		name:   func(user *User) string { return user.Phone },
+	},
+}
+
+// FindChatUser returns the user a chat platform knows by userID, or nil when there is none.
+func FindChatUser(users UserLookup, platform, userID string) (*User, error) {
+	p, ok := chatPlatforms[platform]
+	if !ok {
+		return nil, nil
+	}
+	return p.lookup(users, userID)
+}
+
+// AnonymousChatPlatform reports whether a chat platform's users are anonymous. Their
+// phone numbers are unverified, so they must never be matched to existing customers.
+func AnonymousChatPlatform(platform string) bool {
+	return chatPlatforms[platform].anonymous
+}
+
+// NewChatUser creates a user known only by a chat platform's userID.
+func NewChatUser(platform, userID, name string) *User {
+	user := &User{UUID: uuid.NewString(), Name: name}
+	user.LinkChatUser(platform, userID)
+	return user
+}
+
+// LinkChatUser stores the chat platform's userID on u unless u is already linked to
+// an account on that platform. It reports whether u changed.
+func (u *User) LinkChatUser(platform, userID string) bool {
+	p, ok := chatPlatforms[platform]
+	return ok && p.link(u, userID)
+}
+
+// MessengerName returns the user's name on a chat platform.
+func (u *User) MessengerName(platform string) string {
+	p, ok := chatPlatforms[platform]
+	if !ok {
+		return ""
+	}
+	return p.name(u)
+}
+
+// SetMessengerName stores the user's name on a chat platform and reports whether it changed.
+func (u *User) SetMessengerName(platform, name string) bool {
+	p, ok := chatPlatforms[platform]
+	return ok && p.setName != nil && p.setName(u, name)
+}
+
+func linkID[T comparable](field *T, id T) bool {
+	var zero T
+	if *field != zero || id == zero {
+		return false
+	}
+	*field = id
+	return true
+}
+
+func setField(field *string, value string) bool {
+	if *field == value {
+		return false
+	}
+	*field = value
+	return true
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrChatMessageNotFound is returned when a chat message does not exist in the given chat.
+var ErrChatMessageNotFound = errors.New("chat message not found")
+
+// ChatSearch is a full-text query over the stored CRM chat history.
+// Query follows MongoDB $text syntax: words match any of them, "quoted phrases" must appear
+// as written and -word excludes messages containing it. Empty filters match everything.
+type ChatSearch struct {
+	Query     string
+	Platform  string
+	Direction string // "incoming" | "outgoing"
+	Sender    string // "user" | "manager" | "bot"
+	From      time.Time
+	To        time.Time
+	// HasAttachments keeps messages with (true) or without (false) attachments when set
+	HasAttachments *bool
+	// ByRelevance sorts the best matches first instead of the newest
+	ByRelevance bool
+	Limit       int
+	Offset      int
+}
+
+// ChatSearchHit is a message matching a ChatSearch. Platform and UserID of the message
+// point to the chat; Snippet is the matching part of the text, HTML-escaped,
+// with the search words wrapped in <mark></mark>.
+type ChatSearchHit struct {
+	Message ChatMessage `json:"message"`
+	Snippet string      `json:"snippet"`
+	Score   float64     `json:"score"`
+}
+
+// ChatMessageContext is a message with the messages around it in its chat, oldest first.
+type ChatMessageContext struct {
+	MessageID string        `json:"message_id"`
+	Messages  []ChatMessage `json:"messages"`
+	// HasBefore and HasAfter report whether the chat has more messages beyond the context
+	HasBefore bool `json:"has_before"`
+	HasAfter  bool `json:"has_after"`
+}
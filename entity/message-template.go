@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMessagingWindowClosed is returned when a free-form message is sent to a user
+// whose platform only accepts them shortly after the user's last message
+// (the WhatsApp 24-hour customer service window). A template must be sent instead.
+var ErrMessagingWindowClosed = errors.New("messaging window is closed")
+
+// ErrTemplateNotFound is returned when a message template is not in the registry.
+var ErrTemplateNotFound = errors.New("message template not found")
+
+// ErrTemplateParams is returned when a template parameter has no value.
+var ErrTemplateParams = errors.New("missing template parameter")
+
+// MessageTemplate describes a pre-approved platform message template.
+// Params lists the body variables in the order the platform expects them;
+// Text is the body with {{param}} placeholders, used to show the sent message in the CRM.
+type MessageTemplate struct {
+	Name     string   `json:"name" yaml:"name"`
+	Language string   `json:"language" yaml:"language"`
+	Params   []string `json:"params" yaml:"params"`
+	Text     string   `json:"text,omitempty" yaml:"text"`
+}
+
+// Values returns the parameter values in template order.
+// Every parameter must have a non-empty value.
+func (t MessageTemplate) Values(values map[string]string) ([]string, error) {
+	ordered := make([]string, 0, len(t.Params))
+	for _, param := range t.Params {
+		v := strings.TrimSpace(values[param])
+		if v == "" {
+			return nil, fmt.Errorf("%w %q in template %s", ErrTemplateParams, param, t.Name)
+		}
+		ordered = append(ordered, v)
+	}
+	return ordered, nil
+}
+
+// Render fills the template text with values for display in the CRM.
+// Templates without Text are shown by name.
+func (t MessageTemplate) Render(values map[string]string) string {
+	if t.Text == "" {
+		return "[" + t.Name + "]"
+	}
+	text := t.Text
+	for _, param := range t.Params {
+		text = strings.ReplaceAll(text, "{{"+param+"}}", values[param])
+	}
+	return text
+}
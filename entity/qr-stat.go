@@ -2,7 +2,8 @@ package entity
 
 import (
 	"time"
-	"unicode"
+
+	"DarkCS/internal/lib/i18n"
 )
 
 // QrStat records a single QR-code funnel event.
@@ -20,25 +21,10 @@ type QrStat struct {
 
 // GetMonthName returns the Ukrainian month name for the given date, capitalized.
 func GetMonthName(date time.Time) string {
-	months := []string{
-		"січень",   // January
-		"лютий",    // February
-		"березень", // March
-		"квітень",  // April
-		"травень",  // May
-		"червень",  // June
-		"липень",   // July
-		"серпень",  // August
-		"вересень", // September
-		"жовтень",  // October
-		"листопад", // November
-		"грудень",  // December
-	}
-
-	month := months[date.Month()-1]
-	// Capitalize first letter (works with Cyrillic too)
-	runes := []rune(month)
-	runes[0] = unicode.ToUpper(runes[0])
+	return GetLocalizedMonthName(date, i18n.Default)
+}
 
-	return string(runes)
+// GetLocalizedMonthName returns the month name for the given date in locale, capitalized.
+func GetLocalizedMonthName(date time.Time, locale string) string {
+	return i18n.MonthName(locale, date)
 }
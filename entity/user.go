@@ -17,6 +17,11 @@ type User struct {
 	TelegramUsername  string          `json:"telegram_username" bson:"telegram_username" validate:"omitempty"`
 	InstagramId       string          `json:"instagram_id" bson:"instagram_id" validate:"omitempty"`
 	InstagramUsername string          `json:"instagram_username" bson:"instagram_username" validate:"omitempty"`
+	FacebookId        string          `json:"facebook_id" bson:"facebook_id" validate:"omitempty"`
+	FacebookName      string          `json:"facebook_name" bson:"facebook_name" validate:"omitempty"`
+	ViberId           string          `json:"viber_id" bson:"viber_id" validate:"omitempty"`
+	ViberName         string          `json:"viber_name" bson:"viber_name" validate:"omitempty"`
+	WebId             string          `json:"web_id" bson:"web_id" validate:"omitempty"`
 	SmartSenderId     string          `json:"smart_sender_id" bson:"smart_sender_id" validate:"omitempty"`
 	ZohoId            string          `json:"zoho_id" bson:"zoho_id" validate:"omitempty"`
 	Role              string          `json:"role" bson:"role" validate:"omitempty"`
@@ -104,6 +109,18 @@ func (u *User) SameUser(other *User) bool {
 		return u.InstagramId == other.InstagramId
 	}
 
+	if u.FacebookId != "" && other.FacebookId != "" {
+		return u.FacebookId == other.FacebookId
+	}
+
+	if u.ViberId != "" && other.ViberId != "" {
+		return u.ViberId == other.ViberId
+	}
+
+	if u.WebId != "" && other.WebId != "" {
+		return u.WebId == other.WebId
+	}
+
 	if u.Email != "" && other.Email != "" {
 		return u.Email == other.Email
 	}
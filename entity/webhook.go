@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookEvent is a platform webhook payload waiting in the processing queue.
+// LockedUntil hides the event from other instances while one holds it; the lease is
+// renewed until the event is processed, so it only expires after a crash. Attempts
+// counts the times processing started, retries after a failure included.
+type WebhookEvent struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Platform    string             `bson:"platform"`
+	Payload     []byte             `bson:"payload"`
+	Attempts    int                `bson:"attempts"`
+	LockedUntil time.Time          `bson:"locked_until"`
+	CreatedAt   time.Time          `bson:"created_at"`
+}
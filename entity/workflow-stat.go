@@ -0,0 +1,45 @@
+package entity
+
+import "time"
+
+// WorkflowUserSteps maps the steps a single user reached in a workflow to the
+// time they first entered each of them.
+type WorkflowUserSteps struct {
+	Platform string               `json:"platform" bson:"platform"`
+	UserID   string               `json:"user_id" bson:"user_id"`
+	Steps    map[string]time.Time `json:"steps" bson:"steps"`
+}
+
+// FunnelStage is one step of a workflow funnel.
+type FunnelStage struct {
+	Step  string `json:"step"`
+	Users int    `json:"users"`
+	// DropOff is the number of users who reached the previous stage but not this one.
+	DropOff int `json:"drop_off"`
+	// Conversion is the share of users of the previous stage that reached this one (0..1).
+	Conversion float64 `json:"conversion"`
+	// TotalConversion is the share of users of the first stage that reached this one (0..1).
+	TotalConversion float64 `json:"total_conversion"`
+}
+
+// WorkflowFunnel reports how many users pass through an ordered list of steps.
+// A user counts for a stage only if they also reached every earlier stage.
+type WorkflowFunnel struct {
+	WorkflowID string        `json:"workflow_id"`
+	From       time.Time     `json:"from"`
+	To         time.Time     `json:"to"`
+	Stages     []FunnelStage `json:"stages"`
+}
+
+// WorkflowStepStat aggregates workflow events for a single step.
+type WorkflowStepStat struct {
+	Step    string `json:"step" bson:"_id"`
+	Users   int    `json:"users" bson:"users"`     // distinct users that entered the step
+	Entries int    `json:"entries" bson:"entries"` // times the step was entered
+	Exits   int    `json:"exits" bson:"exits"`     // times the step was left by a transition or completion
+	Errors  int    `json:"errors" bson:"errors"`
+	// AvgDuration is the average time in seconds spent in the step before leaving it.
+	AvgDuration float64 `json:"avg_duration_sec" bson:"-"`
+	// TotalDuration is the summed time in the step in nanoseconds, as stored in the events.
+	TotalDuration int64 `json:"-" bson:"duration"`
+}
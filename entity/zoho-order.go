@@ -1,5 +1,7 @@
 package entity
 
+import "time"
+
 type ZohoOrder struct {
 	ContactName        ContactName     `json:"Contact_Name"`
 	ContactFullName    string          `json:"A0887ffac4e3b73fb6168af580709fd74"`
@@ -76,6 +78,7 @@ type OrderDetail struct {
 	Status      string      `json:"Status"`
 	ContactName ContactName `json:"Contact_Name"`
 	TTN         string      `json:"Aa2e053928236368ec7865f3558a58c4f"`
+	CreatedTime string      `json:"Created_Time"`
 }
 
 // ServiceRating represents a service rating to be sent to Zoho CRM.
@@ -91,6 +94,12 @@ func (o *OrderDetail) IsActive() bool {
 	return !o.IsCompleted()
 }
 
+// Created returns when the order was created, or the zero time when Zoho did not say.
+func (o *OrderDetail) Created() time.Time {
+	created, _ := time.Parse(time.RFC3339, o.CreatedTime)
+	return created
+}
+
 // IsCompleted returns true if the order has reached a terminal status.
 func (o *OrderDetail) IsCompleted() bool {
 	switch o.Status {
@@ -14,6 +14,7 @@ require (
 	go.mongodb.org/mongo-driver v1.17.4
 	golang.org/x/oauth2 v0.36.0
 	google.golang.org/api v0.273.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -54,6 +55,5 @@ require (
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20260319201613-d00831a3d3e7 // indirect
 	google.golang.org/grpc v1.79.3 // indirect
 	google.golang.org/protobuf v1.36.11 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 	olympos.io/encoding/edn v0.0.0-20201019073823-d3554ca0b0a3 // indirect
 )
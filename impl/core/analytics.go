@@ -0,0 +1,82 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"DarkCS/entity"
+)
+
+// GetWorkflowFunnel counts the users that passed through the given steps of a
+// workflow, in order, between from and to. A user counts for a stage only if
+// they first entered every earlier stage, one after another, before it.
+func (c *Core) GetWorkflowFunnel(workflowID string, steps []string, from, to time.Time) (*entity.WorkflowFunnel, error) {
+	if c.repo == nil {
+		return nil, fmt.Errorf("repository is not set")
+	}
+
+	users, err := c.repo.GetWorkflowUserSteps(workflowID, steps, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int, len(steps))
+	for _, u := range users {
+		var previous time.Time
+		for i, step := range steps {
+			first, ok := u.Steps[step]
+			if !ok || first.Before(previous) {
+				break
+			}
+			counts[i]++
+			previous = first
+		}
+	}
+
+	funnel := &entity.WorkflowFunnel{
+		WorkflowID: workflowID,
+		From:       from,
+		To:         to,
+		Stages:     make([]entity.FunnelStage, len(steps)),
+	}
+	for i, step := range steps {
+		stage := entity.FunnelStage{Step: step, Users: counts[i]}
+		if i == 0 {
+			if counts[0] > 0 {
+				stage.Conversion, stage.TotalConversion = 1, 1
+			}
+		} else {
+			stage.DropOff = counts[i-1] - counts[i]
+			stage.Conversion = ratio(counts[i], counts[i-1])
+			stage.TotalConversion = ratio(counts[i], counts[0])
+		}
+		funnel.Stages[i] = stage
+	}
+	return funnel, nil
+}
+
+// GetWorkflowStepStats returns entry, exit, error counts and the average time
+// spent in each step of a workflow between from and to.
+func (c *Core) GetWorkflowStepStats(workflowID string, from, to time.Time) ([]entity.WorkflowStepStat, error) {
+	if c.repo == nil {
+		return nil, fmt.Errorf("repository is not set")
+	}
+
+	stats, err := c.repo.GetWorkflowStepStats(workflowID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		if stats[i].Exits > 0 {
+			stats[i].AvgDuration = time.Duration(stats[i].TotalDuration / int64(stats[i].Exits)).Seconds()
+		}
+	}
+	return stats, nil
+}
+
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
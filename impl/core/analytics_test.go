@@ -0,0 +1,152 @@
+package core
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"DarkCS/entity"
+)
+
+// analyticsRepo returns fixed workflow events; other Repository methods are not used.
+type analyticsRepo struct {
+	Repository
+	users []entity.WorkflowUserSteps
+	stats []entity.WorkflowStepStat
+}
+
+func (r *analyticsRepo) GetWorkflowUserSteps(workflowID string, steps []string, from, to time.Time) ([]entity.WorkflowUserSteps, error) {
+	return r.users, nil
+}
+
+func (r *analyticsRepo) GetWorkflowStepStats(workflowID string, from, to time.Time) ([]entity.WorkflowStepStat, error) {
+	return slices.Clone(r.stats), nil
+}
+
+// visited records a user who first entered steps one minute apart, in the given order.
+func visited(userID string, steps ...string) entity.WorkflowUserSteps {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := make(map[string]time.Time, len(steps))
+	for i, step := range steps {
+		first[step] = start.Add(time.Duration(i) * time.Minute)
+	}
+	return entity.WorkflowUserSteps{Platform: "telegram", UserID: userID, Steps: first}
+}
+
+func TestGetWorkflowFunnel(t *testing.T) {
+	steps := []string{"start", "phone", "done"}
+	tests := []struct {
+		name  string
+		users []entity.WorkflowUserSteps
+		want  []entity.FunnelStage
+	}{
+		{
+			name: "drop-off and conversion",
+			users: []entity.WorkflowUserSteps{
+				visited("1", "start", "phone", "done"),
+				visited("2", "start", "phone"),
+				visited("3", "start", "phone"),
+				visited("4", "start"),
+			},
+			want: []entity.FunnelStage{
+				{Step: "start", Users: 4, Conversion: 1, TotalConversion: 1},
+				{Step: "phone", Users: 3, DropOff: 1, Conversion: 0.75, TotalConversion: 0.75},
+				{Step: "done", Users: 1, DropOff: 2, Conversion: 1.0 / 3, TotalConversion: 0.25},
+			},
+		},
+		{
+			name: "user who skips a stage counts only up to the gap",
+			users: []entity.WorkflowUserSteps{
+				visited("1", "start", "phone", "done"),
+				visited("2", "start", "done"),
+			},
+			want: []entity.FunnelStage{
+				{Step: "start", Users: 2, Conversion: 1, TotalConversion: 1},
+				{Step: "phone", Users: 1, DropOff: 1, Conversion: 0.5, TotalConversion: 0.5},
+				{Step: "done", Users: 1, Conversion: 1, TotalConversion: 0.5},
+			},
+		},
+		{
+			name: "user who reached a stage before an earlier one counts only up to it",
+			users: []entity.WorkflowUserSteps{
+				visited("1", "start", "phone", "done"),
+				visited("2", "phone", "start", "done"),
+				visited("3", "start", "done", "phone"),
+			},
+			want: []entity.FunnelStage{
+				{Step: "start", Users: 3, Conversion: 1, TotalConversion: 1},
+				{Step: "phone", Users: 2, DropOff: 1, Conversion: 2.0 / 3, TotalConversion: 2.0 / 3},
+				{Step: "done", Users: 1, DropOff: 1, Conversion: 0.5, TotalConversion: 1.0 / 3},
+			},
+		},
+		{
+			name: "stage nobody reached",
+			users: []entity.WorkflowUserSteps{
+				visited("1", "start"),
+				visited("2", "start"),
+			},
+			want: []entity.FunnelStage{
+				{Step: "start", Users: 2, Conversion: 1, TotalConversion: 1},
+				{Step: "phone", Users: 0, DropOff: 2},
+				{Step: "done", Users: 0},
+			},
+		},
+		{
+			name: "no users",
+			want: []entity.FunnelStage{
+				{Step: "start"},
+				{Step: "phone"},
+				{Step: "done"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Core{repo: &analyticsRepo{users: tt.users}}
+			funnel, err := c.GetWorkflowFunnel("onboarding", steps, time.Time{}, time.Now())
+			if err != nil {
+				t.Fatalf("GetWorkflowFunnel: %v", err)
+			}
+			if !slices.Equal(funnel.Stages, tt.want) {
+				t.Errorf("stages = %+v, want %+v", funnel.Stages, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetWorkflowStepStatsAveragesDurations(t *testing.T) {
+	repo := &analyticsRepo{stats: []entity.WorkflowStepStat{
+		{Step: "start", Entries: 3, Exits: 2, TotalDuration: int64(30 * time.Second)},
+		{Step: "phone", Entries: 1, Exits: 0},
+	}}
+	c := &Core{repo: repo}
+
+	stats, err := c.GetWorkflowStepStats("onboarding", time.Time{}, time.Now())
+	if err != nil {
+		t.Fatalf("GetWorkflowStepStats: %v", err)
+	}
+	if got := stats[0].AvgDuration; got != 15 {
+		t.Errorf("start average = %v s, want 15", got)
+	}
+	if got := stats[1].AvgDuration; got != 0 {
+		t.Errorf("average of a step never left = %v s, want 0", got)
+	}
+}
+
+func TestRatio(t *testing.T) {
+	tests := []struct {
+		n, total int
+		want     float64
+	}{
+		{n: 1, total: 4, want: 0.25},
+		{n: 4, total: 4, want: 1},
+		{n: 0, total: 4, want: 0},
+		{n: 0, total: 0, want: 0},
+	}
+	for _, tt := range tests {
+		if got := ratio(tt.n, tt.total); got != tt.want {
+			t.Errorf("ratio(%d, %d) = %v, want %v", tt.n, tt.total, got, tt.want)
+		}
+	}
+}
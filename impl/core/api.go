@@ -6,6 +6,7 @@ import (
 	"DarkCS/entity"
 	"DarkCS/internal/lib/sl"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
@@ -500,6 +501,30 @@ func (c *Core) ImportTelegramUsers(items []entity.TelegramImportItem) (int, erro
 
 		// Save chat state for pre-main menu
 		tgID := strconv.FormatInt(item.TelegramID, 10)
+		if err := c.saveImportedState(context.Background(), tgID); err != nil {
+			c.log.Error("import telegram: save chat state", sl.Err(err))
+			continue
+		}
+
+		processed++
+	}
+
+	return processed, nil
+}
+
+// importStateAttempts is how many times an imported chat state is saved when the
+// chat engine changes it in the meantime.
+const importStateAttempts = 3
+
+// saveImportedState puts an imported Telegram user at the pre-main menu step,
+// replacing any state they had. A save that conflicts with the chat engine is
+// retried on the freshly loaded version.
+func (c *Core) saveImportedState(ctx context.Context, tgID string) error {
+	for attempt := 1; ; attempt++ {
+		prev, err := c.repo.LoadChatState(ctx, "telegram", tgID)
+		if err != nil {
+			return err
+		}
 		state := &chat.ChatState{
 			Platform:    "telegram",
 			UserID:      tgID,
@@ -507,13 +532,12 @@ func (c *Core) ImportTelegramUsers(items []entity.TelegramImportItem) (int, erro
 			WorkflowID:  mainmenu.WorkflowID,
 			CurrentStep: mainmenu.StepPreMainMenu,
 		}
-		if err := c.repo.SaveChatState(context.Background(), state); err != nil {
-			c.log.Error("import telegram: save chat state", sl.Err(err))
-			continue
+		if prev != nil {
+			state.Version = prev.Version
+		}
+		err = c.repo.SaveChatState(ctx, state)
+		if !errors.Is(err, chat.ErrStateConflict) || attempt == importStateAttempts {
+			return err
 		}
-
-		processed++
 	}
-
-	return processed, nil
 }
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"DarkCS/bot/chat"
+	"DarkCS/bot/chat/mainmenu"
+)
+
+// stateRepo stores chat states with version checks and lets the chat engine
+// save a newer version between a load and the following save.
+type stateRepo struct {
+	Repository
+	state *chat.ChatState
+	// racing is how many saves lose to a concurrent engine save
+	racing int
+}
+
+func (r *stateRepo) LoadChatState(ctx context.Context, platform, userID string) (*chat.ChatState, error) {
+	if r.state == nil {
+		return nil, nil
+	}
+	state := *r.state
+	return &state, nil
+}
+
+func (r *stateRepo) SaveChatState(ctx context.Context, state *chat.ChatState) error {
+	if r.racing > 0 {
+		r.racing--
+		r.state.Version++
+	}
+	if r.state != nil && r.state.Version != state.Version {
+		return chat.ErrStateConflict
+	}
+	saved := *state
+	saved.Version++
+	r.state = &saved
+	return nil
+}
+
+func TestSaveImportedStateRetriesConflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		racing  int
+		wantErr bool
+	}{
+		{name: "no conflict", racing: 0},
+		{name: "engine saves in between", racing: importStateAttempts - 1},
+		{name: "engine keeps saving", racing: importStateAttempts, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stateRepo{
+				state:  &chat.ChatState{Platform: "telegram", UserID: "42", WorkflowID: mainmenu.WorkflowID, CurrentStep: mainmenu.StepAIConsultant, Version: 3},
+				racing: tt.racing,
+			}
+			c := &Core{repo: repo, log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+			err := c.saveImportedState(context.Background(), "42")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want a conflict error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("save imported state: %v", err)
+			}
+			if repo.state.CurrentStep != mainmenu.StepPreMainMenu {
+				t.Errorf("step = %s, want %s", repo.state.CurrentStep, mainmenu.StepPreMainMenu)
+			}
+		})
+	}
+}
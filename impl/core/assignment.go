@@ -0,0 +1,243 @@
+package core
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"DarkCS/entity"
+)
+
+// AssignChat gives a chat to a CRM user, or returns it to the unassigned queue when assignee is empty.
+// username is the CRM user making the change. An unassigned chat may be assigned by anyone, but
+// a chat held by someone fails with entity.ErrChatAssigned unless username holds it or is a CRM admin.
+// The change is broadcast to every CRM client.
+func (c *Core) AssignChat(username, platform, userID, assignee string) (*entity.ChatAssignment, error) {
+	var assignment *entity.ChatAssignment
+	var err error
+	if slices.Contains(c.admins, username) {
+		assignment, err = c.repo.SetChatAssignee(platform, userID, assignee)
+	} else {
+		assignment, err = c.repo.TransferChat(platform, userID, username, assignee)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if assignment == nil {
+		return nil, entity.ErrChatAssigned
+	}
+	c.broadcastAssignment(assignment)
+	return assignment, nil
+}
+
+// SetChatStatus opens, suspends or closes a chat, keeping its assignee.
+// username is the CRM user making the change; a chat held by someone else fails
+// with entity.ErrChatAssigned unless username is a CRM admin.
+// Closing a chat hands it back to the bot.
+func (c *Core) SetChatStatus(username, platform, userID string, status entity.ChatStatus) (*entity.ChatAssignment, error) {
+	if !status.Valid() {
+		return nil, fmt.Errorf("unknown chat status: %s", status)
+	}
+	if err := c.checkChatHolder(username, platform, userID); err != nil {
+		return nil, err
+	}
+	assignment, err := c.repo.SetChatStatus(platform, userID, status)
+	if err != nil {
+		return nil, err
+	}
+	if status == entity.ChatClosed && assignment.HumanMode {
+		if assignment, err = c.repo.SetChatHumanMode(platform, userID, false); err != nil {
+			return nil, err
+		}
+	}
+	c.broadcastAssignment(assignment)
+	return assignment, nil
+}
+
+// HumanMode reports whether a manager is handling the chat, so the bot must stay silent.
+// Human mode that ran out is turned off here. On a storage error the bot keeps answering.
+func (c *Core) HumanMode(platform, userID string) bool {
+	assignment, err := c.repo.GetChatAssignment(platform, userID)
+	if err != nil {
+		c.log.Error("failed to get chat assignment",
+			slog.String("platform", platform),
+			slog.String("user_id", userID),
+			slog.String("error", err.Error()),
+		)
+		return false
+	}
+	if assignment == nil || !assignment.HumanMode {
+		return false
+	}
+	if assignment.HumanModeActive(time.Now(), c.handoffTimeout) {
+		return true
+	}
+
+	c.log.Info("human mode timed out, the bot answers again",
+		slog.String("platform", platform),
+		slog.String("user_id", userID),
+	)
+	c.setHumanMode(platform, userID, false)
+	return false
+}
+
+// RequestHuman turns human mode on because the user asked for a manager.
+func (c *Core) RequestHuman(platform, userID string) {
+	c.setHumanMode(platform, userID, true)
+}
+
+// setHumanMode turns human mode on or off and notifies CRM clients.
+// Turning it on again restarts the handoff timeout.
+func (c *Core) setHumanMode(platform, userID string, on bool) {
+	assignment, err := c.repo.SetChatHumanMode(platform, userID, on)
+	if err != nil {
+		c.log.Error("failed to set human mode",
+			slog.String("platform", platform),
+			slog.String("user_id", userID),
+			slog.Bool("human_mode", on),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	c.broadcastAssignment(assignment)
+}
+
+// checkChatHolder makes sure the CRM user may change the chat: it is unassigned,
+// held by them, or they are a CRM admin. Otherwise it fails with entity.ErrChatAssigned.
+func (c *Core) checkChatHolder(username, platform, userID string) error {
+	if username == "" {
+		return fmt.Errorf("%w: no CRM user", entity.ErrChatAssigned)
+	}
+	if slices.Contains(c.admins, username) {
+		return nil
+	}
+
+	current, err := c.repo.GetChatAssignment(platform, userID)
+	if err != nil {
+		return fmt.Errorf("get chat assignment %s/%s: %w", platform, userID, err)
+	}
+	if current != nil && current.Assignee != "" && current.Assignee != username {
+		return fmt.Errorf("%w: %s", entity.ErrChatAssigned, current.Assignee)
+	}
+	return nil
+}
+
+// claimChatForReply makes sure the CRM user may reply to the chat. An unassigned chat
+// is assigned to them; a chat assigned to someone else fails with entity.ErrChatAssigned.
+func (c *Core) claimChatForReply(username, platform, userID string) error {
+	if username == "" {
+		return fmt.Errorf("%w: no CRM user", entity.ErrChatAssigned)
+	}
+
+	current, err := c.repo.GetChatAssignment(platform, userID)
+	if err != nil {
+		return fmt.Errorf("get chat assignment %s/%s: %w", platform, userID, err)
+	}
+	if current != nil && current.Assignee == username {
+		return nil
+	}
+	if current != nil && current.Assignee != "" {
+		return fmt.Errorf("%w: %s", entity.ErrChatAssigned, current.Assignee)
+	}
+
+	assignment, err := c.repo.ClaimChat(platform, userID, username)
+	if err != nil {
+		return fmt.Errorf("claim chat %s/%s: %w", platform, userID, err)
+	}
+	if assignment == nil {
+		// Another CRM user claimed the chat first
+		return entity.ErrChatAssigned
+	}
+	c.broadcastAssignment(assignment)
+	return nil
+}
+
+// routeIncomingChat runs when the customer writes. A closed chat is opened again
+// for its assignee, and with auto-assignment enabled an unassigned chat goes to the next agent.
+// A chat that is assigned and not closed costs a single read.
+func (c *Core) routeIncomingChat(platform, userID string) {
+	// Messages in a row route the chat once, so they do not give it to several agents in turn
+	key := platform + "/" + userID
+	if _, running := c.routing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+	defer c.routing.Delete(key)
+
+	log := c.log.With(
+		slog.String("platform", platform),
+		slog.String("user_id", userID),
+	)
+
+	assignment, err := c.repo.GetChatAssignment(platform, userID)
+	if err != nil {
+		log.Error("failed to get chat assignment", slog.String("error", err.Error()))
+		return
+	}
+
+	if assignment != nil && assignment.Status == entity.ChatClosed {
+		assignment, err = c.repo.SetChatStatus(platform, userID, entity.ChatOpen)
+		if err != nil {
+			log.Error("failed to reopen chat", slog.String("error", err.Error()))
+			return
+		}
+		c.broadcastAssignment(assignment)
+	}
+
+	if assignment != nil && assignment.Assignee != "" {
+		return
+	}
+
+	agent, err := c.pickAgent()
+	if err != nil {
+		log.Error("failed to pick an agent", slog.String("error", err.Error()))
+		return
+	}
+	if agent == "" {
+		return
+	}
+
+	assignment, err = c.repo.ClaimChat(platform, userID, agent)
+	if err != nil {
+		log.Error("failed to auto-assign chat", slog.String("agent", agent), slog.String("error", err.Error()))
+		return
+	}
+	if assignment != nil {
+		c.broadcastAssignment(assignment)
+	}
+}
+
+// pickAgent returns the agent for the next auto-assigned chat, or "" when auto-assignment is off.
+// Least-loaded counts the open and pending chats of each agent; ties go to the agent listed first.
+func (c *Core) pickAgent() (string, error) {
+	c.assignMu.Lock()
+	defer c.assignMu.Unlock()
+
+	if c.autoAssign == "" || len(c.agents) == 0 {
+		return "", nil
+	}
+
+	if c.autoAssign == entity.AssignRoundRobin {
+		agent := c.agents[c.nextAgent%len(c.agents)]
+		c.nextAgent++
+		return agent, nil
+	}
+
+	load, err := c.repo.CountActiveChats(c.agents)
+	if err != nil {
+		return "", err
+	}
+	best := c.agents[0]
+	for _, agent := range c.agents[1:] {
+		if load[agent] < load[best] {
+			best = agent
+		}
+	}
+	return best, nil
+}
+
+func (c *Core) broadcastAssignment(assignment *entity.ChatAssignment) {
+	if c.wsHub != nil {
+		c.wsHub.BroadcastChatAssignment(*assignment)
+	}
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"DarkCS/entity"
+)
+
+// assignmentRepo keeps one chat assignment in memory; other Repository methods are not used.
+type assignmentRepo struct {
+	Repository
+	assignment *entity.ChatAssignment
+}
+
+func (r *assignmentRepo) GetChatAssignment(platform, userID string) (*entity.ChatAssignment, error) {
+	if r.assignment == nil {
+		return nil, nil
+	}
+	assignment := *r.assignment
+	return &assignment, nil
+}
+
+func (r *assignmentRepo) ClaimChat(platform, userID, assignee string) (*entity.ChatAssignment, error) {
+	return r.TransferChat(platform, userID, assignee, assignee)
+}
+
+func (r *assignmentRepo) TransferChat(platform, userID, holder, assignee string) (*entity.ChatAssignment, error) {
+	if r.assignment == nil {
+		r.assignment = &entity.ChatAssignment{Platform: platform, UserID: userID, Status: entity.ChatOpen}
+	}
+	if r.assignment.Assignee != "" && r.assignment.Assignee != holder {
+		return nil, nil
+	}
+	r.assignment.Assignee = assignee
+	return r.GetChatAssignment(platform, userID)
+}
+
+func (r *assignmentRepo) SetChatStatus(platform, userID string, status entity.ChatStatus) (*entity.ChatAssignment, error) {
+	if r.assignment == nil {
+		r.assignment = &entity.ChatAssignment{Platform: platform, UserID: userID}
+	}
+	r.assignment.Status = status
+	return r.GetChatAssignment(platform, userID)
+}
+
+func (r *assignmentRepo) SetChatHumanMode(platform, userID string, on bool) (*entity.ChatAssignment, error) {
+	r.assignment.HumanMode = on
+	return r.GetChatAssignment(platform, userID)
+}
+
+func newAssignmentCore(assignee string) (*Core, *assignmentRepo) {
+	repo := &assignmentRepo{assignment: &entity.ChatAssignment{
+		Platform:  "telegram",
+		UserID:    "100",
+		Assignee:  assignee,
+		Status:    entity.ChatOpen,
+		HumanMode: true,
+	}}
+	c := &Core{repo: repo, log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	c.SetCrmAdmins([]string{"admin"})
+	return c, repo
+}
+
+func TestSetChatStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		assignee string
+		username string
+		denied   bool
+	}{
+		{name: "assignee", assignee: "olena", username: "olena"},
+		{name: "admin", assignee: "olena", username: "admin"},
+		{name: "unassigned chat", assignee: "", username: "ivan"},
+		{name: "another manager", assignee: "olena", username: "ivan", denied: true},
+		{name: "no CRM user", assignee: "", username: "", denied: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, repo := newAssignmentCore(tt.assignee)
+
+			_, err := c.SetChatStatus(tt.username, "telegram", "100", entity.ChatClosed)
+			if tt.denied {
+				if !errors.Is(err, entity.ErrChatAssigned) {
+					t.Fatalf("error = %v, want %v", err, entity.ErrChatAssigned)
+				}
+				if repo.assignment.Status != entity.ChatOpen || !repo.assignment.HumanMode {
+					t.Errorf("assignment = %+v, want it left open in human mode", repo.assignment)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("set chat status: %v", err)
+			}
+			if repo.assignment.Status != entity.ChatClosed || repo.assignment.HumanMode {
+				t.Errorf("assignment = %+v, want it closed and handed back to the bot", repo.assignment)
+			}
+		})
+	}
+}
+
+func TestClaimChatForReply(t *testing.T) {
+	tests := []struct {
+		name     string
+		assignee string
+		username string
+		want     string
+		denied   bool
+	}{
+		{name: "unassigned chat is claimed", assignee: "", username: "olena", want: "olena"},
+		{name: "assignee replies", assignee: "olena", username: "olena", want: "olena"},
+		{name: "another manager", assignee: "olena", username: "ivan", want: "olena", denied: true},
+		{name: "no CRM user", assignee: "", username: "", want: "", denied: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, repo := newAssignmentCore(tt.assignee)
+
+			err := c.claimChatForReply(tt.username, "telegram", "100")
+			if tt.denied != errors.Is(err, entity.ErrChatAssigned) {
+				t.Fatalf("error = %v, denied = %v", err, tt.denied)
+			}
+			if !tt.denied && err != nil {
+				t.Fatalf("claim chat: %v", err)
+			}
+			if repo.assignment.Assignee != tt.want {
+				t.Errorf("assignee = %q, want %q", repo.assignment.Assignee, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package core
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"DarkCS/entity"
+	"DarkCS/internal/lib/fileurl"
+)
+
+// GetCannedResponses returns the team replies and the user's personal replies.
+func (c *Core) GetCannedResponses(username string) ([]entity.CannedResponse, error) {
+	responses, err := c.repo.GetCannedResponses(username)
+	if err != nil {
+		return nil, err
+	}
+	for i := range responses {
+		c.signCannedAttachments(&responses[i])
+	}
+	return responses, nil
+}
+
+// CreateCannedResponse saves a new reply owned by the CRM user.
+func (c *Core) CreateCannedResponse(username, title, text, scope string) (*entity.CannedResponse, error) {
+	if !entity.ValidScope(scope) {
+		return nil, fmt.Errorf("unknown canned response scope: %s", scope)
+	}
+	now := time.Now()
+	return c.repo.CreateCannedResponse(entity.CannedResponse{
+		Title:       title,
+		Text:        text,
+		Attachments: []entity.Attachment{},
+		Scope:       scope,
+		Owner:       username,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+}
+
+// UpdateCannedResponse changes the title, text and scope of a reply. Every CRM user may edit
+// team replies, but only the owner may change the scope.
+func (c *Core) UpdateCannedResponse(username string, id primitive.ObjectID, title, text, scope string) (*entity.CannedResponse, error) {
+	if !entity.ValidScope(scope) {
+		return nil, fmt.Errorf("unknown canned response scope: %s", scope)
+	}
+	current, err := c.cannedResponse(username, id)
+	if err != nil {
+		return nil, err
+	}
+	if scope != current.Scope && current.Owner != username {
+		return nil, entity.ErrCannedResponseOwner
+	}
+
+	response, err := c.repo.UpdateCannedResponse(id, title, text, scope)
+	if err != nil {
+		return nil, err
+	}
+	c.signCannedAttachments(response)
+	return response, nil
+}
+
+// DeleteCannedResponse deletes a reply the CRM user can see, along with its files.
+func (c *Core) DeleteCannedResponse(username string, id primitive.ObjectID) error {
+	response, err := c.cannedResponse(username, id)
+	if err != nil {
+		return err
+	}
+	if err = c.repo.DeleteCannedResponse(id); err != nil {
+		return err
+	}
+	for _, attachment := range response.Attachments {
+		c.deleteCannedFile(attachment.FileID)
+	}
+	return nil
+}
+
+// GetCannedResponse returns a reply the CRM user can see.
+func (c *Core) GetCannedResponse(username string, id primitive.ObjectID) (*entity.CannedResponse, error) {
+	response, err := c.cannedResponse(username, id)
+	if err != nil {
+		return nil, err
+	}
+	c.signCannedAttachments(response)
+	return response, nil
+}
+
+// AddCannedAttachments attaches uploaded files to a reply. When they cannot be
+// attached, the files are deleted.
+func (c *Core) AddCannedAttachments(username string, id primitive.ObjectID, attachments []entity.Attachment) (*entity.CannedResponse, error) {
+	response, err := c.addCannedAttachments(username, id, attachments)
+	if err != nil {
+		for _, attachment := range attachments {
+			c.deleteCannedFile(attachment.FileID)
+		}
+		return nil, err
+	}
+	c.signCannedAttachments(response)
+	return response, nil
+}
+
+func (c *Core) addCannedAttachments(username string, id primitive.ObjectID, attachments []entity.Attachment) (*entity.CannedResponse, error) {
+	if _, err := c.cannedResponse(username, id); err != nil {
+		return nil, err
+	}
+	return c.repo.AddCannedAttachments(id, attachments)
+}
+
+// RemoveCannedAttachment detaches a file from a reply and deletes it.
+func (c *Core) RemoveCannedAttachment(username string, id, fileID primitive.ObjectID) (*entity.CannedResponse, error) {
+	if _, err := c.cannedResponse(username, id); err != nil {
+		return nil, err
+	}
+	response, err := c.repo.RemoveCannedAttachment(id, fileID)
+	if err != nil {
+		return nil, err
+	}
+	c.deleteCannedFile(fileID)
+	c.signCannedAttachments(response)
+	return response, nil
+}
+
+// deleteCannedFile deletes a file that no longer belongs to a reply. A file that was
+// sent with the reply is kept, since the chat messages still refer to it.
+func (c *Core) deleteCannedFile(fileID primitive.ObjectID) {
+	log := c.log.With(slog.String("file_id", fileID.Hex()))
+
+	sent, err := c.repo.ChatMessageFileExists(fileID)
+	if err != nil {
+		log.Error("failed to check canned response file", slog.String("error", err.Error()))
+		return
+	}
+	if sent {
+		return
+	}
+	if err = c.repo.DeleteFile(fileID); err != nil {
+		log.Error("failed to delete canned response file", slog.String("error", err.Error()))
+	}
+}
+
+// RenderCannedResponse returns the reply text with its placeholders filled in for the chat.
+// A placeholder without a value fails with entity.ErrCannedVariable.
+func (c *Core) RenderCannedResponse(username, platform, userID string, id primitive.ObjectID) (string, error) {
+	response, err := c.cannedResponse(username, id)
+	if err != nil {
+		return "", err
+	}
+	return response.Render(c.cannedVariables(platform, userID, response.Variables()))
+}
+
+// SendCannedResponse fills in a reply for the chat and sends it like a manager message:
+// through SendCrmFiles with the text as caption when it has files, SendCrmMessage otherwise.
+func (c *Core) SendCannedResponse(username, platform, userID string, id primitive.ObjectID) error {
+	response, err := c.cannedResponse(username, id)
+	if err != nil {
+		return err
+	}
+	text, err := response.Render(c.cannedVariables(platform, userID, response.Variables()))
+	if err != nil {
+		return err
+	}
+
+	if len(response.Attachments) > 0 {
+		return c.SendCrmFiles(username, platform, userID, text, response.Attachments)
+	}
+	return c.SendCrmMessage(username, platform, userID, text)
+}
+
+// cannedResponse loads a reply and hides the personal replies of other CRM users.
+func (c *Core) cannedResponse(username string, id primitive.ObjectID) (*entity.CannedResponse, error) {
+	response, err := c.repo.GetCannedResponse(id)
+	if err != nil {
+		return nil, err
+	}
+	if !response.VisibleTo(username) {
+		return nil, entity.ErrCannedResponseNotFound
+	}
+	return response, nil
+}
+
+// cannedVariables resolves the placeholders a reply uses for the chat's user:
+//   - name, phone, email: from the user profile;
+//   - last_order.number, last_order.status, last_order.ttn: from the latest active Zoho order,
+//     or the latest order when none is active;
+//   - school: the school the user selected.
+//
+// Zoho and the school records are only queried when the reply needs them.
+// Unknown and unresolved placeholders are left out.
+func (c *Core) cannedVariables(platform, userID string, names []string) map[string]string {
+	values := make(map[string]string)
+	if len(names) == 0 {
+		return values
+	}
+
+	log := c.log.With(
+		slog.String("platform", platform),
+		slog.String("user_id", userID),
+	)
+
+	user := c.lookupUserByPlatform(platform, userID)
+	var order *entity.OrderDetail
+	orderLoaded := false
+
+	for _, name := range names {
+		switch {
+		case name == "name" && user != nil:
+			values[name] = user.Name
+		case name == "phone" && user != nil:
+			values[name] = user.Phone
+		case name == "email" && user != nil:
+			values[name] = user.Email
+		case name == "school":
+			stat, err := c.repo.GetSchoolStat(platform, userID)
+			if err != nil {
+				log.Error("failed to get school for canned response", slog.String("error", err.Error()))
+			} else if stat != nil {
+				values[name] = stat.SchoolName
+			}
+		case strings.HasPrefix(name, "last_order."):
+			if !orderLoaded {
+				order = c.lastOrder(user)
+				orderLoaded = true
+			}
+			if order == nil {
+				continue
+			}
+			switch strings.TrimPrefix(name, "last_order.") {
+			case "number":
+				values[name] = order.Subject
+			case "status":
+				values[name] = order.Status
+			case "ttn":
+				values[name] = order.TTN
+			}
+		}
+	}
+	return values
+}
+
+// lastOrder returns the user's latest active order, or their latest order when none is active.
+func (c *Core) lastOrder(user *entity.User) *entity.OrderDetail {
+	if user == nil || user.ZohoId == "" || c.zoho == nil {
+		return nil
+	}
+	orders, err := c.zoho.GetOrdersDetailedByZohoId(user.ZohoId)
+	if err != nil {
+		c.log.Error("failed to get orders for canned response",
+			slog.String("zoho_id", user.ZohoId),
+			slog.String("error", err.Error()),
+		)
+		return nil
+	}
+	if len(orders) == 0 {
+		return nil
+	}
+	// Zoho lists the orders of a contact in no particular order
+	slices.SortStableFunc(orders, func(a, b entity.OrderDetail) int {
+		return b.Created().Compare(a.Created())
+	})
+	for i := range orders {
+		if orders[i].IsActive() {
+			return &orders[i]
+		}
+	}
+	return &orders[0]
+}
+
+func (c *Core) signCannedAttachments(response *entity.CannedResponse) {
+	for i := range response.Attachments {
+		response.Attachments[i].URL = fileurl.SignURL(response.Attachments[i].FileID.Hex(), c.signingSecret, 15*time.Minute)
+	}
+}
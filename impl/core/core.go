@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"io"
 	"log/slog"
+	"sync"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -20,26 +21,67 @@ type Repository interface {
 	GenerateApiKey(username string) (string, error)
 
 	SaveChatMessage(msg entity.ChatMessage) error
+	ChatMessageFileExists(fileID primitive.ObjectID) (bool, error)
 	GetChatMessages(platform, userID string, limit, offset int) ([]entity.ChatMessage, error)
+	SearchChatMessages(search entity.ChatSearch) ([]entity.ChatSearchHit, error)
+	GetChatMessageContext(platform, userID string, id primitive.ObjectID, before, after int) (*entity.ChatMessageContext, error)
+	SetChatMessageTranscript(fileID primitive.ObjectID, transcript string) (*entity.ChatMessage, error)
+	UpdateChatMessageStatus(platform, platformMessageID string, status entity.MessageStatus, statusError string) (*entity.ChatMessage, error)
+	MarkChatMessagesReadBefore(platform, userID string, before time.Time) ([]entity.ChatMessage, error)
 	GetActiveChats() ([]entity.ChatSummary, error)
 	CountUnreadPerChat(receipts map[string]time.Time) (map[string]int, error)
 	CleanupChatMessages() error
 	EnsureChatMessageIndexes() error
 
 	UploadFile(filename string, reader io.Reader, meta entity.FileMetadata) (primitive.ObjectID, int64, error)
+	DeleteFile(fileID primitive.ObjectID) error
 	DownloadFile(fileID primitive.ObjectID) (string, entity.FileMetadata, io.ReadCloser, error)
 
 	UpsertReadReceipt(username, platform, userID string, readAt time.Time) error
 	GetReadReceipts(username string) ([]entity.ChatReadReceipt, error)
 	EnsureReadReceiptIndexes() error
 
+	GetChatAssignments() ([]entity.ChatAssignment, error)
+	GetChatAssignment(platform, userID string) (*entity.ChatAssignment, error)
+	CountActiveChats(assignees []string) (map[string]int, error)
+	ClaimChat(platform, userID, assignee string) (*entity.ChatAssignment, error)
+	TransferChat(platform, userID, holder, assignee string) (*entity.ChatAssignment, error)
+	SetChatAssignee(platform, userID, assignee string) (*entity.ChatAssignment, error)
+	SetChatStatus(platform, userID string, status entity.ChatStatus) (*entity.ChatAssignment, error)
+	SetChatHumanMode(platform, userID string, on bool) (*entity.ChatAssignment, error)
+	AddChatTag(platform, userID, tag string) (*entity.ChatAssignment, error)
+	RemoveChatTag(platform, userID, tag string) (*entity.ChatAssignment, error)
+	GetChatTags() ([]string, error)
+	EnsureChatAssignmentIndexes() error
+
+	AddChatNote(note entity.ChatNote) (*entity.ChatNote, error)
+	GetChatNotes(platform, userID string) ([]entity.ChatNote, error)
+	DeleteChatNote(id primitive.ObjectID, author string) (*entity.ChatNote, error)
+	EnsureChatNoteIndexes() error
+
+	CreateCannedResponse(response entity.CannedResponse) (*entity.CannedResponse, error)
+	GetCannedResponse(id primitive.ObjectID) (*entity.CannedResponse, error)
+	GetCannedResponses(username string) ([]entity.CannedResponse, error)
+	UpdateCannedResponse(id primitive.ObjectID, title, text, scope string) (*entity.CannedResponse, error)
+	AddCannedAttachments(id primitive.ObjectID, attachments []entity.Attachment) (*entity.CannedResponse, error)
+	RemoveCannedAttachment(id, fileID primitive.ObjectID) (*entity.CannedResponse, error)
+	DeleteCannedResponse(id primitive.ObjectID) error
+
 	SaveChatState(ctx context.Context, state *chat.ChatState) error
+	LoadChatState(ctx context.Context, platform, userID string) (*chat.ChatState, error)
+	EnsureChatStateIndexes() error
+
+	EnsureWorkflowEventIndexes() error
+	EnsureWebhookIndexes() error
+	GetWorkflowUserSteps(workflowID string, steps []string, from, to time.Time) ([]entity.WorkflowUserSteps, error)
+	GetWorkflowStepStats(workflowID string, from, to time.Time) ([]entity.WorkflowStepStat, error)
 
 	UpsertAssistant(assistant *entity.Assistant) (*entity.Assistant, error)
 	GetAssistant(name string) (*entity.Assistant, error)
 	GetAllAssistants() ([]entity.Assistant, error)
 
 	GetAllQrStat() ([]entity.QrStat, error)
+	GetSchoolStat(platform, userID string) (*entity.QrStat, error)
 
 	FollowQr(smartSenderId string) error
 	RegisterQr(smartSenderId string) error
@@ -66,6 +108,7 @@ type Assistant interface {
 	HandleCommand(user *entity.User, name string, args json.RawMessage) (interface{}, error)
 
 	GetAudioText(fileURL string) (string, error)
+	TranscribeAudio(r io.Reader, filename string) (string, error)
 
 	AttachNewFile() error
 }
@@ -75,6 +118,9 @@ type AuthService interface {
 	GetUser(email, phone string, telegramId int64) (*entity.User, error)
 	GetUserByUUID(uuid string) (*entity.User, error)
 	GetUserByInstagramId(instagramId string) (*entity.User, error)
+	GetUserByFacebookId(facebookId string) (*entity.User, error)
+	GetUserByViberId(viberId string) (*entity.User, error)
+	GetUserByWebId(webId string) (*entity.User, error)
 	GetUserBySmartSenderId(smartSenderId string) (*entity.User, error)
 	UserExists(email, phone string, telegramId int64) (*entity.User, error)
 	BlockUser(email, phone string, telegramId int64, block bool, role string) error
@@ -140,6 +186,21 @@ type Core struct {
 	log           *slog.Logger
 	wsHub         *ws.Hub
 	messengers    map[string]chat.Messenger
+
+	voiceMaxDuration time.Duration
+
+	// assignMu guards the auto-assignment state below
+	assignMu   sync.Mutex
+	autoAssign string
+	agents     []string
+	nextAgent  int
+
+	// routing holds the chats whose incoming message is being routed
+	routing sync.Map
+	// admins are the CRM usernames that may reassign any chat
+	admins []string
+
+	handoffTimeout time.Duration
 }
 
 func New(log *slog.Logger) *Core {
@@ -200,6 +261,39 @@ func (c *Core) SetPublicURL(url string) {
 	c.publicURL = url
 }
 
+// SetVoiceMaxDuration sets the longest voice message that chat workflows transcribe; 0 means no limit.
+func (c *Core) SetVoiceMaxDuration(d time.Duration) {
+	c.voiceMaxDuration = d
+}
+
+// SetAutoAssign sets how chats without an assignee are given to agents when the customer writes:
+// entity.AssignRoundRobin, entity.AssignLeastLoaded, or "" to leave them in the unassigned queue.
+// agents are the CRM usernames that take part.
+func (c *Core) SetAutoAssign(mode string, agents []string) {
+	c.assignMu.Lock()
+	defer c.assignMu.Unlock()
+
+	switch mode {
+	case "", entity.AssignRoundRobin, entity.AssignLeastLoaded:
+	default:
+		c.log.Warn("unknown auto-assign mode, chats stay unassigned", slog.String("mode", mode))
+		mode = ""
+	}
+	c.autoAssign = mode
+	c.agents = agents
+	c.nextAgent = 0
+}
+
+// SetCrmAdmins sets the CRM usernames that may reassign chats held by other users.
+func (c *Core) SetCrmAdmins(admins []string) {
+	c.admins = admins
+}
+
+// SetHandoffTimeout sets how long human mode lasts after the manager's last message; 0 keeps it until the chat is closed.
+func (c *Core) SetHandoffTimeout(d time.Duration) {
+	c.handoffTimeout = d
+}
+
 // FileSigningSecret returns the HMAC secret used to sign file download URLs.
 func (c *Core) FileSigningSecret() string {
 	return c.signingSecret
@@ -253,10 +347,35 @@ func (c *Core) Init() {
 		c.log.Error("failed to ensure chat message indexes", slog.String("error", err.Error()))
 	}
 
+	// Ensure chat state indexes (required for optimistic versioning)
+	if err := c.repo.EnsureChatStateIndexes(); err != nil {
+		c.log.Error("failed to ensure chat state indexes", slog.String("error", err.Error()))
+	}
+
+	// Ensure workflow event indexes (used by funnel analytics)
+	if err := c.repo.EnsureWorkflowEventIndexes(); err != nil {
+		c.log.Error("failed to ensure workflow event indexes", slog.String("error", err.Error()))
+	}
+
+	// Ensure webhook queue and deduplication indexes
+	if err := c.repo.EnsureWebhookIndexes(); err != nil {
+		c.log.Error("failed to ensure webhook indexes", slog.String("error", err.Error()))
+	}
+
 	// Ensure read receipt indexes
 	if err := c.repo.EnsureReadReceiptIndexes(); err != nil {
 		c.log.Error("failed to ensure read receipt indexes", slog.String("error", err.Error()))
 	}
+
+	// Ensure chat assignment indexes (required for claiming chats)
+	if err := c.repo.EnsureChatAssignmentIndexes(); err != nil {
+		c.log.Error("failed to ensure chat assignment indexes", slog.String("error", err.Error()))
+	}
+
+	// Ensure chat note indexes
+	if err := c.repo.EnsureChatNoteIndexes(); err != nil {
+		c.log.Error("failed to ensure chat note indexes", slog.String("error", err.Error()))
+	}
 }
 
 func (c *Core) SendMail(message *entity.MailMessage) (interface{}, error) {
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
-	"strconv"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -14,13 +13,53 @@ import (
 	"DarkCS/internal/lib/fileurl"
 )
 
-// GetActiveChats returns the list of active chats from MongoDB, enriched with user names
-// and per-user unread counts based on read receipts.
-func (c *Core) GetActiveChats(username string) ([]entity.ChatSummary, error) {
-	summaries, err := c.repo.GetActiveChats()
+// MessagingWindow is implemented by messengers of platforms that only accept
+// free-form messages for a limited time after the user's last message.
+type MessagingWindow interface {
+	WindowExpiresAt(chatID string) time.Time
+}
+
+// TemplateSender is implemented by messengers that can send pre-approved message templates.
+type TemplateSender interface {
+	Templates() []entity.MessageTemplate
+	SendTemplate(chatID, name string, values map[string]string) (text, messageID string, err error)
+}
+
+// GetActiveChats returns the active chats that pass the filter, enriched with their assignment,
+// user names, per-user unread counts based on read receipts and the messaging window state.
+func (c *Core) GetActiveChats(username string, filter entity.ChatFilter) ([]entity.ChatSummary, error) {
+	all, err := c.repo.GetActiveChats()
+	if err != nil {
+		return nil, err
+	}
+
+	assignments, err := c.repo.GetChatAssignments()
 	if err != nil {
 		return nil, err
 	}
+	assignmentMap := make(map[string]entity.ChatAssignment, len(assignments))
+	for _, a := range assignments {
+		assignmentMap[a.Platform+":"+a.UserID] = a
+	}
+
+	// Chats without an assignment are open and wait in the unassigned queue
+	now := time.Now()
+	summaries := all[:0]
+	for _, summary := range all {
+		summary.Status = entity.ChatOpen
+		if a, ok := assignmentMap[summary.Platform+":"+summary.UserID]; ok {
+			summary.Assignee = a.Assignee
+			summary.Status = a.Status
+			summary.HumanMode = a.HumanModeActive(now, c.handoffTimeout)
+			summary.Tags = a.Tags
+		}
+		if summary.Tags == nil {
+			summary.Tags = []string{}
+		}
+		if filter.Match(summary) {
+			summaries = append(summaries, summary)
+		}
+	}
 
 	// Build read receipts map for this CRM user
 	receiptMap := make(map[string]time.Time)
@@ -53,11 +92,15 @@ func (c *Core) GetActiveChats(username string) ([]entity.ChatSummary, error) {
 		user := c.lookupUserByPlatform(summaries[i].Platform, summaries[i].UserID)
 		if user != nil {
 			summaries[i].UserName = user.Name
-			switch summaries[i].Platform {
-			case "telegram":
-				summaries[i].MessengerName = user.TelegramUsername
-			case "instagram":
-				summaries[i].MessengerName = user.InstagramUsername
+			summaries[i].MessengerName = user.MessengerName(summaries[i].Platform)
+		}
+
+		if window, ok := c.messengers[summaries[i].Platform].(MessagingWindow); ok {
+			expires := window.WindowExpiresAt(summaries[i].UserID)
+			open := time.Now().Before(expires)
+			summaries[i].WindowOpen = &open
+			if !expires.IsZero() {
+				summaries[i].WindowExpiresAt = &expires
 			}
 		}
 	}
@@ -84,21 +127,7 @@ func (c *Core) lookupUserByPlatform(platform, userID string) *entity.User {
 		return nil
 	}
 
-	var user *entity.User
-	var err error
-
-	switch platform {
-	case "telegram":
-		telegramId, _ := strconv.ParseInt(userID, 10, 64)
-		if telegramId != 0 {
-			user, err = c.authService.GetUser("", "", telegramId)
-		}
-	case "instagram":
-		user, err = c.authService.GetUserByInstagramId(userID)
-	case "whatsapp":
-		user, err = c.authService.GetUser("", userID, 0)
-	}
-
+	user, err := entity.FindChatUser(c.authService, platform, userID)
 	if err != nil || user == nil {
 		return nil
 	}
@@ -113,14 +142,7 @@ func (c *Core) enrichMessageUser(msg *entity.ChatMessage) {
 		return
 	}
 	msg.UserName = user.Name
-	switch msg.Platform {
-	case "telegram":
-		msg.MessengerName = user.TelegramUsername
-	case "instagram":
-		msg.MessengerName = user.InstagramUsername
-	case "whatsapp":
-		msg.MessengerName = user.Phone
-	}
+	msg.MessengerName = user.MessengerName(msg.Platform)
 }
 
 // GetChatMessages returns paginated message history from MongoDB.
@@ -132,37 +154,46 @@ func (c *Core) GetChatMessages(platform, userID string, limit, offset int) ([]en
 	}
 
 	for i := range messages {
-		for j := range messages[i].Attachments {
-			messages[i].Attachments[j].URL = fileurl.SignURL(messages[i].Attachments[j].FileID.Hex(), c.signingSecret, 15*time.Minute)
-		}
+		c.signMessageAttachments(&messages[i])
 	}
 
 	return messages, nil
 }
 
 // SendCrmMessage sends a message from a manager to a user via their platform.
-func (c *Core) SendCrmMessage(platform, userID, text string) error {
+// An unassigned chat is assigned to the manager; a chat assigned to another manager
+// fails with entity.ErrChatAssigned.
+func (c *Core) SendCrmMessage(username, platform, userID, text string) error {
 	messenger, ok := c.messengers[platform]
 	if !ok {
 		return fmt.Errorf("no messenger for platform: %s", platform)
 	}
+	if err := c.claimChatForReply(username, platform, userID); err != nil {
+		return err
+	}
 
 	// For all platforms, chatID == userID
-	if err := messenger.SendText(userID, text); err != nil {
+	messageID, err := chat.SendTrackedText(messenger, userID, text)
+	if err != nil {
 		return fmt.Errorf("send message to %s/%s: %w", platform, userID, err)
 	}
 
 	// Store as outgoing message with sender="manager"
 	msg := entity.ChatMessage{
-		Platform:  platform,
-		UserID:    userID,
-		ChatID:    userID,
-		Direction: "outgoing",
-		Sender:    "manager",
-		Text:      text,
-		CreatedAt: time.Now(),
+		Platform:           platform,
+		UserID:             userID,
+		ChatID:             userID,
+		Direction:          "outgoing",
+		Sender:             "manager",
+		Text:               text,
+		CreatedAt:          time.Now(),
+		PlatformMessageIDs: entity.PlatformMessageIDs(messageID),
+		Status:             entity.MessageSent,
 	}
 
+	// The manager has taken over; the bot stays silent
+	c.setHumanMode(platform, userID, true)
+
 	if err := c.repo.SaveChatMessage(msg); err != nil {
 		c.log.Error("failed to save outgoing CRM message",
 			slog.String("platform", platform),
@@ -179,6 +210,61 @@ func (c *Core) SendCrmMessage(platform, userID, text string) error {
 	return nil
 }
 
+// GetMessageTemplates returns the message templates registered for a platform.
+func (c *Core) GetMessageTemplates(platform string) ([]entity.MessageTemplate, error) {
+	sender, ok := c.messengers[platform].(TemplateSender)
+	if !ok {
+		return nil, fmt.Errorf("platform %s does not support message templates", platform)
+	}
+	return sender.Templates(), nil
+}
+
+// SendCrmTemplate sends a pre-approved template from a manager to a user.
+// Unlike SendCrmMessage it also works after the messaging window has closed.
+// Chat assignment is enforced as in SendCrmMessage.
+func (c *Core) SendCrmTemplate(username, platform, userID, name string, values map[string]string) error {
+	sender, ok := c.messengers[platform].(TemplateSender)
+	if !ok {
+		return fmt.Errorf("platform %s does not support message templates", platform)
+	}
+	if err := c.claimChatForReply(username, platform, userID); err != nil {
+		return err
+	}
+
+	text, messageID, err := sender.SendTemplate(userID, name, values)
+	if err != nil {
+		return fmt.Errorf("send template %s to %s/%s: %w", name, platform, userID, err)
+	}
+
+	msg := entity.ChatMessage{
+		Platform:           platform,
+		UserID:             userID,
+		ChatID:             userID,
+		Direction:          "outgoing",
+		Sender:             "manager",
+		Text:               text,
+		CreatedAt:          time.Now(),
+		PlatformMessageIDs: entity.PlatformMessageIDs(messageID),
+		Status:             entity.MessageSent,
+	}
+
+	c.setHumanMode(platform, userID, true)
+
+	if err := c.repo.SaveChatMessage(msg); err != nil {
+		c.log.Error("failed to save outgoing CRM template",
+			slog.String("platform", platform),
+			slog.String("user_id", userID),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	if c.wsHub != nil {
+		c.wsHub.BroadcastMessage(msg)
+	}
+
+	return nil
+}
+
 // UpdateUserPlatformInfo saves a platform-specific username for the user.
 func (c *Core) UpdateUserPlatformInfo(platform, userID, messengerName string) {
 	if c.authService == nil || messengerName == "" {
@@ -190,18 +276,7 @@ func (c *Core) UpdateUserPlatformInfo(platform, userID, messengerName string) {
 		return
 	}
 
-	switch platform {
-	case "telegram":
-		if user.TelegramUsername == messengerName {
-			return
-		}
-		user.TelegramUsername = messengerName
-	case "instagram":
-		if user.InstagramUsername == messengerName {
-			return
-		}
-		user.InstagramUsername = messengerName
-	default:
+	if !user.SetMessengerName(platform, messengerName) {
 		return
 	}
 
@@ -216,9 +291,9 @@ func (c *Core) UpdateUserPlatformInfo(platform, userID, messengerName string) {
 
 // UploadAndSaveFile uploads a file to GridFS, saves a ChatMessage with the attachment, and broadcasts via WebSocket.
 // Called by platform bots when receiving media from users.
-func (c *Core) UploadAndSaveFile(platform, userID string, reader io.Reader, filename, mimeType string, size int64, caption string) error {
+func (c *Core) UploadAndSaveFile(platform, userID string, reader io.Reader, filename, mimeType string, size int64, caption string) (entity.Attachment, error) {
 	if size > entity.MaxFileSize {
-		return entity.FileTooLargeError(filename, size)
+		return entity.Attachment{}, entity.FileTooLargeError(filename, size)
 	}
 
 	// Wrap reader with a size-limited reader to enforce the limit even when size is unknown or incorrect
@@ -233,11 +308,11 @@ func (c *Core) UploadAndSaveFile(platform, userID string, reader io.Reader, file
 
 	fileID, storedSize, err := c.repo.UploadFile(filename, reader, meta)
 	if err != nil {
-		return fmt.Errorf("upload file: %w", err)
+		return entity.Attachment{}, fmt.Errorf("upload file: %w", err)
 	}
 
 	if storedSize > entity.MaxFileSize {
-		return entity.FileTooLargeError(filename, storedSize)
+		return entity.Attachment{}, entity.FileTooLargeError(filename, storedSize)
 	}
 
 	if size == 0 {
@@ -264,7 +339,7 @@ func (c *Core) UploadAndSaveFile(platform, userID string, reader io.Reader, file
 	}
 
 	c.SaveAndBroadcastChatMessage(msg)
-	return nil
+	return att, nil
 }
 
 // UploadFile stores a file in GridFS and returns the resulting object ID and stored size.
@@ -284,14 +359,19 @@ func (c *Core) DownloadFile(fileID primitive.ObjectID) (string, string, io.ReadC
 
 // SendCrmFiles sends files from a manager to a user via their platform messenger.
 // It downloads each file from GridFS, sends it via the platform, then saves a single ChatMessage.
-func (c *Core) SendCrmFiles(platform, userID, caption string, attachments []entity.Attachment) error {
+// Chat assignment is enforced as in SendCrmMessage.
+func (c *Core) SendCrmFiles(username, platform, userID, caption string, attachments []entity.Attachment) error {
 	messenger, ok := c.messengers[platform]
 	if !ok {
 		return fmt.Errorf("no messenger for platform: %s", platform)
 	}
+	if err := c.claimChatForReply(username, platform, userID); err != nil {
+		return err
+	}
 
 	// Send caption only with the first file
 	fileCaption := caption
+	var sentIDs []string
 	for _, att := range attachments {
 		_, meta, reader, err := c.repo.DownloadFile(att.FileID)
 		if err != nil {
@@ -304,10 +384,11 @@ func (c *Core) SendCrmFiles(platform, userID, caption string, attachments []enti
 			fileURL = c.publicURL + "/api/v1" + fileurl.SignURL(att.FileID.Hex(), c.signingSecret, 15*time.Minute)
 		}
 
-		sendErr := messenger.SendFile(userID, chat.FileMessage{
+		messageID, sendErr := chat.SendTrackedFile(messenger, userID, chat.FileMessage{
 			Reader:   reader,
 			Filename: att.Filename,
 			MIMEType: meta.MIMEType,
+			Size:     att.Size,
 			Caption:  fileCaption,
 			URL:      fileURL,
 		})
@@ -317,6 +398,7 @@ func (c *Core) SendCrmFiles(platform, userID, caption string, attachments []enti
 			return fmt.Errorf("send file to %s/%s: %w", platform, userID, sendErr)
 		}
 
+		sentIDs = append(sentIDs, messageID)
 		fileCaption = ""
 	}
 
@@ -326,16 +408,20 @@ func (c *Core) SendCrmFiles(platform, userID, caption string, attachments []enti
 	}
 
 	msg := entity.ChatMessage{
-		Platform:    platform,
-		UserID:      userID,
-		ChatID:      userID,
-		Direction:   "outgoing",
-		Sender:      "manager",
-		Text:        caption,
-		Attachments: attachments,
-		CreatedAt:   time.Now(),
+		Platform:           platform,
+		UserID:             userID,
+		ChatID:             userID,
+		Direction:          "outgoing",
+		Sender:             "manager",
+		Text:               caption,
+		Attachments:        attachments,
+		CreatedAt:          time.Now(),
+		PlatformMessageIDs: entity.PlatformMessageIDs(sentIDs...),
+		Status:             entity.MessageSent,
 	}
 
+	c.setHumanMode(platform, userID, true)
+
 	if err := c.repo.SaveChatMessage(msg); err != nil {
 		c.log.Error("failed to save outgoing file message",
 			slog.String("platform", platform),
@@ -354,6 +440,7 @@ func (c *Core) SendCrmFiles(platform, userID, caption string, attachments []enti
 
 // SaveAndBroadcastChatMessage saves a chat message and broadcasts it via WebSocket.
 // If the user has a Zoho contact ID, the message is also buffered for Zoho Functions.
+// A customer message reopens a closed chat and may auto-assign it.
 func (c *Core) SaveAndBroadcastChatMessage(msg entity.ChatMessage) {
 	if err := c.repo.SaveChatMessage(msg); err != nil {
 		c.log.Error("failed to save chat message",
@@ -363,6 +450,11 @@ func (c *Core) SaveAndBroadcastChatMessage(msg entity.ChatMessage) {
 		)
 	}
 
+	if msg.Direction == "incoming" {
+		// Routing goes to the database, so the bot answers the customer without waiting for it
+		go c.routeIncomingChat(msg.Platform, msg.UserID)
+	}
+
 	var user *entity.User
 	if c.wsHub != nil || c.zohoFn != nil {
 		user = c.lookupUserByPlatform(msg.Platform, msg.UserID)
@@ -371,14 +463,7 @@ func (c *Core) SaveAndBroadcastChatMessage(msg entity.ChatMessage) {
 	if c.wsHub != nil {
 		if user != nil {
 			msg.UserName = user.Name
-			switch msg.Platform {
-			case "telegram":
-				msg.MessengerName = user.TelegramUsername
-			case "instagram":
-				msg.MessengerName = user.InstagramUsername
-			case "whatsapp":
-				msg.MessengerName = user.Phone
-			}
+			msg.MessengerName = user.MessengerName(msg.Platform)
 		}
 		c.wsHub.BroadcastMessage(msg)
 	}
@@ -392,3 +477,75 @@ func (c *Core) SaveAndBroadcastChatMessage(msg entity.ChatMessage) {
 		})
 	}
 }
+
+// UpdateMessageStatus applies a delivery or read receipt from a platform webhook
+// and notifies CRM clients. A read receipt also covers every earlier outgoing
+// message in the chat, since platforms only report the latest one read.
+func (c *Core) UpdateMessageStatus(platform, messageID string, status entity.MessageStatus, errText string) {
+	log := c.log.With(
+		slog.String("platform", platform),
+		slog.String("message_id", messageID),
+		slog.String("status", string(status)),
+	)
+
+	if messageID == "" || len(status.Replaces()) == 0 {
+		// "sent" is already recorded when the message is stored
+		return
+	}
+
+	msg, err := c.repo.UpdateChatMessageStatus(platform, messageID, status, errText)
+	if err != nil {
+		log.Error("failed to update message status", slog.String("error", err.Error()))
+		return
+	}
+	if msg == nil {
+		// Not an outgoing CRM message, or the receipt arrived out of order
+		return
+	}
+	if status == entity.MessageFailed {
+		log.Warn("message delivery failed", slog.String("user_id", msg.UserID), slog.String("error", errText))
+	}
+
+	updated := []entity.ChatMessage{*msg}
+	if status == entity.MessageRead {
+		earlier, err := c.repo.MarkChatMessagesReadBefore(platform, msg.UserID, msg.CreatedAt)
+		if err != nil {
+			log.Error("failed to mark earlier messages read", slog.String("error", err.Error()))
+		}
+		updated = append(updated, earlier...)
+	}
+
+	if c.wsHub != nil {
+		for _, m := range updated {
+			c.wsHub.BroadcastMessageStatus(m)
+		}
+	}
+}
+
+// readWatermarkSkew covers the delay between the platform accepting a message
+// and the CRM storing it, which puts created_at slightly after the platform timestamp.
+const readWatermarkSkew = time.Second
+
+// MarkMessagesReadUntil marks the user's outgoing messages up to the watermark as read
+// and broadcasts the change. Facebook Messenger reports reads this way.
+func (c *Core) MarkMessagesReadUntil(platform, userID string, watermark time.Time) {
+	if userID == "" || watermark.IsZero() {
+		return
+	}
+
+	updated, err := c.repo.MarkChatMessagesReadBefore(platform, userID, watermark.Add(readWatermarkSkew))
+	if err != nil {
+		c.log.Error("failed to mark messages read",
+			slog.String("platform", platform),
+			slog.String("user_id", userID),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	if c.wsHub != nil {
+		for _, m := range updated {
+			c.wsHub.BroadcastMessageStatus(m)
+		}
+	}
+}
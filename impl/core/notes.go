@@ -0,0 +1,101 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"DarkCS/entity"
+)
+
+// GetChatNotes returns the internal notes of a chat, oldest first.
+func (c *Core) GetChatNotes(platform, userID string) ([]entity.ChatNote, error) {
+	return c.repo.GetChatNotes(platform, userID)
+}
+
+// AddChatNote stores an internal note of a CRM user and broadcasts it to the other CRM clients.
+// Notes are never sent to the platform. Surrounding spaces are trimmed; an empty or overlong
+// note fails with entity.ErrInvalidNote.
+func (c *Core) AddChatNote(username, platform, userID, text string) (*entity.ChatNote, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > entity.MaxNoteLength {
+		return nil, fmt.Errorf("%w: %d characters", entity.ErrInvalidNote, utf8.RuneCountInString(text))
+	}
+	note, err := c.repo.AddChatNote(entity.ChatNote{
+		Platform:  platform,
+		UserID:    userID,
+		Author:    username,
+		Text:      text,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if c.wsHub != nil {
+		c.wsHub.BroadcastNoteAdded(*note)
+	}
+	return note, nil
+}
+
+// DeleteChatNote deletes a note written by the CRM user.
+// Notes of other users fail with entity.ErrChatNoteNotFound.
+func (c *Core) DeleteChatNote(username string, id primitive.ObjectID) error {
+	note, err := c.repo.DeleteChatNote(id, username)
+	if err != nil {
+		return err
+	}
+	if c.wsHub != nil {
+		c.wsHub.BroadcastNoteDeleted(*note)
+	}
+	return nil
+}
+
+// AddChatTag puts a tag on a chat and broadcasts the chat's tags.
+// Surrounding spaces are trimmed; an empty or overlong tag fails with entity.ErrInvalidTag.
+func (c *Core) AddChatTag(platform, userID, tag string) ([]string, error) {
+	tag, err := entity.NormalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	assignment, err := c.repo.AddChatTag(platform, userID, tag)
+	if err != nil {
+		return nil, err
+	}
+	return c.broadcastTags(assignment), nil
+}
+
+// RemoveChatTag takes a tag off a chat and broadcasts the chat's tags.
+func (c *Core) RemoveChatTag(platform, userID, tag string) ([]string, error) {
+	tag, err := entity.NormalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	assignment, err := c.repo.RemoveChatTag(platform, userID, tag)
+	if err != nil {
+		return nil, err
+	}
+	if assignment == nil {
+		// A chat without an assignment has no tags to remove
+		return []string{}, nil
+	}
+	return c.broadcastTags(assignment), nil
+}
+
+// GetChatTags returns every tag in use, for suggestions in the CRM.
+func (c *Core) GetChatTags() ([]string, error) {
+	return c.repo.GetChatTags()
+}
+
+func (c *Core) broadcastTags(assignment *entity.ChatAssignment) []string {
+	tags := assignment.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	if c.wsHub != nil {
+		c.wsHub.BroadcastChatTags(assignment.Platform, assignment.UserID, tags)
+	}
+	return tags
+}
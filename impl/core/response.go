@@ -1,10 +1,17 @@
 package core
 
 import (
+	"DarkCS/bot/chat"
 	"DarkCS/entity"
 	"DarkCS/internal/lib/sl"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const (
@@ -71,6 +78,116 @@ func (c *Core) ProcessUserRequest(user *entity.User, message string) (*entity.Ai
 	return &answer, nil
 }
 
+// TranscribeVoice converts a voice message sent in a chat workflow to text.
+// The transcript is also stored on the CRM message carrying the file, so managers can read it.
+func (c *Core) TranscribeVoice(platform, userID string, att chat.Attachment) (string, error) {
+	if c.ass == nil {
+		return "", fmt.Errorf("assistant not initialized")
+	}
+
+	if c.voiceMaxDuration > 0 && att.Duration > c.voiceMaxDuration {
+		return "", entity.VoiceTooLongError(att.Duration, c.voiceMaxDuration)
+	}
+	// Platforms that do not report the duration are held to the size the limit allows
+	maxSize := c.voiceMaxSize()
+	if att.Duration == 0 && maxSize > 0 && att.Size > maxSize {
+		return "", entity.VoiceTooLongError(voiceDuration(att.Size), c.voiceMaxDuration)
+	}
+
+	if att.Open == nil {
+		return "", fmt.Errorf("voice message %q has no content", att.Filename)
+	}
+	reader, err := att.Open()
+	if err != nil {
+		return "", fmt.Errorf("open voice message: %w", err)
+	}
+	defer reader.Close()
+
+	var audio io.Reader = reader
+	if att.Duration == 0 && maxSize > 0 {
+		// The size may be unknown as well, so the limit is also enforced while reading
+		audio = &voiceLimitReader{r: reader, left: maxSize, limit: c.voiceMaxDuration}
+	}
+
+	text, err := c.ass.TranscribeAudio(audio, att.Filename)
+	if errors.Is(err, entity.ErrVoiceTooLong) {
+		return "", err
+	}
+	if err != nil {
+		c.log.With(
+			slog.String("platform", platform),
+			slog.String("user_id", userID),
+			sl.Err(err),
+		).Error("transcribe voice message")
+		return "", err
+	}
+	text = strings.TrimSpace(text)
+
+	if att.FileID != "" && c.repo != nil {
+		c.saveTranscript(platform, userID, att.FileID, text)
+	}
+
+	return text, nil
+}
+
+// voiceMaxBytesPerSecond bounds the bitrate of voice messages: 256 kbit/s, well above
+// the Opus and AAC rates messengers record at. It converts the duration limit to a size
+// limit for platforms that do not report how long a voice message is.
+const voiceMaxBytesPerSecond = 32 * 1024
+
+// voiceMaxSize returns the largest voice message within the duration limit; 0 means no limit.
+func (c *Core) voiceMaxSize() int64 {
+	return int64(c.voiceMaxDuration.Seconds() * voiceMaxBytesPerSecond)
+}
+
+// voiceDuration estimates the shortest duration of a voice message of the given size.
+func voiceDuration(size int64) time.Duration {
+	return time.Duration(size) * time.Second / voiceMaxBytesPerSecond
+}
+
+// voiceLimitReader fails with ErrVoiceTooLong once more than left bytes are read.
+type voiceLimitReader struct {
+	r     io.Reader
+	left  int64
+	read  int64
+	limit time.Duration
+}
+
+func (l *voiceLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.left {
+		return n, entity.VoiceTooLongError(voiceDuration(l.read), l.limit)
+	}
+	return n, err
+}
+
+// saveTranscript stores the transcript on the chat message and notifies CRM clients.
+// Failures are only logged: the user still gets an answer.
+func (c *Core) saveTranscript(platform, userID, fileID, text string) {
+	log := c.log.With(
+		slog.String("platform", platform),
+		slog.String("user_id", userID),
+		slog.String("file_id", fileID),
+	)
+
+	id, err := primitive.ObjectIDFromHex(fileID)
+	if err != nil {
+		log.With(sl.Err(err)).Error("invalid voice file id")
+		return
+	}
+
+	msg, err := c.repo.SetChatMessageTranscript(id, text)
+	if err != nil {
+		log.With(sl.Err(err)).Error("save voice transcript")
+		return
+	}
+
+	if c.wsHub != nil {
+		c.wsHub.BroadcastTranscript(*msg)
+	}
+}
+
 func (c *Core) processRequest(msg entity.HttpUserMsg) (*entity.AiAnswer, error) {
 	if c.ass == nil {
 		return nil, fmt.Errorf("assistant not initialized")
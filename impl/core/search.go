@@ -0,0 +1,181 @@
+package core
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"DarkCS/entity"
+	"DarkCS/internal/lib/fileurl"
+)
+
+// snippetRadius is how many characters of text a search snippet keeps around the first match.
+const snippetRadius = 60
+
+// SearchChatMessages finds messages across all chats and returns them with highlighted snippets.
+func (c *Core) SearchChatMessages(search entity.ChatSearch) ([]entity.ChatSearchHit, error) {
+	if strings.TrimSpace(search.Query) == "" {
+		return nil, fmt.Errorf("search query is empty")
+	}
+
+	hits, err := c.repo.SearchChatMessages(search)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := searchTerms(search.Query)
+	users := make(map[string]*entity.ChatMessage)
+	for i := range hits {
+		msg := &hits[i].Message
+		// Each chat's user is looked up once
+		key := msg.Platform + ":" + msg.UserID
+		if known, ok := users[key]; ok {
+			msg.UserName, msg.MessengerName = known.UserName, known.MessengerName
+		} else {
+			c.enrichMessageUser(msg)
+			users[key] = msg
+		}
+		c.signMessageAttachments(msg)
+		hits[i].Snippet = searchSnippet(*msg, terms)
+	}
+	return hits, nil
+}
+
+// GetChatMessageContext returns a message with up to before older and after newer messages
+// of its chat, so the CRM can open the conversation at a search result.
+func (c *Core) GetChatMessageContext(platform, userID string, id primitive.ObjectID, before, after int) (*entity.ChatMessageContext, error) {
+	result, err := c.repo.GetChatMessageContext(platform, userID, id, before, after)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result.Messages {
+		c.signMessageAttachments(&result.Messages[i])
+	}
+	return result, nil
+}
+
+func (c *Core) signMessageAttachments(msg *entity.ChatMessage) {
+	for i := range msg.Attachments {
+		msg.Attachments[i].URL = fileurl.SignURL(msg.Attachments[i].FileID.Hex(), c.signingSecret, 15*time.Minute)
+	}
+}
+
+// searchTerms returns the words and "quoted phrases" of a $text query, without -excluded words.
+func searchTerms(query string) []string {
+	var terms []string
+	for i, part := range strings.Split(query, `"`) {
+		if i%2 == 1 {
+			if phrase := strings.TrimSpace(part); phrase != "" {
+				terms = append(terms, phrase)
+			}
+			continue
+		}
+		for _, word := range strings.Fields(part) {
+			if strings.HasPrefix(word, "-") {
+				continue
+			}
+			word = strings.TrimFunc(word, func(r rune) bool { return !isWordRune(r) })
+			if word != "" {
+				terms = append(terms, word)
+			}
+		}
+	}
+	return terms
+}
+
+// searchSnippet cuts the matching part out of the message text, its voice transcript
+// or an attachment name, HTML-escaped, with the matched terms wrapped in <mark></mark>.
+func searchSnippet(msg entity.ChatMessage, terms []string) string {
+	sources := []string{msg.Text, msg.Transcript}
+	for _, attachment := range msg.Attachments {
+		sources = append(sources, attachment.Filename)
+	}
+	for _, source := range sources {
+		if snippet, ok := highlight(source, terms); ok {
+			return snippet
+		}
+	}
+
+	text := []rune(msg.Text)
+	if len(text) > 2*snippetRadius {
+		return html.EscapeString(string(text[:2*snippetRadius])) + "…"
+	}
+	return html.EscapeString(msg.Text)
+}
+
+// highlight marks whole-word, case-insensitive occurrences of the terms in text and keeps
+// snippetRadius characters around the first one. It reports false when no term occurs.
+func highlight(text string, terms []string) (string, bool) {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	type span struct{ start, end int }
+	var spans []span
+	for _, term := range terms {
+		needle := []rune(strings.ToLower(term))
+		if len(needle) == 0 {
+			continue
+		}
+		for i := 0; i+len(needle) <= len(lower); i++ {
+			if !slices.Equal(lower[i:i+len(needle)], needle) {
+				continue
+			}
+			end := i + len(needle)
+			if (i > 0 && isWordRune(lower[i-1])) || (end < len(lower) && isWordRune(lower[end])) {
+				continue
+			}
+			spans = append(spans, span{i, end})
+		}
+	}
+	if len(spans) == 0 {
+		return "", false
+	}
+
+	slices.SortFunc(spans, func(a, b span) int { return a.start - b.start })
+	merged := spans[:1]
+	for _, s := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if s.start <= last.end {
+			last.end = max(last.end, s.end)
+			continue
+		}
+		merged = append(merged, s)
+	}
+
+	from := max(0, merged[0].start-snippetRadius)
+	to := min(len(runes), merged[0].end+snippetRadius)
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, s := range merged {
+		if s.start >= to {
+			break
+		}
+		end := min(s.end, to)
+		b.WriteString(html.EscapeString(string(runes[pos:s.start])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[s.start:end])))
+		b.WriteString("</mark>")
+		pos = end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:to])))
+	if to < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
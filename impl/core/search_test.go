@@ -0,0 +1,76 @@
+package core
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"DarkCS/entity"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "нігті гель", want: []string{"нігті", "гель"}},
+		{query: `"база для гелю" топ`, want: []string{"база для гелю", "топ"}},
+		{query: "гель -лак", want: []string{"гель"}},
+		{query: "замовлення?! (№42)", want: []string{"замовлення", "42"}},
+		{query: `"" -`, want: nil},
+	}
+	for _, tt := range tests {
+		if got := searchTerms(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("searchTerms(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	long := strings.Repeat("a ", snippetRadius)
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+		found bool
+	}{
+		{name: "case-insensitive whole word", text: "Де моє Замовлення?", terms: []string{"замовлення"}, want: "Де моє <mark>Замовлення</mark>?", found: true},
+		{name: "part of a word does not match", text: "гелевий лак", terms: []string{"гель"}},
+		{name: "overlapping terms merge", text: "база для гелю", terms: []string{"база для", "для гелю"}, want: "<mark>база для гелю</mark>", found: true},
+		{name: "text is escaped", text: "<b>ТТН</b> & 2045", terms: []string{"ттн"}, want: "&lt;b&gt;<mark>ТТН</mark>&lt;/b&gt; &amp; 2045", found: true},
+		{
+			name:  "long text is cut around the first match",
+			text:  long + "ТТН " + long,
+			terms: []string{"ттн"},
+			want:  "…" + long[len(long)-snippetRadius:] + "<mark>ТТН</mark>" + (" " + long)[:snippetRadius] + "…",
+			found: true,
+		},
+		{name: "no terms", text: "anything"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := highlight(tt.text, tt.terms)
+			if found != tt.found || got != tt.want {
+				t.Errorf("highlight = %q, %v; want %q, %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestSearchSnippetFallsBackToTranscriptsAndFiles(t *testing.T) {
+	msg := entity.ChatMessage{
+		Text:        "voice message",
+		Transcript:  "хочу повернути товар",
+		Attachments: []entity.Attachment{{Filename: "invoice.pdf"}},
+	}
+	if got, want := searchSnippet(msg, []string{"повернути"}), "хочу <mark>повернути</mark> товар"; got != want {
+		t.Errorf("transcript snippet = %q, want %q", got, want)
+	}
+	if got, want := searchSnippet(msg, []string{"invoice"}), "<mark>invoice</mark>.pdf"; got != want {
+		t.Errorf("attachment snippet = %q, want %q", got, want)
+	}
+	if got, want := searchSnippet(msg, []string{"missing"}), "voice message"; got != want {
+		t.Errorf("snippet without a match = %q, want %q", got, want)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
+
+	"DarkCS/entity"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -16,15 +19,21 @@ type Config struct {
 		BotName     string  `yaml:"bot_name" env-default:"DarkCSBot"`
 		Enabled     bool    `yaml:"enabled" env-default:"false"`
 		MinLogLevel string  `yaml:"min_log_level" env-default:"debug"`
+		// Webhook receives updates at <public_url>/webhook/telegram instead of long polling
+		Webhook TelegramWebhook `yaml:"webhook"`
 	} `yaml:"telegram"`
 	UserBot struct {
 		ApiKey  string `yaml:"api_key" env-default:""`
 		BotName string `yaml:"bot_name" env-default:"DarkCSUserBot"`
 		Enabled bool   `yaml:"enabled" env-default:"false"`
+		// Webhook receives updates at <public_url>/webhook/userbot instead of long polling
+		Webhook TelegramWebhook `yaml:"webhook"`
 	} `yaml:"userbot"`
 	OpenAI struct {
 		ApiKey    string `yaml:"api_key" env-default:""`
 		DevPrefix string `yaml:"dev_prefix" env-default:""`
+		// VoiceMaxDuration limits voice messages transcribed in chat workflows; 0 disables the limit
+		VoiceMaxDuration time.Duration `yaml:"voice_max_duration" env-default:"2m"`
 	} `yaml:"openai"`
 	Username string `yaml:"username" env-default:""`
 	SavePath string `yaml:"save_path" env-default:""`
@@ -51,6 +60,9 @@ type Config struct {
 		BindIP string `yaml:"bind_ip" env-default:"127.0.0.1"`
 		Port   string `yaml:"port" env-default:"9100"`
 		ApiKey string `yaml:"key" env-default:""`
+		// TrustedProxies are the addresses or CIDR ranges of reverse proxies whose
+		// X-Forwarded-For header is used to rate limit the widget; empty trusts none
+		TrustedProxies []string `yaml:"trusted_proxies"`
 	} `yaml:"listen"`
 	Zoho struct {
 		ClientId     string `yaml:"client_id" env-default:""`
@@ -73,7 +85,42 @@ type Config struct {
 		VerifyToken   string `yaml:"verify_token" env-default:""`
 		AppSecret     string `yaml:"app_secret" env-default:""`
 		PhoneNumberID string `yaml:"phone_number_id" env-default:""`
+		// Templates are the pre-approved message templates managers can send outside the 24-hour window
+		Templates []entity.MessageTemplate `yaml:"templates"`
 	} `yaml:"whatsapp"`
+	Facebook struct {
+		Enabled bool `yaml:"enabled" env-default:"false"`
+		// PageAccessToken is the token of the Facebook Page the customers write to
+		PageAccessToken string `yaml:"page_access_token" env-default:""`
+		VerifyToken     string `yaml:"verify_token" env-default:""`
+		AppSecret       string `yaml:"app_secret" env-default:""`
+	} `yaml:"facebook"`
+	Viber struct {
+		Enabled   bool   `yaml:"enabled" env-default:"false"`
+		AuthToken string `yaml:"auth_token" env-default:""`
+		// SenderName is the bot name shown with every message, at most 28 characters
+		SenderName string `yaml:"sender_name" env-default:"DarkCS"`
+		// PublicURL is the externally reachable base URL; the webhook is registered at {public_url}/webhook/viber
+		PublicURL string `yaml:"public_url" env-default:""`
+	} `yaml:"viber"`
+	WebChat struct {
+		Enabled bool `yaml:"enabled" env-default:"false"`
+		// SessionSecret signs visitor tokens; changing it starts new sessions for every visitor
+		SessionSecret string `yaml:"session_secret" env-default:""`
+		// AllowedOrigins lists the sites that may open the widget socket; empty rejects every site
+		AllowedOrigins []string `yaml:"allowed_origins"`
+	} `yaml:"webchat"`
+	Crm struct {
+		// AutoAssign gives unassigned chats to an agent when the customer writes:
+		// round_robin, least_loaded, or empty to leave them in the unassigned queue
+		AutoAssign string `yaml:"auto_assign" env-default:""`
+		// Agents are the CRM usernames that receive auto-assigned chats
+		Agents []string `yaml:"agents"`
+		// Admins are the CRM usernames that may reassign chats held by other users
+		Admins []string `yaml:"admins"`
+		// HandoffTimeout hands a chat back to the bot after this long without a manager message; 0 waits until it is closed
+		HandoffTimeout time.Duration `yaml:"handoff_timeout" env-default:"30m"`
+	} `yaml:"crm"`
 	ZohoFunctions struct {
 		MsgUrl string `yaml:"msg_url" env-default:""`
 		ApiKey string `yaml:"api_key" env-default:""`
@@ -87,6 +134,20 @@ type Config struct {
 		FolderID        string `yaml:"folder_id" env-default:""`
 		CacheTTLMinutes int    `yaml:"cache_ttl_minutes" env-default:"60"`
 	} `yaml:"google-drive"`
+	Workflows struct {
+		// Dir holds YAML workflow definitions loaded at startup; empty disables them.
+		Dir string `yaml:"dir" env-default:""`
+	} `yaml:"workflows"`
+}
+
+// TelegramWebhook switches a Telegram bot from long polling to webhook delivery.
+// Polling stays the default for local development.
+type TelegramWebhook struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// PublicURL is the externally reachable base URL of the HTTP server, e.g. https://cs.example.com
+	PublicURL string `yaml:"public_url" env-default:""`
+	// SecretToken is echoed by Telegram in the X-Telegram-Bot-Api-Secret-Token header
+	SecretToken string `yaml:"secret_token" env-default:""`
 }
 
 var instance *Config
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"DarkCS/entity"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const cannedResponsesCollection = "canned-responses"
+
+// CreateCannedResponse stores a new canned response and returns it with its ID.
+func (m *MongoDB) CreateCannedResponse(response entity.CannedResponse) (*entity.CannedResponse, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(cannedResponsesCollection)
+
+	result, err := collection.InsertOne(m.ctx, response)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb insert canned response: %w", err)
+	}
+	response.ID = result.InsertedID.(primitive.ObjectID)
+	return &response, nil
+}
+
+// GetCannedResponse returns a canned response by ID.
+// It fails with entity.ErrCannedResponseNotFound when there is none.
+func (m *MongoDB) GetCannedResponse(id primitive.ObjectID) (*entity.CannedResponse, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(cannedResponsesCollection)
+
+	var response entity.CannedResponse
+	err = collection.FindOne(m.ctx, bson.D{{"_id", id}}).Decode(&response)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, entity.ErrCannedResponseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mongodb find canned response: %w", err)
+	}
+	return &response, nil
+}
+
+// GetCannedResponses returns the team replies and the personal replies of username, sorted by title.
+func (m *MongoDB) GetCannedResponses(username string) ([]entity.CannedResponse, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(cannedResponsesCollection)
+
+	filter := bson.D{{"$or", bson.A{
+		bson.D{{"scope", entity.CannedScopeTeam}},
+		bson.D{{"owner", username}},
+	}}}
+	opts := options.Find().SetSort(bson.D{{"title", 1}})
+	cursor, err := collection.Find(m.ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb find canned responses: %w", err)
+	}
+	defer cursor.Close(m.ctx)
+
+	var responses []entity.CannedResponse
+	if err = cursor.All(m.ctx, &responses); err != nil {
+		return nil, fmt.Errorf("mongodb decode canned responses: %w", err)
+	}
+	return responses, nil
+}
+
+// UpdateCannedResponse replaces the title, text and scope of a canned response.
+func (m *MongoDB) UpdateCannedResponse(id primitive.ObjectID, title, text, scope string) (*entity.CannedResponse, error) {
+	update := bson.D{{"$set", bson.D{
+		{"title", title},
+		{"text", text},
+		{"scope", scope},
+		{"updated_at", time.Now()},
+	}}}
+	return m.updateCannedResponse(id, update)
+}
+
+// AddCannedAttachments appends files to a canned response.
+func (m *MongoDB) AddCannedAttachments(id primitive.ObjectID, attachments []entity.Attachment) (*entity.CannedResponse, error) {
+	update := bson.D{
+		{"$push", bson.D{{"attachments", bson.D{{"$each", attachments}}}}},
+		{"$set", bson.D{{"updated_at", time.Now()}}},
+	}
+	return m.updateCannedResponse(id, update)
+}
+
+// RemoveCannedAttachment removes a file from a canned response. The file itself stays in GridFS.
+func (m *MongoDB) RemoveCannedAttachment(id, fileID primitive.ObjectID) (*entity.CannedResponse, error) {
+	update := bson.D{
+		{"$pull", bson.D{{"attachments", bson.D{{"file_id", fileID}}}}},
+		{"$set", bson.D{{"updated_at", time.Now()}}},
+	}
+	return m.updateCannedResponse(id, update)
+}
+
+func (m *MongoDB) updateCannedResponse(id primitive.ObjectID, update bson.D) (*entity.CannedResponse, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(cannedResponsesCollection)
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var response entity.CannedResponse
+	err = collection.FindOneAndUpdate(m.ctx, bson.D{{"_id", id}}, update, opts).Decode(&response)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, entity.ErrCannedResponseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mongodb update canned response: %w", err)
+	}
+	return &response, nil
+}
+
+// DeleteCannedResponse deletes a canned response. Its files stay in GridFS.
+func (m *MongoDB) DeleteCannedResponse(id primitive.ObjectID) error {
+	connection, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(cannedResponsesCollection)
+
+	result, err := collection.DeleteOne(m.ctx, bson.D{{"_id", id}})
+	if err != nil {
+		return fmt.Errorf("mongodb delete canned response: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return entity.ErrCannedResponseNotFound
+	}
+	return nil
+}
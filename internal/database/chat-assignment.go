@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"DarkCS/entity"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const chatAssignmentsCollection = "chat-assignments"
+
+// GetChatAssignments returns the assignments of all chats that have one.
+func (m *MongoDB) GetChatAssignments() ([]entity.ChatAssignment, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatAssignmentsCollection)
+
+	cursor, err := collection.Find(m.ctx, bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("mongodb find chat assignments: %w", err)
+	}
+	defer cursor.Close(m.ctx)
+
+	var assignments []entity.ChatAssignment
+	if err = cursor.All(m.ctx, &assignments); err != nil {
+		return nil, fmt.Errorf("mongodb decode chat assignments: %w", err)
+	}
+
+	return assignments, nil
+}
+
+// CountActiveChats returns how many open and pending chats each of the assignees holds.
+// Assignees without any are left out.
+func (m *MongoDB) CountActiveChats(assignees []string) (map[string]int, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatAssignmentsCollection)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{"assignee", bson.D{{"$in", assignees}}},
+			{"status", bson.D{{"$ne", entity.ChatClosed}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{"_id", "$assignee"},
+			{"count", bson.D{{"$sum", 1}}},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(m.ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb aggregate active chats: %w", err)
+	}
+	defer cursor.Close(m.ctx)
+
+	var counts []struct {
+		Assignee string `bson:"_id"`
+		Count    int    `bson:"count"`
+	}
+	if err = cursor.All(m.ctx, &counts); err != nil {
+		return nil, fmt.Errorf("mongodb decode active chats: %w", err)
+	}
+
+	load := make(map[string]int, len(counts))
+	for _, c := range counts {
+		load[c.Assignee] = c.Count
+	}
+	return load, nil
+}
+
+// GetChatAssignment returns the assignment of a chat, or nil if it has none.
+func (m *MongoDB) GetChatAssignment(platform, userID string) (*entity.ChatAssignment, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatAssignmentsCollection)
+
+	var assignment entity.ChatAssignment
+	err = collection.FindOne(m.ctx, bson.D{{"platform", platform}, {"user_id", userID}}).Decode(&assignment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mongodb find chat assignment: %w", err)
+	}
+	return &assignment, nil
+}
+
+// ClaimChat assigns a chat to the assignee unless it is assigned to someone else.
+// It returns the assignment, or nil when another CRM user holds the chat.
+func (m *MongoDB) ClaimChat(platform, userID, assignee string) (*entity.ChatAssignment, error) {
+	return m.TransferChat(platform, userID, assignee, assignee)
+}
+
+// TransferChat assigns a chat to the assignee, or to the unassigned queue when it is empty,
+// if the chat is unassigned or held by holder. It returns the assignment, or nil when
+// another CRM user holds the chat.
+func (m *MongoDB) TransferChat(platform, userID, holder, assignee string) (*entity.ChatAssignment, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatAssignmentsCollection)
+
+	filter := bson.D{
+		{"platform", platform},
+		{"user_id", userID},
+		{"assignee", bson.D{{"$in", bson.A{"", holder}}}},
+	}
+	update := bson.D{
+		{"$set", bson.D{{"assignee", assignee}, {"updated_at", time.Now()}}},
+		{"$setOnInsert", bson.D{{"status", entity.ChatOpen}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var assignment entity.ChatAssignment
+	err = collection.FindOneAndUpdate(m.ctx, filter, update, opts).Decode(&assignment)
+	if mongo.IsDuplicateKeyError(err) {
+		// The upsert collided with the assignment of another CRM user
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mongodb transfer chat: %w", err)
+	}
+	return &assignment, nil
+}
+
+// SetChatAssignee assigns a chat to the assignee, or to the unassigned queue when it is empty.
+func (m *MongoDB) SetChatAssignee(platform, userID, assignee string) (*entity.ChatAssignment, error) {
+	update := bson.D{
+		{"$set", bson.D{{"assignee", assignee}, {"updated_at", time.Now()}}},
+		{"$setOnInsert", bson.D{{"status", entity.ChatOpen}}},
+	}
+	return m.updateChatAssignment(platform, userID, update)
+}
+
+// SetChatStatus changes the status of a chat, keeping its assignee.
+func (m *MongoDB) SetChatStatus(platform, userID string, status entity.ChatStatus) (*entity.ChatAssignment, error) {
+	update := bson.D{
+		{"$set", bson.D{{"status", status}, {"updated_at", time.Now()}}},
+		{"$setOnInsert", bson.D{{"assignee", ""}}},
+	}
+	return m.updateChatAssignment(platform, userID, update)
+}
+
+// SetChatHumanMode turns human mode on or off. Turning it on also marks the manager as active now.
+func (m *MongoDB) SetChatHumanMode(platform, userID string, on bool) (*entity.ChatAssignment, error) {
+	now := time.Now()
+	set := bson.D{{"human_mode", on}, {"updated_at", now}}
+	if on {
+		set = append(set, bson.E{Key: "human_active_at", Value: now})
+	}
+	update := bson.D{
+		{"$set", set},
+		{"$setOnInsert", bson.D{{"assignee", ""}, {"status", entity.ChatOpen}}},
+	}
+	return m.updateChatAssignment(platform, userID, update)
+}
+
+// AddChatTag puts a tag on a chat; a tag the chat already has is kept once.
+func (m *MongoDB) AddChatTag(platform, userID, tag string) (*entity.ChatAssignment, error) {
+	update := bson.D{
+		{"$addToSet", bson.D{{"tags", tag}}},
+		{"$set", bson.D{{"updated_at", time.Now()}}},
+		{"$setOnInsert", bson.D{{"assignee", ""}, {"status", entity.ChatOpen}}},
+	}
+	return m.updateChatAssignment(platform, userID, update)
+}
+
+// RemoveChatTag takes a tag off a chat. It returns nil when the chat has no assignment.
+func (m *MongoDB) RemoveChatTag(platform, userID, tag string) (*entity.ChatAssignment, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatAssignmentsCollection)
+
+	filter := bson.D{{"platform", platform}, {"user_id", userID}}
+	update := bson.D{
+		{"$pull", bson.D{{"tags", tag}}},
+		{"$set", bson.D{{"updated_at", time.Now()}}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var assignment entity.ChatAssignment
+	err = collection.FindOneAndUpdate(m.ctx, filter, update, opts).Decode(&assignment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mongodb remove chat tag: %w", err)
+	}
+	return &assignment, nil
+}
+
+// GetChatTags returns every tag in use on any chat.
+func (m *MongoDB) GetChatTags() ([]string, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatAssignmentsCollection)
+
+	values, err := collection.Distinct(m.ctx, "tags", bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("mongodb distinct chat tags: %w", err)
+	}
+	tags := make([]string, 0, len(values))
+	for _, v := range values {
+		if tag, ok := v.(string); ok {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func (m *MongoDB) updateChatAssignment(platform, userID string, update bson.D) (*entity.ChatAssignment, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatAssignmentsCollection)
+
+	filter := bson.D{{"platform", platform}, {"user_id", userID}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var assignment entity.ChatAssignment
+	if err = collection.FindOneAndUpdate(m.ctx, filter, update, opts).Decode(&assignment); err != nil {
+		return nil, fmt.Errorf("mongodb update chat assignment: %w", err)
+	}
+	return &assignment, nil
+}
+
+// EnsureChatAssignmentIndexes creates the unique chat index that ClaimChat relies on.
+func (m *MongoDB) EnsureChatAssignmentIndexes() error {
+	connection, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatAssignmentsCollection)
+
+	index := mongo.IndexModel{
+		Keys:    bson.D{{"platform", 1}, {"user_id", 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err = collection.Indexes().CreateOne(m.ctx, index)
+	if err != nil {
+		return fmt.Errorf("mongodb create chat assignment index: %w", err)
+	}
+	return nil
+}
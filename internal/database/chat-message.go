@@ -2,10 +2,12 @@ package repository
 
 import (
 	"DarkCS/entity"
+	"errors"
 	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -86,6 +88,157 @@ func (m *MongoDB) GetChatMessages(platform, userID string, limit, offset int) ([
 	return messages, nil
 }
 
+// SetChatMessageTranscript stores the transcript on the message carrying the given file
+// and returns the updated message.
+func (m *MongoDB) SetChatMessageTranscript(fileID primitive.ObjectID, transcript string) (*entity.ChatMessage, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatMessagesCollection)
+
+	filter := bson.D{{"attachments.file_id", fileID}}
+	update := bson.D{{"$set", bson.D{{"transcript", transcript}}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var msg entity.ChatMessage
+	err = collection.FindOneAndUpdate(m.ctx, filter, update, opts).Decode(&msg)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb set chat message transcript: %w", err)
+	}
+	return &msg, nil
+}
+
+// UpdateChatMessageStatus moves the outgoing message with the given platform message ID
+// to a new delivery status and returns the updated message. Statuses only move forward:
+// it returns nil when no message with that ID is waiting for the status.
+func (m *MongoDB) UpdateChatMessageStatus(platform, platformMessageID string, status entity.MessageStatus, statusError string) (*entity.ChatMessage, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatMessagesCollection)
+
+	filter := bson.D{
+		{"platform", platform},
+		{"platform_message_ids", platformMessageID},
+		{"status", bson.D{{"$in", status.Replaces()}}},
+	}
+	set := bson.D{{"status", status}, {"status_updated_at", time.Now()}}
+	if statusError != "" {
+		set = append(set, bson.E{Key: "status_error", Value: statusError})
+	}
+	update := bson.D{{"$set", set}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var msg entity.ChatMessage
+	err = collection.FindOneAndUpdate(m.ctx, filter, update, opts).Decode(&msg)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mongodb update chat message status: %w", err)
+	}
+	return &msg, nil
+}
+
+// MarkChatMessagesReadBefore marks the user's unread outgoing messages sent before the given
+// time as read and returns them. A read receipt covers every earlier message in the chat.
+func (m *MongoDB) MarkChatMessagesReadBefore(platform, userID string, before time.Time) ([]entity.ChatMessage, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatMessagesCollection)
+
+	filter := bson.D{
+		{"platform", platform},
+		{"user_id", userID},
+		{"direction", "outgoing"},
+		{"status", bson.D{{"$in", entity.MessageRead.Replaces()}}},
+		{"created_at", bson.D{{"$lt", before}}},
+	}
+
+	cursor, err := collection.Find(m.ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb find unread chat messages: %w", err)
+	}
+	defer cursor.Close(m.ctx)
+
+	var messages []entity.ChatMessage
+	if err = cursor.All(m.ctx, &messages); err != nil {
+		return nil, fmt.Errorf("mongodb decode unread chat messages: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(messages))
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+
+	now := time.Now()
+	update := bson.D{{"$set", bson.D{{"status", entity.MessageRead}, {"status_updated_at", now}}}}
+	_, err = collection.UpdateMany(m.ctx, bson.D{{"_id", bson.D{{"$in", ids}}}}, update)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb mark chat messages read: %w", err)
+	}
+
+	for i := range messages {
+		messages[i].Status = entity.MessageRead
+		messages[i].StatusUpdatedAt = &now
+	}
+	return messages, nil
+}
+
+// GetLastIncomingTime returns when the user last wrote to the chat, or the zero time if never.
+func (m *MongoDB) GetLastIncomingTime(platform, userID string) (time.Time, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatMessagesCollection)
+
+	filter := bson.D{{"platform", platform}, {"user_id", userID}, {"direction", "incoming"}}
+	opts := options.FindOne().SetSort(bson.D{{"created_at", -1}})
+
+	var msg entity.ChatMessage
+	err = collection.FindOne(m.ctx, filter, opts).Decode(&msg)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("mongodb find last incoming message: %w", err)
+	}
+	return msg.CreatedAt, nil
+}
+
+// ChatMessageFileExists reports whether a chat message was sent or received with the file.
+func (m *MongoDB) ChatMessageFileExists(fileID primitive.ObjectID) (bool, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return false, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatMessagesCollection)
+
+	count, err := collection.CountDocuments(m.ctx, bson.D{{"attachments.file_id", fileID}}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("mongodb count chat messages with file: %w", err)
+	}
+	return count > 0, nil
+}
+
 // GetActiveChats returns chat summaries with last message info (without unread counts).
 func (m *MongoDB) GetActiveChats() ([]entity.ChatSummary, error) {
 	connection, err := m.connect()
@@ -368,5 +521,184 @@ func (m *MongoDB) EnsureChatMessageIndexes() error {
 		return fmt.Errorf("mongodb create chat message index: %w", err)
 	}
 
+	// Delivery and read receipts look messages up by their platform message ID
+	statusIndex := mongo.IndexModel{
+		Keys:    bson.D{{"platform_message_ids", 1}},
+		Options: options.Index().SetSparse(true),
+	}
+
+	_, err = collection.Indexes().CreateOne(m.ctx, statusIndex)
+	if err != nil {
+		return fmt.Errorf("mongodb create chat message status index: %w", err)
+	}
+
+	// Transcripts and canned response cleanup look messages up by their files
+	fileIndex := mongo.IndexModel{
+		Keys:    bson.D{{"attachments.file_id", 1}},
+		Options: options.Index().SetSparse(true),
+	}
+
+	_, err = collection.Indexes().CreateOne(m.ctx, fileIndex)
+	if err != nil {
+		return fmt.Errorf("mongodb create chat message file index: %w", err)
+	}
+
+	// Full-text search over the message text, voice transcripts and file names.
+	// Chats mix Ukrainian, Russian and English, which MongoDB cannot stem, so words match as written.
+	textIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{"text", "text"},
+			{"transcript", "text"},
+			{"attachments.filename", "text"},
+		},
+		Options: options.Index().
+			SetName("chat_message_text").
+			SetDefaultLanguage("none"),
+	}
+
+	_, err = collection.Indexes().CreateOne(m.ctx, textIndex)
+	if err != nil {
+		return fmt.Errorf("mongodb create chat message text index: %w", err)
+	}
+
 	return nil
 }
+
+// SearchChatMessages runs a full-text search over chat messages and returns the matches
+// with their text score, newest first or best first. Snippets are left to the caller.
+func (m *MongoDB) SearchChatMessages(search entity.ChatSearch) ([]entity.ChatSearchHit, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatMessagesCollection)
+
+	filter := bson.D{{"$text", bson.D{{"$search", search.Query}}}}
+	if search.Platform != "" {
+		filter = append(filter, bson.E{Key: "platform", Value: search.Platform})
+	}
+	if search.Direction != "" {
+		filter = append(filter, bson.E{Key: "direction", Value: search.Direction})
+	}
+	if search.Sender != "" {
+		filter = append(filter, bson.E{Key: "sender", Value: search.Sender})
+	}
+	if !search.From.IsZero() || !search.To.IsZero() {
+		createdAt := bson.D{}
+		if !search.From.IsZero() {
+			createdAt = append(createdAt, bson.E{Key: "$gte", Value: search.From})
+		}
+		if !search.To.IsZero() {
+			createdAt = append(createdAt, bson.E{Key: "$lt", Value: search.To})
+		}
+		filter = append(filter, bson.E{Key: "created_at", Value: createdAt})
+	}
+	if search.HasAttachments != nil {
+		filter = append(filter, bson.E{Key: "attachments.0", Value: bson.D{{"$exists", *search.HasAttachments}}})
+	}
+
+	score := bson.D{{"$meta", "textScore"}}
+	sort := bson.D{{"created_at", -1}}
+	if search.ByRelevance {
+		sort = bson.D{{"score", score}, {"created_at", -1}}
+	}
+	opts := options.Find().
+		SetProjection(bson.D{{"score", score}}).
+		SetSort(sort).
+		SetLimit(int64(search.Limit)).
+		SetSkip(int64(search.Offset))
+
+	cursor, err := collection.Find(m.ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb search chat messages: %w", err)
+	}
+	defer cursor.Close(m.ctx)
+
+	var docs []struct {
+		entity.ChatMessage `bson:",inline"`
+		Score              float64 `bson:"score"`
+	}
+	if err = cursor.All(m.ctx, &docs); err != nil {
+		return nil, fmt.Errorf("mongodb decode chat search results: %w", err)
+	}
+
+	hits := make([]entity.ChatSearchHit, 0, len(docs))
+	for _, doc := range docs {
+		hits = append(hits, entity.ChatSearchHit{Message: doc.ChatMessage, Score: doc.Score})
+	}
+	return hits, nil
+}
+
+// GetChatMessageContext returns a message of the chat with up to before older
+// and after newer messages around it, oldest first.
+func (m *MongoDB) GetChatMessageContext(platform, userID string, id primitive.ObjectID, before, after int) (*entity.ChatMessageContext, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatMessagesCollection)
+
+	var target entity.ChatMessage
+	err = collection.FindOne(m.ctx, bson.D{{"_id", id}, {"platform", platform}, {"user_id", userID}}).Decode(&target)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, entity.ErrChatMessageNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mongodb find chat message: %w", err)
+	}
+
+	// One extra message on each side tells whether the chat goes on beyond the context.
+	// Messages are ordered by (created_at, _id), so those stored in the same instant are neither skipped nor repeated.
+	find := func(op string, order, limit int) ([]entity.ChatMessage, error) {
+		filter := bson.D{
+			{"platform", platform},
+			{"user_id", userID},
+			{"$or", bson.A{
+				bson.D{{"created_at", bson.D{{op, target.CreatedAt}}}},
+				bson.D{{"created_at", target.CreatedAt}, {"_id", bson.D{{op, target.ID}}}},
+			}},
+		}
+		opts := options.Find().SetSort(bson.D{{"created_at", order}, {"_id", order}}).SetLimit(int64(limit + 1))
+
+		cursor, err := collection.Find(m.ctx, filter, opts)
+		if err != nil {
+			return nil, fmt.Errorf("mongodb find chat message context: %w", err)
+		}
+		defer cursor.Close(m.ctx)
+
+		var messages []entity.ChatMessage
+		if err = cursor.All(m.ctx, &messages); err != nil {
+			return nil, fmt.Errorf("mongodb decode chat message context: %w", err)
+		}
+		return messages, nil
+	}
+
+	older, err := find("$lt", -1, before)
+	if err != nil {
+		return nil, err
+	}
+	newer, err := find("$gt", 1, after)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &entity.ChatMessageContext{
+		MessageID: id.Hex(),
+		HasBefore: len(older) > before,
+		HasAfter:  len(newer) > after,
+	}
+	older = older[:min(len(older), before)]
+	newer = newer[:min(len(newer), after)]
+
+	result.Messages = make([]entity.ChatMessage, 0, len(older)+1+len(newer))
+	for i := len(older) - 1; i >= 0; i-- {
+		result.Messages = append(result.Messages, older[i])
+	}
+	result.Messages = append(result.Messages, target)
+	result.Messages = append(result.Messages, newer...)
+	return result, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"DarkCS/entity"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const chatNotesCollection = "chat-notes"
+
+// AddChatNote stores an internal note and returns it with its ID.
+func (m *MongoDB) AddChatNote(note entity.ChatNote) (*entity.ChatNote, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatNotesCollection)
+
+	result, err := collection.InsertOne(m.ctx, note)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb insert chat note: %w", err)
+	}
+	note.ID = result.InsertedID.(primitive.ObjectID)
+	return &note, nil
+}
+
+// GetChatNotes returns the notes of a chat, oldest first.
+func (m *MongoDB) GetChatNotes(platform, userID string) ([]entity.ChatNote, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatNotesCollection)
+
+	filter := bson.D{{"platform", platform}, {"user_id", userID}}
+	opts := options.Find().SetSort(bson.D{{"created_at", 1}})
+	cursor, err := collection.Find(m.ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb find chat notes: %w", err)
+	}
+	defer cursor.Close(m.ctx)
+
+	var notes []entity.ChatNote
+	if err = cursor.All(m.ctx, &notes); err != nil {
+		return nil, fmt.Errorf("mongodb decode chat notes: %w", err)
+	}
+	return notes, nil
+}
+
+// DeleteChatNote deletes a note of the author and returns it.
+// It fails with entity.ErrChatNoteNotFound when the author has no such note.
+func (m *MongoDB) DeleteChatNote(id primitive.ObjectID, author string) (*entity.ChatNote, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatNotesCollection)
+
+	var note entity.ChatNote
+	err = collection.FindOneAndDelete(m.ctx, bson.D{{"_id", id}, {"author", author}}).Decode(&note)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, entity.ErrChatNoteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mongodb delete chat note: %w", err)
+	}
+	return &note, nil
+}
+
+// EnsureChatNoteIndexes creates the index used to list the notes of a chat.
+func (m *MongoDB) EnsureChatNoteIndexes() error {
+	connection, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatNotesCollection)
+
+	index := mongo.IndexModel{
+		Keys: bson.D{{"platform", 1}, {"user_id", 1}, {"created_at", 1}},
+	}
+	_, err = collection.Indexes().CreateOne(m.ctx, index)
+	if err != nil {
+		return fmt.Errorf("mongodb create chat note index: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"DarkCS/entity"
+)
+
+func TestChatMessageContextKeepsMessagesOfTheSameInstant(t *testing.T) {
+	m := testMongo(t)
+
+	// Platforms may deliver several messages with one timestamp
+	at := time.Now().Truncate(time.Millisecond)
+	ids := make([]primitive.ObjectID, 5)
+	for i := range ids {
+		ids[i] = primitive.NewObjectID()
+		err := m.SaveChatMessage(entity.ChatMessage{
+			ID:        ids[i],
+			Platform:  "test",
+			UserID:    "u1",
+			Direction: "incoming",
+			Text:      "message",
+			CreatedAt: at,
+		})
+		if err != nil {
+			t.Fatalf("save message %d: %v", i, err)
+		}
+	}
+
+	result, err := m.GetChatMessageContext("test", "u1", ids[2], 1, 1)
+	if err != nil {
+		t.Fatalf("get context: %v", err)
+	}
+
+	var got []primitive.ObjectID
+	for _, msg := range result.Messages {
+		got = append(got, msg.ID)
+	}
+	want := ids[1:4]
+	if !slices.Equal(got, want) {
+		t.Errorf("context = %v, want %v", got, want)
+	}
+	if !result.HasBefore || !result.HasAfter {
+		t.Errorf("has before/after = %v/%v, want true/true", result.HasBefore, result.HasAfter)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"DarkCS/bot/chat"
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -11,9 +12,14 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-const chatStatesCollection = "chat_states"
+const (
+	chatStatesCollection  = "chat_states"
+	chatLocalesCollection = "chat_locales"
+)
 
 // SaveChatState persists a user's chat state by {platform, user_id}.
+// The write only succeeds if the stored version still matches state.Version;
+// otherwise chat.ErrStateConflict is returned and the stored state is left untouched.
 func (m *MongoDB) SaveChatState(ctx context.Context, state *chat.ChatState) error {
 	connection, err := m.connect()
 	if err != nil {
@@ -25,12 +31,70 @@ func (m *MongoDB) SaveChatState(ctx context.Context, state *chat.ChatState) erro
 
 	state.UpdatedAt = time.Now()
 
+	expected := state.Version
+	state.Version = expected + 1
+
 	filter := bson.D{{"platform", state.Platform}, {"user_id", state.UserID}}
 	update := bson.D{{"$set", state}}
-	opts := options.Update().SetUpsert(true)
 
-	_, err = collection.UpdateOne(ctx, filter, update, opts)
-	return err
+	if expected == 0 {
+		// New state (or a legacy document without a version): insert or replace an unversioned one.
+		filter = append(filter, bson.E{Key: "version", Value: bson.D{{"$in", bson.A{0, nil}}}})
+		_, err = collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		if err != nil {
+			state.Version = expected
+			if mongo.IsDuplicateKeyError(err) {
+				return chat.ErrStateConflict
+			}
+			return err
+		}
+		return nil
+	}
+
+	filter = append(filter, bson.E{Key: "version", Value: expected})
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		state.Version = expected
+		return err
+	}
+	if res.MatchedCount == 0 {
+		state.Version = expected
+		return chat.ErrStateConflict
+	}
+	return nil
+}
+
+// EnsureChatStateIndexes creates a unique index on {platform, user_id}, which
+// SaveChatState relies on to detect concurrent creation of the same state.
+// The chat locales get the same index.
+func (m *MongoDB) EnsureChatStateIndexes() error {
+	connection, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatStatesCollection)
+
+	index := mongo.IndexModel{
+		Keys: bson.D{
+			{"platform", 1},
+			{"user_id", 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err = collection.Indexes().CreateOne(m.ctx, index)
+	if err != nil {
+		return fmt.Errorf("mongodb create chat state index: %w", err)
+	}
+
+	_, err = connection.Database(m.database).Collection(chatLocalesCollection).Indexes().CreateOne(m.ctx, index)
+	if err != nil {
+		return fmt.Errorf("mongodb create chat locale index: %w", err)
+	}
+
+	return nil
 }
 
 // LoadChatState retrieves a user's chat state by {platform, user_id}.
@@ -57,9 +121,9 @@ func (m *MongoDB) LoadChatState(ctx context.Context, platform, userID string) (*
 	return &state, nil
 }
 
-// FindChatStatesBySteps returns all chat states in the given workflow whose current step
-// matches any of the provided step IDs. Used for admin bulk operations.
-func (m *MongoDB) FindChatStatesBySteps(ctx context.Context, workflowID chat.WorkflowID, steps []chat.StepID) ([]*chat.ChatState, error) {
+// FindIdleChatStates returns all chat states in the given workflow at the given step
+// whose last update is older than before. Used by the chat engine timeout sweeper.
+func (m *MongoDB) FindIdleChatStates(ctx context.Context, workflowID chat.WorkflowID, step chat.StepID, before time.Time) ([]*chat.ChatState, error) {
 	connection, err := m.connect()
 	if err != nil {
 		return nil, err
@@ -68,14 +132,10 @@ func (m *MongoDB) FindChatStatesBySteps(ctx context.Context, workflowID chat.Wor
 
 	collection := connection.Database(m.database).Collection(chatStatesCollection)
 
-	stepValues := make(bson.A, len(steps))
-	for i, s := range steps {
-		stepValues[i] = string(s)
-	}
-
 	filter := bson.D{
 		{"workflow_id", string(workflowID)},
-		{"current_step", bson.D{{"$in", stepValues}}},
+		{"current_step", string(step)},
+		{"updated_at", bson.D{{"$lt", before}}},
 	}
 
 	cursor, err := collection.Find(ctx, filter)
@@ -107,3 +167,49 @@ func (m *MongoDB) DeleteChatState(ctx context.Context, platform, userID string)
 	_, err = collection.DeleteOne(ctx, filter)
 	return err
 }
+
+// GetChatLocale returns the locale a user chose, or "" when they never switched it.
+func (m *MongoDB) GetChatLocale(ctx context.Context, platform, userID string) (string, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return "", err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatLocalesCollection)
+
+	filter := bson.D{{"platform", platform}, {"user_id", userID}}
+
+	var doc struct {
+		Locale string `bson:"locale"`
+	}
+	err = collection.FindOne(ctx, filter).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return doc.Locale, nil
+}
+
+// SetChatLocale stores the locale a user chose. It outlives the user's chat state.
+func (m *MongoDB) SetChatLocale(ctx context.Context, platform, userID, locale string) error {
+	connection, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(chatLocalesCollection)
+
+	filter := bson.D{{"platform", platform}, {"user_id", userID}}
+	update := bson.D{{"$set", bson.D{
+		{"locale", locale},
+		{"updated_at", time.Now()},
+	}}}
+
+	_, err = collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
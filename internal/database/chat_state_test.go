@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+
+	"DarkCS/bot/chat"
+	"DarkCS/internal/config"
+)
+
+// testMongo connects to the MongoDB at DARKCS_TEST_MONGO_HOST (host:27017, no auth)
+// using a throwaway database. Tests are skipped when it is not set.
+func testMongo(t *testing.T) *MongoDB {
+	t.Helper()
+	host := os.Getenv("DARKCS_TEST_MONGO_HOST")
+	if host == "" {
+		t.Skip("DARKCS_TEST_MONGO_HOST is not set")
+	}
+
+	conf := &config.Config{}
+	conf.Mongo.Enabled = true
+	conf.Mongo.Host = host
+	conf.Mongo.Port = "27017"
+	conf.Mongo.Database = "darkcs_test"
+
+	m, err := NewMongoClient(conf, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("mongo client: %v", err)
+	}
+	t.Cleanup(func() {
+		connection, err := m.connect()
+		if err == nil {
+			_ = connection.Database(m.database).Drop(context.Background())
+			m.disconnect(connection)
+		}
+	})
+	return m
+}
+
+func TestSaveChatStateRejectsStaleVersion(t *testing.T) {
+	m := testMongo(t)
+	ctx := context.Background()
+	if err := m.EnsureChatStateIndexes(); err != nil {
+		t.Fatalf("ensure indexes: %v", err)
+	}
+
+	if err := m.SaveChatState(ctx, chat.NewChatState("telegram", "100", "100", "mainmenu", "menu")); err != nil {
+		t.Fatalf("initial save: %v", err)
+	}
+	first, _ := m.LoadChatState(ctx, "telegram", "100")
+	second, _ := m.LoadChatState(ctx, "telegram", "100")
+
+	if err := m.SaveChatState(ctx, first); err != nil {
+		t.Fatalf("save first: %v", err)
+	}
+	if err := m.SaveChatState(ctx, second); !errors.Is(err, chat.ErrStateConflict) {
+		t.Fatalf("stale save error = %v, want ErrStateConflict", err)
+	}
+}
+
+// A new state is saved with version 0; of concurrent creations only one may succeed,
+// which relies on the unique {platform, user_id} index.
+func TestSaveChatStateRejectsConcurrentCreation(t *testing.T) {
+	m := testMongo(t)
+	ctx := context.Background()
+	if err := m.EnsureChatStateIndexes(); err != nil {
+		t.Fatalf("ensure indexes: %v", err)
+	}
+
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- m.SaveChatState(ctx, chat.NewChatState("telegram", "200", "200", "onboarding", "start"))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	saved := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			saved++
+		case !errors.Is(err, chat.ErrStateConflict):
+			t.Fatalf("save error = %v, want nil or ErrStateConflict", err)
+		}
+	}
+	if saved != 1 {
+		t.Errorf("%d concurrent creations saved, want 1", saved)
+	}
+}
@@ -45,6 +45,25 @@ func (m *MongoDB) UploadFile(filename string, reader io.Reader, meta entity.File
 	return fileID, size, nil
 }
 
+// DeleteFile removes a file and its chunks from GridFS.
+func (m *MongoDB) DeleteFile(fileID primitive.ObjectID) error {
+	connection, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer m.disconnect(connection)
+
+	bucket, err := gridfs.NewBucket(connection.Database(m.database))
+	if err != nil {
+		return fmt.Errorf("gridfs bucket: %w", err)
+	}
+
+	if err := bucket.Delete(fileID); err != nil {
+		return fmt.Errorf("gridfs delete: %w", err)
+	}
+	return nil
+}
+
 // gridfsReadCloser wraps a GridFS download stream and disconnects
 // the MongoDB client when closed.
 type gridfsReadCloser struct {
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"DarkCS/entity"
+	"errors"
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"time"
 )
@@ -83,6 +85,27 @@ func (m *MongoDB) SaveSchoolStat(platform, userID, schoolName string) error {
 	return nil
 }
 
+// GetSchoolStat returns the school record of a user, or nil if the user has not selected a school.
+func (m *MongoDB) GetSchoolStat(platform, userID string) (*entity.QrStat, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(qrStatCollection)
+
+	var stat entity.QrStat
+	err = collection.FindOne(m.ctx, bson.D{{"platform", platform}, {"user_id", userID}}).Decode(&stat)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mongodb find school stat: %w", err)
+	}
+	return &stat, nil
+}
+
 func (m *MongoDB) GetAllQrStat() ([]entity.QrStat, error) {
 	connection, err := m.connect()
 	if err != nil {
@@ -34,6 +34,18 @@ func (m *MongoDB) UpsertUser(user entity.User) error {
 		orFilter = append(orFilter, bson.D{{"instagram_id", user.InstagramId}})
 	}
 
+	if user.FacebookId != "" {
+		orFilter = append(orFilter, bson.D{{"facebook_id", user.FacebookId}})
+	}
+
+	if user.ViberId != "" {
+		orFilter = append(orFilter, bson.D{{"viber_id", user.ViberId}})
+	}
+
+	if user.WebId != "" {
+		orFilter = append(orFilter, bson.D{{"web_id", user.WebId}})
+	}
+
 	if len(orFilter) == 0 {
 		return fmt.Errorf("no valid identifier fields to upsert")
 	}
@@ -68,6 +80,66 @@ func (m *MongoDB) GetUserByInstagramId(instagramId string) (*entity.User, error)
 	return &user, nil
 }
 
+func (m *MongoDB) GetUserByFacebookId(facebookId string) (*entity.User, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(usersCollection)
+
+	filter := bson.D{{"facebook_id", facebookId}}
+
+	var user entity.User
+	err = collection.FindOne(m.ctx, filter).Decode(&user)
+	if err != nil {
+		return nil, m.findError(err)
+	}
+
+	return &user, nil
+}
+
+func (m *MongoDB) GetUserByViberId(viberId string) (*entity.User, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(usersCollection)
+
+	filter := bson.D{{"viber_id", viberId}}
+
+	var user entity.User
+	err = collection.FindOne(m.ctx, filter).Decode(&user)
+	if err != nil {
+		return nil, m.findError(err)
+	}
+
+	return &user, nil
+}
+
+func (m *MongoDB) GetUserByWebId(webId string) (*entity.User, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(usersCollection)
+
+	filter := bson.D{{"web_id", webId}}
+
+	var user entity.User
+	err = collection.FindOne(m.ctx, filter).Decode(&user)
+	if err != nil {
+		return nil, m.findError(err)
+	}
+
+	return &user, nil
+}
+
 func (m *MongoDB) GetUser(email, phone string, telegramId int64) (*entity.User, error) {
 	connection, err := m.connect()
 	if err != nil {
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"DarkCS/entity"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	webhookQueueCollection    = "webhook-queue"
+	webhookMessagesCollection = "webhook-messages"
+)
+
+// webhookMessageTTL is how long processed message IDs are remembered.
+// Meta retries unacknowledged webhooks for up to 7 days.
+const webhookMessageTTL = 7 * 24 * time.Hour
+
+// EnqueueWebhook stores a webhook payload for processing.
+func (m *MongoDB) EnqueueWebhook(platform string, payload []byte) error {
+	connection, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(webhookQueueCollection)
+
+	now := time.Now()
+	_, err = collection.InsertOne(m.ctx, entity.WebhookEvent{
+		Platform:    platform,
+		Payload:     payload,
+		LockedUntil: now,
+		CreatedAt:   now,
+	})
+	if err != nil {
+		return fmt.Errorf("mongodb insert webhook event: %w", err)
+	}
+	return nil
+}
+
+// ClaimWebhook locks the oldest unlocked payload of the platform for the lease duration
+// and returns it. It returns nil when the queue is empty. Claiming does not count an
+// attempt; StartWebhook does that when the payload is processed.
+func (m *MongoDB) ClaimWebhook(platform string, lease time.Duration) (*entity.WebhookEvent, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(webhookQueueCollection)
+
+	now := time.Now()
+	filter := bson.D{{"platform", platform}, {"locked_until", bson.D{{"$lte", now}}}}
+	update := bson.D{{"$set", bson.D{{"locked_until", now.Add(lease)}}}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{"created_at", 1}}).
+		SetReturnDocument(options.After)
+
+	var event entity.WebhookEvent
+	err = collection.FindOneAndUpdate(m.ctx, filter, update, opts).Decode(&event)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mongodb claim webhook event: %w", err)
+	}
+	return &event, nil
+}
+
+// StartWebhook counts an attempt to process a claimed payload and renews its lease.
+// It returns the number of attempts so far, or 0 when the payload is no longer queued.
+func (m *MongoDB) StartWebhook(id primitive.ObjectID, lease time.Duration) (int, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return 0, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(webhookQueueCollection)
+
+	update := bson.D{
+		{"$set", bson.D{{"locked_until", time.Now().Add(lease)}}},
+		{"$inc", bson.D{{"attempts", 1}}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var event entity.WebhookEvent
+	err = collection.FindOneAndUpdate(m.ctx, bson.D{{"_id", id}}, update, opts).Decode(&event)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("mongodb start webhook event: %w", err)
+	}
+	return event.Attempts, nil
+}
+
+// RenewWebhooks extends the lease of claimed payloads that are still waiting or running.
+func (m *MongoDB) RenewWebhooks(ids []primitive.ObjectID, lease time.Duration) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	connection, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(webhookQueueCollection)
+
+	filter := bson.D{{"_id", bson.D{{"$in", ids}}}}
+	update := bson.D{{"$set", bson.D{{"locked_until", time.Now().Add(lease)}}}}
+	_, err = collection.UpdateMany(m.ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("mongodb renew webhook events: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhook removes a processed payload from the queue.
+func (m *MongoDB) DeleteWebhook(id primitive.ObjectID) error {
+	connection, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(webhookQueueCollection)
+
+	_, err = collection.DeleteOne(m.ctx, bson.D{{"_id", id}})
+	if err != nil {
+		return fmt.Errorf("mongodb delete webhook event: %w", err)
+	}
+	return nil
+}
+
+// MarkWebhookMessageSeen records a platform message ID and reports whether
+// it was seen for the first time. Repeated deliveries of the message return false.
+func (m *MongoDB) MarkWebhookMessageSeen(platform, messageID string) (bool, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return false, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(webhookMessagesCollection)
+
+	_, err = collection.InsertOne(m.ctx, bson.D{
+		{"platform", platform},
+		{"message_id", messageID},
+		{"created_at", time.Now()},
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("mongodb insert webhook message: %w", err)
+	}
+	return true, nil
+}
+
+// ForgetWebhookMessage removes a message ID recorded by MarkWebhookMessageSeen,
+// so a repeated delivery of a message that failed to process is handled again.
+func (m *MongoDB) ForgetWebhookMessage(platform, messageID string) error {
+	connection, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(webhookMessagesCollection)
+
+	_, err = collection.DeleteOne(m.ctx, bson.D{{"platform", platform}, {"message_id", messageID}})
+	if err != nil {
+		return fmt.Errorf("mongodb delete webhook message: %w", err)
+	}
+	return nil
+}
+
+// EnsureWebhookIndexes creates the queue index, the unique index that detects repeated
+// message deliveries and the TTL index that forgets them after webhookMessageTTL.
+func (m *MongoDB) EnsureWebhookIndexes() error {
+	connection, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer m.disconnect(connection)
+
+	queue := connection.Database(m.database).Collection(webhookQueueCollection)
+	_, err = queue.Indexes().CreateOne(m.ctx, mongo.IndexModel{
+		Keys: bson.D{{"platform", 1}, {"created_at", 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("mongodb create webhook queue index: %w", err)
+	}
+
+	messages := connection.Database(m.database).Collection(webhookMessagesCollection)
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{"platform", 1}, {"message_id", 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{"created_at", 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(webhookMessageTTL.Seconds())),
+		},
+	}
+	_, err = messages.Indexes().CreateMany(m.ctx, indexes)
+	if err != nil {
+		return fmt.Errorf("mongodb create webhook message indexes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestClaimWebhookHonoursLease(t *testing.T) {
+	m := testMongo(t)
+	if err := m.EnsureWebhookIndexes(); err != nil {
+		t.Fatalf("ensure indexes: %v", err)
+	}
+	if err := m.EnqueueWebhook("test", []byte("payload")); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	event, err := m.ClaimWebhook("test", 50*time.Millisecond)
+	if err != nil || event == nil {
+		t.Fatalf("first claim = %v, %v", event, err)
+	}
+	if event.Attempts != 0 {
+		t.Errorf("attempts = %d, want a claim not to count one", event.Attempts)
+	}
+
+	if again, err := m.ClaimWebhook("test", time.Minute); err != nil || again != nil {
+		t.Fatalf("claim within the lease = %v, %v, want nothing", again, err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	again, err := m.ClaimWebhook("test", time.Minute)
+	if err != nil || again == nil {
+		t.Fatalf("claim after the lease = %v, %v", again, err)
+	}
+	if again.ID != event.ID || again.Attempts != 0 {
+		t.Errorf("reclaimed %s with %d attempts, want %s with 0", again.ID.Hex(), again.Attempts, event.ID.Hex())
+	}
+}
+
+func TestStartAndRenewWebhook(t *testing.T) {
+	m := testMongo(t)
+	if err := m.EnsureWebhookIndexes(); err != nil {
+		t.Fatalf("ensure indexes: %v", err)
+	}
+	if err := m.EnqueueWebhook("test", []byte("payload")); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	event, err := m.ClaimWebhook("test", 50*time.Millisecond)
+	if err != nil || event == nil {
+		t.Fatalf("claim = %v, %v", event, err)
+	}
+
+	for want := 1; want <= 2; want++ {
+		attempts, err := m.StartWebhook(event.ID, 50*time.Millisecond)
+		if err != nil || attempts != want {
+			t.Fatalf("start = %d, %v, want %d", attempts, err, want)
+		}
+	}
+
+	if err := m.RenewWebhooks([]primitive.ObjectID{event.ID}, time.Minute); err != nil {
+		t.Fatalf("renew: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if again, err := m.ClaimWebhook("test", time.Minute); err != nil || again != nil {
+		t.Fatalf("claim within the renewed lease = %v, %v, want nothing", again, err)
+	}
+
+	if err := m.DeleteWebhook(event.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if attempts, err := m.StartWebhook(event.ID, time.Minute); err != nil || attempts != 0 {
+		t.Errorf("start of a deleted payload = %d, %v, want 0", attempts, err)
+	}
+}
+
+func TestForgetWebhookMessage(t *testing.T) {
+	m := testMongo(t)
+	if err := m.EnsureWebhookIndexes(); err != nil {
+		t.Fatalf("ensure indexes: %v", err)
+	}
+
+	for i, want := range []bool{true, false} {
+		first, err := m.MarkWebhookMessageSeen("test", "mid.1")
+		if err != nil || first != want {
+			t.Fatalf("mark %d = %v, %v, want %v", i, first, err, want)
+		}
+	}
+
+	if err := m.ForgetWebhookMessage("test", "mid.1"); err != nil {
+		t.Fatalf("forget: %v", err)
+	}
+	if first, err := m.MarkWebhookMessageSeen("test", "mid.1"); err != nil || !first {
+		t.Errorf("mark after forget = %v, %v, want true", first, err)
+	}
+}
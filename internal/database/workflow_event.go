@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"DarkCS/bot/chat"
+	"DarkCS/entity"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const workflowEventsCollection = "workflow_events"
+
+// SaveWorkflowEvent appends an event to the workflow audit log.
+func (m *MongoDB) SaveWorkflowEvent(ctx context.Context, event chat.WorkflowEvent) error {
+	connection, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(workflowEventsCollection)
+
+	_, err = collection.InsertOne(ctx, event)
+	if err != nil {
+		return fmt.Errorf("mongodb insert workflow event: %w", err)
+	}
+	return nil
+}
+
+// EnsureWorkflowEventIndexes creates the indexes used by the workflow analytics queries.
+func (m *MongoDB) EnsureWorkflowEventIndexes() error {
+	connection, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(workflowEventsCollection)
+
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{"workflow_id", 1}, {"timestamp", 1}}},
+		{Keys: bson.D{{"platform", 1}, {"user_id", 1}, {"timestamp", 1}}},
+	}
+
+	_, err = collection.Indexes().CreateMany(m.ctx, indexes)
+	if err != nil {
+		return fmt.Errorf("mongodb create workflow event indexes: %w", err)
+	}
+	return nil
+}
+
+// GetWorkflowUserSteps returns, per user, the steps out of steps that the user
+// entered in the workflow between from and to, with the time of the first entry.
+func (m *MongoDB) GetWorkflowUserSteps(workflowID string, steps []string, from, to time.Time) ([]entity.WorkflowUserSteps, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(workflowEventsCollection)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{"workflow_id", workflowID},
+			{"to_step", bson.D{{"$in", steps}}},
+			{"timestamp", bson.D{{"$gte", from}, {"$lt", to}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{"_id", bson.D{{"platform", "$platform"}, {"user_id", "$user_id"}, {"step", "$to_step"}}},
+			{"first", bson.D{{"$min", "$timestamp"}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{"_id", bson.D{{"platform", "$_id.platform"}, {"user_id", "$_id.user_id"}}},
+			{"steps", bson.D{{"$push", bson.D{{"k", "$_id.step"}, {"v", "$first"}}}}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{"_id", 0},
+			{"platform", "$_id.platform"},
+			{"user_id", "$_id.user_id"},
+			{"steps", bson.D{{"$arrayToObject", "$steps"}}},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(m.ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb aggregate workflow user steps: %w", err)
+	}
+	defer cursor.Close(m.ctx)
+
+	var users []entity.WorkflowUserSteps
+	if err = cursor.All(m.ctx, &users); err != nil {
+		return nil, fmt.Errorf("mongodb decode workflow user steps: %w", err)
+	}
+	return users, nil
+}
+
+// GetWorkflowStepStats aggregates the workflow's events between from and to per step.
+// Every event is counted as an entry into its to_step and as an exit from (or an
+// error in) its from_step, so each step's duration comes from the events leaving it.
+func (m *MongoDB) GetWorkflowStepStats(workflowID string, from, to time.Time) ([]entity.WorkflowStepStat, error) {
+	connection, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.disconnect(connection)
+
+	collection := connection.Database(m.database).Collection(workflowEventsCollection)
+
+	isExit := bson.D{{"$in", bson.A{"$type", bson.A{chat.EventTransition, chat.EventComplete}}}}
+	isError := bson.D{{"$eq", bson.A{"$type", chat.EventError}}}
+	one, zero := bson.D{{"$literal", 1}}, bson.D{{"$literal", 0}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{"workflow_id", workflowID},
+			{"timestamp", bson.D{{"$gte", from}, {"$lt", to}}},
+		}}},
+		// Split every event into the step it entered and the step it left
+		{{Key: "$project", Value: bson.D{
+			{"touches", bson.A{
+				bson.D{
+					{"step", "$to_step"},
+					{"user", bson.D{{"$concat", bson.A{"$platform", ":", "$user_id"}}}},
+					{"in", one}, {"out", zero}, {"err", zero}, {"duration", zero},
+				},
+				bson.D{
+					{"step", "$from_step"},
+					{"user", nil},
+					{"in", zero},
+					{"out", bson.D{{"$cond", bson.A{isExit, 1, 0}}}},
+					{"err", bson.D{{"$cond", bson.A{isError, 1, 0}}}},
+					{"duration", bson.D{{"$cond", bson.A{isExit, "$duration", 0}}}},
+				},
+			}},
+		}}},
+		{{Key: "$unwind", Value: "$touches"}},
+		{{Key: "$match", Value: bson.D{{"touches.step", bson.D{{"$exists", true}, {"$ne", ""}}}}}},
+		{{Key: "$group", Value: bson.D{
+			{"_id", "$touches.step"},
+			{"users", bson.D{{"$addToSet", "$touches.user"}}},
+			{"entries", bson.D{{"$sum", "$touches.in"}}},
+			{"exits", bson.D{{"$sum", "$touches.out"}}},
+			{"errors", bson.D{{"$sum", "$touches.err"}}},
+			{"duration", bson.D{{"$sum", "$touches.duration"}}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{"entries", 1},
+			{"exits", 1},
+			{"errors", 1},
+			{"duration", 1},
+			{"users", bson.D{{"$size", bson.D{{"$setDifference", bson.A{"$users", bson.A{nil}}}}}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{"entries", -1}}}},
+	}
+
+	cursor, err := collection.Aggregate(m.ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb aggregate workflow step stats: %w", err)
+	}
+	defer cursor.Close(m.ctx)
+
+	var stats []entity.WorkflowStepStat
+	if err = cursor.All(m.ctx, &stats); err != nil {
+		return nil, fmt.Errorf("mongodb decode workflow step stats: %w", err)
+	}
+	return stats, nil
+}
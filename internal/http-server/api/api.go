@@ -6,17 +6,23 @@ import (
 	"net"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 
 	"DarkCS/bot/insta"
+	"DarkCS/bot/messenger"
+	"DarkCS/bot/viber"
+	"DarkCS/bot/web"
 	"DarkCS/bot/whatsapp"
 	"DarkCS/internal/config"
+	"DarkCS/internal/http-server/handlers/analytics"
 	"DarkCS/internal/http-server/handlers/assistant"
 	"DarkCS/internal/http-server/handlers/crm"
 	"DarkCS/internal/http-server/handlers/errors"
+	"DarkCS/internal/http-server/handlers/facebook"
 	"DarkCS/internal/http-server/handlers/instagram"
 	"DarkCS/internal/http-server/handlers/key"
 	"DarkCS/internal/http-server/handlers/mcp"
@@ -28,22 +34,34 @@ import (
 	"DarkCS/internal/http-server/handlers/service"
 	"DarkCS/internal/http-server/handlers/smart"
 	"DarkCS/internal/http-server/handlers/user"
+	vb "DarkCS/internal/http-server/handlers/viber"
+	"DarkCS/internal/http-server/handlers/webchat"
 	wa "DarkCS/internal/http-server/handlers/whatsapp"
+	"DarkCS/internal/http-server/handlers/workflows"
 	"DarkCS/internal/http-server/handlers/zoho"
 	"DarkCS/internal/http-server/middleware/authenticate"
+	"DarkCS/internal/http-server/middleware/ratelimit"
 	"DarkCS/internal/http-server/middleware/timeout"
 	"DarkCS/internal/lib/sl"
 	"DarkCS/internal/ws"
 )
 
+// webchatRateLimit is how many session and socket requests one address may make per minute.
+const webchatRateLimit = 20
+
 type Server struct {
-	conf        *config.Config
-	httpServer  *http.Server
-	log         *slog.Logger
-	instaBot    *insta.InstaBot
-	whatsappBot *whatsapp.WhatsAppBot
-	wsHub       *ws.Hub
-	wsAuth      ws.Authenticator
+	conf         *config.Config
+	httpServer   *http.Server
+	log          *slog.Logger
+	instaBot     *insta.InstaBot
+	whatsappBot  *whatsapp.WhatsAppBot
+	messengerBot *messenger.MessengerBot
+	viberBot     *viber.ViberBot
+	webChat      *web.WebChat
+	tgWebhooks   map[string]http.Handler
+	wsHub        *ws.Hub
+	wsAuth       ws.Authenticator
+	workflows    workflows.Reloader
 }
 
 // Option is a functional option for configuring the server
@@ -63,6 +81,44 @@ func WithWhatsAppBot(bot *whatsapp.WhatsAppBot) Option {
 	}
 }
 
+// WithMessengerBot sets the Facebook Messenger bot for the server
+func WithMessengerBot(bot *messenger.MessengerBot) Option {
+	return func(s *Server) {
+		s.messengerBot = bot
+	}
+}
+
+// WithViberBot sets the Viber bot for the server
+func WithViberBot(bot *viber.ViberBot) Option {
+	return func(s *Server) {
+		s.viberBot = bot
+	}
+}
+
+// WithWebChat sets the website chat widget channel for the server
+func WithWebChat(chat *web.WebChat) Option {
+	return func(s *Server) {
+		s.webChat = chat
+	}
+}
+
+// WithWorkflowReloader enables reloading the declarative workflows over the API
+func WithWorkflowReloader(reloader workflows.Reloader) Option {
+	return func(s *Server) {
+		s.workflows = reloader
+	}
+}
+
+// WithTelegramWebhook serves a Telegram bot's webhook at /webhook/{name}
+func WithTelegramWebhook(name string, handler http.Handler) Option {
+	return func(s *Server) {
+		if s.tgWebhooks == nil {
+			s.tgWebhooks = make(map[string]http.Handler)
+		}
+		s.tgWebhooks[name] = handler
+	}
+}
+
 // WithWsHub sets the WebSocket hub and authenticator for the server
 func WithWsHub(hub *ws.Hub, auth ws.Authenticator) Option {
 	return func(s *Server) {
@@ -86,6 +142,7 @@ type Handler interface {
 	mcp.Core
 	school.Core
 	crm.Core
+	analytics.Core
 	SetPublicURL(url string)
 }
 
@@ -121,6 +178,16 @@ func New(conf *config.Config, log *slog.Logger, handler Handler, opts ...Option)
 			r.Get("/whatsapp", wa.WebhookVerify(log, server.whatsappBot))
 			r.Post("/whatsapp", wa.WebhookHandler(log, server.whatsappBot))
 		}
+		if server.messengerBot != nil {
+			r.Get("/facebook", facebook.WebhookVerify(log, server.messengerBot))
+			r.Post("/facebook", facebook.WebhookHandler(log, server.messengerBot))
+		}
+		if server.viberBot != nil {
+			r.Post("/viber", vb.WebhookHandler(log, server.viberBot))
+		}
+		for name, handler := range server.tgWebhooks {
+			r.Method(http.MethodPost, "/"+name, handler)
+		}
 	})
 
 	// API v1 routes
@@ -132,6 +199,15 @@ func New(conf *config.Config, log *slog.Logger, handler Handler, opts ...Option)
 			})
 		}
 
+		// Website chat widget (anonymous visitors, authenticated by their session token)
+		if server.webChat != nil {
+			v1.Group(func(r chi.Router) {
+				r.Use(ratelimit.New(log, webchatRateLimit, time.Minute, conf.Listen.TrustedProxies))
+				r.Post("/webchat/session", webchat.Session(log, server.webChat))
+				r.Get("/webchat/ws", webchat.Connect(log, server.webChat))
+			})
+		}
+
 		// File download endpoint — authenticated via HMAC-signed URL
 		v1.Get("/crm/files/{file_id}", crm.DownloadFile(log, handler))
 
@@ -186,9 +262,36 @@ func New(conf *config.Config, log *slog.Logger, handler Handler, opts ...Option)
 			auth.Route("/crm", func(r chi.Router) {
 				r.Get("/chats", crm.GetChats(log, handler))
 				r.Get("/chats/{platform}/{user_id}/messages", crm.GetMessages(log, handler))
+				r.Get("/chats/{platform}/{user_id}/messages/{message_id}/context", crm.GetMessageContext(log, handler))
+				r.Get("/search", crm.SearchMessages(log, handler))
 				r.Post("/chats/{platform}/{user_id}/send", crm.SendMessage(log, handler))
 				r.Post("/chats/{platform}/{user_id}/send-file", crm.SendFile(log, handler))
+				r.Post("/chats/{platform}/{user_id}/send-template", crm.SendTemplate(log, handler))
+				r.Post("/chats/{platform}/{user_id}/assign", crm.AssignChat(log, handler))
+				r.Post("/chats/{platform}/{user_id}/status", crm.SetChatStatus(log, handler))
+				r.Get("/chats/{platform}/{user_id}/notes", crm.GetNotes(log, handler))
+				r.Post("/chats/{platform}/{user_id}/notes", crm.AddNote(log, handler))
+				r.Delete("/notes/{note_id}", crm.DeleteNote(log, handler))
+				r.Post("/chats/{platform}/{user_id}/tags", crm.AddTag(log, handler))
+				r.Delete("/chats/{platform}/{user_id}/tags", crm.RemoveTag(log, handler))
+				r.Get("/tags", crm.GetTags(log, handler))
+				r.Get("/canned", crm.GetCannedResponses(log, handler))
+				r.Post("/canned", crm.CreateCannedResponse(log, handler))
+				r.Put("/canned/{canned_id}", crm.UpdateCannedResponse(log, handler))
+				r.Delete("/canned/{canned_id}", crm.DeleteCannedResponse(log, handler))
+				r.Post("/canned/{canned_id}/attachments", crm.AddCannedAttachments(log, handler))
+				r.Delete("/canned/{canned_id}/attachments/{file_id}", crm.RemoveCannedAttachment(log, handler))
+				r.Get("/chats/{platform}/{user_id}/canned/{canned_id}/preview", crm.PreviewCannedResponse(log, handler))
+				r.Post("/chats/{platform}/{user_id}/send-canned", crm.SendCannedResponse(log, handler))
+				r.Get("/templates/{platform}", crm.GetTemplates(log, handler))
+			})
+			auth.Route("/analytics", func(r chi.Router) {
+				r.Get("/funnel", analytics.Funnel(log, handler))
+				r.Get("/steps", analytics.Steps(log, handler))
 			})
+			if server.workflows != nil {
+				auth.Post("/workflows/reload", workflows.Reload(log, server.workflows))
+			}
 		})
 	})
 
@@ -0,0 +1,137 @@
+package analytics
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"DarkCS/entity"
+	"DarkCS/internal/lib/api/response"
+	"DarkCS/internal/lib/sl"
+)
+
+// defaultPeriod is the reporting window used when no "from" date is given.
+const defaultPeriod = 30 * 24 * time.Hour
+
+// Core defines the methods required by workflow analytics handlers.
+type Core interface {
+	GetWorkflowFunnel(workflowID string, steps []string, from, to time.Time) (*entity.WorkflowFunnel, error)
+	GetWorkflowStepStats(workflowID string, from, to time.Time) ([]entity.WorkflowStepStat, error)
+}
+
+// Funnel returns the conversion between consecutive steps of a workflow.
+//
+//	GET /analytics/funnel?workflow=onboarding&steps=hello,request_phone,confirm_data&from=2025-01-01&to=2025-02-01
+func Funnel(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := log.With(
+			sl.Module("http.handlers.analytics"),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		q := r.URL.Query()
+		workflowID := q.Get("workflow")
+		var steps []string
+		for _, s := range strings.Split(q.Get("steps"), ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				steps = append(steps, s)
+			}
+		}
+		if workflowID == "" || len(steps) == 0 {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("workflow and steps are required"))
+			return
+		}
+
+		from, to, ok := parsePeriod(w, r)
+		if !ok {
+			return
+		}
+
+		funnel, err := handler.GetWorkflowFunnel(workflowID, steps, from, to)
+		if err != nil {
+			logger.Error("get workflow funnel", slog.String("workflow_id", workflowID), sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get funnel"))
+			return
+		}
+
+		render.JSON(w, r, response.Ok(funnel))
+	}
+}
+
+// Steps returns per-step entries, exits, errors and average time in step for a workflow.
+//
+//	GET /analytics/steps?workflow=mainmenu&from=2025-01-01
+func Steps(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := log.With(
+			sl.Module("http.handlers.analytics"),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		workflowID := r.URL.Query().Get("workflow")
+		if workflowID == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("workflow is required"))
+			return
+		}
+
+		from, to, ok := parsePeriod(w, r)
+		if !ok {
+			return
+		}
+
+		stats, err := handler.GetWorkflowStepStats(workflowID, from, to)
+		if err != nil {
+			logger.Error("get workflow step stats", slog.String("workflow_id", workflowID), sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get step stats"))
+			return
+		}
+
+		if stats == nil {
+			stats = []entity.WorkflowStepStat{}
+		}
+
+		render.JSON(w, r, response.Ok(stats))
+	}
+}
+
+// parsePeriod reads the optional "from" and "to" query parameters (RFC 3339 or
+// YYYY-MM-DD). "to" defaults to now and "from" to 30 days before "to".
+// On invalid input it writes a 400 response and returns false.
+func parsePeriod(w http.ResponseWriter, r *http.Request) (from, to time.Time, ok bool) {
+	to = time.Now()
+	if v := r.URL.Query().Get("to"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid 'to' date"))
+			return from, to, false
+		}
+		to = t
+	}
+	from = to.Add(-defaultPeriod)
+	if v := r.URL.Query().Get("from"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid 'from' date"))
+			return from, to, false
+		}
+		from = t
+	}
+	return from, to, true
+}
+
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, v, time.Local)
+}
@@ -0,0 +1,113 @@
+package crm
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"DarkCS/entity"
+	"DarkCS/internal/lib/api/cont"
+	"DarkCS/internal/lib/api/response"
+)
+
+// AssignChat assigns a chat to a CRM user. "me" stands for the current user;
+// an empty assignee returns the chat to the unassigned queue. Only the current
+// assignee or a CRM admin may reassign an assigned chat.
+// Endpoint: POST /api/v1/crm/chats/{platform}/{user_id}/assign
+// Body: {"assignee": "olena"}
+func AssignChat(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		platform := chi.URLParam(r, "platform")
+		userID := chi.URLParam(r, "user_id")
+
+		if platform == "" || userID == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("platform and user_id are required"))
+			return
+		}
+
+		var req struct {
+			Assignee string `json:"assignee"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid request body"))
+			return
+		}
+		username := cont.GetUser(r.Context()).Username
+		if req.Assignee == "me" {
+			req.Assignee = username
+		}
+
+		assignment, err := handler.AssignChat(username, platform, userID, req.Assignee)
+		if errors.Is(err, entity.ErrChatAssigned) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("Chat is assigned to another user"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to assign chat",
+				slog.String("platform", platform),
+				slog.String("user_id", userID),
+				slog.String("assignee", req.Assignee),
+				slog.String("error", err.Error()),
+			)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to assign chat"))
+			return
+		}
+
+		render.JSON(w, r, response.Ok(assignment))
+	}
+}
+
+// SetChatStatus opens, suspends or closes a chat. Only the current assignee or
+// a CRM admin may change the status of an assigned chat.
+// Endpoint: POST /api/v1/crm/chats/{platform}/{user_id}/status
+// Body: {"status": "closed"}
+func SetChatStatus(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		platform := chi.URLParam(r, "platform")
+		userID := chi.URLParam(r, "user_id")
+
+		if platform == "" || userID == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("platform and user_id are required"))
+			return
+		}
+
+		var req struct {
+			Status entity.ChatStatus `json:"status"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.Status.Valid() {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("status must be open, pending or closed"))
+			return
+		}
+
+		username := cont.GetUser(r.Context()).Username
+		assignment, err := handler.SetChatStatus(username, platform, userID, req.Status)
+		if errors.Is(err, entity.ErrChatAssigned) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("Chat is assigned to another user"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to set chat status",
+				slog.String("platform", platform),
+				slog.String("user_id", userID),
+				slog.String("status", string(req.Status)),
+				slog.String("error", err.Error()),
+			)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to set chat status"))
+			return
+		}
+
+		render.JSON(w, r, response.Ok(assignment))
+	}
+}
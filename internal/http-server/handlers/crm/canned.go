@@ -0,0 +1,304 @@
+package crm
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"DarkCS/entity"
+	"DarkCS/internal/lib/api/cont"
+	"DarkCS/internal/lib/api/response"
+)
+
+type cannedRequest struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+	Scope string `json:"scope"`
+}
+
+// decodeCannedRequest reads a canned response body; scope defaults to personal.
+func decodeCannedRequest(r *http.Request) (cannedRequest, bool) {
+	var req cannedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, false
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	req.Text = strings.TrimSpace(req.Text)
+	if req.Scope == "" {
+		req.Scope = entity.CannedScopePersonal
+	}
+	return req, req.Title != "" && req.Text != "" && entity.ValidScope(req.Scope)
+}
+
+// GetCannedResponses returns the team replies and the current user's personal replies.
+// Endpoint: GET /api/v1/crm/canned
+func GetCannedResponses(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := cont.GetUser(r.Context()).Username
+
+		responses, err := handler.GetCannedResponses(username)
+		if err != nil {
+			log.Error("failed to get canned responses", slog.String("error", err.Error()))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get canned responses"))
+			return
+		}
+
+		if responses == nil {
+			responses = []entity.CannedResponse{}
+		}
+
+		render.JSON(w, r, response.Ok(responses))
+	}
+}
+
+// CreateCannedResponse saves a reply. Text may use {{name}}, {{phone}}, {{email}}, {{school}}
+// and {{last_order.number}}, {{last_order.status}}, {{last_order.ttn}} placeholders.
+// Endpoint: POST /api/v1/crm/canned
+// Body: {"title": "TTN", "text": "{{name}}, your TTN is {{last_order.ttn}}", "scope": "team|personal"}
+func CreateCannedResponse(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, ok := decodeCannedRequest(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("title and text are required, scope must be team or personal"))
+			return
+		}
+
+		username := cont.GetUser(r.Context()).Username
+		canned, err := handler.CreateCannedResponse(username, req.Title, req.Text, req.Scope)
+		if err != nil {
+			log.Error("failed to create canned response", slog.String("error", err.Error()))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to create canned response"))
+			return
+		}
+
+		render.JSON(w, r, response.Ok(canned))
+	}
+}
+
+// UpdateCannedResponse changes a reply. Only its owner may change the scope.
+// Endpoint: PUT /api/v1/crm/canned/{canned_id}
+// Body: {"title": "TTN", "text": "...", "scope": "team|personal"}
+func UpdateCannedResponse(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := cannedID(w, r)
+		if !ok {
+			return
+		}
+
+		req, ok := decodeCannedRequest(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("title and text are required, scope must be team or personal"))
+			return
+		}
+
+		username := cont.GetUser(r.Context()).Username
+		canned, err := handler.UpdateCannedResponse(username, id, req.Title, req.Text, req.Scope)
+		if err != nil {
+			cannedError(log, w, r, id, err, "Failed to update canned response")
+			return
+		}
+
+		render.JSON(w, r, response.Ok(canned))
+	}
+}
+
+// DeleteCannedResponse deletes a reply. Its attachments stay in file storage.
+// Endpoint: DELETE /api/v1/crm/canned/{canned_id}
+func DeleteCannedResponse(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := cannedID(w, r)
+		if !ok {
+			return
+		}
+
+		username := cont.GetUser(r.Context()).Username
+		if err := handler.DeleteCannedResponse(username, id); err != nil {
+			cannedError(log, w, r, id, err, "Failed to delete canned response")
+			return
+		}
+
+		render.JSON(w, r, response.Ok("canned response deleted"))
+	}
+}
+
+// AddCannedAttachments uploads files and attaches them to a reply.
+// Endpoint: POST /api/v1/crm/canned/{canned_id}/attachments
+// Content-Type: multipart/form-data
+// Fields: files (multiple)
+func AddCannedAttachments(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := cannedID(w, r)
+		if !ok {
+			return
+		}
+
+		// Nothing is uploaded for a reply the user cannot see
+		username := cont.GetUser(r.Context()).Username
+		if _, err := handler.GetCannedResponse(username, id); err != nil {
+			cannedError(log, w, r, id, err, "Failed to attach files")
+			return
+		}
+
+		attachments, ok := uploadFiles(log, handler, w, r, entity.FileMetadata{Uploader: "manager"})
+		if !ok {
+			return
+		}
+
+		canned, err := handler.AddCannedAttachments(username, id, attachments)
+		if err != nil {
+			cannedError(log, w, r, id, err, "Failed to attach files")
+			return
+		}
+
+		render.JSON(w, r, response.Ok(canned))
+	}
+}
+
+// RemoveCannedAttachment detaches a file from a reply and deletes it, unless it was sent
+// with the reply to a chat.
+// Endpoint: DELETE /api/v1/crm/canned/{canned_id}/attachments/{file_id}
+func RemoveCannedAttachment(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := cannedID(w, r)
+		if !ok {
+			return
+		}
+		fileID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "file_id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid file_id"))
+			return
+		}
+
+		username := cont.GetUser(r.Context()).Username
+		canned, err := handler.RemoveCannedAttachment(username, id, fileID)
+		if err != nil {
+			cannedError(log, w, r, id, err, "Failed to detach file")
+			return
+		}
+
+		render.JSON(w, r, response.Ok(canned))
+	}
+}
+
+// PreviewCannedResponse returns the reply text as it would be sent to the chat.
+// Endpoint: GET /api/v1/crm/chats/{platform}/{user_id}/canned/{canned_id}/preview
+func PreviewCannedResponse(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		platform := chi.URLParam(r, "platform")
+		userID := chi.URLParam(r, "user_id")
+
+		if platform == "" || userID == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("platform and user_id are required"))
+			return
+		}
+
+		id, ok := cannedID(w, r)
+		if !ok {
+			return
+		}
+
+		username := cont.GetUser(r.Context()).Username
+		text, err := handler.RenderCannedResponse(username, platform, userID, id)
+		if err != nil {
+			cannedError(log, w, r, id, err, "Failed to render canned response")
+			return
+		}
+
+		render.JSON(w, r, response.Ok(text))
+	}
+}
+
+// SendCannedResponse sends a reply to a user with its placeholders filled in and its files attached.
+// Endpoint: POST /api/v1/crm/chats/{platform}/{user_id}/send-canned
+// Body: {"id": "665f1c..."}
+func SendCannedResponse(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		platform := chi.URLParam(r, "platform")
+		userID := chi.URLParam(r, "user_id")
+
+		if platform == "" || userID == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("platform and user_id are required"))
+			return
+		}
+
+		var req struct {
+			ID string `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid request body"))
+			return
+		}
+		id, err := primitive.ObjectIDFromHex(req.ID)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid canned response id"))
+			return
+		}
+
+		username := cont.GetUser(r.Context()).Username
+		err = handler.SendCannedResponse(username, platform, userID, id)
+		if errors.Is(err, entity.ErrChatAssigned) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("Chat is assigned to another user"))
+			return
+		}
+		if errors.Is(err, entity.ErrMessagingWindowClosed) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("Messaging window is closed, send a template instead"))
+			return
+		}
+		if err != nil {
+			cannedError(log, w, r, id, err, "Failed to send canned response")
+			return
+		}
+
+		render.JSON(w, r, response.Ok("canned response sent"))
+	}
+}
+
+func cannedID(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "canned_id"))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid canned_id"))
+		return id, false
+	}
+	return id, true
+}
+
+// cannedError answers with the status matching a canned response error; other errors are logged as 500.
+func cannedError(log *slog.Logger, w http.ResponseWriter, r *http.Request, id primitive.ObjectID, err error, message string) {
+	switch {
+	case errors.Is(err, entity.ErrCannedResponseNotFound):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("Canned response not found"))
+	case errors.Is(err, entity.ErrCannedResponseOwner):
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, response.Error("Only the owner can change the scope"))
+	case errors.Is(err, entity.ErrCannedVariable):
+		render.Status(r, http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+	default:
+		log.Error("canned response request failed",
+			slog.String("canned_id", id.Hex()),
+			slog.String("action", message),
+			slog.String("error", err.Error()),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error(message))
+	}
+}
@@ -2,6 +2,8 @@ package crm
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
@@ -18,20 +20,73 @@ import (
 
 // Core defines the methods required by CRM handlers.
 type Core interface {
-	GetActiveChats(username string) ([]entity.ChatSummary, error)
+	GetActiveChats(username string, filter entity.ChatFilter) ([]entity.ChatSummary, error)
 	GetChatMessages(platform, userID string, limit, offset int) ([]entity.ChatMessage, error)
-	SendCrmMessage(platform, userID, text string) error
+	SearchChatMessages(search entity.ChatSearch) ([]entity.ChatSearchHit, error)
+	GetChatMessageContext(platform, userID string, id primitive.ObjectID, before, after int) (*entity.ChatMessageContext, error)
+	SendCrmMessage(username, platform, userID, text string) error
 	DownloadFile(fileID primitive.ObjectID) (filename, mimeType string, reader io.ReadCloser, err error)
 	UploadFile(filename string, reader io.Reader, meta entity.FileMetadata) (primitive.ObjectID, int64, error)
-	SendCrmFiles(platform, userID, caption string, attachments []entity.Attachment) error
+	SendCrmFiles(username, platform, userID, caption string, attachments []entity.Attachment) error
 	FileSigningSecret() string
+	GetMessageTemplates(platform string) ([]entity.MessageTemplate, error)
+	SendCrmTemplate(username, platform, userID, name string, values map[string]string) error
+	AssignChat(username, platform, userID, assignee string) (*entity.ChatAssignment, error)
+	SetChatStatus(username, platform, userID string, status entity.ChatStatus) (*entity.ChatAssignment, error)
+	GetChatNotes(platform, userID string) ([]entity.ChatNote, error)
+	AddChatNote(username, platform, userID, text string) (*entity.ChatNote, error)
+	DeleteChatNote(username string, id primitive.ObjectID) error
+	AddChatTag(platform, userID, tag string) ([]string, error)
+	RemoveChatTag(platform, userID, tag string) ([]string, error)
+	GetChatTags() ([]string, error)
+	GetCannedResponses(username string) ([]entity.CannedResponse, error)
+	CreateCannedResponse(username, title, text, scope string) (*entity.CannedResponse, error)
+	UpdateCannedResponse(username string, id primitive.ObjectID, title, text, scope string) (*entity.CannedResponse, error)
+	DeleteCannedResponse(username string, id primitive.ObjectID) error
+	GetCannedResponse(username string, id primitive.ObjectID) (*entity.CannedResponse, error)
+	AddCannedAttachments(username string, id primitive.ObjectID, attachments []entity.Attachment) (*entity.CannedResponse, error)
+	RemoveCannedAttachment(username string, id, fileID primitive.ObjectID) (*entity.CannedResponse, error)
+	RenderCannedResponse(username, platform, userID string, id primitive.ObjectID) (string, error)
+	SendCannedResponse(username, platform, userID string, id primitive.ObjectID) error
 }
 
 // GetChats returns the list of active chats with last message info.
+// Query: assignee=me|none|<username> narrows the list to a CRM user or to the unassigned queue,
+// status=open|pending|closed to one chat status, and each tag=<tag> to chats carrying that tag.
 func GetChats(log *slog.Logger, handler Core) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		username := cont.GetUser(r.Context()).Username
-		chats, err := handler.GetActiveChats(username)
+
+		var filter entity.ChatFilter
+		switch assignee := r.URL.Query().Get("assignee"); assignee {
+		case "":
+		case "me":
+			filter.Assignee = username
+		case "none":
+			filter.Unassigned = true
+		default:
+			filter.Assignee = assignee
+		}
+		if status := r.URL.Query().Get("status"); status != "" {
+			filter.Status = entity.ChatStatus(status)
+			if !filter.Status.Valid() {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error("status must be open, pending or closed"))
+				return
+			}
+		}
+
+		for _, tag := range r.URL.Query()["tag"] {
+			tag, err := entity.NormalizeTag(tag)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error(fmt.Sprintf("tag must be 1 to %d characters", entity.MaxTagLength)))
+				return
+			}
+			filter.Tags = append(filter.Tags, tag)
+		}
+
+		chats, err := handler.GetActiveChats(username, filter)
 		if err != nil {
 			log.Error("failed to get active chats", slog.String("error", err.Error()))
 			render.Status(r, http.StatusInternalServerError)
@@ -113,7 +168,18 @@ func SendMessage(log *slog.Logger, handler Core) http.HandlerFunc {
 			return
 		}
 
-		err := handler.SendCrmMessage(platform, userID, req.Text)
+		username := cont.GetUser(r.Context()).Username
+		err := handler.SendCrmMessage(username, platform, userID, req.Text)
+		if errors.Is(err, entity.ErrChatAssigned) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("Chat is assigned to another user"))
+			return
+		}
+		if errors.Is(err, entity.ErrMessagingWindowClosed) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("Messaging window is closed, send a template instead"))
+			return
+		}
 		if err != nil {
 			log.Error("failed to send CRM message",
 				slog.String("platform", platform),
@@ -0,0 +1,128 @@
+package crm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"DarkCS/entity"
+	"DarkCS/internal/lib/api/cont"
+	"DarkCS/internal/lib/api/response"
+)
+
+// GetNotes returns the internal notes of a chat, oldest first.
+// Endpoint: GET /api/v1/crm/chats/{platform}/{user_id}/notes
+func GetNotes(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		platform := chi.URLParam(r, "platform")
+		userID := chi.URLParam(r, "user_id")
+
+		if platform == "" || userID == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("platform and user_id are required"))
+			return
+		}
+
+		notes, err := handler.GetChatNotes(platform, userID)
+		if err != nil {
+			log.Error("failed to get chat notes",
+				slog.String("platform", platform),
+				slog.String("user_id", userID),
+				slog.String("error", err.Error()),
+			)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get notes"))
+			return
+		}
+
+		if notes == nil {
+			notes = []entity.ChatNote{}
+		}
+
+		render.JSON(w, r, response.Ok(notes))
+	}
+}
+
+// AddNote attaches an internal note to a chat. The note is never sent to the user.
+// Endpoint: POST /api/v1/crm/chats/{platform}/{user_id}/notes
+// Body: {"text": "Wants a wholesale price list"}
+func AddNote(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		platform := chi.URLParam(r, "platform")
+		userID := chi.URLParam(r, "user_id")
+
+		if platform == "" || userID == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("platform and user_id are required"))
+			return
+		}
+
+		var req struct {
+			Text string `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Text) == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("text is required"))
+			return
+		}
+
+		username := cont.GetUser(r.Context()).Username
+		note, err := handler.AddChatNote(username, platform, userID, req.Text)
+		if errors.Is(err, entity.ErrInvalidNote) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(fmt.Sprintf("text must be at most %d characters", entity.MaxNoteLength)))
+			return
+		}
+		if err != nil {
+			log.Error("failed to add chat note",
+				slog.String("platform", platform),
+				slog.String("user_id", userID),
+				slog.String("error", err.Error()),
+			)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to add note"))
+			return
+		}
+
+		render.JSON(w, r, response.Ok(note))
+	}
+}
+
+// DeleteNote deletes an internal note. CRM users can only delete their own notes.
+// Endpoint: DELETE /api/v1/crm/notes/{note_id}
+func DeleteNote(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "note_id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid note_id"))
+			return
+		}
+
+		username := cont.GetUser(r.Context()).Username
+		err = handler.DeleteChatNote(username, noteID)
+		if errors.Is(err, entity.ErrChatNoteNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("Note not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete chat note",
+				slog.String("note_id", noteID.Hex()),
+				slog.String("error", err.Error()),
+			)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to delete note"))
+			return
+		}
+
+		render.JSON(w, r, response.Ok("note deleted"))
+	}
+}
@@ -0,0 +1,195 @@
+package crm
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"DarkCS/entity"
+	"DarkCS/internal/lib/api/response"
+)
+
+// SearchMessages runs a full-text search over the chat history of all chats.
+// Endpoint: GET /api/v1/crm/search
+// Query: q (required; words, "exact phrases", -excluded), platform, direction=incoming|outgoing,
+// sender=user|manager|bot, from and to (RFC 3339 or YYYY-MM-DD, to is inclusive for dates),
+// has_attachments=true|false, sort=recent|relevance, limit (max 100, default 20), offset.
+func SearchMessages(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		search := entity.ChatSearch{
+			Query:     strings.TrimSpace(query.Get("q")),
+			Platform:  query.Get("platform"),
+			Direction: query.Get("direction"),
+			Sender:    query.Get("sender"),
+		}
+		if search.Query == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("q is required"))
+			return
+		}
+		if search.Direction != "" && search.Direction != "incoming" && search.Direction != "outgoing" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("direction must be incoming or outgoing"))
+			return
+		}
+
+		var err error
+		if search.From, err = parseSearchTime(query.Get("from"), false); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid from date"))
+			return
+		}
+		if search.To, err = parseSearchTime(query.Get("to"), true); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid to date"))
+			return
+		}
+
+		if v := query.Get("has_attachments"); v != "" {
+			has, err := strconv.ParseBool(v)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error("has_attachments must be true or false"))
+				return
+			}
+			search.HasAttachments = &has
+		}
+
+		switch query.Get("sort") {
+		case "", "recent":
+		case "relevance":
+			search.ByRelevance = true
+		default:
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("sort must be recent or relevance"))
+			return
+		}
+
+		var ok bool
+		if search.Limit, ok = queryInt(query.Get("limit"), 20, 1, 100); !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("limit must be between 1 and 100"))
+			return
+		}
+		if search.Offset, ok = queryInt(query.Get("offset"), 0, 0, math.MaxInt); !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("offset must be a non-negative number"))
+			return
+		}
+
+		hits, err := handler.SearchChatMessages(search)
+		if err != nil {
+			log.Error("failed to search chat messages",
+				slog.String("query", search.Query),
+				slog.String("error", err.Error()),
+			)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to search messages"))
+			return
+		}
+
+		if hits == nil {
+			hits = []entity.ChatSearchHit{}
+		}
+
+		render.JSON(w, r, response.Ok(hits))
+	}
+}
+
+// GetMessageContext opens a chat at a message, e.g. a search result, with the messages around it.
+// Endpoint: GET /api/v1/crm/chats/{platform}/{user_id}/messages/{message_id}/context
+// Query: before, after — messages to include on each side (max 50, default 10)
+func GetMessageContext(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		platform := chi.URLParam(r, "platform")
+		userID := chi.URLParam(r, "user_id")
+
+		if platform == "" || userID == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("platform and user_id are required"))
+			return
+		}
+
+		messageID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "message_id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid message_id"))
+			return
+		}
+
+		before, ok := queryInt(r.URL.Query().Get("before"), 10, 0, 50)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("before must be between 0 and 50"))
+			return
+		}
+		after, ok := queryInt(r.URL.Query().Get("after"), 10, 0, 50)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("after must be between 0 and 50"))
+			return
+		}
+
+		result, err := handler.GetChatMessageContext(platform, userID, messageID, before, after)
+		if errors.Is(err, entity.ErrChatMessageNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("Message not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get message context",
+				slog.String("platform", platform),
+				slog.String("user_id", userID),
+				slog.String("message_id", messageID.Hex()),
+				slog.String("error", err.Error()),
+			)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get messages"))
+			return
+		}
+
+		render.JSON(w, r, response.Ok(result))
+	}
+}
+
+// parseSearchTime parses an RFC 3339 time or a YYYY-MM-DD date. A date used as
+// the end of a range means the end of that day.
+func parseSearchTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// queryInt parses an integer query parameter between lo and hi, returning def when it is empty.
+// It reports false for a value that is not a number or out of range.
+func queryInt(value string, def, lo, hi int) (int, bool) {
+	if value == "" {
+		return def, true
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v < lo || v > hi {
+		return 0, false
+	}
+	return v, true
+}
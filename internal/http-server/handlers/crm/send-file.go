@@ -1,6 +1,7 @@
 package crm
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"github.com/go-chi/render"
 
 	"DarkCS/entity"
+	"DarkCS/internal/lib/api/cont"
 	"DarkCS/internal/lib/api/response"
 )
 
@@ -27,86 +29,106 @@ func SendFile(log *slog.Logger, handler Core) http.HandlerFunc {
 			return
 		}
 
-		if err := r.ParseMultipartForm(entity.MaxFileSize); err != nil {
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, response.Error("invalid multipart form"))
+		attachments, ok := uploadFiles(log, handler, w, r, entity.FileMetadata{
+			Platform: platform,
+			UserID:   userID,
+			Uploader: "manager",
+		})
+		if !ok {
 			return
 		}
-
 		caption := r.FormValue("caption")
-		files := r.MultipartForm.File["files"]
 
-		if len(files) == 0 {
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, response.Error("at least one file is required"))
+		username := cont.GetUser(r.Context()).Username
+		err := handler.SendCrmFiles(username, platform, userID, caption, attachments)
+		if errors.Is(err, entity.ErrChatAssigned) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("chat is assigned to another user"))
 			return
 		}
-
-		// Validate file sizes before uploading
-		for _, fh := range files {
-			if fh.Size > entity.MaxFileSize {
-				render.Status(r, http.StatusRequestEntityTooLarge)
-				render.JSON(w, r, response.Error(fmt.Sprintf("file %q exceeds the %d MB limit", fh.Filename, entity.MaxFileSize>>20)))
-				return
-			}
+		if errors.Is(err, entity.ErrMessagingWindowClosed) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("messaging window is closed, send a template instead"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to send files",
+				slog.String("platform", platform),
+				slog.String("user_id", userID),
+				slog.String("error", err.Error()),
+			)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to send files"))
+			return
 		}
 
-		var attachments []entity.Attachment
-		for _, fh := range files {
-			file, err := fh.Open()
-			if err != nil {
-				log.Error("failed to open uploaded file",
-					slog.String("filename", fh.Filename),
-					slog.String("error", err.Error()),
-				)
-				render.Status(r, http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to read uploaded file"))
-				return
-			}
+		render.JSON(w, r, response.Ok("files sent"))
+	}
+}
+
+// uploadFiles stores the files of the multipart form field "files" in GridFS with meta,
+// completed by the MIME type of each file. On failure it writes the error response and
+// returns false.
+func uploadFiles(log *slog.Logger, handler Core, w http.ResponseWriter, r *http.Request, meta entity.FileMetadata) ([]entity.Attachment, bool) {
+	if err := r.ParseMultipartForm(entity.MaxFileSize); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid multipart form"))
+		return nil, false
+	}
 
-			mimeType := fh.Header.Get("Content-Type")
-			if mimeType == "" {
-				mimeType = "application/octet-stream"
-			}
+	files := r.MultipartForm.File["files"]
+	if len(files) == 0 {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("at least one file is required"))
+		return nil, false
+	}
 
-			meta := entity.FileMetadata{
-				MIMEType: mimeType,
-				Platform: platform,
-				UserID:   userID,
-				Uploader: "manager",
-			}
+	// Validate file sizes before uploading
+	for _, fh := range files {
+		if fh.Size > entity.MaxFileSize {
+			render.Status(r, http.StatusRequestEntityTooLarge)
+			render.JSON(w, r, response.Error(fmt.Sprintf("file %q exceeds the %d MB limit", fh.Filename, entity.MaxFileSize>>20)))
+			return nil, false
+		}
+	}
 
-			fileID, size, err := handler.UploadFile(fh.Filename, file, meta)
-			file.Close()
-			if err != nil {
-				log.Error("failed to upload file to GridFS",
-					slog.String("filename", fh.Filename),
-					slog.String("error", err.Error()),
-				)
-				render.Status(r, http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to store file"))
-				return
-			}
+	var attachments []entity.Attachment
+	for _, fh := range files {
+		file, err := fh.Open()
+		if err != nil {
+			log.Error("failed to open uploaded file",
+				slog.String("filename", fh.Filename),
+				slog.String("error", err.Error()),
+			)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to read uploaded file"))
+			return nil, false
+		}
 
-			attachments = append(attachments, entity.Attachment{
-				FileID:   fileID,
-				Filename: fh.Filename,
-				MIMEType: mimeType,
-				Size:     size,
-			})
+		mimeType := fh.Header.Get("Content-Type")
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
 		}
+		meta.MIMEType = mimeType
 
-		if err := handler.SendCrmFiles(platform, userID, caption, attachments); err != nil {
-			log.Error("failed to send files",
-				slog.String("platform", platform),
-				slog.String("user_id", userID),
+		fileID, size, err := handler.UploadFile(fh.Filename, file, meta)
+		file.Close()
+		if err != nil {
+			log.Error("failed to upload file to GridFS",
+				slog.String("filename", fh.Filename),
 				slog.String("error", err.Error()),
 			)
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, response.Error("failed to send files"))
-			return
+			render.JSON(w, r, response.Error("failed to store file"))
+			return nil, false
 		}
 
-		render.JSON(w, r, response.Ok("files sent"))
+		attachments = append(attachments, entity.Attachment{
+			FileID:   fileID,
+			Filename: fh.Filename,
+			MIMEType: mimeType,
+			Size:     size,
+		})
 	}
+	return attachments, true
 }
@@ -0,0 +1,88 @@
+package crm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"DarkCS/entity"
+	"DarkCS/internal/lib/api/response"
+)
+
+// GetTags returns every tag in use on any chat.
+// Endpoint: GET /api/v1/crm/tags
+func GetTags(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tags, err := handler.GetChatTags()
+		if err != nil {
+			log.Error("failed to get chat tags", slog.String("error", err.Error()))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get tags"))
+			return
+		}
+
+		render.JSON(w, r, response.Ok(tags))
+	}
+}
+
+// AddTag puts a tag on a chat and returns the chat's tags.
+// Endpoint: POST /api/v1/crm/chats/{platform}/{user_id}/tags
+// Body: {"tag": "wholesale"}
+func AddTag(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Tag string `json:"tag"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid request body"))
+			return
+		}
+		changeTag(log, handler.AddChatTag, "add", req.Tag, w, r)
+	}
+}
+
+// RemoveTag takes a tag off a chat and returns the chat's tags.
+// Endpoint: DELETE /api/v1/crm/chats/{platform}/{user_id}/tags?tag=wholesale
+func RemoveTag(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		changeTag(log, handler.RemoveChatTag, "remove", r.URL.Query().Get("tag"), w, r)
+	}
+}
+
+func changeTag(log *slog.Logger, change func(platform, userID, tag string) ([]string, error), action, tag string, w http.ResponseWriter, r *http.Request) {
+	platform := chi.URLParam(r, "platform")
+	userID := chi.URLParam(r, "user_id")
+
+	if platform == "" || userID == "" {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("platform and user_id are required"))
+		return
+	}
+
+	tags, err := change(platform, userID, tag)
+	if errors.Is(err, entity.ErrInvalidTag) {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error(fmt.Sprintf("tag must be 1 to %d characters", entity.MaxTagLength)))
+		return
+	}
+	if err != nil {
+		log.Error("failed to update chat tags",
+			slog.String("action", action),
+			slog.String("platform", platform),
+			slog.String("user_id", userID),
+			slog.String("tag", tag),
+			slog.String("error", err.Error()),
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("Failed to update tags"))
+		return
+	}
+
+	render.JSON(w, r, response.Ok(tags))
+}
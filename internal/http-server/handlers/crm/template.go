@@ -0,0 +1,93 @@
+package crm
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"DarkCS/entity"
+	"DarkCS/internal/lib/api/cont"
+	"DarkCS/internal/lib/api/response"
+)
+
+// GetTemplates returns the message templates registered for a platform.
+// Endpoint: GET /api/v1/crm/templates/{platform}
+func GetTemplates(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		platform := chi.URLParam(r, "platform")
+
+		templates, err := handler.GetMessageTemplates(platform)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		if templates == nil {
+			templates = []entity.MessageTemplate{}
+		}
+
+		render.JSON(w, r, response.Ok(templates))
+	}
+}
+
+// SendTemplate sends a pre-approved template to a user, also outside the messaging window.
+// Endpoint: POST /api/v1/crm/chats/{platform}/{user_id}/send-template
+// Body: {"name": "order_ready", "params": {"name": "Олена", "order": "1234"}}
+func SendTemplate(log *slog.Logger, handler Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		platform := chi.URLParam(r, "platform")
+		userID := chi.URLParam(r, "user_id")
+
+		if platform == "" || userID == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("platform and user_id are required"))
+			return
+		}
+
+		var req struct {
+			Name   string            `json:"name"`
+			Params map[string]string `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("name is required"))
+			return
+		}
+
+		username := cont.GetUser(r.Context()).Username
+		err := handler.SendCrmTemplate(username, platform, userID, req.Name, req.Params)
+		if errors.Is(err, entity.ErrChatAssigned) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("Chat is assigned to another user"))
+			return
+		}
+		if errors.Is(err, entity.ErrTemplateNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("Template not found"))
+			return
+		}
+		if errors.Is(err, entity.ErrTemplateParams) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+		if err != nil {
+			log.Error("failed to send CRM template",
+				slog.String("platform", platform),
+				slog.String("user_id", userID),
+				slog.String("template", req.Name),
+				slog.String("error", err.Error()),
+			)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to send template"))
+			return
+		}
+
+		render.JSON(w, r, response.Ok("template sent"))
+	}
+}
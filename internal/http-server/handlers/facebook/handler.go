@@ -0,0 +1,24 @@
+package facebook
+
+import (
+	"log/slog"
+	"net/http"
+
+	"DarkCS/bot/messenger"
+	"DarkCS/internal/lib/sl"
+)
+
+// WebhookVerify handles GET requests for webhook verification
+func WebhookVerify(log *slog.Logger, bot *messenger.MessengerBot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.With(sl.Module("facebook.webhook")).Debug("webhook verification request")
+		bot.HandleWebhookVerification(w, r)
+	}
+}
+
+// WebhookHandler handles POST requests for incoming messages
+func WebhookHandler(log *slog.Logger, bot *messenger.MessengerBot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bot.HandleWebhook(w, r)
+	}
+}
@@ -0,0 +1,15 @@
+package viber
+
+import (
+	"log/slog"
+	"net/http"
+
+	"DarkCS/bot/viber"
+)
+
+// WebhookHandler handles POST requests for incoming callbacks
+func WebhookHandler(log *slog.Logger, bot *viber.ViberBot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bot.HandleWebhook(w, r)
+	}
+}
@@ -0,0 +1,35 @@
+package webchat
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"DarkCS/bot/web"
+	"DarkCS/internal/lib/api/response"
+)
+
+// Session starts or resumes an anonymous website visitor session.
+// Endpoint: POST /api/v1/webchat/session
+// Body (optional): {"token": "..."} to resume the visitor of a stored token.
+func Session(log *slog.Logger, chat *web.WebChat) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Token string `json:"token"`
+		}
+		// An empty or invalid body starts a new visitor
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		render.JSON(w, r, response.Ok(chat.NewSession(req.Token)))
+	}
+}
+
+// Connect opens the visitor WebSocket.
+// Endpoint: GET /api/v1/webchat/ws?token=...
+func Connect(log *slog.Logger, chat *web.WebChat) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chat.ServeWs(w, r)
+	}
+}
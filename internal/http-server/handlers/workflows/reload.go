@@ -0,0 +1,49 @@
+package workflows
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"DarkCS/internal/lib/api/response"
+	"DarkCS/internal/lib/sl"
+)
+
+// Reloader reloads the declarative workflows.
+type Reloader interface {
+	Reload() ([]string, []error)
+}
+
+// ReloadResult lists the installed workflows and the files or workflows that were skipped.
+type ReloadResult struct {
+	Workflows []string `json:"workflows"`
+	Errors    []string `json:"errors"`
+}
+
+// Reload reloads the YAML workflows from the configured directory without a restart.
+// Invalid files are skipped and listed in errors; the valid ones are installed.
+//
+//	POST /workflows/reload
+func Reload(log *slog.Logger, reloader Reloader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := log.With(
+			sl.Module("http.handlers.workflows"),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		ids, problems := reloader.Reload()
+
+		result := ReloadResult{Workflows: ids, Errors: make([]string, 0, len(problems))}
+		if result.Workflows == nil {
+			result.Workflows = []string{}
+		}
+		for _, err := range problems {
+			result.Errors = append(result.Errors, err.Error())
+		}
+		logger.Info("workflows reloaded", slog.Int("workflows", len(ids)), slog.Int("errors", len(problems)))
+
+		render.JSON(w, r, response.Ok(result))
+	}
+}
@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/render"
+
+	"DarkCS/internal/lib/api/response"
+	"DarkCS/internal/lib/sl"
+)
+
+// maxClients is how many client addresses are tracked before expired windows are swept.
+const maxClients = 10000
+
+// window counts the requests of one client address in the current period.
+type window struct {
+	start time.Time
+	count int
+}
+
+// New limits each client address to limit requests per period.
+// Requests over the limit are rejected with 429 Too Many Requests.
+// trustedProxies lists the addresses or CIDR ranges of the reverse proxies in front
+// of the server; X-Forwarded-For is read only from requests they pass on.
+func New(log *slog.Logger, limit int, period time.Duration, trustedProxies []string) func(next http.Handler) http.Handler {
+	logger := log.With(sl.Module("middleware.ratelimit"))
+	proxies := parseProxies(logger, trustedProxies)
+
+	var mu sync.Mutex
+	windows := make(map[string]*window)
+
+	allow := func(addr string, now time.Time) bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		w, ok := windows[addr]
+		if !ok || now.Sub(w.start) >= period {
+			if !ok && len(windows) >= maxClients {
+				for key, other := range windows {
+					if now.Sub(other.start) >= period {
+						delete(windows, key)
+					}
+				}
+			}
+			w = &window{start: now}
+			windows[addr] = w
+		}
+		w.count++
+		return w.count <= limit
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			addr := clientAddr(r, proxies)
+			if !allow(addr, time.Now()) {
+				logger.Warn("rate limit exceeded",
+					slog.String("path", r.URL.Path),
+					slog.String("remote_addr", addr),
+				)
+				render.Status(r, http.StatusTooManyRequests)
+				render.JSON(w, r, response.Error("Too many requests"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// parseProxies parses addresses and CIDR ranges; invalid entries are logged and skipped.
+func parseProxies(log *slog.Logger, entries []string) []netip.Prefix {
+	var proxies []netip.Prefix
+	for _, entry := range entries {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			log.Error("invalid trusted proxy", slog.String("proxy", entry))
+			continue
+		}
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return proxies
+}
+
+// trusted reports whether addr belongs to one of the proxies.
+func trusted(addr string, proxies []netip.Prefix) bool {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range proxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientAddr returns the address the request came from. X-Forwarded-For is used only
+// when the request comes from a trusted proxy: the client is then the last entry not
+// added by a trusted proxy. Anyone else could put any address in the header.
+func clientAddr(r *http.Request, proxies []netip.Prefix) string {
+	addr, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		addr = r.RemoteAddr
+	}
+	if !trusted(addr, proxies) {
+		return addr
+	}
+
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if hop == "" {
+			continue
+		}
+		if !trusted(hop, proxies) {
+			return hop
+		}
+		addr = hop
+	}
+	return addr
+}
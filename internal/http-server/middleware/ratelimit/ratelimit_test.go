@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientAddr(t *testing.T) {
+	proxies := parseProxies(slog.New(slog.NewTextHandler(io.Discard, nil)), []string{"127.0.0.1", "10.0.0.0/8"})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.7:5000", want: "203.0.113.7"},
+		{name: "spoofed header from a client", remoteAddr: "203.0.113.7:5000", forwarded: "198.51.100.1", want: "203.0.113.7"},
+		{name: "trusted proxy", remoteAddr: "127.0.0.1:5000", forwarded: "198.51.100.1", want: "198.51.100.1"},
+		{name: "client entry before the proxy's", remoteAddr: "127.0.0.1:5000", forwarded: "192.0.2.9, 198.51.100.1", want: "198.51.100.1"},
+		{name: "chain of trusted proxies", remoteAddr: "127.0.0.1:5000", forwarded: "198.51.100.1, 10.1.2.3", want: "198.51.100.1"},
+		{name: "trusted proxy without header", remoteAddr: "127.0.0.1:5000", want: "127.0.0.1"},
+		{name: "IPv4-mapped proxy address", remoteAddr: "[::ffff:127.0.0.1]:5000", forwarded: "198.51.100.1", want: "198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if got := clientAddr(r, proxies); got != tt.want {
+				t.Errorf("clientAddr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package i18n
+
+var en = map[string]string{
+	"lang.name":    "🇬🇧 English",
+	"lang.prompt":  "Choose your language:",
+	"lang.changed": "Language switched to English.",
+
+	"month.1":  "january",
+	"month.2":  "february",
+	"month.3":  "march",
+	"month.4":  "april",
+	"month.5":  "may",
+	"month.6":  "june",
+	"month.7":  "july",
+	"month.8":  "august",
+	"month.9":  "september",
+	"month.10": "october",
+	"month.11": "november",
+	"month.12": "december",
+
+	// Onboarding
+	"onboarding.hello":              "Hi! 🖤\nTo quickly find you in our system, please share your phone number in international format, starting with +380... 📱",
+	"onboarding.choose_phone":       "Would you like to use your WhatsApp phone number or enter another one?",
+	"onboarding.use_wa_phone":       "📱 Use %s",
+	"onboarding.enter_other_phone":  "✏️ Enter another number",
+	"onboarding.phone_accepted":     "✅ Phone number: %s",
+	"onboarding.share_phone_prompt": "Tap the button below to share your phone number:",
+	"onboarding.share_phone_button": "📱 Share phone number",
+	"onboarding.enter_phone":        "Enter your phone number (e.g. +380XXXXXXXXX):",
+	"onboarding.invalid_phone":      "❌ Invalid phone number format. Please try again (e.g. +380XXXXXXXXX):",
+	"onboarding.request_name":       "Please tell us your first and last name so we can get acquainted 😎",
+	"onboarding.invalid_name":       "Please enter a valid name (at least 2 characters).",
+	"onboarding.confirm_data":       "📋 Please check your details:\n\n👤 Name: %s\n📱 Phone: %s\n\nIs everything correct?",
+	"onboarding.confirm_name":       "📋 Please check your details:\n\n👤 Name: %s\n\nIs everything correct?",
+	"onboarding.confirm_yes":        "✅ Yes",
+	"onboarding.confirm_no":         "❌ No, change",
+	"onboarding.save_failed":        "Something went wrong while saving your details. Please try again later.",
+	"onboarding.saved":              "✅ Details saved!",
+	"onboarding.done":               "%s, this chat bot is here to make working with us even easier!",
+
+	// Main menu buttons
+	"btn.my_office":        "📦My account",
+	"btn.service_rate":     "⭐Rate our service",
+	"btn.order_status":     "🛒Order status",
+	"btn.ai_consultant":    "👋 AI consultation",
+	"btn.make_order":       "Place an order😎",
+	"btn.current_order":    "🛍️Current orders",
+	"btn.completed_orders": "✅Completed orders",
+	"btn.back":             "↩️Back",
+	"btn.back_to_menu":     "↩️Back to menu",
+	"btn.school_stat":      "📊School statistics",
+	"btn.learning":         "📚Learning",
+	"word.back":            "back",
+
+	// Main menu
+	"school.select_intro": "Please tell us which school you heard about us from 🖤\n\nChoose a school:",
+	"school.select":       "Choose a school:",
+	"school.welcome":      "Welcome, %s!\n\nGet -15%% off your first order with the promo code *DARKSCHOOL* 🖤\nUse it within 14 days at 👉 riornails.com\n\nTap 📚Learning to get extra tips from our brand master 🖤\n\nP.S. Your personal -7%% discount is already active and may grow over time ✨",
+	"menu.prompt":         "Tap an option below to open the section you need 👇",
+	"office.prompt":       "What are you interested in?",
+	"session.expired":     "Your session ended due to inactivity. Returning you to the main menu.",
+	"operator.requested":  "Your request has been passed to a manager. They will contact you shortly.",
+	"stop.done":           "Conversation ended. Send any message to start again.",
+
+	"error.user_info":      "Could not load your user information.",
+	"error.orders":         "Could not load your order information.",
+	"error.products":       "Could not load the products.",
+	"error.request":        "Something went wrong while processing your request. Please try again.",
+	"error.statistics":     "Could not load the statistics.",
+	"error.invalid_input":  "Invalid value, please try again.",
+	"error.file_too_large": "The file is too large. The maximum allowed size is %d MB.",
+
+	// Orders
+	"orders.no_active":         "You have no active orders.",
+	"orders.no_completed":      "You have no completed orders.",
+	"orders.products":          "📋 Products",
+	"orders.products_numbered": "📋 Products #%d",
+	"orders.choose_products":   "Choose an order to see its products:",
+	"order.title":              "Order #%d",
+	"order.details":            "Customer: %s\nStatus: %s",
+	"order.number":             "Order number: %s",
+	"order.ttn":                "Tracking number: %s",
+
+	// Service rating
+	"rate.no_orders":   "You have no orders to rate.",
+	"rate.prompt":      "How was our service? 🙌\nPlease leave a rating — it helps us get better.\n\nYour feedback matters to us!",
+	"rate.save_failed": "Could not save your rating.",
+	"rate.save_retry":  "Could not save your rating. Please try again later.",
+	"rate.saved":       "Your rating has been saved! 🎉\n\nThank you for your feedback!",
+
+	// AI modes
+	"ai.greeting":               "Hi! I'm the DARK brand consultant 🖤\nI can help you choose products, answer questions about them and place an order.",
+	"order.greeting":            "Ready to place your order!",
+	"ai.attachment_received":    "Got your file 📎 A manager will look at it; meanwhile, please describe in text what you are looking for.",
+	"order.attachment_received": "File received ✅ A manager will check it and get back to you.",
+	"voice.too_long":            "This voice message is too long 🎙 Please record a shorter one or type your question.",
+	"voice.failed":              "Sorry, I couldn't make out this voice message 🎙 Please type your question.",
+
+	// School statistics
+	"stat.summary":        "📊 School statistics\n\n🔗 Subscribed via QR: %d\n📝 Registered: %d\n🏫 Chose a school: %d",
+	"stat.by_schools":     "📚 By school",
+	"stat.by_months":      "📅 By month",
+	"stat.no_schools":     "No school data yet.",
+	"stat.schools_header": "📚 By school:\n",
+	"stat.no_months":      "No monthly data yet.",
+	"stat.months_header":  "📊 Statistics by month:\n",
+
+	// Training videos
+	"video.unavailable": "Training materials are temporarily unavailable.",
+	"video.list_failed": "Could not load the video list. Please try again later.",
+	"video.none":        "There are no videos yet.",
+	"video.title":       "📚 Training videos",
+	"video.choose":      "Choose a video to watch:",
+	"video.load_failed": "Could not load the video. Please try again later.",
+	"video.too_large":   "The video \"%s\" is too large to send via Telegram.\n\nOpen it here:\n%s",
+	"video.send_failed": "Could not send the video. Please try again later.",
+
+	// Texts written by the platform messengers
+	"messenger.choose_option": "Choose an option:",
+	"messenger.video":         "[Video: %s]",
+	"messenger.list_button":   "Choose",
+}
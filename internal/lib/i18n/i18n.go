@@ -0,0 +1,70 @@
+// Package i18n holds the message catalog for user-facing bot texts.
+// Ukrainian is the default locale; keys missing in another locale fall back to it.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	Ukrainian = "uk"
+	English   = "en"
+	Default   = Ukrainian
+)
+
+var catalogs = map[string]map[string]string{
+	Ukrainian: uk,
+	English:   en,
+}
+
+// Supported returns the available locales, default first.
+func Supported() []string {
+	return []string{Ukrainian, English}
+}
+
+// Normalize maps a language code such as "en-US" or "EN" to a supported locale,
+// falling back to Default.
+func Normalize(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i > 0 {
+		code = code[:i]
+	}
+	if _, ok := catalogs[code]; ok {
+		return code
+	}
+	return Default
+}
+
+// T returns the message for key in locale, formatted with args when given.
+// Unknown locales and missing keys fall back to Default; an unknown key is returned as is.
+func T(locale, key string, args ...any) string {
+	msg, ok := catalogs[Normalize(locale)][key]
+	if !ok {
+		msg, ok = catalogs[Default][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Matches reports whether text is the label of key in locale or in the default locale.
+// The default is accepted too so a keyboard sent before a locale switch keeps working.
+func Matches(locale, text, key string) bool {
+	text = strings.TrimSpace(text)
+	return text == T(locale, key) || text == T(Default, key)
+}
+
+// MonthName returns the capitalized month name of date in locale.
+func MonthName(locale string, date time.Time) string {
+	month := T(locale, fmt.Sprintf("month.%d", int(date.Month())))
+	runes := []rune(month)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
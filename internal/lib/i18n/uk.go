@@ -0,0 +1,122 @@
+package i18n
+
+var uk = map[string]string{
+	"lang.name":    "🇺🇦 Українська",
+	"lang.prompt":  "Оберіть мову спілкування:",
+	"lang.changed": "Мову змінено на українську.",
+
+	"month.1":  "січень",
+	"month.2":  "лютий",
+	"month.3":  "березень",
+	"month.4":  "квітень",
+	"month.5":  "травень",
+	"month.6":  "червень",
+	"month.7":  "липень",
+	"month.8":  "серпень",
+	"month.9":  "вересень",
+	"month.10": "жовтень",
+	"month.11": "листопад",
+	"month.12": "грудень",
+
+	// Onboarding
+	"onboarding.hello":              "Привіт! 🖤\nДля швидкої перевірки в системі, будь ласка, надайте свій номер телефону у міжнародному форматі, починаючи з +380... 📱",
+	"onboarding.choose_phone":       "Бажаєте використати номер телефону з WhatsApp або ввести інший?",
+	"onboarding.use_wa_phone":       "📱 Використати %s",
+	"onboarding.enter_other_phone":  "✏️ Ввести інший номер",
+	"onboarding.phone_accepted":     "✅ Номер телефону: %s",
+	"onboarding.share_phone_prompt": "Натисніть кнопку нижче, щоб поділитися номером телефону:",
+	"onboarding.share_phone_button": "📱 Поділитися номером телефону",
+	"onboarding.enter_phone":        "Введіть номер телефону (наприклад +380XXXXXXXXX):",
+	"onboarding.invalid_phone":      "❌ Невірний формат номера телефону. Спробуйте ще раз (наприклад +380XXXXXXXXX):",
+	"onboarding.request_name":       "Будь ласка, залиште ваші ім'я та прізвище для знайомства 😎",
+	"onboarding.invalid_name":       "Будь ласка, введіть коректне ім'я (мінімум 2 символи).",
+	"onboarding.confirm_data":       "📋 Перевірте дані:\n\n👤 Ім'я: %s\n📱 Телефон: %s\n\nВсе вірно?",
+	"onboarding.confirm_name":       "📋 Перевірте дані:\n\n👤 Ім'я: %s\n\nВсе вірно?",
+	"onboarding.confirm_yes":        "✅ Так",
+	"onboarding.confirm_no":         "❌ Ні, змінити",
+	"onboarding.save_failed":        "Виникла помилка при збереженні даних. Спробуйте пізніше.",
+	"onboarding.saved":              "✅ Дані збережено!",
+	"onboarding.done":               "%s, цей чат-бот для того, щоб зробити нашу взаємодію ще зручнішою!",
+
+	// Main menu buttons
+	"btn.my_office":        "📦Особистий кабінет",
+	"btn.service_rate":     "⭐Оцінка сервісу",
+	"btn.order_status":     "🛒Статус замовлення",
+	"btn.ai_consultant":    "👋 AI-консультація",
+	"btn.make_order":       "Зробити замовлення😎",
+	"btn.current_order":    "🛍️Поточні замовлення",
+	"btn.completed_orders": "✅Виконані замовлення",
+	"btn.back":             "↩️Назад",
+	"btn.back_to_menu":     "↩️Назад до меню",
+	"btn.school_stat":      "📊Статистика шкіл",
+	"btn.learning":         "📚Навчання",
+	"word.back":            "назад",
+
+	// Main menu
+	"school.select_intro": "Розкажи, будь ласка, з якої школи ти дізнався/дізналася про нас 🖤\n\nОберіть школу:",
+	"school.select":       "Оберіть школу:",
+	"school.welcome":      "Вітаємо, %s!\n\nОтримай -15%% на перше замовлення з промо-кодом *DARKSCHOOL* 🖤\nСкористайся протягом 14 днів на сайті 👉 riornails.com\n\nНатискай кнопку 📚Навчання та отримуй додаткові поради від нашого бренд-майстра 🖤\n\nP.S. Твоя особиста знижка -7%% вже активна, і з часом може стати ще більшою ✨",
+	"menu.prompt":         "Натисніть на потрібний варіант, щоб перейти у бажаний розділ 👇",
+	"office.prompt":       "Що саме цікавить?",
+	"session.expired":     "Сесію завершено через неактивність. Повертаємо вас до головного меню.",
+	"operator.requested":  "Ваш запит передано менеджеру. Він зв'яжеться з вами найближчим часом.",
+	"stop.done":           "Розмову завершено. Напишіть будь-що, щоб почати знову.",
+
+	"error.user_info":      "Не вдалося отримати інформацію про користувача.",
+	"error.orders":         "Не вдалося отримати інформацію про замовлення.",
+	"error.products":       "Не вдалося отримати товари.",
+	"error.request":        "Виникла помилка при обробці запиту. Спробуйте ще раз.",
+	"error.statistics":     "Не вдалося отримати статистику.",
+	"error.invalid_input":  "Некоректне значення, спробуйте ще раз.",
+	"error.file_too_large": "Файл занадто великий. Максимально дозволений розмір - %d MB.",
+
+	// Orders
+	"orders.no_active":         "У вас немає активних замовлень.",
+	"orders.no_completed":      "У вас немає виконаних замовлень.",
+	"orders.products":          "📋 Товари",
+	"orders.products_numbered": "📋 Товари №%d",
+	"orders.choose_products":   "Оберіть замовлення для перегляду товарів:",
+	"order.title":              "Замовлення №%d",
+	"order.details":            "Замовник: %s\nСтатус: %s",
+	"order.number":             "Номер замовлення: %s",
+	"order.ttn":                "ТТН: %s",
+
+	// Service rating
+	"rate.no_orders":   "У вас немає замовлень для оцінки.",
+	"rate.prompt":      "Як вам сервіс? 🙌\nЗалиште, будь ласка, оцінку — це допоможе нам ставати кращими.\n\nВаш відгук важливий для нас!",
+	"rate.save_failed": "Не вдалося зберегти оцінку.",
+	"rate.save_retry":  "Не вдалося зберегти оцінку. Спробуйте пізніше.",
+	"rate.saved":       "Ваша оцінка успішно створена! 🎉\n\nДякуємо за ваш відгук!",
+
+	// AI modes
+	"ai.greeting":               "Привіт! Я — консультант бренду DARK 🖤\nДопоможу з вибором товарів, проконсультую щодо продукції та оформлення замовлення.",
+	"order.greeting":            "Готові оформити замовлення!",
+	"ai.attachment_received":    "Файл отримано 📎 Менеджер перегляне його, а поки опишіть, будь ласка, текстом, що саме вас цікавить.",
+	"order.attachment_received": "Файл отримано ✅ Менеджер перевірить його та зв'яжеться з вами.",
+	"voice.too_long":            "Голосове повідомлення задовге 🎙 Будь ласка, запишіть коротше або напишіть текстом.",
+	"voice.failed":              "Не вдалося розпізнати голосове повідомлення 🎙 Будь ласка, напишіть текстом.",
+
+	// School statistics
+	"stat.summary":        "📊 Статистика шкіл\n\n🔗 Підписалися через QR: %d\n📝 Зареєстровані: %d\n🏫 Обрали школу: %d",
+	"stat.by_schools":     "📚 По школах",
+	"stat.by_months":      "📅 По місяцях",
+	"stat.no_schools":     "Немає даних по школах.",
+	"stat.schools_header": "📚 По школах:\n",
+	"stat.no_months":      "Немає даних по місяцях.",
+	"stat.months_header":  "📊 Статистика по місяцях:\n",
+
+	// Training videos
+	"video.unavailable": "Навчальні матеріали тимчасово недоступні.",
+	"video.list_failed": "Помилка завантаження списку відео. Спробуйте пізніше.",
+	"video.none":        "Наразі відео-матеріали відсутні.",
+	"video.title":       "📚 Навчальні відео",
+	"video.choose":      "Оберіть відео для перегляду:",
+	"video.load_failed": "Помилка завантаження відео. Спробуйте пізніше.",
+	"video.too_large":   "Відео \"%s\" занадто велике для надсилання через Telegram.\n\nВідкрийте за посиланням:\n%s",
+	"video.send_failed": "Помилка відправки відео. Спробуйте пізніше.",
+
+	// Texts written by the platform messengers
+	"messenger.choose_option": "Оберіть опцію:",
+	"messenger.video":         "[Відео: %s]",
+	"messenger.list_button":   "Обрати",
+}
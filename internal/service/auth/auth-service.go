@@ -14,6 +14,9 @@ type Repository interface {
 	GetUser(email, phone string, telegramId int64) (*entity.User, error)
 	GetUserByUUID(uuid string) (*entity.User, error)
 	GetUserByInstagramId(instagramId string) (*entity.User, error)
+	GetUserByFacebookId(facebookId string) (*entity.User, error)
+	GetUserByViberId(viberId string) (*entity.User, error)
+	GetUserByWebId(webId string) (*entity.User, error)
 	GetUserBySmartSenderId(smartSenderId string) (*entity.User, error)
 
 	UpsertBasket(basket *entity.Basket) (*entity.Basket, error)
@@ -155,6 +158,57 @@ func (s *Service) GetUserByInstagramId(instagramId string) (*entity.User, error)
 	return nil, nil
 }
 
+func (s *Service) GetUserByFacebookId(facebookId string) (*entity.User, error) {
+	for _, user := range s.users {
+		if user.FacebookId == facebookId {
+			return &user, nil
+		}
+	}
+	user, err := s.repository.GetUserByFacebookId(facebookId)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		s.users = append(s.users, *user)
+		return user, nil
+	}
+	return nil, nil
+}
+
+func (s *Service) GetUserByViberId(viberId string) (*entity.User, error) {
+	for _, user := range s.users {
+		if user.ViberId == viberId {
+			return &user, nil
+		}
+	}
+	user, err := s.repository.GetUserByViberId(viberId)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		s.users = append(s.users, *user)
+		return user, nil
+	}
+	return nil, nil
+}
+
+func (s *Service) GetUserByWebId(webId string) (*entity.User, error) {
+	for _, user := range s.users {
+		if user.WebId == webId {
+			return &user, nil
+		}
+	}
+	user, err := s.repository.GetUserByWebId(webId)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		s.users = append(s.users, *user)
+		return user, nil
+	}
+	return nil, nil
+}
+
 func (s *Service) GetUserBySmartSenderId(smartSenderId string) (*entity.User, error) {
 	for _, user := range s.users {
 		if user.SmartSenderId == smartSenderId {
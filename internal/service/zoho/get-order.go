@@ -151,7 +151,7 @@ func (s *ZohoService) getOrdersDetailed(contactID string) ([]entity.OrderDetail,
 		return nil, fmt.Errorf("build url: %w", err)
 	}
 
-	fullURL = fullURL + "?fields=Name,Owner,Subject,Status,Contact_Name,Aa2e053928236368ec7865f3558a58c4f"
+	fullURL = fullURL + "?fields=Name,Owner,Subject,Status,Contact_Name,Aa2e053928236368ec7865f3558a58c4f,Created_Time"
 
 	// Create request
 	req, err := http.NewRequest(http.MethodGet, fullURL, nil)
@@ -90,6 +90,35 @@ func (h *Hub) BroadcastMessage(msg entity.ChatMessage) {
 	}
 }
 
+// BroadcastTranscript sends a transcript event when a voice message has been transcribed.
+func (h *Hub) BroadcastTranscript(msg entity.ChatMessage) {
+	h.broadcast <- &Event{
+		Type: "transcript",
+		Data: map[string]string{
+			"platform":   msg.Platform,
+			"user_id":    msg.UserID,
+			"message_id": msg.ID.Hex(),
+			"transcript": msg.Transcript,
+		},
+	}
+}
+
+// BroadcastMessageStatus sends a message_status event when an outgoing message
+// was delivered, read or failed on the platform.
+func (h *Hub) BroadcastMessageStatus(msg entity.ChatMessage) {
+	h.broadcast <- &Event{
+		Type: "message_status",
+		Data: map[string]interface{}{
+			"platform":             msg.Platform,
+			"user_id":              msg.UserID,
+			"message_id":           msg.ID.Hex(),
+			"platform_message_ids": msg.PlatformMessageIDs,
+			"status":               msg.Status,
+			"status_error":         msg.StatusError,
+		},
+	}
+}
+
 // BroadcastTyping sends a typing event to all connected CRM clients.
 func (h *Hub) BroadcastTyping(platform, userID string) {
 	h.broadcast <- &Event{
@@ -113,6 +142,47 @@ func (h *Hub) BroadcastReadReceipt(username, platform, userID string) {
 	}
 }
 
+// BroadcastChatAssignment sends a chat_assignment event when a chat is assigned,
+// unassigned or changes status.
+func (h *Hub) BroadcastChatAssignment(assignment entity.ChatAssignment) {
+	h.broadcast <- &Event{
+		Type: "chat_assignment",
+		Data: assignment,
+	}
+}
+
+// BroadcastNoteAdded sends a note_added event when a CRM user adds an internal note to a chat.
+func (h *Hub) BroadcastNoteAdded(note entity.ChatNote) {
+	h.broadcast <- &Event{
+		Type: "note_added",
+		Data: note,
+	}
+}
+
+// BroadcastNoteDeleted sends a note_deleted event when an internal note is deleted.
+func (h *Hub) BroadcastNoteDeleted(note entity.ChatNote) {
+	h.broadcast <- &Event{
+		Type: "note_deleted",
+		Data: map[string]string{
+			"platform": note.Platform,
+			"user_id":  note.UserID,
+			"id":       note.ID.Hex(),
+		},
+	}
+}
+
+// BroadcastChatTags sends a chat_tags event with the current tags of a chat.
+func (h *Hub) BroadcastChatTags(platform, userID string, tags []string) {
+	h.broadcast <- &Event{
+		Type: "chat_tags",
+		Data: map[string]interface{}{
+			"platform": platform,
+			"user_id":  userID,
+			"tags":     tags,
+		},
+	}
+}
+
 // clientEvent represents an incoming WebSocket message from a CRM client.
 type clientEvent struct {
 	Type string          `json:"type"`
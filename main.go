@@ -4,18 +4,27 @@ import (
 	"context"
 	"flag"
 	"log/slog"
+	"net/url"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"DarkCS/ai/gpt"
 	"DarkCS/bot"
 	"DarkCS/bot/chat"
+	fbmessenger "DarkCS/bot/chat/facebook"
+	chatflow "DarkCS/bot/chat/flow"
 	igmessenger "DarkCS/bot/chat/instagram"
 	chatmainmenu "DarkCS/bot/chat/mainmenu"
 	chatonboarding "DarkCS/bot/chat/onboarding"
 	tgmessenger "DarkCS/bot/chat/telegram"
+	vbmessenger "DarkCS/bot/chat/viber"
+	webmessenger "DarkCS/bot/chat/web"
 	wamessenger "DarkCS/bot/chat/whatsapp"
 	"DarkCS/bot/insta"
+	"DarkCS/bot/messenger"
+	"DarkCS/bot/viber"
+	"DarkCS/bot/web"
 	"DarkCS/bot/whatsapp"
 	"DarkCS/impl/core"
 	"DarkCS/internal/config"
@@ -58,6 +67,12 @@ func main() {
 
 		// Start admin telegram bot
 		if tgBot != nil {
+			if conf.Telegram.Webhook.Enabled {
+				tgBot.UseWebhook(bot.WebhookConfig{
+					PublicURL:   conf.Telegram.Webhook.PublicURL,
+					SecretToken: conf.Telegram.Webhook.SecretToken,
+				})
+			}
 			go func() {
 				if err := tgBot.Start(); err != nil {
 					lg.Error("telegram bot error", slog.String("error", err.Error()))
@@ -72,6 +87,9 @@ func main() {
 	handler := core.New(lg)
 	handler.SetAuthKey(conf.Listen.ApiKey)
 	handler.SetSigningSecret(conf.Listen.ApiKey)
+	handler.SetAutoAssign(conf.Crm.AutoAssign, conf.Crm.Agents)
+	handler.SetCrmAdmins(conf.Crm.Admins)
+	handler.SetHandoffTimeout(conf.Crm.HandoffTimeout)
 
 	authService := auth.NewAuthService(lg)
 
@@ -102,6 +120,11 @@ func main() {
 			userBot, err = bot.NewUserBot(conf.UserBot.BotName, conf.UserBot.ApiKey, lg)
 			if err != nil {
 				lg.Error("failed to initialize user bot", slog.String("error", err.Error()))
+			} else if conf.UserBot.Webhook.Enabled {
+				userBot.UseWebhook(bot.WebhookConfig{
+					PublicURL:   conf.UserBot.Webhook.PublicURL,
+					SecretToken: conf.UserBot.Webhook.SecretToken,
+				})
 			}
 		}
 	}
@@ -134,6 +157,7 @@ func main() {
 		overseer.SetProductService(ps)
 		overseer.SetAuthService(authService)
 		handler.SetAssistant(overseer)
+		handler.SetVoiceMaxDuration(conf.OpenAI.VoiceMaxDuration)
 		lg.With(
 			sl.Secret("openai_key", conf.OpenAI.ApiKey),
 		).Info("overseer initialized")
@@ -198,20 +222,49 @@ func main() {
 
 	// Initialize unified ChatEngine shared by all platforms (Telegram, Instagram, WhatsApp)
 	var chatEngine *chat.ChatEngine
+	var workflowSource *chatflow.Source
 	if db != nil {
 		chatStateStorage := chat.NewMongoChatStateStorage(db)
 		chatEngine = chat.NewChatEngine(chatStateStorage, lg)
 
 		// Register chat workflows
 		chatOnboarding := chatonboarding.NewOnboardingWorkflow(authService, zohoService, lg)
-		chatEngine.RegisterWorkflow(chatOnboarding)
+		if err := chatEngine.RegisterWorkflow(chatOnboarding); err != nil {
+			lg.Error("register workflow", sl.Err(err))
+		}
 
 		chatMainMenu := chatmainmenu.NewMainMenuWorkflow(authService, zohoService, handler, db, db, driveService, lg)
-		chatEngine.RegisterWorkflow(chatMainMenu)
+		if err := chatEngine.RegisterWorkflow(chatMainMenu); err != nil {
+			lg.Error("register workflow", sl.Err(err))
+		}
+
+		// Install declarative workflows defined in YAML; they can be reloaded over the API
+		if conf.Workflows.Dir != "" {
+			workflowSource = chatflow.NewSource(conf.Workflows.Dir, chatmainmenu.Actions(), chatEngine, lg)
+			workflowSource.Reload()
+		}
+
+		// Register global intents, checked before step dispatch on every platform
+		chatEngine.RegisterIntent(chatonboarding.StartIntent())
+		for _, intent := range chatmainmenu.Intents() {
+			chatEngine.RegisterIntent(intent)
+		}
 
 		// Wire message listener for CRM
 		chatEngine.SetMessageListener(handler)
 
+		// Keep the bot silent in chats a manager has taken over
+		chatEngine.SetHandoff(handler)
+
+		// Persist workflow starts, transitions, completions and errors for funnel analytics,
+		// off the users' mailboxes
+		eventWriter := chat.NewEventWriter(db, lg)
+		eventWriter.Start(context.Background())
+		chatEngine.SetEventRecorder(eventWriter)
+
+		// Keep each user's language beyond the chat state
+		chatEngine.SetLocaleStore(db)
+
 		lg.Info("chat engine initialized")
 	}
 
@@ -219,7 +272,9 @@ func main() {
 	if userBot != nil && chatEngine != nil {
 		userBot.SetChatEngine(chatEngine)
 		userBot.SetAuthService(authService)
-		handler.SetPlatformMessenger("telegram", tgmessenger.NewMessenger(userBot.GetAPI()))
+		tgMessenger := tgmessenger.NewMessenger(userBot.GetAPI())
+		handler.SetPlatformMessenger("telegram", tgMessenger)
+		chatEngine.SetPlatformMessenger("telegram", tgMessenger)
 		go func() {
 			if err := userBot.Start(); err != nil {
 				lg.Error("user bot error", slog.String("error", err.Error()))
@@ -236,10 +291,12 @@ func main() {
 			conf.Instagram.AppSecret,
 			lg,
 		)
+		igMessenger := igmessenger.NewMessenger(instaBot)
 		if chatEngine != nil {
 			instaBot.SetChatEngine(chatEngine)
+			chatEngine.SetPlatformMessenger("instagram", igMessenger)
 		}
-		handler.SetPlatformMessenger("instagram", igmessenger.NewMessenger(instaBot))
+		handler.SetPlatformMessenger("instagram", igMessenger)
 		// Persist the token to MongoDB so DarkBot can import it via the same server.
 		if db != nil {
 			instaBot.SetTokenPersister(db.SaveInstagramToken)
@@ -248,6 +305,10 @@ func main() {
 			}
 		}
 		instaBot.StartTokenRefresh(context.Background())
+		// Queue webhooks in MongoDB and skip Meta's repeated deliveries
+		if db != nil {
+			instaBot.StartWebhookQueue(context.Background(), db)
+		}
 		lg.Info("instagram bot initialized")
 	}
 
@@ -261,13 +322,92 @@ func main() {
 			conf.WhatsApp.PhoneNumberID,
 			lg,
 		)
+		whatsappBot.SetTemplates(conf.WhatsApp.Templates)
+		if db != nil {
+			whatsappBot.SetLastInboundLoader(func(phone string) (time.Time, error) {
+				return db.GetLastIncomingTime("whatsapp", phone)
+			})
+		}
+		waMessenger := wamessenger.NewMessenger(whatsappBot)
 		if chatEngine != nil {
 			whatsappBot.SetChatEngine(chatEngine)
+			chatEngine.SetPlatformMessenger("whatsapp", waMessenger)
+		}
+		handler.SetPlatformMessenger("whatsapp", waMessenger)
+		if db != nil {
+			whatsappBot.StartWebhookQueue(context.Background(), db)
 		}
-		handler.SetPlatformMessenger("whatsapp", wamessenger.NewMessenger(whatsappBot))
 		lg.Info("whatsapp bot initialized")
 	}
 
+	// Initialize Facebook Messenger bot if enabled
+	var messengerBot *messenger.MessengerBot
+	if conf.Facebook.Enabled {
+		messengerBot = messenger.NewMessengerBot(
+			conf.Facebook.PageAccessToken,
+			conf.Facebook.VerifyToken,
+			conf.Facebook.AppSecret,
+			lg,
+		)
+		fbMessenger := fbmessenger.NewMessenger(messengerBot)
+		if chatEngine != nil {
+			messengerBot.SetChatEngine(chatEngine)
+			chatEngine.SetPlatformMessenger("facebook", fbMessenger)
+		}
+		handler.SetPlatformMessenger("facebook", fbMessenger)
+		if db != nil {
+			messengerBot.StartWebhookQueue(context.Background(), db)
+		}
+		lg.Info("facebook messenger bot initialized")
+	}
+
+	// Initialize Viber bot if enabled
+	var viberBot *viber.ViberBot
+	if conf.Viber.Enabled {
+		viberBot = viber.NewViberBot(conf.Viber.AuthToken, conf.Viber.SenderName, lg)
+		vbMessenger := vbmessenger.NewMessenger(viberBot)
+		if chatEngine != nil {
+			viberBot.SetChatEngine(chatEngine)
+			chatEngine.SetPlatformMessenger("viber", vbMessenger)
+		}
+		handler.SetPlatformMessenger("viber", vbMessenger)
+		// Queue webhooks in MongoDB and skip repeated deliveries
+		if db != nil {
+			viberBot.StartWebhookQueue(context.Background(), db)
+		}
+		// Viber calls the webhook URL during registration, so this retries until the HTTP server is up
+		if publicURL, err := url.Parse(conf.Viber.PublicURL); err != nil || publicURL.Scheme != "https" || publicURL.Host == "" {
+			lg.Error("viber webhook not registered: public_url must be an https URL",
+				slog.String("public_url", conf.Viber.PublicURL))
+		} else {
+			viberBot.RegisterWebhook(context.Background(), strings.TrimRight(conf.Viber.PublicURL, "/")+"/webhook/viber")
+		}
+		lg.Info("viber bot initialized")
+	}
+
+	// Initialize the website chat widget if enabled
+	var webChat *web.WebChat
+	if conf.WebChat.Enabled && conf.WebChat.SessionSecret == "" {
+		lg.Error("webchat is enabled without a session secret")
+	} else if conf.WebChat.Enabled {
+		if len(conf.WebChat.AllowedOrigins) == 0 {
+			lg.Warn("webchat has no allowed_origins; every widget connection will be rejected")
+		}
+		webChat = web.NewWebChat(conf.WebChat.SessionSecret, conf.WebChat.AllowedOrigins, lg)
+		webMessenger := webmessenger.NewMessenger(webChat)
+		if chatEngine != nil {
+			webChat.SetChatEngine(chatEngine)
+			chatEngine.SetPlatformMessenger("web", webMessenger)
+		}
+		handler.SetPlatformMessenger("web", webMessenger)
+		lg.Info("webchat initialized")
+	}
+
+	// Expire idle workflow steps (AI sessions, video selection) once all platform messengers are registered
+	if chatEngine != nil {
+		chatEngine.StartTimeoutSweeper(context.Background(), time.Minute)
+	}
+
 	// *** blocking start with http server ***
 	var apiOpts []api.Option
 	if instaBot != nil {
@@ -276,6 +416,24 @@ func main() {
 	if whatsappBot != nil {
 		apiOpts = append(apiOpts, api.WithWhatsAppBot(whatsappBot))
 	}
+	if messengerBot != nil {
+		apiOpts = append(apiOpts, api.WithMessengerBot(messengerBot))
+	}
+	if viberBot != nil {
+		apiOpts = append(apiOpts, api.WithViberBot(viberBot))
+	}
+	if webChat != nil {
+		apiOpts = append(apiOpts, api.WithWebChat(webChat))
+	}
+	if tgBot != nil && conf.Telegram.Webhook.Enabled {
+		apiOpts = append(apiOpts, api.WithTelegramWebhook("telegram", tgBot.WebhookHandler()))
+	}
+	if userBot != nil && conf.UserBot.Webhook.Enabled {
+		apiOpts = append(apiOpts, api.WithTelegramWebhook("userbot", userBot.WebhookHandler()))
+	}
+	if workflowSource != nil {
+		apiOpts = append(apiOpts, api.WithWorkflowReloader(workflowSource))
+	}
 	apiOpts = append(apiOpts, api.WithWsHub(wsHub, handler))
 	err = api.New(conf, lg, handler, apiOpts...)
 	if err != nil {